@@ -3,6 +3,9 @@ package brng
 import (
 	"fmt"
 
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/mpc/parallel"
 	"github.com/renproject/mpc/params"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
@@ -13,6 +16,10 @@ type BRNGer struct {
 	batchSize uint32
 	index     secp256k1.Fn
 	h         secp256k1.Point
+
+	// Precomputed fixed-base table for h, which is used to check revealed
+	// shares. This is not marshaled, and is computed again when unmarshaling.
+	hTable *msm.FixedBase
 }
 
 // New creates a new BRNG state machine for the given indices and pedersen
@@ -43,7 +50,7 @@ func New(batchSize, k uint32, indices []secp256k1.Fn, index secp256k1.Fn, h secp
 		shamir.VShareSecret(&sharings[i].Shares, &sharings[i].Commitment,
 			indices, h, secp256k1.RandomFn(), int(k))
 	}
-	brnger := BRNGer{batchSize, index, h}
+	brnger := BRNGer{batchSize, index, h, msm.NewFixedBase(h)}
 	return brnger, sharings
 }
 
@@ -92,23 +99,51 @@ func (brnger *BRNGer) IsValid(
 	if uint32(len(sharesBatch)) != brnger.batchSize {
 		return ErrIncorrectSharesBatchSize
 	}
-	for i, shares := range sharesBatch {
-		if len(shares) != numContributions {
-			return ErrInvalidShareDimensions
-		}
-		for j, share := range shares {
-			if !share.Share.IndexEq(&brnger.index) {
-				return ErrIncorrectIndex
-			}
-			if !shamir.IsValid(brnger.h, &commitmentsBatch[i][j], &share) {
-				return ErrInvalidShares
-			}
-		}
+	// The elements of the batch are checked using the worker pool from the
+	// parallel package. The error for the first invalid element is returned,
+	// which is the same error that checking them in order would give.
+	errs := make([]error, len(sharesBatch))
+	i := parallel.First(len(sharesBatch), func(i int) bool {
+		errs[i] = brnger.checkShares(sharesBatch[i], commitmentsBatch[i], numContributions)
+		return errs[i] != nil
+	})
+	if i >= 0 {
+		return errs[i]
 	}
 
 	return nil
 }
 
+// checkShares checks the validity of the shares for one element of the batch.
+// The shares are secret, and so they are checked in constant time.
+func (brnger *BRNGer) checkShares(
+	shares shamir.VerifiableShares,
+	commitments []shamir.Commitment,
+	numContributions int,
+) error {
+	if len(shares) != numContributions {
+		return ErrInvalidShareDimensions
+	}
+	// Each share is checked for the correct index before its validity is
+	// checked, and so only the shares before the first share with an
+	// incorrect index need to be verified to get the same error as checking
+	// them in order.
+	m := len(shares)
+	for j := range shares {
+		if !shares[j].Share.IndexEq(&brnger.index) {
+			m = j
+			break
+		}
+	}
+	if _, ok := open.VerifyShareBatchSecret(&brnger.h, commitments[:m], shares[:m]); !ok {
+		return ErrInvalidShares
+	}
+	if m < len(shares) {
+		return ErrIncorrectIndex
+	}
+	return nil
+}
+
 // HandleConsensusOutput performs the state transition for the BRNger state
 // machine upon receiving the slice of verifiable shares that is output by the
 // consensus protocol. It is assumed that the consensus protocol will decide on
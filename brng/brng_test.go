@@ -10,6 +10,8 @@ import (
 	. "github.com/renproject/mpc/mpcutil"
 
 	"github.com/renproject/mpc/brng/brngutil"
+	"github.com/renproject/mpc/ecies"
+	"github.com/renproject/mpc/parallel"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
 	"github.com/renproject/shamir/shamirutil"
@@ -173,6 +175,383 @@ var _ = Describe("BRNG", func() {
 				err := brnger.IsValid(sharesBatch, commitmentsBatch, t)
 				Expect(err).To(Equal(ErrInvalidShares))
 			})
+
+			Specify("shares should be checked in order", func() {
+				_, k, b, t, indices, index, h := RandomTestParameters()
+				// This test only makes sense if there is more than one share.
+				if t == 1 {
+					return
+				}
+				sharesBatch, commitmentsBatch := ValidBatches(k, b, t, indices, index, h)
+				brnger, _ := New(b, k, indices, index, h)
+
+				// An invalid share before a share with an incorrect index.
+				sharesBatch[0][0].Share.Value = secp256k1.RandomFn()
+				sharesBatch[0][1].Share.Index = secp256k1.RandomFn()
+				err := brnger.IsValid(sharesBatch, commitmentsBatch, t)
+				Expect(err).To(Equal(ErrInvalidShares))
+
+				// A share with an incorrect index before an invalid share.
+				sharesBatch[0][0], sharesBatch[0][1] = sharesBatch[0][1], sharesBatch[0][0]
+				commitmentsBatch[0][0], commitmentsBatch[0][1] = commitmentsBatch[0][1], commitmentsBatch[0][0]
+				err = brnger.IsValid(sharesBatch, commitmentsBatch, t)
+				Expect(err).To(Equal(ErrIncorrectIndex))
+			})
+
+			Specify("the first error should be reported when using a worker pool", func() {
+				parallel.SetWorkers(4)
+				defer parallel.SetWorkers(1)
+
+				_, k, _, t, indices, index, h := RandomTestParameters()
+				b := uint32(4)
+				sharesBatch, commitmentsBatch := ValidBatches(k, b, t, indices, index, h)
+				brnger, _ := New(b, k, indices, index, h)
+				Expect(brnger.IsValid(sharesBatch, commitmentsBatch, t)).To(Succeed())
+
+				// The invalid shares come before the shares with the wrong
+				// dimensions, and so they determine the error.
+				sharesBatch[1][0].Share.Value = secp256k1.RandomFn()
+				sharesBatch[3] = sharesBatch[3][1:]
+				err := brnger.IsValid(sharesBatch, commitmentsBatch, t)
+				Expect(err).To(Equal(ErrInvalidShares))
+			})
+		})
+	})
+
+	Context("consensus tables", func() {
+		RandomTable := func(k, b uint32, t int, indices []secp256k1.Fn, h secp256k1.Point) Table {
+			table := make(Table, t)
+			for i := range table {
+				_, table[i] = New(b, k, indices, indices[i%len(indices)], h)
+			}
+			return table
+		}
+
+		Specify("the column for a player should match the table", func() {
+			_, k, b, t, indices, index, h := RandomTestParameters()
+			table := RandomTable(k, b, t, indices, h)
+			sharesBatch, commitmentsBatch := table.Column(index)
+			Expect(len(sharesBatch)).To(Equal(int(b)))
+			Expect(len(commitmentsBatch)).To(Equal(int(b)))
+			for j := 0; j < int(b); j++ {
+				Expect(len(sharesBatch[j])).To(Equal(t))
+				Expect(len(commitmentsBatch[j])).To(Equal(t))
+				for i := 0; i < t; i++ {
+					Expect(sharesBatch[j][i].Share.IndexEq(&index)).To(BeTrue())
+					Expect(commitmentsBatch[j][i].Eq(table[i][j].Commitment)).To(BeTrue())
+				}
+			}
+		})
+
+		Specify("the column of an empty table should be nil", func() {
+			_, _, _, _, _, index, _ := RandomTestParameters()
+			sharesBatch, commitmentsBatch := Table{}.Column(index)
+			Expect(sharesBatch).To(BeNil())
+			Expect(commitmentsBatch).To(BeNil())
+		})
+
+		Specify("valid tables", func() {
+			_, k, b, t, indices, index, h := RandomTestParameters()
+			table := RandomTable(k, b, t, indices, h)
+			brnger, _ := New(b, k, indices, index, h)
+			Expect(brnger.IsValidTable(table, t)).To(Succeed())
+		})
+
+		Specify("rows with the wrong batch size", func() {
+			_, k, b, t, indices, index, h := RandomTestParameters()
+			table := RandomTable(k, b, t, indices, h)
+			brnger, _ := New(b, k, indices, index, h)
+			i := rand.Intn(t)
+			table[i] = append(table[i], table[i][0])
+			Expect(brnger.IsValidTable(table, t)).To(Equal(ErrIncorrectCommitmentsBatchSize))
+		})
+
+		Specify("invalid shares", func() {
+			_, k, b, t, indices, index, h := RandomTestParameters()
+			table := RandomTable(k, b, t, indices, h)
+			brnger, _ := New(b, k, indices, index, h)
+			i, j := rand.Intn(t), rand.Intn(int(b))
+			for l := range table[i][j].Shares {
+				if table[i][j].Shares[l].Share.IndexEq(&index) {
+					table[i][j].Shares[l].Decommitment = secp256k1.RandomFn()
+				}
+			}
+			Expect(brnger.IsValidTable(table, t)).To(Equal(ErrInvalidShares))
+		})
+	})
+
+	Context("encrypted tables", func() {
+		RandomEncryptedTable := func(k, b uint32, t int, indices []secp256k1.Fn, h secp256k1.Point) (
+			EncryptedTable, Table, []secp256k1.Fn, []secp256k1.Point,
+		) {
+			privKeys := make([]secp256k1.Fn, len(indices))
+			pubKeys := make([]secp256k1.Point, len(indices))
+			for i := range privKeys {
+				privKeys[i] = secp256k1.RandomFn()
+				pubKeys[i] = ecies.PublicKey(&privKeys[i])
+			}
+			table := make(Table, t)
+			encryptedTable := make(EncryptedTable, t)
+			for i := range table {
+				_, table[i] = New(b, k, indices, indices[i%len(indices)], h)
+				encryptedTable[i] = EncryptSharings(table[i], indices, pubKeys)
+			}
+			return encryptedTable, table, privKeys, pubKeys
+		}
+
+		// RandomPlayer returns the position of a random player in the indices.
+		RandomPlayer := func(indices []secp256k1.Fn) int {
+			return rand.Intn(len(indices))
+		}
+
+		// CorruptShare replaces the ciphertext of the share for the player
+		// with the given index in the given sharing.
+		CorruptShare := func(sharing *EncryptedSharing, index secp256k1.Fn, ciphertext []byte) {
+			for l := range sharing.Shares {
+				if sharing.Shares[l].Index.Eq(&index) {
+					sharing.Shares[l].Ciphertext = ciphertext
+				}
+			}
+		}
+
+		Specify("the decrypted column should match the plaintext column", func() {
+			_, k, b, t, indices, _, h := RandomTestParameters()
+			encryptedTable, table, privKeys, _ := RandomEncryptedTable(k, b, t, indices, h)
+			p := RandomPlayer(indices)
+			brnger, _ := New(b, k, indices, indices[p], h)
+			sharesBatch, commitmentsBatch, complaints := brnger.DecryptColumn(&privKeys[p], encryptedTable)
+			Expect(complaints).To(BeEmpty())
+			expectedShares, expectedCommitments := table.Column(indices[p])
+			for j := range sharesBatch {
+				for i := range sharesBatch[j] {
+					Expect(sharesBatch[j][i].Eq(&expectedShares[j][i])).To(BeTrue())
+					Expect(commitmentsBatch[j][i].Eq(expectedCommitments[j][i])).To(BeTrue())
+				}
+			}
+		})
+
+		Specify("valid encrypted tables", func() {
+			_, k, b, t, indices, _, h := RandomTestParameters()
+			encryptedTable, table, privKeys, _ := RandomEncryptedTable(k, b, t, indices, h)
+			p := RandomPlayer(indices)
+			brnger, _ := New(b, k, indices, indices[p], h)
+			complaints, err := brnger.IsValidEncrypted(&privKeys[p], encryptedTable, t)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(complaints).To(BeEmpty())
+
+			shares, commitments := brnger.HandleEncryptedConsensusOutput(&privKeys[p], encryptedTable, t)
+			expectedShares, expectedCommitments := HandleConsensusOutput(table.Column(indices[p]))
+			for j := range shares {
+				Expect(shares[j].Eq(&expectedShares[j])).To(BeTrue())
+				Expect(commitments[j].Eq(expectedCommitments[j])).To(BeTrue())
+			}
+
+			// The table does not have enough contributions if more are
+			// required.
+			shares, _ = brnger.HandleEncryptedConsensusOutput(&privKeys[p], encryptedTable, t+1)
+			Expect(shares).To(BeNil())
+		})
+
+		Specify("shares that can not be decrypted should give valid complaints", func() {
+			_, k, b, t, indices, _, h := RandomTestParameters()
+			encryptedTable, _, privKeys, pubKeys := RandomEncryptedTable(k, b, t, indices, h)
+			p := RandomPlayer(indices)
+			brnger, _ := New(b, k, indices, indices[p], h)
+
+			// Encrypt a share for the wrong key, and a share that is not a
+			// valid share at all.
+			i1, j1 := rand.Intn(t), rand.Intn(int(b))
+			otherPrivKey := secp256k1.RandomFn()
+			otherPubKey := ecies.PublicKey(&otherPrivKey)
+			CorruptShare(&encryptedTable[i1][j1], indices[p], ecies.Encrypt(&otherPubKey, []byte{1, 2, 3}))
+			i2, j2 := rand.Intn(t), rand.Intn(int(b))
+			CorruptShare(&encryptedTable[i2][j2], indices[p], ecies.Encrypt(&pubKeys[p], []byte{1, 2, 3}))
+
+			complaints, err := brnger.IsValidEncrypted(&privKeys[p], encryptedTable, t)
+			Expect(err).To(Equal(ErrUndecryptableShares))
+			if i1 == i2 && j1 == j2 {
+				Expect(len(complaints)).To(Equal(1))
+			} else {
+				Expect(len(complaints)).To(Equal(2))
+			}
+			for _, complaint := range complaints {
+				Expect(complaint.Index.Eq(&indices[p])).To(BeTrue())
+				Expect(encryptedTable.VerifyComplaint(complaint, pubKeys[p])).To(BeTrue())
+
+				// The complaint should not be valid for another player.
+				other := (p + 1) % len(indices)
+				Expect(encryptedTable.VerifyComplaint(complaint, pubKeys[other])).To(BeFalse())
+			}
+
+			shares, _ := brnger.HandleEncryptedConsensusOutput(&privKeys[p], encryptedTable, t)
+			Expect(shares).To(BeNil())
+		})
+
+		Specify("malformed ciphertexts should give valid complaints", func() {
+			_, k, b, t, indices, _, h := RandomTestParameters()
+			encryptedTable, _, privKeys, pubKeys := RandomEncryptedTable(k, b, t, indices, h)
+			p := RandomPlayer(indices)
+			brnger, _ := New(b, k, indices, indices[p], h)
+			i, j := rand.Intn(t), rand.Intn(int(b))
+			CorruptShare(&encryptedTable[i][j], indices[p], []byte{})
+
+			complaints, err := brnger.IsValidEncrypted(&privKeys[p], encryptedTable, t)
+			Expect(err).To(Equal(ErrUndecryptableShares))
+			Expect(len(complaints)).To(Equal(1))
+			Expect(encryptedTable.VerifyComplaint(complaints[0], pubKeys[p])).To(BeTrue())
+		})
+
+		Specify("complaints against shares that can be decrypted should be invalid", func() {
+			_, k, b, t, indices, _, h := RandomTestParameters()
+			encryptedTable, _, privKeys, pubKeys := RandomEncryptedTable(k, b, t, indices, h)
+			p := RandomPlayer(indices)
+			i, j := rand.Intn(t), rand.Intn(int(b))
+			var ciphertext []byte
+			for _, share := range encryptedTable[i][j].Shares {
+				if share.Index.Eq(&indices[p]) {
+					ciphertext = share.Ciphertext
+				}
+			}
+			disclosure, err := ecies.Disclose(&privKeys[p], ciphertext)
+			Expect(err).ToNot(HaveOccurred())
+			complaint := Complaint{
+				Row:        uint32(i),
+				Element:    uint32(j),
+				Index:      indices[p],
+				Disclosure: disclosure,
+			}
+			Expect(encryptedTable.VerifyComplaint(complaint, pubKeys[p])).To(BeFalse())
+
+			// Complaints for positions outside of the table are also invalid.
+			complaint.Row = uint32(t)
+			Expect(encryptedTable.VerifyComplaint(complaint, pubKeys[p])).To(BeFalse())
+		})
+	})
+
+	Context("disputes", func() {
+		// RandomOutput returns the rows created by the first t players, and the
+		// column of the table of these rows for the given player.
+		RandomOutput := func(k, b uint32, t int, indices []secp256k1.Fn, index secp256k1.Fn, h secp256k1.Point) (
+			Table, []secp256k1.Fn, []shamir.VerifiableShares, [][]shamir.Commitment,
+		) {
+			table := make(Table, t)
+			for i := range table {
+				_, table[i] = New(b, k, indices, indices[i], h)
+			}
+			sharesBatch, commitmentsBatch := table.Column(index)
+			return table, indices[:t], sharesBatch, commitmentsBatch
+		}
+
+		Specify("there should be no complaints for a valid output", func() {
+			_, k, b, t, indices, index, h := RandomTestParameters()
+			_, dealerIndices, sharesBatch, commitmentsBatch := RandomOutput(k, b, t, indices, index, h)
+			brnger, _ := New(b, k, indices, index, h)
+			Expect(brnger.Complain(dealerIndices, sharesBatch, commitmentsBatch)).To(BeEmpty())
+		})
+
+		Specify("a dealer that reveals valid shares should not be disqualified", func() {
+			_, k, b, t, indices, index, h := RandomTestParameters()
+			table, dealerIndices, sharesBatch, commitmentsBatch := RandomOutput(k, b, t, indices, index, h)
+			brnger, _ := New(b, k, indices, index, h)
+			expectedShares, expectedCommitments := HandleConsensusOutput(sharesBatch, commitmentsBatch)
+
+			// The share received from the dealer is not valid, but the dealer
+			// reveals the valid share.
+			d := rand.Intn(t)
+			sharesBatch[rand.Intn(int(b))][d].Decommitment = secp256k1.RandomFn()
+			Expect(brnger.IsValid(sharesBatch, commitmentsBatch, t)).To(Equal(ErrInvalidShares))
+
+			complaints := brnger.Complain(dealerIndices, sharesBatch, commitmentsBatch)
+			Expect(len(complaints)).To(Equal(1))
+			Expect(complaints[0].Dealer.Eq(&dealerIndices[d])).To(BeTrue())
+			Expect(complaints[0].Index.Eq(&index)).To(BeTrue())
+			reveal, ok := Reveal(table[d], complaints[0])
+			Expect(ok).To(BeTrue())
+			reveals := []ShareReveal{reveal}
+
+			Expect(brnger.Disqualified(indices, dealerIndices, commitmentsBatch, complaints, reveals)).To(BeEmpty())
+			remaining, newSharesBatch, newCommitmentsBatch := brnger.HandleDisputes(
+				indices, dealerIndices, sharesBatch, commitmentsBatch, complaints, reveals,
+			)
+			Expect(len(remaining)).To(Equal(t))
+			Expect(brnger.IsValid(newSharesBatch, newCommitmentsBatch, t)).To(Succeed())
+			shares, commitments := HandleConsensusOutput(newSharesBatch, newCommitmentsBatch)
+			for j := range shares {
+				Expect(shares[j].Eq(&expectedShares[j])).To(BeTrue())
+				Expect(commitments[j].Eq(expectedCommitments[j])).To(BeTrue())
+			}
+		})
+
+		Specify("a dealer that does not reveal valid shares should be disqualified", func() {
+			_, k, b, t, indices, index, h := RandomTestParameters()
+			if t < 2 {
+				t = 2
+			}
+			table, dealerIndices, sharesBatch, commitmentsBatch := RandomOutput(k, b, t, indices, index, h)
+			brnger, _ := New(b, k, indices, index, h)
+
+			// Two dealers give invalid shares; one of them does not respond
+			// and the other reveals an invalid share.
+			perm := rand.Perm(t)
+			d1, d2 := perm[0], perm[1]
+			sharesBatch[rand.Intn(int(b))][d1].Share.Value = secp256k1.RandomFn()
+			sharesBatch[rand.Intn(int(b))][d2].Share.Value = secp256k1.RandomFn()
+			complaints := brnger.Complain(dealerIndices, sharesBatch, commitmentsBatch)
+			Expect(len(complaints)).To(Equal(2))
+			var reveals []ShareReveal
+			for _, complaint := range complaints {
+				if complaint.Dealer.Eq(&dealerIndices[d2]) {
+					reveal, ok := Reveal(table[d2], complaint)
+					Expect(ok).To(BeTrue())
+					reveal.Shares[0].Decommitment = secp256k1.RandomFn()
+					reveals = append(reveals, reveal)
+				}
+			}
+
+			disqualified := brnger.Disqualified(indices, dealerIndices, commitmentsBatch, complaints, reveals)
+			Expect(len(disqualified)).To(Equal(2))
+			remaining, newSharesBatch, newCommitmentsBatch := brnger.HandleDisputes(
+				indices, dealerIndices, sharesBatch, commitmentsBatch, complaints, reveals,
+			)
+			Expect(len(remaining)).To(Equal(t - 2))
+			for _, dealer := range remaining {
+				Expect(dealer.Eq(&dealerIndices[d1])).To(BeFalse())
+				Expect(dealer.Eq(&dealerIndices[d2])).To(BeFalse())
+			}
+			if t > 2 {
+				Expect(brnger.IsValid(newSharesBatch, newCommitmentsBatch, t-2)).To(Succeed())
+			}
+		})
+
+		Specify("complaints from unknown players and against non-dealers should be ignored", func() {
+			_, k, b, t, indices, index, h := RandomTestParameters()
+			_, dealerIndices, sharesBatch, commitmentsBatch := RandomOutput(k, b, t, indices, index, h)
+			brnger, _ := New(b, k, indices, index, h)
+			complaints := []ShareComplaint{
+				{Dealer: dealerIndices[rand.Intn(t)], Index: secp256k1.RandomFn()},
+				{Dealer: secp256k1.RandomFn(), Index: index},
+			}
+			Expect(brnger.Disqualified(indices, dealerIndices, commitmentsBatch, complaints, nil)).To(BeEmpty())
+			remaining, newSharesBatch, _ := brnger.HandleDisputes(
+				indices, dealerIndices, sharesBatch, commitmentsBatch, complaints, nil,
+			)
+			Expect(len(remaining)).To(Equal(t))
+			for j := range newSharesBatch {
+				for i := range newSharesBatch[j] {
+					Expect(newSharesBatch[j][i].Eq(&sharesBatch[j][i])).To(BeTrue())
+				}
+			}
+		})
+
+		Specify("nil shares should remain nil", func() {
+			_, k, b, t, indices, index, h := RandomTestParameters()
+			_, dealerIndices, _, commitmentsBatch := RandomOutput(k, b, t, indices, index, h)
+			brnger, _ := New(b, k, indices, index, h)
+			_, newSharesBatch, newCommitmentsBatch := brnger.HandleDisputes(
+				indices, dealerIndices, nil, commitmentsBatch, nil, nil,
+			)
+			Expect(newSharesBatch).To(BeNil())
+			Expect(len(newCommitmentsBatch)).To(Equal(int(b)))
 		})
 	})
 
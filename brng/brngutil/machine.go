@@ -2,6 +2,7 @@ package brngutil
 
 import (
 	"fmt"
+	"math/rand"
 
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
@@ -93,13 +94,22 @@ func (pm *PlayerMachine) Handle(msg mpcutil.Message) []mpcutil.Message {
 	return nil
 }
 
-// ConsensusMachine represents the trusted party for the consensus algorithm
-// used by the BRNG algorithm.
+// A ConsensusEngine is an implementation of the brng.Consensus interface that
+// can be used by a ConsensusMachine. The engine needs to be able to be
+// marshaled so that the state of the machine can be captured by the network
+// simulation.
+type ConsensusEngine interface {
+	brng.Consensus
+	surge.MarshalUnmarshaler
+}
+
+// ConsensusMachine represents the party or parties that run the consensus
+// algorithm used by the BRNG algorithm.
 type ConsensusMachine struct {
 	id        mpcutil.ID
 	playerIDs []mpcutil.ID
 	indices   []secp256k1.Fn
-	engine    mock.PullConsensus
+	engine    ConsensusEngine
 }
 
 // SizeHint implements the surge.SizeHinter interface.
@@ -128,8 +138,13 @@ func (cm ConsensusMachine) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	return buf, rem, err
 }
 
-// Unmarshal implements the surge.Unmarshaler interface.
+// Unmarshal implements the surge.Unmarshaler interface. If the machine does not
+// already have a consensus engine, the engine is assumed to be a
+// mock.PullConsensus.
 func (cm *ConsensusMachine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	if cm.engine == nil {
+		cm.engine = new(mock.PullConsensus)
+	}
 	buf, rem, err := cm.id.Unmarshal(buf, rem)
 	if err != nil {
 		return buf, rem, err
@@ -182,28 +197,8 @@ func (cm ConsensusMachine) formConsensusMessages() []mpcutil.Message {
 	var messages []mpcutil.Message
 
 	table := cm.engine.Table()
-	t := len(table)
-	b := len(table[0])
-
-	commitmentsBatch := make([][]shamir.Commitment, b)
-	for i := range commitmentsBatch {
-		commitmentsBatch[i] = make([]shamir.Commitment, t)
-		for j := range commitmentsBatch[i] {
-			commitmentsBatch[i][j] = table[j][i].Commitment
-		}
-	}
-
 	for i, id := range cm.playerIDs {
-		// index := cm.indices[i]
-		sharesBatch := make([]shamir.VerifiableShares, b)
-
-		for j := range sharesBatch {
-			sharesBatch[j] = make(shamir.VerifiableShares, t)
-			for k := range sharesBatch[j] {
-				sharesBatch[j][k] = table[k][j].Shares[i]
-			}
-		}
-
+		sharesBatch, commitmentsBatch := table.Column(cm.indices[i])
 		message := BrngMessage{
 			msg: &ConsensusMessage{
 				from:             cm.id,
@@ -233,7 +228,9 @@ type BrngMachine struct {
 // corresponding Shamir indices is given by the indices argument. The
 // honestIndices argument is the list of those indices for which the players
 // are honest; neither offline nor malicious. h is the Pedersen parameter, k is
-// the Shamir threshold and b is the batch size.
+// the Shamir threshold and b is the batch size. The consensus trusted party
+// uses a mock.PullConsensus engine; to use a different engine, see
+// NewConsensusMachine.
 func NewMachine(
 	machineType TypeID,
 	id, consID mpcutil.ID,
@@ -261,23 +258,49 @@ func NewMachine(
 	}
 
 	if machineType == BrngTypeConsensus {
-		engine := mock.NewPullConsensus(indices, honestIndices, k-1, h)
-
-		cmachine := ConsensusMachine{
-			id:        consID,
-			playerIDs: playerIDs,
-			indices:   indices,
-			engine:    engine,
+		// The engine checks the validity of rows using the state machines of
+		// a random subset of k of the honest players.
+		shuffled := make([]secp256k1.Fn, len(honestIndices))
+		copy(shuffled, honestIndices)
+		rand.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		honest := make([]brng.BRNGer, k)
+		for i := range honest {
+			honest[i], _ = brng.New(uint32(b), uint32(k), indices, shuffled[i], h)
 		}
+		engine := mock.NewPullConsensus(honest, k-1)
 
-		return BrngMachine{
-			machine: &cmachine,
-		}
+		return NewConsensusMachine(consID, playerIDs, indices, &engine)
 	}
 
 	panic("unexpected machine type")
 }
 
+// NewConsensusMachine constructs a new machine for the BRNG algorithm tests
+// that represents the consensus algorithm, using the given consensus engine.
+// This allows implementations of the brng.Consensus interface other than the
+// mock to be tested using the same network simulation as the mock. The
+// machine will have an ID given by the id argument, the IDs of all of the
+// players in the network is playerIDs and the corresponding Shamir indices is
+// given by the indices argument.
+func NewConsensusMachine(
+	id mpcutil.ID,
+	playerIDs []mpcutil.ID,
+	indices []secp256k1.Fn,
+	engine ConsensusEngine,
+) BrngMachine {
+	cmachine := ConsensusMachine{
+		id:        id,
+		playerIDs: playerIDs,
+		indices:   indices,
+		engine:    engine,
+	}
+	return BrngMachine{
+		machine: &cmachine,
+	}
+}
+
 // SizeHint implements the surge.SizeHinter interface.
 func (bm BrngMachine) SizeHint() int {
 	return 1 + bm.machine.SizeHint()
@@ -0,0 +1,99 @@
+package brng
+
+import (
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// A Table is the table of sharings that the players in the BRNG protocol
+// agree on using a consensus algorithm. Each row of the table is the batch of
+// sharings created by one of the players (the dealer of that row) using New,
+// so table[i][j] is the sharing for the jth element of the batch by the ith
+// dealer.
+type Table [][]Sharing
+
+// Column returns the shares for the player with the given index and the
+// commitments from the table, in the form that is expected by IsValid and
+// HandleConsensusOutput; that is, sharesBatch[j][i] and commitmentsBatch[j][i]
+// are the share and commitment for the jth element of the batch by the ith
+// dealer. The batch size is taken to be the length of the first row. If a
+// sharing does not contain a share for the given index, or a row is too short,
+// the corresponding entries are left as zero values, which will fail the
+// checks in IsValid.
+func (table Table) Column(index secp256k1.Fn) ([]shamir.VerifiableShares, [][]shamir.Commitment) {
+	if len(table) == 0 {
+		return nil, nil
+	}
+	b := len(table[0])
+	sharesBatch := make([]shamir.VerifiableShares, b)
+	commitmentsBatch := make([][]shamir.Commitment, b)
+	for j := 0; j < b; j++ {
+		sharesBatch[j] = make(shamir.VerifiableShares, len(table))
+		commitmentsBatch[j] = make([]shamir.Commitment, len(table))
+		for i, row := range table {
+			if j >= len(row) {
+				continue
+			}
+			commitmentsBatch[j][i] = row[j].Commitment
+			for _, share := range row[j].Shares {
+				if share.Share.IndexEq(&index) {
+					sharesBatch[j][i] = share
+					break
+				}
+			}
+		}
+	}
+	return sharesBatch, commitmentsBatch
+}
+
+// IsValidTable checks the validity of the given potential consensus output
+// from the point of view of this player. The column of the table for the
+// player is checked using IsValid, after first checking that every row of the
+// table has the correct batch size.
+//
+// Panics: This function will panic if the given required contributions is less
+// than 1.
+func (brnger *BRNGer) IsValidTable(table Table, requiredContributions int) error {
+	for _, row := range table {
+		if uint32(len(row)) != brnger.batchSize {
+			return ErrIncorrectCommitmentsBatchSize
+		}
+	}
+	sharesBatch, commitmentsBatch := table.Column(brnger.index)
+	return brnger.IsValid(sharesBatch, commitmentsBatch, requiredContributions)
+}
+
+// Consensus is the interface for the consensus algorithm that is used by the
+// players in the BRNG protocol to agree on a table of sharings. The protocol
+// uses the consensus algorithm as follows.
+//	1. Each player creates its row of sharings using New, and proposes it
+//	using HandleRow.
+//	2. The consensus algorithm decides on a table consisting of rows from at
+//	least the required number of dealers (usually the reconstruction threshold
+//	k). Before a table is decided, enough honest players must have found it to
+//	be valid using BRNGer.IsValidTable (or equivalently BRNGer.IsValid on
+//	their column of the table), so that at least k honest players will be able
+//	to use their shares.
+//	3. Once the table has been decided, Done returns true and the table is
+//	delivered to the players by Table. Each player then uses the column of the
+//	table for its index (see Table.Column) as the input to
+//	HandleConsensusOutput, passing nil shares if its column was not valid.
+//	Alternatively, players whose column was not valid can complain about the
+//	dealers of the invalid shares in a dispute round (see ShareComplaint)
+//	before calling HandleConsensusOutput.
+//
+// The mock.PullConsensus type is an implementation of this interface that
+// acts as an ideal trusted party, and is intended for testing.
+type Consensus interface {
+	// HandleRow proposes the given row of sharings for inclusion in the
+	// table. The return value is true if a table has been decided, either
+	// before or as a result of handling this row.
+	HandleRow(row []Sharing) bool
+
+	// Done returns true if a table has been decided.
+	Done() bool
+
+	// Table returns the decided table. It should only be called once Done
+	// returns true.
+	Table() Table
+}
@@ -0,0 +1,211 @@
+package brng
+
+import (
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// A ShareComplaint is published by a player when the shares that it received
+// from a dealer in the consensus output are not valid. The dealer is the index
+// of the dealer, and the index is the index of the complaining player. It is
+// assumed that complaints are published over an authenticated channel, such as
+// the consensus algorithm, so that a player can not complain on behalf of
+// another player.
+//
+// The dispute round proceeds as follows.
+//	1. Each player checks the consensus output using IsValid, and if it is not
+//	valid, it uses Complain to find the dealers whose shares are invalid and
+//	publishes the resulting complaints.
+//	2. Each dealer that has been complained about uses Reveal to publish the
+//	disputed shares.
+//	3. Once the complaints and reveals have been agreed on, every player uses
+//	HandleDisputes to remove the rows of the dealers that have been
+//	disqualified from the consensus output, which is the same for every
+//	player. If the complaints of a player were rejected, the revealed shares
+//	are used in place of the shares that it received, so that honest players
+//	keep usable shares.
+//	4. The players then use IsValid and HandleConsensusOutput on the result
+//	as usual.
+type ShareComplaint struct {
+	Dealer, Index secp256k1.Fn
+}
+
+// A ShareReveal is published by a dealer in response to a complaint, and
+// contains the shares for the complaining player for every element of the
+// batch.
+type ShareReveal struct {
+	Dealer, Index secp256k1.Fn
+	Shares        shamir.VerifiableShares
+}
+
+// Complain returns the complaints of this player against the dealers in the
+// given consensus output, which has the same form as for IsValid, and where
+// the ith contribution is from the dealer with index dealerIndices[i]. A
+// complaint is returned for each dealer for which any of the shares are
+// missing, do not have the index of this player or are not valid. It is
+// assumed that the commitments have the correct dimensions, that is, IsValid
+// did not return ErrIncorrectCommitmentsBatchSize,
+// ErrNotEnoughContributions or ErrInvalidCommitmentDimensions.
+func (brnger *BRNGer) Complain(
+	dealerIndices []secp256k1.Fn,
+	sharesBatch []shamir.VerifiableShares,
+	commitmentsBatch [][]shamir.Commitment,
+) []ShareComplaint {
+	var complaints []ShareComplaint
+	for i := range dealerIndices {
+		valid := len(sharesBatch) == len(commitmentsBatch)
+		for j := 0; valid && j < len(commitmentsBatch); j++ {
+			valid = i < len(sharesBatch[j]) && i < len(commitmentsBatch[j]) &&
+				sharesBatch[j][i].Share.IndexEq(&brnger.index) &&
+				msm.IsValidSecret(&brnger.h, &commitmentsBatch[j][i], &sharesBatch[j][i])
+		}
+		if !valid {
+			complaints = append(complaints, ShareComplaint{Dealer: dealerIndices[i], Index: brnger.index})
+		}
+	}
+	return complaints
+}
+
+// Reveal returns the response of a dealer to the given complaint, where the
+// row is the batch of sharings that the dealer created using New. The return
+// value is false if the row does not contain shares for the complaining
+// player, in which case the complaint should be ignored, as it will also be
+// ignored by HandleDisputes.
+func Reveal(row []Sharing, complaint ShareComplaint) (ShareReveal, bool) {
+	shares := make(shamir.VerifiableShares, len(row))
+	for j, sharing := range row {
+		found := false
+		for _, share := range sharing.Shares {
+			if share.Share.IndexEq(&complaint.Index) {
+				shares[j] = share
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ShareReveal{}, false
+		}
+	}
+	return ShareReveal{Dealer: complaint.Dealer, Index: complaint.Index, Shares: shares}, true
+}
+
+// Disqualified returns the indices of the dealers that should be disqualified
+// based on the given complaints and reveals. The indices are the indices of
+// all of the players, and the dealer indices and commitments are the same as
+// for Complain. A dealer is disqualified if there is a complaint against it
+// for which it has not revealed valid shares. Complaints from players whose
+// index is not in the set of indices, and complaints against players that are
+// not dealers, are ignored.
+func (brnger *BRNGer) Disqualified(
+	indices, dealerIndices []secp256k1.Fn,
+	commitmentsBatch [][]shamir.Commitment,
+	complaints []ShareComplaint,
+	reveals []ShareReveal,
+) []secp256k1.Fn {
+	var disqualified []secp256k1.Fn
+	for _, complaint := range complaints {
+		row := position(dealerIndices, complaint.Dealer)
+		if row == -1 || position(indices, complaint.Index) == -1 {
+			continue
+		}
+		if position(disqualified, complaint.Dealer) != -1 {
+			continue
+		}
+		if _, ok := validReveal(brnger.hTable, row, commitmentsBatch, complaint, reveals); !ok {
+			disqualified = append(disqualified, complaint.Dealer)
+		}
+	}
+	return disqualified
+}
+
+// HandleDisputes returns the consensus output with the contributions of the
+// disqualified dealers (see Disqualified) removed, along with the indices of
+// the dealers for the remaining contributions. The arguments are the same as
+// for Disqualified, along with the shares of this player in the same form as
+// for IsValid. For each of the complaints of this player that was rejected,
+// the shares that it received from the dealer are replaced by the revealed
+// shares. If the shares are nil, the returned shares will also be nil.
+func (brnger *BRNGer) HandleDisputes(
+	indices, dealerIndices []secp256k1.Fn,
+	sharesBatch []shamir.VerifiableShares,
+	commitmentsBatch [][]shamir.Commitment,
+	complaints []ShareComplaint,
+	reveals []ShareReveal,
+) ([]secp256k1.Fn, []shamir.VerifiableShares, [][]shamir.Commitment) {
+	disqualified := brnger.Disqualified(indices, dealerIndices, commitmentsBatch, complaints, reveals)
+
+	remaining := make([]secp256k1.Fn, 0, len(dealerIndices))
+	var newSharesBatch []shamir.VerifiableShares
+	if sharesBatch != nil {
+		newSharesBatch = make([]shamir.VerifiableShares, len(sharesBatch))
+	}
+	newCommitmentsBatch := make([][]shamir.Commitment, len(commitmentsBatch))
+	for i, dealer := range dealerIndices {
+		if position(disqualified, dealer) != -1 {
+			continue
+		}
+		remaining = append(remaining, dealer)
+		for j := range newCommitmentsBatch {
+			newCommitmentsBatch[j] = append(newCommitmentsBatch[j], commitmentsBatch[j][i])
+		}
+		if sharesBatch == nil {
+			continue
+		}
+
+		// If this player complained about the dealer and the dealer was not
+		// disqualified, the dealer must have revealed valid shares.
+		complaint := ShareComplaint{Dealer: dealer, Index: brnger.index}
+		reveal, complained := validReveal(brnger.hTable, i, commitmentsBatch, complaint, reveals)
+		for j := range newSharesBatch {
+			share := shamir.VerifiableShare{}
+			if complained {
+				share = reveal.Shares[j]
+			} else if i < len(sharesBatch[j]) {
+				share = sharesBatch[j][i]
+			}
+			newSharesBatch[j] = append(newSharesBatch[j], share)
+		}
+	}
+	return remaining, newSharesBatch, newCommitmentsBatch
+}
+
+// validReveal returns the first of the given reveals that is a valid response
+// to the given complaint against the dealer for the given row, and false if
+// there is no such reveal.
+func validReveal(
+	hTable *msm.FixedBase,
+	row int,
+	commitmentsBatch [][]shamir.Commitment,
+	complaint ShareComplaint,
+	reveals []ShareReveal,
+) (ShareReveal, bool) {
+	for _, reveal := range reveals {
+		if !reveal.Dealer.Eq(&complaint.Dealer) || !reveal.Index.Eq(&complaint.Index) {
+			continue
+		}
+		if len(reveal.Shares) != len(commitmentsBatch) {
+			continue
+		}
+		valid := true
+		for j := 0; valid && j < len(commitmentsBatch); j++ {
+			valid = reveal.Shares[j].Share.IndexEq(&complaint.Index) &&
+				msm.IsValid(hTable, &commitmentsBatch[j][row], &reveal.Shares[j])
+		}
+		if valid {
+			return reveal, true
+		}
+	}
+	return ShareReveal{}, false
+}
+
+// position returns the position of the given index in the given slice, or -1
+// if it is not in the slice.
+func position(indices []secp256k1.Fn, index secp256k1.Fn) int {
+	for i := range indices {
+		if indices[i].Eq(&index) {
+			return i
+		}
+	}
+	return -1
+}
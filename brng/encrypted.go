@@ -0,0 +1,233 @@
+package brng
+
+import (
+	"fmt"
+
+	"github.com/renproject/mpc/ecies"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// An EncryptedShare is a verifiable share that has been encrypted using ECIES
+// for the player with the given index.
+type EncryptedShare struct {
+	Index      secp256k1.Fn
+	Ciphertext []byte
+}
+
+// An EncryptedSharing is a Sharing in which each of the shares has been
+// encrypted for the player that it is for. Since the data that is submitted to
+// the consensus algorithm is public, sharings should be encrypted before they
+// are submitted, as otherwise anyone would be able to reconstruct the secrets.
+type EncryptedSharing struct {
+	Shares     []EncryptedShare
+	Commitment shamir.Commitment
+}
+
+// An EncryptedTable is a Table in which the shares have been encrypted.
+type EncryptedTable [][]EncryptedSharing
+
+// A Complaint is a proof that the share for a player in an EncryptedTable can
+// not be decrypted, and so that the dealer of the row is malicious. The row and
+// element give the position of the sharing in the table, and the index is the
+// index of the player that is complaining. The disclosure is the shared secret
+// for the ciphertext, which allows anyone to check that the ciphertext can not
+// be decrypted; see EncryptedTable.VerifyComplaint. If the ciphertext is
+// malformed, this can be checked without the disclosure, and so it is left as
+// the zero value.
+type Complaint struct {
+	Row, Element uint32
+	Index        secp256k1.Fn
+	Disclosure   ecies.Disclosure
+}
+
+// EncryptSharings encrypts each of the shares in the given sharings, such as
+// those returned by New, for the player that it is for. The public key for the
+// player with index indices[i] is pubKeys[i].
+//
+// Panics: This function will panic if the number of public keys is not equal
+// to the number of indices, or if one of the shares has an index that is not
+// in the set of indices.
+func EncryptSharings(sharings []Sharing, indices []secp256k1.Fn, pubKeys []secp256k1.Point) []EncryptedSharing {
+	if len(indices) != len(pubKeys) {
+		panic(fmt.Sprintf(
+			"number of public keys should equal number of indices: expected %v, got %v",
+			len(indices), len(pubKeys),
+		))
+	}
+	encrypted := make([]EncryptedSharing, len(sharings))
+	for i, sharing := range sharings {
+		encrypted[i].Shares = make([]EncryptedShare, len(sharing.Shares))
+		encrypted[i].Commitment.Set(sharing.Commitment)
+		for j, share := range sharing.Shares {
+			pos := -1
+			for l := range indices {
+				if share.Share.IndexEq(&indices[l]) {
+					pos = l
+					break
+				}
+			}
+			if pos == -1 {
+				panic("share index is not in the set of indices")
+			}
+			plaintext, err := surge.ToBinary(share)
+			if err != nil {
+				panic(fmt.Sprintf("marshaling share: %v", err))
+			}
+			encrypted[i].Shares[j] = EncryptedShare{
+				Index:      share.Share.Index,
+				Ciphertext: ecies.Encrypt(&pubKeys[pos], plaintext),
+			}
+		}
+	}
+	return encrypted
+}
+
+// DecryptColumn decrypts the shares for this player in the given table using
+// the given private key, and returns them along with the commitments in the
+// same form as Table.Column. If any of the shares can not be decrypted, a
+// complaint is returned for each of them, and the corresponding entries are
+// left as zero values. As for Table.Column, missing shares and short rows also
+// result in zero values.
+func (brnger *BRNGer) DecryptColumn(privKey *secp256k1.Fn, table EncryptedTable) (
+	[]shamir.VerifiableShares, [][]shamir.Commitment, []Complaint,
+) {
+	if len(table) == 0 {
+		return nil, nil, nil
+	}
+	b := len(table[0])
+	sharesBatch := make([]shamir.VerifiableShares, b)
+	commitmentsBatch := make([][]shamir.Commitment, b)
+	var complaints []Complaint
+	for j := 0; j < b; j++ {
+		sharesBatch[j] = make(shamir.VerifiableShares, len(table))
+		commitmentsBatch[j] = make([]shamir.Commitment, len(table))
+		for i, row := range table {
+			if j >= len(row) {
+				continue
+			}
+			commitmentsBatch[j][i] = row[j].Commitment
+			for _, share := range row[j].Shares {
+				if !share.Index.Eq(&brnger.index) {
+					continue
+				}
+				plaintext, err := ecies.Decrypt(privKey, share.Ciphertext)
+				if err == nil {
+					sharesBatch[j][i], err = decodeShare(plaintext, &brnger.index)
+				}
+				if err != nil {
+					// The disclosure can only fail to be created if the
+					// ciphertext is malformed, in which case it is not needed.
+					disclosure, _ := ecies.Disclose(privKey, share.Ciphertext)
+					complaints = append(complaints, Complaint{
+						Row:        uint32(i),
+						Element:    uint32(j),
+						Index:      brnger.index,
+						Disclosure: disclosure,
+					})
+				}
+				break
+			}
+		}
+	}
+	return sharesBatch, commitmentsBatch, complaints
+}
+
+// IsValidEncrypted checks the validity of the given encrypted potential
+// consensus output from the point of view of this player. The shares for this
+// player are decrypted using the given private key, and if any of them can not
+// be decrypted, ErrUndecryptableShares is returned along with the
+// corresponding complaints, which should be broadcast so that the dealers can
+// be held responsible. Otherwise, the decrypted shares are checked in the same
+// way as for IsValidTable.
+//
+// Panics: This function will panic if the given required contributions is less
+// than 1.
+func (brnger *BRNGer) IsValidEncrypted(
+	privKey *secp256k1.Fn,
+	table EncryptedTable,
+	requiredContributions int,
+) ([]Complaint, error) {
+	if requiredContributions < 1 {
+		panic(fmt.Sprintf("required contributions must be at least 1: got %v", requiredContributions))
+	}
+	for _, row := range table {
+		if uint32(len(row)) != brnger.batchSize {
+			return nil, ErrIncorrectCommitmentsBatchSize
+		}
+	}
+	sharesBatch, commitmentsBatch, complaints := brnger.DecryptColumn(privKey, table)
+	if len(complaints) != 0 {
+		return complaints, ErrUndecryptableShares
+	}
+	return nil, brnger.IsValid(sharesBatch, commitmentsBatch, requiredContributions)
+}
+
+// HandleEncryptedConsensusOutput is the same as HandleConsensusOutput, but
+// takes the encrypted table that is output by the consensus algorithm. The
+// shares for this player are decrypted using the given private key, and if
+// they can not all be decrypted or are not valid, using the given required
+// contributions as for IsValid, the returned shares will be nil. It is
+// assumed that the table is valid for at least k players, which should be the
+// case if it was checked using IsValidEncrypted during consensus.
+//
+// Panics: This function will panic if the given required contributions is less
+// than 1.
+func (brnger *BRNGer) HandleEncryptedConsensusOutput(
+	privKey *secp256k1.Fn,
+	table EncryptedTable,
+	requiredContributions int,
+) (
+	shamir.VerifiableShares, []shamir.Commitment,
+) {
+	sharesBatch, commitmentsBatch, complaints := brnger.DecryptColumn(privKey, table)
+	if len(complaints) != 0 || brnger.IsValid(sharesBatch, commitmentsBatch, requiredContributions) != nil {
+		sharesBatch = nil
+	}
+	return HandleConsensusOutput(sharesBatch, commitmentsBatch)
+}
+
+// VerifyComplaint returns true if the given complaint is a valid proof that
+// the share for the complaining player in the table can not be decrypted, and
+// false otherwise. The public key is the public key of the complaining player.
+func (table EncryptedTable) VerifyComplaint(complaint Complaint, pubKey secp256k1.Point) bool {
+	if int(complaint.Row) >= len(table) || int(complaint.Element) >= len(table[complaint.Row]) {
+		return false
+	}
+	sharing := table[complaint.Row][complaint.Element]
+	for _, share := range sharing.Shares {
+		if !share.Index.Eq(&complaint.Index) {
+			continue
+		}
+		plaintext, err := ecies.DecryptWithSecret(&complaint.Disclosure.Secret, share.Ciphertext)
+		if err == ecies.ErrMalformedCiphertext {
+			// A malformed ciphertext can not be decrypted by anyone, and so
+			// the disclosure is not needed.
+			return true
+		}
+		if !complaint.Disclosure.Verify(&pubKey, share.Ciphertext) {
+			return false
+		}
+		if err != nil {
+			return true
+		}
+		_, err = decodeShare(plaintext, &complaint.Index)
+		return err != nil
+	}
+	return false
+}
+
+// decodeShare unmarshals the verifiable share from the given plaintext,
+// returning ErrUndecryptableShares if it can not be unmarshaled or does not
+// have the given index.
+func decodeShare(plaintext []byte, index *secp256k1.Fn) (shamir.VerifiableShare, error) {
+	var share shamir.VerifiableShare
+	if err := surge.FromBinary(&share, plaintext); err != nil {
+		return shamir.VerifiableShare{}, ErrUndecryptableShares
+	}
+	if !share.Share.IndexEq(index) {
+		return shamir.VerifiableShare{}, ErrUndecryptableShares
+	}
+	return share, nil
+}
@@ -35,4 +35,8 @@ var (
 	// ErrNotEnoughContributions is returned when the number of contributions
 	// from other players is smaller than the number of required contributions.
 	ErrNotEnoughContributions = errors.New("not enough contributions")
+
+	// ErrUndecryptableShares is returned when not all of the encrypted shares
+	// for the BRNGer can be decrypted to a share with the correct index.
+	ErrUndecryptableShares = errors.New("undecryptable shares")
 )
@@ -5,7 +5,10 @@ import (
 	"math/rand"
 	"reflect"
 
+	"github.com/renproject/mpc/ecies"
+	"github.com/renproject/mpc/msm"
 	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
 	"github.com/renproject/surge"
 )
 
@@ -14,7 +17,7 @@ func (brnger BRNGer) Generate(_ *rand.Rand, _ int) reflect.Value {
 	batchSize := rand.Uint32()
 	index := secp256k1.RandomFn()
 	h := secp256k1.RandomPoint()
-	return reflect.ValueOf(BRNGer{batchSize, index, h})
+	return reflect.ValueOf(BRNGer{batchSize, index, h, msm.NewFixedBase(h)})
 }
 
 // SizeHint implements the surge.SizeHinter interface.
@@ -55,5 +58,167 @@ func (brnger *BRNGer) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, fmt.Errorf("unmarshaling h: %v", err)
 	}
+	brnger.hTable = msm.NewFixedBase(brnger.h)
+	return buf, rem, nil
+}
+
+// Generate implements the quick.Generator interface.
+func (complaint Complaint) Generate(_ *rand.Rand, size int) reflect.Value {
+	c := Complaint{
+		Row:        rand.Uint32(),
+		Element:    rand.Uint32(),
+		Index:      secp256k1.RandomFn(),
+		Disclosure: ecies.Disclosure{}.Generate(nil, size).Interface().(ecies.Disclosure),
+	}
+	return reflect.ValueOf(c)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (complaint Complaint) SizeHint() int {
+	return surge.SizeHint(complaint.Row) +
+		surge.SizeHint(complaint.Element) +
+		complaint.Index.SizeHint() +
+		complaint.Disclosure.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (complaint Complaint) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.MarshalU32(complaint.Row, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling row: %v", err)
+	}
+	buf, rem, err = surge.MarshalU32(complaint.Element, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling element: %v", err)
+	}
+	buf, rem, err = complaint.Index.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling index: %v", err)
+	}
+	buf, rem, err = complaint.Disclosure.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling disclosure: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (complaint *Complaint) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.UnmarshalU32(&complaint.Row, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling row: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalU32(&complaint.Element, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling element: %v", err)
+	}
+	buf, rem, err = complaint.Index.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling index: %v", err)
+	}
+	buf, rem, err = complaint.Disclosure.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling disclosure: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Generate implements the quick.Generator interface.
+func (complaint ShareComplaint) Generate(_ *rand.Rand, _ int) reflect.Value {
+	c := ShareComplaint{
+		Dealer: secp256k1.RandomFn(),
+		Index:  secp256k1.RandomFn(),
+	}
+	return reflect.ValueOf(c)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (complaint ShareComplaint) SizeHint() int {
+	return complaint.Dealer.SizeHint() + complaint.Index.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (complaint ShareComplaint) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := complaint.Dealer.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling dealer: %v", err)
+	}
+	buf, rem, err = complaint.Index.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling index: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (complaint *ShareComplaint) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := complaint.Dealer.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling dealer: %v", err)
+	}
+	buf, rem, err = complaint.Index.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling index: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Generate implements the quick.Generator interface.
+func (reveal ShareReveal) Generate(_ *rand.Rand, size int) reflect.Value {
+	// A verifiable share is more or less 3 field elements that contain 4
+	// uint64s.
+	shares := make(shamir.VerifiableShares, rand.Intn(size/12+1))
+	for i := range shares {
+		shares[i] = shamir.NewVerifiableShare(
+			shamir.NewShare(secp256k1.RandomFn(), secp256k1.RandomFn()),
+			secp256k1.RandomFn(),
+		)
+	}
+	r := ShareReveal{
+		Dealer: secp256k1.RandomFn(),
+		Index:  secp256k1.RandomFn(),
+		Shares: shares,
+	}
+	return reflect.ValueOf(r)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (reveal ShareReveal) SizeHint() int {
+	return reveal.Dealer.SizeHint() +
+		reveal.Index.SizeHint() +
+		reveal.Shares.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (reveal ShareReveal) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := reveal.Dealer.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling dealer: %v", err)
+	}
+	buf, rem, err = reveal.Index.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling index: %v", err)
+	}
+	buf, rem, err = reveal.Shares.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling shares: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (reveal *ShareReveal) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := reveal.Dealer.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling dealer: %v", err)
+	}
+	buf, rem, err = reveal.Index.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling index: %v", err)
+	}
+	buf, rem, err = reveal.Shares.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling shares: %v", err)
+	}
 	return buf, rem, nil
 }
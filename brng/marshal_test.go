@@ -13,47 +13,55 @@ import (
 
 var _ = Describe("Surge marshalling", func() {
 	trials := 10
-	t := reflect.TypeOf(brng.BRNGer{})
+	tys := []reflect.Type{
+		reflect.TypeOf(brng.BRNGer{}),
+		reflect.TypeOf(brng.Complaint{}),
+		reflect.TypeOf(brng.ShareComplaint{}),
+		reflect.TypeOf(brng.ShareReveal{}),
+	}
 
-	Context(fmt.Sprintf("surge marshalling and unmarshalling for %v", t), func() {
-		It("should be the same after marshalling and unmarshalling", func() {
-			for i := 0; i < trials; i++ {
-				Expect(surgeutil.MarshalUnmarshalCheck(t)).To(Succeed())
-			}
-		})
-
-		It("should not panic when fuzzing", func() {
-			for i := 0; i < trials; i++ {
-				Expect(func() { surgeutil.Fuzz(t) }).ToNot(Panic())
-			}
-		})
-
-		Context("marshalling", func() {
-			It("should return an error when the buffer is too small", func() {
+	for _, t := range tys {
+		t := t
+		Context(fmt.Sprintf("surge marshalling and unmarshalling for %v", t), func() {
+			It("should be the same after marshalling and unmarshalling", func() {
 				for i := 0; i < trials; i++ {
-					Expect(surgeutil.MarshalBufTooSmall(t)).To(Succeed())
+					Expect(surgeutil.MarshalUnmarshalCheck(t)).To(Succeed())
 				}
 			})
 
-			It("should return an error when the memory quota is too small", func() {
+			It("should not panic when fuzzing", func() {
 				for i := 0; i < trials; i++ {
-					Expect(surgeutil.MarshalRemTooSmall(t)).To(Succeed())
+					Expect(func() { surgeutil.Fuzz(t) }).ToNot(Panic())
 				}
 			})
-		})
 
-		Context("unmarshalling", func() {
-			It("should return an error when the buffer is too small", func() {
-				for i := 0; i < trials; i++ {
-					Expect(surgeutil.UnmarshalBufTooSmall(t)).To(Succeed())
-				}
+			Context("marshalling", func() {
+				It("should return an error when the buffer is too small", func() {
+					for i := 0; i < trials; i++ {
+						Expect(surgeutil.MarshalBufTooSmall(t)).To(Succeed())
+					}
+				})
+
+				It("should return an error when the memory quota is too small", func() {
+					for i := 0; i < trials; i++ {
+						Expect(surgeutil.MarshalRemTooSmall(t)).To(Succeed())
+					}
+				})
 			})
 
-			It("should return an error when the memory quota is too small", func() {
-				for i := 0; i < trials; i++ {
-					Expect(surgeutil.UnmarshalRemTooSmall(t)).To(Succeed())
-				}
+			Context("unmarshalling", func() {
+				It("should return an error when the buffer is too small", func() {
+					for i := 0; i < trials; i++ {
+						Expect(surgeutil.UnmarshalBufTooSmall(t)).To(Succeed())
+					}
+				})
+
+				It("should return an error when the memory quota is too small", func() {
+					for i := 0; i < trials; i++ {
+						Expect(surgeutil.UnmarshalRemTooSmall(t)).To(Succeed())
+					}
+				})
 			})
 		})
-	})
+	}
 })
@@ -4,32 +4,27 @@ import (
 	"fmt"
 	"math/rand"
 
-	"github.com/renproject/secp256k1"
-	"github.com/renproject/shamir"
 	"github.com/renproject/surge"
 
 	"github.com/renproject/mpc/brng"
 )
 
 // PullConsensus represents an ideal trusted party for achieving consensus on a
-// table of shares to be used during the BRNG protocol.
+// table of shares to be used during the BRNG protocol. It implements the
+// brng.Consensus interface.
 type PullConsensus struct {
 	done         bool
-	indices      []secp256k1.Fn
-	honestSubset []secp256k1.Fn
+	honestSubset []brng.BRNGer
 	threshold    int32
-	table        [][]brng.Sharing
-	h            secp256k1.Point
+	table        brng.Table
 }
 
 // SizeHint implements the surge.SizeHinter interface.
 func (pc PullConsensus) SizeHint() int {
 	return surge.SizeHint(pc.done) +
-		surge.SizeHint(pc.indices) +
 		surge.SizeHint(pc.honestSubset) +
 		surge.SizeHint(pc.threshold) +
-		surge.SizeHint(pc.table) +
-		pc.h.SizeHint()
+		surge.SizeHint(pc.table)
 }
 
 // Marshal implements the surge.Marshaler interface.
@@ -38,10 +33,6 @@ func (pc PullConsensus) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, fmt.Errorf("error marshaling done: %v", err)
 	}
-	buf, rem, err = surge.Marshal(pc.indices, buf, rem)
-	if err != nil {
-		return buf, rem, fmt.Errorf("error marshaling indices: %v", err)
-	}
 	buf, rem, err = surge.Marshal(pc.honestSubset, buf, rem)
 	if err != nil {
 		return buf, rem, fmt.Errorf("error marshaling honestSubset: %v", err)
@@ -54,23 +45,15 @@ func (pc PullConsensus) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, fmt.Errorf("error marshaling table: %v", err)
 	}
-	buf, rem, err = pc.h.Marshal(buf, rem)
-	if err != nil {
-		return buf, rem, fmt.Errorf("error marshaling h: %v", err)
-	}
 	return buf, rem, nil
 }
 
 // Unmarshal implements the surge.Unmarshaler interface.
-func (pc PullConsensus) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+func (pc *PullConsensus) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	buf, rem, err := surge.UnmarshalBool(&pc.done, buf, rem)
 	if err != nil {
 		return buf, rem, fmt.Errorf("error unmarshaling done: %v", err)
 	}
-	buf, rem, err = surge.Unmarshal(&pc.indices, buf, rem)
-	if err != nil {
-		return buf, rem, fmt.Errorf("error unmarshaling indices: %v", err)
-	}
 	buf, rem, err = surge.Unmarshal(&pc.honestSubset, buf, rem)
 	if err != nil {
 		return buf, rem, fmt.Errorf("error unmarshaling honestSubset: %v", err)
@@ -83,81 +66,71 @@ func (pc PullConsensus) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, fmt.Errorf("error unmarshaling table: %v", err)
 	}
-	buf, rem, err = pc.h.Unmarshal(buf, rem)
-	if err != nil {
-		return buf, rem, fmt.Errorf("error unmarshaling h: %v", err)
-	}
 	return buf, rem, nil
 }
 
-// NewPullConsensus constructs a new mock consensus object. The honest indices
-// represent the indices of the honest players and the adversary count
-// represents the maximum number of adversaries that there will be. `h`
-// represents the Pedersen commitment parameter.
-func NewPullConsensus(inds, honestIndices []secp256k1.Fn, advCount int, h secp256k1.Point) PullConsensus {
-	var table [][]brng.Sharing
-
-	done := false
-	threshold := int32(advCount) + 1
-	indices := make([]secp256k1.Fn, len(inds))
-	copy(indices, inds)
+// NewPullConsensus constructs a new mock consensus object. The honest BRNGers
+// are the state machines of the honest players, which are used to check the
+// validity of the rows, and the adversary count represents the maximum number
+// of adversaries that there will be.
+//
+// Panics: This function will panic if there are fewer than advCount+1 honest
+// BRNGers.
+func NewPullConsensus(honest []brng.BRNGer, advCount int) PullConsensus {
+	if len(honest) < advCount+1 {
+		panic(fmt.Sprintf(
+			"not enough honest players: expected at least %v, got %v",
+			advCount+1, len(honest),
+		))
+	}
 
 	// Pick a random subset of honest parties that we will require to agree in
 	// consensus.
-	honestSubset := make([]secp256k1.Fn, len(honestIndices))
-	copy(honestSubset, honestIndices)
+	honestSubset := make([]brng.BRNGer, len(honest))
+	copy(honestSubset, honest)
 	rand.Shuffle(len(honestSubset), func(i, j int) {
 		honestSubset[i], honestSubset[j] = honestSubset[j], honestSubset[i]
 	})
 	honestSubset = honestSubset[:advCount+1]
 
 	return PullConsensus{
-		done,
-		indices,
-		honestSubset,
-		threshold,
-		table,
-		h,
+		done:         false,
+		honestSubset: honestSubset,
+		threshold:    int32(advCount) + 1,
+		table:        nil,
 	}
 }
 
-// Table returns the output table of the consensus algorithm. This table will
-// only be correct if `HandleRow` has returned `true`.
-func (pc PullConsensus) Table() [][]brng.Sharing {
+// Table implements the brng.Consensus interface. This table will only be
+// correct if `HandleRow` has returned `true`.
+func (pc PullConsensus) Table() brng.Table {
 	return pc.table
 }
 
-// Done returns if the consensus engine has already reached consensus or not
-// yet.
+// Done implements the brng.Consensus interface.
 func (pc PullConsensus) Done() bool {
 	return pc.done
 }
 
-// HandleRow processes a row received from a player. It returns true if
-// consensus has completed, at which point the complete output table can be
-// accessed, and false otherwise.
+// HandleRow implements the brng.Consensus interface. A row is included in the
+// table only if the table with the row added is valid for every player in the
+// honest subset, as determined by BRNGer.IsValidTable. This ensures that the
+// final table is valid for all of the players in the honest subset.
 func (pc *PullConsensus) HandleRow(row []brng.Sharing) bool {
 	if pc.done {
 		return true
 	}
 
-	for _, sharing := range row {
-		for _, index := range pc.honestSubset {
-			var share shamir.VerifiableShare
-			for _, s := range sharing.Shares {
-				if s.Share.IndexEq(&index) {
-					share = s
-				}
-			}
-
-			c := sharing.Commitment
-			if !shamir.IsValid(pc.h, &c, &share) {
-				return pc.done
-			}
+	candidate := make(brng.Table, len(pc.table), len(pc.table)+1)
+	copy(candidate, pc.table)
+	candidate = append(candidate, row)
+	for i := range pc.honestSubset {
+		if err := pc.honestSubset[i].IsValidTable(candidate, len(candidate)); err != nil {
+			return pc.done
 		}
 	}
 
-	pc.table = append(pc.table, row)
+	pc.table = candidate
 	if len(pc.table) == int(pc.threshold) {
 		pc.done = true
 	}
@@ -0,0 +1,298 @@
+package dkg
+
+import (
+	"fmt"
+
+	"github.com/renproject/mpc/brng"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg"
+	"github.com/renproject/mpc/rng"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// An Output is the result of one instance of distributed key generation for a
+// given player. It consists of the player's share of the private key, the
+// commitment to the polynomial that shares the private key, and the
+// corresponding public key, which is the same for all players.
+type Output struct {
+	Share      shamir.VerifiableShare
+	Commitment shamir.Commitment
+	PubKey     secp256k1.Point
+}
+
+// A DKGer is a state machine that implements distributed key generation. The
+// protocol is a composition of the BRNG, RNG, RZG and RKPG protocols.
+//
+// The state machine starts with the output of the consensus step of BRNG.
+// From this, an RNG instance is run to produce the sharings of the private
+// keys, and concurrently an RZG instance is run to produce the sharings of
+// zero that are needed for RKPG. Once both of these instances have completed,
+// the RKPG protocol is run to reveal the public keys. The private key shares
+// and commitments are the outputs of the RNG instance.
+//
+// Since the RKPG messages from other players can arrive before the RNG and RZG
+// instances have completed, they are buffered and handled once RKPG starts.
+//
+// The state machine supports batching. The batch size is the number of keys
+// that will be generated, and requires a BRNG instance with a batch size given
+// by BRNGBatchSize.
+type DKGer struct {
+	// State
+	rnger, rzger   rng.RNGer
+	rngShares      shamir.VerifiableShares
+	rzgShares      shamir.VerifiableShares
+	rngCommitments []shamir.Commitment
+	started        bool
+	rkpger         rkpg.RKPGer
+	pending        []shamir.Shares
+	output         []Output
+
+	// Instance parameters
+	instance params.InstanceID
+
+	// Global parameters
+	index   secp256k1.Fn
+	indices []secp256k1.Fn
+	h       secp256k1.Point
+}
+
+// The labels that are used to derive the instance IDs of the sub-protocols.
+const (
+	rngLabel  = "rng"
+	rzgLabel  = "rzg"
+	rkpgLabel = "rkpg"
+)
+
+// BRNGBatchSize returns the batch size that the BRNG instance, whose output is
+// used to construct a DKGer, needs to have in order to generate a batch of b
+// keys with reconstruction threshold k.
+func BRNGBatchSize(b, k int) int {
+	return b * (2*k - 1)
+}
+
+// New returns a new DKG state machine for the given instance ID along with the
+// directed openings for the RNG and RZG instances that are to be sent to the
+// other players, indexed by the index of the player that the openings are
+// destined for. Each of the sub-protocols is run with its own instance ID,
+// which is derived from the given instance ID using params.SubInstance, so
+// that messages for one sub-protocol can not be replayed into another. The
+// arguments for the BRNG output are the same as for brng.HandleConsensusOutput;
+// the shares are expected to have been checked using brng.BRNGer.IsValid and
+// are nil if they were not valid. In this case the returned maps of openings
+// will also be nil.
+//
+// Panics: This function will panic if any of the following conditions are
+// met.
+//	- The Pedersen parameter is insecure.
+//	- The BRNG output is empty.
+//	- The reconstruction threshold (k) of the BRNG commitments is less than 2.
+//	- The BRNG batch size is not a multiple of 2k-1, where k is the
+//		reconstruction threshold of the BRNG commitments.
+//	- Any of the conditions for which rng.New would panic.
+func New(
+	instance params.InstanceID,
+	ownIndex secp256k1.Fn,
+	indices []secp256k1.Fn,
+	h secp256k1.Point,
+	brngSharesBatch []shamir.VerifiableShares,
+	brngCommitmentsBatch [][]shamir.Commitment,
+) (
+	DKGer,
+	map[secp256k1.Fn]shamir.VerifiableShares,
+	map[secp256k1.Fn]shamir.VerifiableShares,
+) {
+	if !params.ValidPedersenParameter(h) {
+		panic("insecure choice of pedersen parameter")
+	}
+	if len(brngCommitmentsBatch) < 1 || len(brngCommitmentsBatch[0]) < 1 {
+		panic("empty brng output")
+	}
+	k := brngCommitmentsBatch[0][0].Len()
+	if k < 2 {
+		panic(fmt.Sprintf("k must be at least 2: got %v", k))
+	}
+	if len(brngCommitmentsBatch)%(2*k-1) != 0 {
+		panic(fmt.Sprintf(
+			"invalid brng batch size: expected a multiple of %v, got %v",
+			2*k-1, len(brngCommitmentsBatch),
+		))
+	}
+	b := len(brngCommitmentsBatch) / (2*k - 1)
+
+	shareSums, commitmentSums := brng.HandleConsensusOutput(brngSharesBatch, brngCommitmentsBatch)
+
+	// The first b*k outputs of BRNG are used for the RNG instance and the
+	// remaining b*(k-1) outputs for the RZG instance.
+	rngCommitmentBatch := make([][]shamir.Commitment, b)
+	rzgCommitmentBatch := make([][]shamir.Commitment, b)
+	for i := 0; i < b; i++ {
+		rngCommitmentBatch[i] = commitmentSums[i*k : (i+1)*k]
+		rzgCommitmentBatch[i] = commitmentSums[b*k+i*(k-1) : b*k+(i+1)*(k-1)]
+	}
+	var rngShareBatch, rzgShareBatch []shamir.VerifiableShares
+	if shareSums != nil {
+		rngShareBatch = make([]shamir.VerifiableShares, b)
+		rzgShareBatch = make([]shamir.VerifiableShares, b)
+		for i := 0; i < b; i++ {
+			rngShareBatch[i] = shareSums[i*k : (i+1)*k]
+			rzgShareBatch[i] = shareSums[b*k+i*(k-1) : b*k+(i+1)*(k-1)]
+		}
+	}
+
+	rnger, rngOpenings, rngCommitments := rng.New(
+		params.SubInstance(instance, rngLabel),
+		ownIndex, indices, h, rngShareBatch, rngCommitmentBatch, false,
+	)
+	rzger, rzgOpenings, _ := rng.New(
+		params.SubInstance(instance, rzgLabel),
+		ownIndex, indices, h, rzgShareBatch, rzgCommitmentBatch, true,
+	)
+
+	indicesCopy := make([]secp256k1.Fn, len(indices))
+	copy(indicesCopy, indices)
+	dkger := DKGer{
+		rnger:          rnger,
+		rzger:          rzger,
+		rngShares:      nil,
+		rzgShares:      nil,
+		rngCommitments: rngCommitments,
+		started:        false,
+		pending:        nil,
+		output:         nil,
+
+		instance: instance,
+
+		index:   ownIndex,
+		indices: indicesCopy,
+		h:       h,
+	}
+	return dkger, rngOpenings, rzgOpenings
+}
+
+// Instance returns the instance ID of the DKG state machine.
+func (dkger DKGer) Instance() params.InstanceID {
+	return dkger.instance
+}
+
+// HandleRNGShareBatch applies a state transition upon receiving the directed
+// openings for the RNG and RZG instances from another player. The two share
+// batches are handled independently, so that an invalid share batch for one
+// instance does not cause a valid share batch for the other instance to be
+// dropped. If either of the share batches is invalid, an OpeningError is
+// returned that contains the error for each of them. Once both instances have
+// completed, RKPG is started and the share batch that is to be broadcast to
+// the other players in RKPG is returned, otherwise the returned share batch
+// will be nil. If enough RKPG shares had already been received before RKPG
+// started, the output will also be returned, otherwise it will be nil.
+func (dkger *DKGer) HandleRNGShareBatch(
+	instance params.InstanceID,
+	rngShareBatch, rzgShareBatch shamir.VerifiableShares,
+) (shamir.Shares, []Output, error) {
+	var rngErr, rzgErr error
+	if dkger.rngShares == nil {
+		var shares shamir.VerifiableShares
+		shares, rngErr = dkger.rnger.HandleShareBatch(params.SubInstance(instance, rngLabel), rngShareBatch)
+		if rngErr == nil {
+			dkger.rngShares = shares
+		}
+	}
+	if dkger.rzgShares == nil {
+		var shares shamir.VerifiableShares
+		shares, rzgErr = dkger.rzger.HandleShareBatch(params.SubInstance(instance, rzgLabel), rzgShareBatch)
+		if rzgErr == nil {
+			dkger.rzgShares = shares
+		}
+	}
+	if rngErr != nil || rzgErr != nil {
+		return nil, nil, OpeningError{RNG: rngErr, RZG: rzgErr}
+	}
+	if dkger.started || dkger.rngShares == nil || dkger.rzgShares == nil {
+		return nil, nil, nil
+	}
+
+	rkpgInstance := params.SubInstance(instance, rkpgLabel)
+	rkpger, shares := rkpg.New(rkpgInstance, dkger.indices, dkger.h, dkger.rngShares, dkger.rzgShares, dkger.rngCommitments)
+	dkger.rkpger = rkpger
+	dkger.started = true
+
+	// Handle the RKPG shares that were received before RKPG started. These
+	// have already been checked for everything except the validity of the
+	// shares themselves, which only affects the output.
+	for _, pending := range dkger.pending {
+		pubKeys, _, err := dkger.rkpger.HandleShareBatch(rkpgInstance, pending)
+		if err == nil && pubKeys != nil {
+			dkger.setOutput(pubKeys)
+		}
+	}
+	dkger.pending = nil
+
+	return shares, dkger.output, nil
+}
+
+// HandleRKPGShareBatch applies a state transition upon receiving a share batch
+// from another player during RKPG. If the share batch is invalid in any way,
+// an error is returned. If enough shares have been received to reconstruct
+// the public keys, the output of the protocol is returned, otherwise the
+// return value is nil.
+func (dkger *DKGer) HandleRKPGShareBatch(instance params.InstanceID, shares shamir.Shares) ([]Output, error) {
+	if !dkger.started {
+		if err := dkger.checkPending(instance, shares); err != nil {
+			return nil, err
+		}
+		dkger.pending = append(dkger.pending, shares)
+		return nil, nil
+	}
+
+	pubKeys, _, err := dkger.rkpger.HandleShareBatch(params.SubInstance(instance, rkpgLabel), shares)
+	if err != nil {
+		return nil, err
+	}
+	if pubKeys == nil {
+		return nil, nil
+	}
+	dkger.setOutput(pubKeys)
+	return dkger.output, nil
+}
+
+// checkPending performs the checks that the RKPG state machine would perform
+// on a share batch, for a share batch that is received before RKPG starts.
+func (dkger *DKGer) checkPending(instance params.InstanceID, shares shamir.Shares) error {
+	if instance != dkger.Instance() {
+		return rkpg.ErrIncorrectInstance
+	}
+	if len(shares) != len(dkger.rngCommitments) {
+		return rkpg.ErrWrongBatchSize
+	}
+	index := shares[0].Index
+	exists := false
+	for i := range dkger.indices {
+		if index.Eq(&dkger.indices[i]) {
+			exists = true
+		}
+	}
+	if !exists {
+		return rkpg.ErrInvalidIndex
+	}
+	for _, pending := range dkger.pending {
+		if pending[0].IndexEq(&index) {
+			return rkpg.ErrDuplicateIndex
+		}
+	}
+	for i := 1; i < len(shares); i++ {
+		if !shares[i].IndexEq(&index) {
+			return rkpg.ErrInconsistentShares
+		}
+	}
+	return nil
+}
+
+func (dkger *DKGer) setOutput(pubKeys []secp256k1.Point) {
+	output := make([]Output, len(pubKeys))
+	for i := range output {
+		output[i].Share = dkger.rngShares[i]
+		output[i].Commitment.Set(dkger.rngCommitments[i])
+		output[i].PubKey = pubKeys[i]
+	}
+	dkger.output = output
+}
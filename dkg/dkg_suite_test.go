@@ -0,0 +1,13 @@
+package dkg_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestDkg(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Dkg Suite")
+}
@@ -0,0 +1,241 @@
+package dkg_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/renproject/mpc/dkg"
+	"github.com/renproject/mpc/dkg/dkgutil"
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+)
+
+var _ = Describe("DKG", func() {
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
+	Context("state transitions", func() {
+		n := 10
+		k := 3
+		b := 2
+
+		Specify("rkpg shares received before rkpg starts should be checked", func() {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			brngShares, brngComs := dkgutil.BRNGOutput(indices, k, b, h)
+			dkger, _, _ := dkg.New(instance, indices[0], indices, h, brngShares[0], brngComs)
+
+			shares := make(shamir.Shares, b)
+			for i := range shares {
+				shares[i] = shamir.NewShare(indices[1], secp256k1.RandomFn())
+			}
+
+			var otherInstance params.InstanceID
+			rand.Read(otherInstance[:])
+			output, err := dkger.HandleRKPGShareBatch(otherInstance, shares)
+			Expect(output).To(BeNil())
+			Expect(err).To(Equal(rkpg.ErrIncorrectInstance))
+
+			output, err = dkger.HandleRKPGShareBatch(instance, shares[:b-1])
+			Expect(output).To(BeNil())
+			Expect(err).To(Equal(rkpg.ErrWrongBatchSize))
+
+			output, err = dkger.HandleRKPGShareBatch(instance, make(shamir.Shares, b))
+			Expect(output).To(BeNil())
+			Expect(err).To(Equal(rkpg.ErrInvalidIndex))
+
+			output, err = dkger.HandleRKPGShareBatch(instance, shares)
+			Expect(output).To(BeNil())
+			Expect(err).ToNot(HaveOccurred())
+
+			output, err = dkger.HandleRKPGShareBatch(instance, shares)
+			Expect(output).To(BeNil())
+			Expect(err).To(Equal(rkpg.ErrDuplicateIndex))
+		})
+
+		Specify("an invalid rng opening should not cause a valid rzg opening to be dropped", func() {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			brngShares, brngComs := dkgutil.BRNGOutput(indices, k, b, h)
+			dkger, _, _ := dkg.New(instance, indices[0], indices, h, brngShares[0], brngComs)
+
+			rngOpenings := make([]shamir.VerifiableShares, k)
+			rzgOpenings := make([]shamir.VerifiableShares, k)
+			for j := 1; j < k; j++ {
+				_, rngs, rzgs := dkg.New(instance, indices[j], indices, h, brngShares[j], brngComs)
+				rngOpenings[j] = rngs[indices[0]]
+				rzgOpenings[j] = rzgs[indices[0]]
+			}
+
+			invalid := make(shamir.VerifiableShares, b)
+			copy(invalid, rngOpenings[1])
+			invalid[0].Share.Value = secp256k1.RandomFn()
+			shares, output, err := dkger.HandleRNGShareBatch(instance, invalid, rzgOpenings[1])
+			Expect(shares).To(BeNil())
+			Expect(output).To(BeNil())
+			Expect(err).To(HaveOccurred())
+			openingErr, ok := err.(dkg.OpeningError)
+			Expect(ok).To(BeTrue())
+			Expect(openingErr.RNG).To(HaveOccurred())
+			Expect(openingErr.RZG).ToNot(HaveOccurred())
+
+			// The rzg opening should have been kept, and so handling it again
+			// should be rejected as a duplicate.
+			_, _, err = dkger.HandleRNGShareBatch(instance, rngOpenings[1], rzgOpenings[1])
+			Expect(err).To(HaveOccurred())
+			openingErr, ok = err.(dkg.OpeningError)
+			Expect(ok).To(BeTrue())
+			Expect(openingErr.RNG).ToNot(HaveOccurred())
+			Expect(openingErr.RZG).To(HaveOccurred())
+
+			for j := 2; j < k; j++ {
+				shares, _, err = dkger.HandleRNGShareBatch(instance, rngOpenings[j], rzgOpenings[j])
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(shares).ToNot(BeNil())
+		})
+
+		Specify("openings for a different instance should be rejected", func() {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			brngShares, brngComs := dkgutil.BRNGOutput(indices, k, b, h)
+			dkger, _, _ := dkg.New(instance, indices[0], indices, h, brngShares[0], brngComs)
+			_, rngs, rzgs := dkg.New(instance, indices[1], indices, h, brngShares[1], brngComs)
+
+			// The openings for one sub-protocol should not be accepted for
+			// another.
+			_, _, err := dkger.HandleRNGShareBatch(instance, rzgs[indices[0]], rngs[indices[0]])
+			Expect(err).To(HaveOccurred())
+
+			var otherInstance params.InstanceID
+			rand.Read(otherInstance[:])
+			_, _, err = dkger.HandleRNGShareBatch(otherInstance, rngs[indices[0]], rzgs[indices[0]])
+			Expect(err).To(HaveOccurred())
+
+			_, _, err = dkger.HandleRNGShareBatch(instance, rngs[indices[0]], rzgs[indices[0]])
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Specify("players with invalid brng shares should not send openings", func() {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			_, brngComs := dkgutil.BRNGOutput(indices, k, b, h)
+			_, rngOpenings, rzgOpenings := dkg.New(instance, indices[0], indices, h, nil, brngComs)
+			Expect(rngOpenings).To(BeNil())
+			Expect(rzgOpenings).To(BeNil())
+		})
+
+		Specify("brng output with an invalid batch size should panic", func() {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			brngShares, brngComs := dkgutil.BRNGOutput(indices, k, b, h)
+			Expect(func() {
+				dkg.New(instance, indices[0], indices, h, brngShares[0][1:], brngComs[1:])
+			}).To(Panic())
+		})
+	})
+
+	Context("network", func() {
+		n := 15
+		k := 4
+		b := 3
+		t := k - 1
+
+		tys := []dkgutil.MachineType{
+			dkgutil.Offline,
+			dkgutil.Malicious,
+		}
+		for _, ty := range tys {
+			ty := ty
+
+			Specify("all honest nodes should generate the same keys", func() {
+				indices := shamirutil.RandomIndices(n)
+				h := secp256k1.RandomPoint()
+				machines := make([]mpcutil.Machine, n)
+
+				brngShares, brngComs := dkgutil.BRNGOutput(indices, k, b, h)
+
+				ids := make([]mpcutil.ID, n)
+				for i := range ids {
+					ids[i] = mpcutil.ID(i + 1)
+				}
+				dishonestIDs := make(map[mpcutil.ID]struct{}, t)
+				{
+					tmp := make([]mpcutil.ID, n)
+					copy(tmp, ids)
+					rand.Shuffle(len(tmp), func(i, j int) {
+						tmp[i], tmp[j] = tmp[j], tmp[i]
+					})
+					for _, id := range tmp[:t] {
+						dishonestIDs[id] = struct{}{}
+					}
+				}
+				machineType := make(map[mpcutil.ID]dkgutil.MachineType, n)
+				for _, id := range ids {
+					if _, ok := dishonestIDs[id]; ok {
+						machineType[id] = ty
+					} else {
+						machineType[id] = dkgutil.Honest
+					}
+				}
+
+				honestMachines := make([]*dkgutil.Machine, 0, n-t)
+				for i, id := range ids {
+					var machine mpcutil.Machine
+					switch machineType[id] {
+					case dkgutil.Offline:
+						m := mpcutil.OfflineMachine(ids[i])
+						machine = &m
+					case dkgutil.Malicious:
+						m := dkgutil.NewMaliciousMachine(ids, id, instance, indices, b)
+						machine = &m
+					case dkgutil.Honest:
+						m := dkgutil.NewMachine(instance, brngShares[i], brngComs, ids, id, indices, h)
+						honestMachines = append(honestMachines, &m)
+						machine = &m
+					default:
+						panic("unexpected machine type")
+					}
+					machines[i] = machine
+				}
+
+				shuffleMsgs, _ := mpcutil.MessageShufflerDropper(ids, 0)
+				network := mpcutil.NewNetwork(machines, shuffleMsgs)
+				network.SetCaptureHist(true)
+				err := network.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				for i := 0; i < b; i++ {
+					output := honestMachines[0].Output[i]
+					shares := make(shamir.Shares, 0, n)
+					vshares := make(shamir.VerifiableShares, 0, n)
+					for _, machine := range honestMachines {
+						Expect(machine.Output[i].Commitment.Eq(output.Commitment)).To(BeTrue())
+						Expect(machine.Output[i].PubKey.Eq(&output.PubKey)).To(BeTrue())
+						vshares = append(vshares, machine.Output[i].Share)
+						shares = append(shares, machine.Output[i].Share.Share)
+					}
+
+					Expect(shamirutil.VsharesAreConsistent(vshares, k-1)).To(BeFalse())
+					Expect(shamirutil.VsharesAreConsistent(vshares, k)).To(BeTrue())
+					for _, vshare := range vshares {
+						Expect(shamir.IsValid(h, &output.Commitment, &vshare)).To(BeTrue())
+					}
+
+					// The public key should correspond to the shared private
+					// key.
+					secret := shamir.Open(shares)
+					var pubKey secp256k1.Point
+					pubKey.BaseExp(&secret)
+					Expect(pubKey.Eq(&output.PubKey)).To(BeTrue())
+				}
+			})
+		}
+	})
+})
@@ -0,0 +1,161 @@
+package dkgutil
+
+import (
+	"github.com/renproject/mpc/dkg"
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// Machine represents a player that honestly carries out the DKG protocol.
+type Machine struct {
+	OwnID mpcutil.ID
+	IDs   []mpcutil.ID
+	dkg.DKGer
+	InitMsgs []Message
+	Output   []dkg.Output
+}
+
+// NewMachine constructs a new honest machine for a DKG network test. It will
+// have the given instance ID, BRNG output and ID. The player with ID ids[i] is
+// assumed to have index indices[i].
+func NewMachine(
+	instance params.InstanceID,
+	brngSharesBatch []shamir.VerifiableShares, brngCommitmentsBatch [][]shamir.Commitment,
+	ids []mpcutil.ID, ownID mpcutil.ID, indices []secp256k1.Fn, h secp256k1.Point,
+) Machine {
+	var ownIndex secp256k1.Fn
+	for i, id := range ids {
+		if id == ownID {
+			ownIndex = indices[i]
+		}
+	}
+	dkger, rngOpenings, rzgOpenings := dkg.New(instance, ownIndex, indices, h, brngSharesBatch, brngCommitmentsBatch)
+	var initialMessages []Message
+	if rngOpenings != nil {
+		initialMessages = make([]Message, 0, len(ids)-1)
+		for i, id := range ids {
+			if id == ownID {
+				continue
+			}
+			initialMessages = append(initialMessages, Message{
+				FromID:    ownID,
+				ToID:      id,
+				Instance:  instance,
+				Type:      RNGMessage,
+				RNGShares: rngOpenings[indices[i]],
+				RZGShares: rzgOpenings[indices[i]],
+			})
+		}
+	}
+	return Machine{
+		OwnID:    ownID,
+		IDs:      ids,
+		DKGer:    dkger,
+		InitMsgs: initialMessages,
+	}
+}
+
+// ID implements the Machine interface.
+func (m Machine) ID() mpcutil.ID { return m.OwnID }
+
+// InitialMessages implements the Machine interface.
+func (m Machine) InitialMessages() []mpcutil.Message {
+	msgs := make([]mpcutil.Message, len(m.InitMsgs))
+	for i := range m.InitMsgs {
+		msgs[i] = &m.InitMsgs[i]
+	}
+	return msgs
+}
+
+// Handle implements the Machine interface.
+func (m *Machine) Handle(msg mpcutil.Message) []mpcutil.Message {
+	message := msg.(*Message)
+	switch message.Type {
+	case RNGMessage:
+		shares, output, _ := m.DKGer.HandleRNGShareBatch(message.Instance, message.RNGShares, message.RZGShares)
+		if output != nil {
+			m.Output = output
+		}
+		if shares == nil {
+			return nil
+		}
+		msgs := make([]mpcutil.Message, 0, len(m.IDs)-1)
+		for _, id := range m.IDs {
+			if id == m.OwnID {
+				continue
+			}
+			msgShares := make(shamir.Shares, len(shares))
+			copy(msgShares, shares)
+			msgs = append(msgs, &Message{
+				FromID:     m.OwnID,
+				ToID:       id,
+				Instance:   m.DKGer.Instance(),
+				Type:       RKPGMessage,
+				RKPGShares: msgShares,
+			})
+		}
+		return msgs
+	case RKPGMessage:
+		output, _ := m.DKGer.HandleRKPGShareBatch(message.Instance, message.RKPGShares)
+		if output != nil {
+			m.Output = output
+		}
+		return nil
+	default:
+		panic("unexpected message type")
+	}
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (m Machine) SizeHint() int {
+	return m.OwnID.SizeHint() +
+		surge.SizeHint(m.IDs) +
+		m.DKGer.SizeHint() +
+		surge.SizeHint(m.InitMsgs) +
+		surge.SizeHint(m.Output)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (m Machine) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.IDs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = m.DKGer.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.InitMsgs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(m.Output, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (m *Machine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.IDs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = m.DKGer.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.InitMsgs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&m.Output, buf, rem)
+}
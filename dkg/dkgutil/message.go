@@ -0,0 +1,95 @@
+package dkgutil
+
+import (
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// Message is the message type that players send to eachother during an
+// instance of DKG. Depending on the type of the message, either the RNG and
+// RZG shares or the RKPG shares will be set.
+type Message struct {
+	FromID, ToID mpcutil.ID
+	Instance     params.InstanceID
+	Type         MessageType
+
+	RNGShares, RZGShares shamir.VerifiableShares
+	RKPGShares           shamir.Shares
+}
+
+// From implements the mpcutil.Message interface.
+func (msg Message) From() mpcutil.ID { return msg.FromID }
+
+// To implements the mpcutil.Message interface.
+func (msg Message) To() mpcutil.ID { return msg.ToID }
+
+// SizeHint implements the surge.SizeHinter interface.
+func (msg Message) SizeHint() int {
+	return msg.FromID.SizeHint() +
+		msg.ToID.SizeHint() +
+		msg.Instance.SizeHint() +
+		surge.SizeHint(uint8(msg.Type)) +
+		msg.RNGShares.SizeHint() +
+		msg.RZGShares.SizeHint() +
+		msg.RKPGShares.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (msg Message) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := msg.FromID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.ToID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.Instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.MarshalU8(uint8(msg.Type), buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.RNGShares.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.RZGShares.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return msg.RKPGShares.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (msg *Message) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := msg.FromID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.ToID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.Instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.UnmarshalU8((*uint8)(&msg.Type), buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.RNGShares.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.RZGShares.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return msg.RKPGShares.Unmarshal(buf, rem)
+}
@@ -0,0 +1,30 @@
+package dkgutil
+
+// MachineType represents a type of player in the network.
+type MachineType byte
+
+const (
+	// Honest represents a player that follows the DKG protocol as specified.
+	Honest = MachineType(iota)
+
+	// Offline represents a player that is offline.
+	Offline
+
+	// Malicious represents a player that deviates from the DKG protocol by
+	// sending shares with incorrect values.
+	Malicious
+)
+
+// MessageType represents the step of the DKG protocol that a message belongs
+// to.
+type MessageType byte
+
+const (
+	// RNGMessage is a message that contains directed openings for the RNG and
+	// RZG steps of the DKG protocol.
+	RNGMessage = MessageType(iota)
+
+	// RKPGMessage is a message that contains a share batch for the RKPG step
+	// of the DKG protocol.
+	RKPGMessage
+)
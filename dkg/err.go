@@ -0,0 +1,16 @@
+package dkg
+
+import "fmt"
+
+// An OpeningError is returned when the directed openings for at least one of
+// the RNG and RZG instances are invalid. Each of the fields is the error that
+// was returned by the corresponding RNG state machine, and is nil if the
+// openings for that instance were valid.
+type OpeningError struct {
+	RNG, RZG error
+}
+
+// Error implements the error interface.
+func (err OpeningError) Error() string {
+	return fmt.Sprintf("invalid openings: rng: %v, rzg: %v", err.RNG, err.RZG)
+}
@@ -0,0 +1,240 @@
+package dkg
+
+import (
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg"
+	"github.com/renproject/mpc/rng"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+	"github.com/renproject/surge"
+)
+
+// SizeHint implements the surge.SizeHinter interface.
+func (output Output) SizeHint() int {
+	return output.Share.SizeHint() +
+		output.Commitment.SizeHint() +
+		output.PubKey.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (output Output) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := output.Share.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = output.Commitment.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return output.PubKey.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (output *Output) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := output.Share.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = output.Commitment.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return output.PubKey.Unmarshal(buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (output Output) Generate(rand *rand.Rand, size int) reflect.Value {
+	k := rand.Intn(size/12+1) + 1
+	commitment := shamir.NewCommitmentWithCapacity(k)
+	for i := 0; i < k; i++ {
+		commitment.Append(secp256k1.RandomPoint())
+	}
+	share := shamir.NewVerifiableShare(
+		shamir.NewShare(secp256k1.RandomFn(), secp256k1.RandomFn()),
+		secp256k1.RandomFn(),
+	)
+	return reflect.ValueOf(Output{
+		Share:      share,
+		Commitment: commitment,
+		PubKey:     secp256k1.RandomPoint(),
+	})
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (dkger DKGer) SizeHint() int {
+	return dkger.rnger.SizeHint() +
+		dkger.rzger.SizeHint() +
+		surge.SizeHint(dkger.rngShares) +
+		surge.SizeHint(dkger.rzgShares) +
+		surge.SizeHint(dkger.rngCommitments) +
+		surge.SizeHint(dkger.started) +
+		dkger.rkpger.SizeHint() +
+		surge.SizeHint(dkger.pending) +
+		surge.SizeHint(dkger.output) +
+		dkger.instance.SizeHint() +
+		dkger.index.SizeHint() +
+		surge.SizeHint(dkger.indices) +
+		dkger.h.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (dkger DKGer) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := dkger.rnger.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = dkger.rzger.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(dkger.rngShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(dkger.rzgShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(dkger.rngCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.MarshalBool(dkger.started, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = dkger.rkpger.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(dkger.pending, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(dkger.output, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = dkger.instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = dkger.index.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(dkger.indices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return dkger.h.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (dkger *DKGer) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := dkger.rnger.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = dkger.rzger.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&dkger.rngShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&dkger.rzgShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&dkger.rngCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.UnmarshalBool(&dkger.started, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = dkger.rkpger.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&dkger.pending, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&dkger.output, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = dkger.instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = dkger.index.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&dkger.indices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return dkger.h.Unmarshal(buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (dkger DKGer) Generate(rand *rand.Rand, size int) reflect.Value {
+	rnger := rng.RNGer{}.Generate(rand, size).Interface().(rng.RNGer)
+	rzger := rng.RNGer{}.Generate(rand, size).Interface().(rng.RNGer)
+	rkpger := rkpg.RKPGer{}.Generate(rand, size).Interface().(rkpg.RKPGer)
+
+	b := rand.Intn(size/32+1) + 1
+	n := rand.Intn(size/32+1) + 1
+	rngShares := make(shamir.VerifiableShares, b)
+	rzgShares := make(shamir.VerifiableShares, b)
+	rngCommitments := make([]shamir.Commitment, b)
+	output := make([]Output, b)
+	for i := 0; i < b; i++ {
+		rngShares[i] = shamir.NewVerifiableShare(
+			shamir.NewShare(secp256k1.RandomFn(), secp256k1.RandomFn()),
+			secp256k1.RandomFn(),
+		)
+		rzgShares[i] = shamir.NewVerifiableShare(
+			shamir.NewShare(secp256k1.RandomFn(), secp256k1.RandomFn()),
+			secp256k1.RandomFn(),
+		)
+		rngCommitments[i] = shamir.NewCommitmentWithCapacity(1)
+		rngCommitments[i].Append(secp256k1.RandomPoint())
+		output[i] = Output{}.Generate(rand, size/b).Interface().(Output)
+	}
+	pending := make([]shamir.Shares, rand.Intn(n))
+	for i := range pending {
+		pending[i] = make(shamir.Shares, b)
+		for j := range pending[i] {
+			pending[i][j] = shamir.NewShare(secp256k1.RandomFn(), secp256k1.RandomFn())
+		}
+	}
+	dkger = DKGer{
+		rnger:          rnger,
+		rzger:          rzger,
+		rngShares:      rngShares,
+		rzgShares:      rzgShares,
+		rngCommitments: rngCommitments,
+		started:        rand.Int()&1 == 1,
+		rkpger:         rkpger,
+		pending:        pending,
+		output:         output,
+
+		instance: params.InstanceID{}.Generate(rand, size).Interface().(params.InstanceID),
+
+		index:   secp256k1.RandomFn(),
+		indices: shamirutil.RandomIndices(n),
+		h:       secp256k1.RandomPoint(),
+	}
+	return reflect.ValueOf(dkger)
+}
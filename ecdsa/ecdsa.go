@@ -0,0 +1,188 @@
+package ecdsa
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/mulopen"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// halfOrder is the big endian representation of (N-1)/2, where N is the
+// order of the secp256k1 group. Signatures with an s value greater than this
+// are normalised to their low s form.
+var halfOrder = [32]byte{
+	0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x5d, 0x57, 0x6e, 0x73, 0x57, 0xa4, 0x50, 0x1d,
+	0xdf, 0xe9, 0x2f, 0x46, 0x68, 0x1b, 0x20, 0xa0,
+}
+
+// A Signer is a state machine that implements the threshold ECDSA signing
+// protocol.
+//
+// The inputs to the signing protocol are a sharing of the private key x, and
+// for each signature a presignature consisting of the nonce point R = kG
+// (output from RKPG), a sharing of the inverse of the nonce k (output from
+// inversion) and a sharing of zero with threshold 2k-1 (output from RZG).
+// For a message digest z, the signature is (r, s) where r is the x coordinate
+// of R and
+//	s = k^-1 * (z + r*x).
+// Since z and r are public, z + r*x can be computed locally as a linear
+// function of the private key sharing, and so s is computed by a single
+// invocation of multiply and open.
+//
+// The state machine supports batching. Each element in the batch can use a
+// different private key, but all sharings must have been created with the same
+// indices, reconstruction threshold (k) and Pedersen parameter (h).
+type Signer struct {
+	mulopener mulopen.MulOpener
+	rBatch    []secp256k1.Fn
+}
+
+// New returns a new Signer state machine for the given instance ID along with
+// the initial message that is to be broadcast to the other parties. The state
+// machine will handle this message before being returned.
+//
+// Panics: This function will panic if any of the following conditions are
+// met.
+//	- The batch size is less than 1.
+//	- The digests, key sharings, nonce points or any of the presignature
+//		sharings have different batch sizes.
+//	- Any of the nonce points is the point at infinity.
+//	- Any of the conditions for which mulopen.New would panic.
+func New(
+	instance params.InstanceID,
+	digestBatch [][32]byte,
+	keyShareBatch, kInvShareBatch, rzgShareBatch shamir.VerifiableShares,
+	keyCommitmentBatch, kInvCommitmentBatch, rzgCommitmentBatch []shamir.Commitment,
+	nonceBatch []secp256k1.Point,
+	indices []secp256k1.Fn, h secp256k1.Point,
+) (Signer, []mulopen.Message) {
+	if !params.ValidPedersenParameter(h) {
+		panic("insecure choice of pedersen parameter")
+	}
+	b := len(digestBatch)
+	if b < 1 {
+		panic(fmt.Sprintf("batch size should be at least 1: got %v", b))
+	}
+	if len(keyShareBatch) != b ||
+		len(keyCommitmentBatch) != b ||
+		len(nonceBatch) != b {
+		panic("inconsistent batch size")
+	}
+
+	rBatch := make([]secp256k1.Fn, b)
+	zShareBatch := make(shamir.VerifiableShares, b)
+	zCommitmentBatch := make([]shamir.Commitment, b)
+	for i := 0; i < b; i++ {
+		if nonceBatch[i].IsInfinity() {
+			panic("nonce point is the point at infinity")
+		}
+		rBatch[i] = xCoordinate(&nonceBatch[i])
+
+		var z secp256k1.Fn
+		_ = z.SetB32(digestBatch[i][:])
+
+		// Compute a share of z + r*x and the corresponding commitment.
+		zShareBatch[i].Scale(&keyShareBatch[i], &rBatch[i])
+		zShareBatch[i].Share.Value.Add(&zShareBatch[i].Share.Value, &z)
+
+		var zG secp256k1.Point
+		zG.BaseExp(&z)
+		zCommitmentBatch[i] = shamir.NewCommitmentWithCapacity(keyCommitmentBatch[i].Len())
+		zCommitmentBatch[i].Scale(keyCommitmentBatch[i], &rBatch[i])
+		zCommitmentBatch[i][0].Add(&zCommitmentBatch[i][0], &zG)
+	}
+
+	mulopener, messages := mulopen.New(
+		instance,
+		kInvShareBatch, zShareBatch, rzgShareBatch,
+		kInvCommitmentBatch, zCommitmentBatch, rzgCommitmentBatch,
+		indices, h,
+	)
+	signer := Signer{
+		mulopener: mulopener,
+		rBatch:    rBatch,
+	}
+	return signer, messages
+}
+
+// Instance returns the instance ID of the signer.
+func (signer Signer) Instance() params.InstanceID {
+	return signer.mulopener.Instance()
+}
+
+// HandleMulOpenMessageBatch applies a state transition upon receiving the
+// given shares from another party during the multiply and open step in the
+// signing protocol. Once enough valid messages have been received to complete
+// the signing protocol, the output signatures are computed and returned. If
+// not enough messages have been received, the return value will be nil. If
+// the message batch is invalid in any way, an error will be returned along
+// with a nil value.
+func (signer *Signer) HandleMulOpenMessageBatch(
+	instance params.InstanceID,
+	messageBatch []mulopen.Message,
+) ([]Signature, error) {
+	output, err := signer.mulopener.HandleShareBatch(instance, messageBatch)
+	if err != nil {
+		return nil, err
+	}
+	if output == nil {
+		return nil, nil
+	}
+	sigs := make([]Signature, len(output))
+	for i := range output {
+		sigs[i] = NewSignature(signer.rBatch[i], output[i])
+	}
+	return sigs, nil
+}
+
+// Verify returns true if the given signature is a valid ECDSA signature for
+// the given message digest and public key, and false otherwise.
+func Verify(pubKey *secp256k1.Point, digest [32]byte, sig *Signature) bool {
+	if sig.R.IsZero() || sig.S.IsZero() {
+		return false
+	}
+	var z, sInv, u1, u2 secp256k1.Fn
+	_ = z.SetB32(digest[:])
+	sInv.Inverse(&sig.S)
+	u1.Mul(&z, &sInv)
+	u2.Mul(&sig.R, &sInv)
+
+	var p, q secp256k1.Point
+	p.BaseExp(&u1)
+	q.Scale(pubKey, &u2)
+	p.Add(&p, &q)
+	if p.IsInfinity() {
+		return false
+	}
+	r := xCoordinate(&p)
+	return r.Eq(&sig.R)
+}
+
+// xCoordinate returns the x coordinate of the given point reduced modulo the
+// group order.
+//
+// Panics: This function will panic if the point is the point at infinity.
+func xCoordinate(p *secp256k1.Point) secp256k1.Fn {
+	if p.IsInfinity() {
+		panic("point at infinity has no x coordinate")
+	}
+	x, _ := msm.XY(p)
+	var xBytes [32]byte
+	var r secp256k1.Fn
+	x.PutB32(xBytes[:])
+	_ = r.SetB32(xBytes[:])
+	return r
+}
+
+// isHigh returns true if the given scalar is greater than (N-1)/2.
+func isHigh(s *secp256k1.Fn) bool {
+	var sBytes [32]byte
+	s.PutB32(sBytes[:])
+	return bytes.Compare(sBytes[:], halfOrder[:]) > 0
+}
@@ -0,0 +1,676 @@
+package ecdsa_test
+
+import (
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"math/big"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/renproject/mpc/ecdsa"
+	"github.com/renproject/mpc/ecdsa/ecdsautil"
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/mulopen"
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg"
+	"github.com/renproject/mpc/rkpg/rkpgutil"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+)
+
+var _ = Describe("ECDSA", func() {
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
+	randomDigest := func() [32]byte {
+		var digest [32]byte
+		rand.Read(digest[:])
+		return digest
+	}
+
+	Context("signatures", func() {
+		It("should always have a low s value", func() {
+			for i := 0; i < 100; i++ {
+				sig := ecdsa.NewSignature(secp256k1.RandomFn(), secp256k1.RandomFn())
+				var negS secp256k1.Fn
+				negS.Negate(&sig.S)
+				other := ecdsa.NewSignature(sig.R, negS)
+				Expect(other.S.Eq(&sig.S)).To(BeTrue())
+			}
+		})
+
+		It("should not verify with the wrong key or digest", func() {
+			x := secp256k1.RandomFn()
+			k := secp256k1.RandomFn()
+			digest := randomDigest()
+
+			// Compute the signature in the clear.
+			var kInv, z, s secp256k1.Fn
+			var pubKey, nonce secp256k1.Point
+			pubKey.BaseExp(&x)
+			nonce.BaseExp(&k)
+			kInv.Inverse(&k)
+			_ = z.SetB32(digest[:])
+			xCoord, _ := nonce.XY()
+			var zero secp256k1.Fp
+			xCoord.Add(&xCoord, &zero)
+			var xBytes [32]byte
+			var r secp256k1.Fn
+			xCoord.PutB32(xBytes[:])
+			_ = r.SetB32(xBytes[:])
+			s.Mul(&r, &x)
+			s.Add(&s, &z)
+			s.Mul(&s, &kInv)
+			sig := ecdsa.NewSignature(r, s)
+			Expect(ecdsa.Verify(&pubKey, digest, &sig)).To(BeTrue())
+			Expect(stdVerify(&pubKey, digest, &sig)).To(BeTrue())
+
+			wrongKey := secp256k1.RandomPoint()
+			Expect(ecdsa.Verify(&wrongKey, digest, &sig)).To(BeFalse())
+			Expect(ecdsa.Verify(&pubKey, randomDigest(), &sig)).To(BeFalse())
+			Expect(stdVerify(&wrongKey, digest, &sig)).To(BeFalse())
+		})
+
+		It("should agree with crypto/ecdsa", func() {
+			for i := 0; i < 20; i++ {
+				x := secp256k1.RandomFn()
+				var pubKey secp256k1.Point
+				pubKey.BaseExp(&x)
+				digest := randomDigest()
+
+				var xBytes [32]byte
+				x.PutB32(xBytes[:])
+				priv := stdecdsa.PrivateKey{
+					PublicKey: stdPubKey(&pubKey),
+					D:         new(big.Int).SetBytes(xBytes[:]),
+				}
+				stdR, stdS, err := stdecdsa.Sign(crand.Reader, &priv, digest[:])
+				Expect(err).ToNot(HaveOccurred())
+
+				sig := ecdsa.NewSignature(fnFromBig(stdR), fnFromBig(stdS))
+				Expect(ecdsa.Verify(&pubKey, digest, &sig)).To(BeTrue())
+				Expect(stdVerify(&pubKey, digest, &sig)).To(BeTrue())
+				Expect(ecdsa.Verify(&pubKey, randomDigest(), &sig)).To(BeFalse())
+			}
+		})
+	})
+
+	Context("initial messages", func() {
+		Specify("nonce point at infinity", func() {
+			n := 15
+			k := 4
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			keyShares, keyCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, 1, h)
+			kInvShares, kInvCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, 1, h)
+			rzgShares, rzgCommitments := rkpgutil.RZGOutputBatch(indices, 2*k-1, 1, h)
+			nonces := []secp256k1.Point{secp256k1.NewPointInfinity()}
+
+			Expect(func() {
+				ecdsa.New(
+					instance,
+					[][32]byte{randomDigest()},
+					keyShares[0], kInvShares[0], rzgShares[0],
+					keyCommitments, kInvCommitments, rzgCommitments,
+					nonces,
+					indices, h,
+				)
+			}).To(Panic())
+		})
+	})
+
+	Context("network", func() {
+		n := 15
+		k := 4
+		b := 3
+		t := k - 1
+
+		tys := []ecdsautil.MachineType{
+			ecdsautil.Offline,
+			ecdsautil.Malicious,
+		}
+		for _, ty := range tys {
+			ty := ty
+
+			Specify("all honest nodes should compute valid signatures", func() {
+				indices := shamirutil.RandomIndices(n)
+				h := secp256k1.RandomPoint()
+				machines := make([]mpcutil.Machine, n)
+
+				keyShares, keyCommitments, keys := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				rzgShares, rzgCommitments := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
+
+				// Presignatures: the nonce point and a sharing of the inverse of
+				// the nonce.
+				nonces := make([]secp256k1.Point, b)
+				kInvShares := make([]shamir.VerifiableShares, n)
+				for i := range kInvShares {
+					kInvShares[i] = make(shamir.VerifiableShares, b)
+				}
+				kInvCommitments := make([]shamir.Commitment, b)
+				for i := 0; i < b; i++ {
+					var nonce, kInv secp256k1.Fn
+					nonce = secp256k1.RandomFn()
+					nonces[i].BaseExp(&nonce)
+					kInv.Inverse(&nonce)
+					var shares shamir.VerifiableShares
+					shares, kInvCommitments[i] = rkpgutil.RXGOutput(indices, k, h, kInv)
+					for j := range shares {
+						kInvShares[j][i] = shares[j]
+					}
+				}
+
+				digests := make([][32]byte, b)
+				for i := range digests {
+					digests[i] = randomDigest()
+				}
+
+				ids := make([]mpcutil.ID, n)
+				for i := range ids {
+					ids[i] = mpcutil.ID(i + 1)
+				}
+				dishonestIDs := make(map[mpcutil.ID]struct{}, t)
+				{
+					tmp := make([]mpcutil.ID, n)
+					copy(tmp, ids)
+					rand.Shuffle(len(tmp), func(i, j int) {
+						tmp[i], tmp[j] = tmp[j], tmp[i]
+					})
+					for _, id := range tmp[:t] {
+						dishonestIDs[id] = struct{}{}
+					}
+				}
+				machineType := make(map[mpcutil.ID]ecdsautil.MachineType, n)
+				for _, id := range ids {
+					if _, ok := dishonestIDs[id]; ok {
+						machineType[id] = ty
+					} else {
+						machineType[id] = ecdsautil.Honest
+					}
+				}
+
+				honestMachines := make([]*ecdsautil.Machine, 0, n-t)
+				for i, id := range ids {
+					var machine mpcutil.Machine
+					switch machineType[id] {
+					case ecdsautil.Offline:
+						m := mpcutil.OfflineMachine(ids[i])
+						machine = &m
+					case ecdsautil.Malicious:
+						m := ecdsautil.NewMaliciousMachine(
+							instance,
+							digests,
+							keyShares[i], kInvShares[i], rzgShares[i],
+							keyCommitments, kInvCommitments, rzgCommitments,
+							nonces,
+							ids, id, indices, h,
+						)
+						machine = &m
+					case ecdsautil.Honest:
+						m := ecdsautil.NewMachine(
+							instance,
+							digests,
+							keyShares[i], kInvShares[i], rzgShares[i],
+							keyCommitments, kInvCommitments, rzgCommitments,
+							nonces,
+							ids, id, indices, h,
+						)
+						honestMachines = append(honestMachines, &m)
+						machine = &m
+					default:
+						panic("unexpected machine type")
+					}
+					machines[i] = machine
+				}
+
+				shuffleMsgs, _ := mpcutil.MessageShufflerDropper(ids, 0)
+				network := mpcutil.NewNetwork(machines, shuffleMsgs)
+				network.SetCaptureHist(true)
+				err := network.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				for i := 0; i < b; i++ {
+					var pubKey secp256k1.Point
+					pubKey.BaseExp(&keys[i])
+
+					sig := honestMachines[0].Signatures[i]
+					Expect(ecdsa.Verify(&pubKey, digests[i], &sig)).To(BeTrue())
+					Expect(stdVerify(&pubKey, digests[i], &sig)).To(BeTrue())
+					for _, machine := range honestMachines {
+						Expect(machine.Signatures[i].R.Eq(&sig.R)).To(BeTrue())
+						Expect(machine.Signatures[i].S.Eq(&sig.S)).To(BeTrue())
+					}
+				}
+			})
+		}
+	})
+
+	Context("presigning state transitions", func() {
+		n := 15
+		k := 4
+		b := 2
+
+		Specify("inversion messages replayed as multiply and open messages should be rejected", func() {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			keyShares, keyComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			nonceShares, nonceComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			nonceRZGShares, _ := rkpgutil.RZGOutputBatch(indices, k, b, h)
+			invMaskShares, invMaskComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			invRZGShares, invRZGComs := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
+			alphaShares, alphaComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			mulRZGShares, mulRZGComs := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
+
+			NewPresigner := func(i int) (ecdsa.Presigner, shamir.Shares, []mulopen.Message) {
+				return ecdsa.NewPresigner(
+					instance,
+					keyShares[i], keyComs,
+					nonceShares[i], nonceRZGShares[i], nonceComs,
+					invMaskShares[i], invRZGShares[i], invMaskComs, invRZGComs,
+					alphaShares[i], mulRZGShares[i], alphaComs, mulRZGComs,
+					indices, h,
+				)
+			}
+
+			presigner, _, _ := NewPresigner(0)
+			_, _, replayed := NewPresigner(1)
+
+			// The replayed messages can not be checked until the multiply and
+			// open step starts.
+			_, err := presigner.HandleMulOpenMessageBatch(instance, replayed)
+			Expect(err).ToNot(HaveOccurred())
+
+			var mulOpenMessages []mulopen.Message
+			for i := 1; i < 2*k-1; i++ {
+				_, _, invMessages := NewPresigner(i)
+				mulOpenMessages, _, err = presigner.HandleInvMessageBatch(instance, invMessages)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(mulOpenMessages).ToNot(BeNil())
+
+			rejected := presigner.RejectedIndices()
+			Expect(len(rejected)).To(Equal(1))
+			Expect(rejected[0].Eq(&indices[1])).To(BeTrue())
+		})
+	})
+
+	Context("presigning network", func() {
+		n := 15
+		k := 4
+		b := 3
+		t := k - 1
+
+		tys := []ecdsautil.MachineType{
+			ecdsautil.Offline,
+			ecdsautil.Malicious,
+		}
+		for _, ty := range tys {
+			ty := ty
+
+			Specify("honest nodes should compute presignatures that can be used to sign", func() {
+				indices := shamirutil.RandomIndices(n)
+				h := secp256k1.RandomPoint()
+				machines := make([]mpcutil.Machine, n)
+
+				keyShares, keyComs, keys := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				nonceShares, nonceComs, nonceSecrets := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				nonceRZGShares, _ := rkpgutil.RZGOutputBatch(indices, k, b, h)
+				invMaskShares, invMaskComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				invRZGShares, invRZGComs := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
+				alphaShares, alphaComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				mulRZGShares, mulRZGComs := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
+
+				ids := make([]mpcutil.ID, n)
+				for i := range ids {
+					ids[i] = mpcutil.ID(i + 1)
+				}
+				dishonestIDs := make(map[mpcutil.ID]struct{}, t)
+				{
+					tmp := make([]mpcutil.ID, n)
+					copy(tmp, ids)
+					rand.Shuffle(len(tmp), func(i, j int) {
+						tmp[i], tmp[j] = tmp[j], tmp[i]
+					})
+					for _, id := range tmp[:t] {
+						dishonestIDs[id] = struct{}{}
+					}
+				}
+
+				honestMachines := make([]*ecdsautil.PresignMachine, 0, n-t)
+				for i, id := range ids {
+					var machine mpcutil.Machine
+					_, dishonest := dishonestIDs[id]
+					switch {
+					case dishonest && ty == ecdsautil.Offline:
+						m := mpcutil.OfflineMachine(ids[i])
+						machine = &m
+					case dishonest && ty == ecdsautil.Malicious:
+						m := ecdsautil.NewMaliciousPresignMachine(
+							instance,
+							keyShares[i], keyComs,
+							nonceShares[i], nonceRZGShares[i], nonceComs,
+							invMaskShares[i], invRZGShares[i], invMaskComs, invRZGComs,
+							alphaShares[i], mulRZGShares[i], alphaComs, mulRZGComs,
+							ids, id, indices, h,
+						)
+						machine = &m
+					default:
+						m := ecdsautil.NewPresignMachine(
+							instance,
+							keyShares[i], keyComs,
+							nonceShares[i], nonceRZGShares[i], nonceComs,
+							invMaskShares[i], invRZGShares[i], invMaskComs, invRZGComs,
+							alphaShares[i], mulRZGShares[i], alphaComs, mulRZGComs,
+							ids, id, indices, h,
+						)
+						honestMachines = append(honestMachines, &m)
+						machine = &m
+					}
+					machines[i] = machine
+				}
+
+				shuffleMsgs, _ := mpcutil.MessageShufflerDropper(ids, 0)
+				network := mpcutil.NewNetwork(machines, shuffleMsgs)
+				network.SetCaptureHist(true)
+				err := network.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				for i := 0; i < b; i++ {
+					var nonce, kInv, kInvX secp256k1.Fn
+					nonce = nonceSecrets[i]
+					kInv.Inverse(&nonce)
+					kInvX.Mul(&kInv, &keys[i])
+
+					var expectedNonce secp256k1.Point
+					expectedNonce.BaseExp(&nonce)
+
+					kInvShares := make(shamir.Shares, 0, n)
+					kInvXShares := make(shamir.Shares, 0, n)
+					presig := honestMachines[0].Presignatures[i]
+					for _, machine := range honestMachines {
+						p := machine.Presignatures[i]
+						Expect(p.Nonce.Eq(&expectedNonce)).To(BeTrue())
+						Expect(p.KInvCommitment.Eq(presig.KInvCommitment)).To(BeTrue())
+						Expect(p.KInvXCommitment.Eq(presig.KInvXCommitment)).To(BeTrue())
+						Expect(shamir.IsValid(h, &p.KInvCommitment, &p.KInvShare)).To(BeTrue())
+						Expect(shamir.IsValid(h, &p.KInvXCommitment, &p.KInvXShare)).To(BeTrue())
+						kInvShares = append(kInvShares, p.KInvShare.Share)
+						kInvXShares = append(kInvXShares, p.KInvXShare.Share)
+					}
+					secret := shamir.Open(kInvShares)
+					Expect(secret.Eq(&kInv)).To(BeTrue())
+					secret = shamir.Open(kInvXShares)
+					Expect(secret.Eq(&kInvX)).To(BeTrue())
+				}
+
+				// Online phase, which is a separate invocation and so has its
+				// own instance ID.
+				var onlineInstance params.InstanceID
+				rand.Read(onlineInstance[:])
+				digests := make([][32]byte, b)
+				for i := range digests {
+					digests[i] = randomDigest()
+				}
+				signers := make([]ecdsa.PresignedSigner, len(honestMachines))
+				shareBatches := make([]shamir.VerifiableShares, len(honestMachines))
+				for i, machine := range honestMachines {
+					signers[i], shareBatches[i] = ecdsa.NewPresignedSigner(onlineInstance, digests, machine.Presignatures, indices, h)
+				}
+				_, err = signers[0].HandleShareBatch(instance, shareBatches[1])
+				Expect(err).To(Equal(open.ErrIncorrectInstance))
+				for i := range signers {
+					var sigs []ecdsa.Signature
+					for j := range shareBatches {
+						if i == j {
+							continue
+						}
+						out, err := signers[i].HandleShareBatch(onlineInstance, shareBatches[j])
+						Expect(err).ToNot(HaveOccurred())
+						if out != nil {
+							sigs = out
+						}
+					}
+					Expect(sigs).To(HaveLen(b))
+					for l := 0; l < b; l++ {
+						var pubKey secp256k1.Point
+						pubKey.BaseExp(&keys[l])
+						Expect(ecdsa.Verify(&pubKey, digests[l], &sigs[l])).To(BeTrue())
+					}
+				}
+			})
+		}
+	})
+
+	Context("end to end", func() {
+		n := 10
+		k := 3
+		b := 2
+
+		Specify("signatures should verify against public keys generated by rkpg", func() {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+
+			// Key generation, where the public keys are the outputs of RKPG
+			// for the RNG outputs that are the key sharings.
+			keyShares, keyComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			keyRZGShares, _ := rkpgutil.RZGOutputBatch(indices, k, b, h)
+			var keygenInstance params.InstanceID
+			rand.Read(keygenInstance[:])
+			rkpger, _ := rkpg.New(keygenInstance, indices, h, keyShares[0], keyRZGShares[0], keyComs)
+			var pubKeys []secp256k1.Point
+			for i := 1; i < n && pubKeys == nil; i++ {
+				_, shares := rkpg.New(keygenInstance, indices, h, keyShares[i], keyRZGShares[i], keyComs)
+				var err error
+				pubKeys, _, err = rkpger.HandleShareBatch(keygenInstance, shares)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(pubKeys).To(HaveLen(b))
+
+			// Presigning.
+			nonceShares, nonceComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			nonceRZGShares, _ := rkpgutil.RZGOutputBatch(indices, k, b, h)
+			invMaskShares, invMaskComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			invRZGShares, invRZGComs := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
+			alphaShares, alphaComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			mulRZGShares, mulRZGComs := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
+
+			ids := make([]mpcutil.ID, n)
+			for i := range ids {
+				ids[i] = mpcutil.ID(i + 1)
+			}
+			machines := make([]mpcutil.Machine, n)
+			presignMachines := make([]*ecdsautil.PresignMachine, n)
+			for i, id := range ids {
+				m := ecdsautil.NewPresignMachine(
+					instance,
+					keyShares[i], keyComs,
+					nonceShares[i], nonceRZGShares[i], nonceComs,
+					invMaskShares[i], invRZGShares[i], invMaskComs, invRZGComs,
+					alphaShares[i], mulRZGShares[i], alphaComs, mulRZGComs,
+					ids, id, indices, h,
+				)
+				presignMachines[i] = &m
+				machines[i] = &m
+			}
+			shuffleMsgs, _ := mpcutil.MessageShufflerDropper(ids, 0)
+			network := mpcutil.NewNetwork(machines, shuffleMsgs)
+			err := network.Run()
+			Expect(err).ToNot(HaveOccurred())
+
+			// Signing.
+			var onlineInstance params.InstanceID
+			rand.Read(onlineInstance[:])
+			digests := make([][32]byte, b)
+			for i := range digests {
+				digests[i] = randomDigest()
+			}
+			signers := make([]ecdsa.PresignedSigner, n)
+			shareBatches := make([]shamir.VerifiableShares, n)
+			for i, machine := range presignMachines {
+				signers[i], shareBatches[i] = ecdsa.NewPresignedSigner(onlineInstance, digests, machine.Presignatures, indices, h)
+			}
+			var sigs []ecdsa.Signature
+			for j := 1; j < n && sigs == nil; j++ {
+				sigs, err = signers[0].HandleShareBatch(onlineInstance, shareBatches[j])
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(sigs).To(HaveLen(b))
+			for i := range sigs {
+				Expect(ecdsa.Verify(&pubKeys[i], digests[i], &sigs[i])).To(BeTrue())
+				Expect(stdVerify(&pubKeys[i], digests[i], &sigs[i])).To(BeTrue())
+			}
+		})
+	})
+})
+
+// stdCurve is an implementation of the secp256k1 curve for the elliptic
+// package using affine coordinates and math/big, so that signatures can be
+// checked using crypto/ecdsa independently of the secp256k1 package. The point
+// at infinity is represented by (0, 0).
+type stdCurve struct {
+	params *elliptic.CurveParams
+}
+
+var secp256k1Curve = func() stdCurve {
+	fromHex := func(s string) *big.Int {
+		x, ok := new(big.Int).SetString(s, 16)
+		if !ok {
+			panic("invalid hex")
+		}
+		return x
+	}
+	return stdCurve{&elliptic.CurveParams{
+		P:       fromHex("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F"),
+		N:       fromHex("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141"),
+		B:       big.NewInt(7),
+		Gx:      fromHex("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798"),
+		Gy:      fromHex("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8"),
+		BitSize: 256,
+		Name:    "secp256k1",
+	}}
+}()
+
+func (c stdCurve) Params() *elliptic.CurveParams { return c.params }
+
+func (c stdCurve) IsOnCurve(x, y *big.Int) bool {
+	p := c.params.P
+	lhs := new(big.Int).Mul(y, y)
+	lhs.Mod(lhs, p)
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Mul(rhs, x)
+	rhs.Add(rhs, c.params.B)
+	rhs.Mod(rhs, p)
+	return lhs.Cmp(rhs) == 0
+}
+
+func (c stdCurve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	p := c.params.P
+	if x1.Sign() == 0 && y1.Sign() == 0 {
+		return new(big.Int).Set(x2), new(big.Int).Set(y2)
+	}
+	if x2.Sign() == 0 && y2.Sign() == 0 {
+		return new(big.Int).Set(x1), new(big.Int).Set(y1)
+	}
+	if x1.Cmp(x2) == 0 {
+		if y1.Cmp(y2) == 0 {
+			return c.Double(x1, y1)
+		}
+		return new(big.Int), new(big.Int)
+	}
+	// lambda = (y2 - y1) / (x2 - x1)
+	num := new(big.Int).Sub(y2, y1)
+	den := new(big.Int).Sub(x2, x1)
+	den.Mod(den, p)
+	den.ModInverse(den, p)
+	lambda := num.Mul(num, den)
+	lambda.Mod(lambda, p)
+	return c.line(lambda, x1, y1, x2)
+}
+
+func (c stdCurve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	p := c.params.P
+	if y1.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+	// lambda = 3 x1^2 / (2 y1)
+	num := new(big.Int).Mul(x1, x1)
+	num.Mul(num, big.NewInt(3))
+	den := new(big.Int).Lsh(y1, 1)
+	den.Mod(den, p)
+	den.ModInverse(den, p)
+	lambda := num.Mul(num, den)
+	lambda.Mod(lambda, p)
+	return c.line(lambda, x1, y1, x1)
+}
+
+// line returns the third point of intersection of the curve with the line
+// through (x1, y1) with the given slope, negated, where x2 is the x coordinate
+// of the second point of intersection.
+func (c stdCurve) line(lambda, x1, y1, x2 *big.Int) (*big.Int, *big.Int) {
+	p := c.params.P
+	x3 := new(big.Int).Mul(lambda, lambda)
+	x3.Sub(x3, x1)
+	x3.Sub(x3, x2)
+	x3.Mod(x3, p)
+	y3 := new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, lambda)
+	y3.Sub(y3, y1)
+	y3.Mod(y3, p)
+	return x3, y3
+}
+
+func (c stdCurve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+	x, y := new(big.Int), new(big.Int)
+	for _, b := range k {
+		for i := 7; i >= 0; i-- {
+			x, y = c.Double(x, y)
+			if b>>uint(i)&1 == 1 {
+				x, y = c.Add(x, y, x1, y1)
+			}
+		}
+	}
+	return x, y
+}
+
+func (c stdCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return c.ScalarMult(c.params.Gx, c.params.Gy, k)
+}
+
+// stdPubKey returns the given public key as a crypto/ecdsa public key.
+func stdPubKey(pubKey *secp256k1.Point) stdecdsa.PublicKey {
+	x, y := msm.XY(pubKey)
+	var xBytes, yBytes [32]byte
+	x.PutB32(xBytes[:])
+	y.PutB32(yBytes[:])
+	return stdecdsa.PublicKey{
+		Curve: secp256k1Curve,
+		X:     new(big.Int).SetBytes(xBytes[:]),
+		Y:     new(big.Int).SetBytes(yBytes[:]),
+	}
+}
+
+// fnFromBig returns the given integer, which must be less than the group
+// order, as a field element.
+func fnFromBig(x *big.Int) secp256k1.Fn {
+	var b [32]byte
+	xBytes := x.Bytes()
+	copy(b[32-len(xBytes):], xBytes)
+	var fn secp256k1.Fn
+	_ = fn.SetB32(b[:])
+	return fn
+}
+
+// stdVerify verifies the given signature using crypto/ecdsa.
+func stdVerify(pubKey *secp256k1.Point, digest [32]byte, sig *ecdsa.Signature) bool {
+	var rBytes, sBytes [32]byte
+	sig.R.PutB32(rBytes[:])
+	sig.S.PutB32(sBytes[:])
+	pub := stdPubKey(pubKey)
+	return stdecdsa.Verify(&pub, digest[:], new(big.Int).SetBytes(rBytes[:]), new(big.Int).SetBytes(sBytes[:]))
+}
@@ -0,0 +1,120 @@
+package ecdsautil
+
+import (
+	"github.com/renproject/mpc/ecdsa"
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// Machine represents a player that honestly carries out the threshold ECDSA
+// signing protocol.
+type Machine struct {
+	OwnID mpcutil.ID
+	ecdsa.Signer
+	InitMsgs   []Message
+	Signatures []ecdsa.Signature
+}
+
+// NewMachine constructs a new honest machine for a signing network test. It
+// will have the given instance ID, inputs and ID.
+func NewMachine(
+	instance params.InstanceID,
+	digestBatch [][32]byte,
+	keyShareBatch, kInvShareBatch, rzgShareBatch shamir.VerifiableShares,
+	keyCommitmentBatch, kInvCommitmentBatch, rzgCommitmentBatch []shamir.Commitment,
+	nonceBatch []secp256k1.Point,
+	ids []mpcutil.ID, ownID mpcutil.ID, indices []secp256k1.Fn, h secp256k1.Point,
+) Machine {
+	signer, msgs := ecdsa.New(
+		instance,
+		digestBatch,
+		keyShareBatch, kInvShareBatch, rzgShareBatch,
+		keyCommitmentBatch, kInvCommitmentBatch, rzgCommitmentBatch,
+		nonceBatch,
+		indices, h,
+	)
+	initialMessages := make([]Message, 0, len(ids)-1)
+	for _, id := range ids {
+		if id == ownID {
+			continue
+		}
+		initialMessages = append(initialMessages, Message{
+			FromID:   ownID,
+			ToID:     id,
+			Instance: instance,
+			Messages: msgs,
+		})
+	}
+	return Machine{
+		OwnID:    ownID,
+		Signer:   signer,
+		InitMsgs: initialMessages,
+	}
+}
+
+// ID implements the Machine interface.
+func (m Machine) ID() mpcutil.ID { return m.OwnID }
+
+// InitialMessages implements the Machine interface.
+func (m Machine) InitialMessages() []mpcutil.Message {
+	msgs := make([]mpcutil.Message, len(m.InitMsgs))
+	for i := range m.InitMsgs {
+		msgs[i] = &m.InitMsgs[i]
+	}
+	return msgs
+}
+
+// Handle implements the Machine interface.
+func (m *Machine) Handle(msg mpcutil.Message) []mpcutil.Message {
+	message := msg.(*Message)
+	sigs, _ := m.Signer.HandleMulOpenMessageBatch(message.Instance, message.Messages)
+	if sigs != nil {
+		m.Signatures = sigs
+	}
+	return nil
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (m Machine) SizeHint() int {
+	return m.OwnID.SizeHint() +
+		m.Signer.SizeHint() +
+		surge.SizeHint(m.InitMsgs) +
+		surge.SizeHint(m.Signatures)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (m Machine) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = m.Signer.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.InitMsgs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(m.Signatures, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (m *Machine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = m.Signer.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.InitMsgs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&m.Signatures, buf, rem)
+}
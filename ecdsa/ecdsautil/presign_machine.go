@@ -0,0 +1,300 @@
+package ecdsautil
+
+import (
+	"github.com/renproject/mpc/ecdsa"
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/mulopen"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// PresignMachine represents a player that honestly carries out the presigning
+// protocol.
+type PresignMachine struct {
+	OwnID mpcutil.ID
+	IDs   []mpcutil.ID
+	ecdsa.Presigner
+	InitMsgs      []PresignMessage
+	Presignatures []ecdsa.Presignature
+}
+
+// NewPresignMachine constructs a new honest machine for a presigning network
+// test. It will have the given instance ID, inputs and ID.
+func NewPresignMachine(
+	instance params.InstanceID,
+	keyShareBatch shamir.VerifiableShares, keyCommitmentBatch []shamir.Commitment,
+	nonceShareBatch, nonceRZGShareBatch shamir.VerifiableShares, nonceCommitmentBatch []shamir.Commitment,
+	invMaskShareBatch, invRZGShareBatch shamir.VerifiableShares,
+	invMaskCommitmentBatch, invRZGCommitmentBatch []shamir.Commitment,
+	alphaShareBatch, mulRZGShareBatch shamir.VerifiableShares,
+	alphaCommitmentBatch, mulRZGCommitmentBatch []shamir.Commitment,
+	ids []mpcutil.ID, ownID mpcutil.ID, indices []secp256k1.Fn, h secp256k1.Point,
+) PresignMachine {
+	presigner, rkpgShares, invMessages := ecdsa.NewPresigner(
+		instance,
+		keyShareBatch, keyCommitmentBatch,
+		nonceShareBatch, nonceRZGShareBatch, nonceCommitmentBatch,
+		invMaskShareBatch, invRZGShareBatch,
+		invMaskCommitmentBatch, invRZGCommitmentBatch,
+		alphaShareBatch, mulRZGShareBatch,
+		alphaCommitmentBatch, mulRZGCommitmentBatch,
+		indices, h,
+	)
+	initialMessages := make([]PresignMessage, 0, 2*(len(ids)-1))
+	for _, id := range ids {
+		if id == ownID {
+			continue
+		}
+		initialMessages = append(initialMessages,
+			PresignMessage{
+				FromID:     ownID,
+				ToID:       id,
+				Instance:   instance,
+				Type:       RKPGMessage,
+				RKPGShares: rkpgShares,
+			},
+			PresignMessage{
+				FromID:   ownID,
+				ToID:     id,
+				Instance: instance,
+				Type:     InvMessage,
+				Messages: invMessages,
+			},
+		)
+	}
+	return PresignMachine{
+		OwnID:     ownID,
+		IDs:       ids,
+		Presigner: presigner,
+		InitMsgs:  initialMessages,
+	}
+}
+
+// ID implements the Machine interface.
+func (m PresignMachine) ID() mpcutil.ID { return m.OwnID }
+
+// InitialMessages implements the Machine interface.
+func (m PresignMachine) InitialMessages() []mpcutil.Message {
+	msgs := make([]mpcutil.Message, len(m.InitMsgs))
+	for i := range m.InitMsgs {
+		msgs[i] = &m.InitMsgs[i]
+	}
+	return msgs
+}
+
+// Handle implements the Machine interface.
+func (m *PresignMachine) Handle(msg mpcutil.Message) []mpcutil.Message {
+	message := msg.(*PresignMessage)
+	switch message.Type {
+	case RKPGMessage:
+		presigs, _ := m.Presigner.HandleRKPGShareBatch(message.Instance, message.RKPGShares)
+		m.setPresignatures(presigs)
+		return nil
+	case InvMessage:
+		msgs, presigs, _ := m.Presigner.HandleInvMessageBatch(message.Instance, message.Messages)
+		m.setPresignatures(presigs)
+		if msgs == nil {
+			return nil
+		}
+		responses := make([]mpcutil.Message, 0, len(m.IDs)-1)
+		for _, id := range m.IDs {
+			if id == m.OwnID {
+				continue
+			}
+			msgsCopy := make([]mulopen.Message, len(msgs))
+			copy(msgsCopy, msgs)
+			responses = append(responses, &PresignMessage{
+				FromID:   m.OwnID,
+				ToID:     id,
+				Instance: m.Presigner.Instance(),
+				Type:     MulOpenMessage,
+				Messages: msgsCopy,
+			})
+		}
+		return responses
+	case MulOpenMessage:
+		presigs, _ := m.Presigner.HandleMulOpenMessageBatch(message.Instance, message.Messages)
+		m.setPresignatures(presigs)
+		return nil
+	default:
+		panic("unexpected message type")
+	}
+}
+
+func (m *PresignMachine) setPresignatures(presigs []ecdsa.Presignature) {
+	if presigs != nil && m.Presignatures == nil {
+		m.Presignatures = presigs
+	}
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (m PresignMachine) SizeHint() int {
+	return m.OwnID.SizeHint() +
+		surge.SizeHint(m.IDs) +
+		m.Presigner.SizeHint() +
+		surge.SizeHint(m.InitMsgs) +
+		surge.SizeHint(m.Presignatures)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (m PresignMachine) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.IDs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = m.Presigner.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.InitMsgs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(m.Presignatures, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (m *PresignMachine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.IDs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = m.Presigner.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.InitMsgs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&m.Presignatures, buf, rem)
+}
+
+// MaliciousPresignMachine represents a player that deviates from the
+// presigning protocol by sending shares with random values in every step.
+type MaliciousPresignMachine struct {
+	OwnID    mpcutil.ID
+	InitMsgs []PresignMessage
+}
+
+// NewMaliciousPresignMachine constructs a new malicious machine for a
+// presigning network test. It sends modified versions of the messages that an
+// honest player with the given inputs would send, and additionally sends
+// random multiply and open messages without waiting for the inversion to
+// complete.
+func NewMaliciousPresignMachine(
+	instance params.InstanceID,
+	keyShareBatch shamir.VerifiableShares, keyCommitmentBatch []shamir.Commitment,
+	nonceShareBatch, nonceRZGShareBatch shamir.VerifiableShares, nonceCommitmentBatch []shamir.Commitment,
+	invMaskShareBatch, invRZGShareBatch shamir.VerifiableShares,
+	invMaskCommitmentBatch, invRZGCommitmentBatch []shamir.Commitment,
+	alphaShareBatch, mulRZGShareBatch shamir.VerifiableShares,
+	alphaCommitmentBatch, mulRZGCommitmentBatch []shamir.Commitment,
+	ids []mpcutil.ID, ownID mpcutil.ID, indices []secp256k1.Fn, h secp256k1.Point,
+) MaliciousPresignMachine {
+	_, rkpgShares, invMessages := ecdsa.NewPresigner(
+		instance,
+		keyShareBatch, keyCommitmentBatch,
+		nonceShareBatch, nonceRZGShareBatch, nonceCommitmentBatch,
+		invMaskShareBatch, invRZGShareBatch,
+		invMaskCommitmentBatch, invRZGCommitmentBatch,
+		alphaShareBatch, mulRZGShareBatch,
+		alphaCommitmentBatch, mulRZGCommitmentBatch,
+		indices, h,
+	)
+	index := keyShareBatch[0].Share.Index
+	initialMessages := make([]PresignMessage, 0, 3*(len(ids)-1))
+	for _, id := range ids {
+		if id == ownID {
+			continue
+		}
+		rkpgSharesCopy := make(shamir.Shares, len(rkpgShares))
+		for i := range rkpgSharesCopy {
+			rkpgSharesCopy[i] = shamir.NewShare(index, secp256k1.RandomFn())
+		}
+		invMessagesCopy := make([]mulopen.Message, len(invMessages))
+		copy(invMessagesCopy, invMessages)
+		modifyMessageBatch(invMessagesCopy)
+		mulOpenMessages := make([]mulopen.Message, len(invMessages))
+		copy(mulOpenMessages, invMessages)
+		modifyMessageBatch(mulOpenMessages)
+		initialMessages = append(initialMessages,
+			PresignMessage{
+				FromID:     ownID,
+				ToID:       id,
+				Instance:   instance,
+				Type:       RKPGMessage,
+				RKPGShares: rkpgSharesCopy,
+			},
+			PresignMessage{
+				FromID:   ownID,
+				ToID:     id,
+				Instance: instance,
+				Type:     InvMessage,
+				Messages: invMessagesCopy,
+			},
+			PresignMessage{
+				FromID:   ownID,
+				ToID:     id,
+				Instance: instance,
+				Type:     MulOpenMessage,
+				Messages: mulOpenMessages,
+			},
+		)
+	}
+	return MaliciousPresignMachine{
+		OwnID:    ownID,
+		InitMsgs: initialMessages,
+	}
+}
+
+// ID implements the Machine interface.
+func (m MaliciousPresignMachine) ID() mpcutil.ID { return m.OwnID }
+
+// InitialMessages implements the Machine interface.
+func (m MaliciousPresignMachine) InitialMessages() []mpcutil.Message {
+	msgs := make([]mpcutil.Message, len(m.InitMsgs))
+	for i := range m.InitMsgs {
+		msgs[i] = &m.InitMsgs[i]
+	}
+	return msgs
+}
+
+// Handle implements the Machine interface.
+func (m *MaliciousPresignMachine) Handle(msg mpcutil.Message) []mpcutil.Message {
+	return nil
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (m MaliciousPresignMachine) SizeHint() int {
+	return m.OwnID.SizeHint() +
+		surge.SizeHint(m.InitMsgs)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (m MaliciousPresignMachine) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(m.InitMsgs, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (m *MaliciousPresignMachine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&m.InitMsgs, buf, rem)
+}
@@ -0,0 +1,87 @@
+package ecdsautil
+
+import (
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/mulopen"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// PresignMessage is the message type that players send to eachother during an
+// instance of the presigning protocol. Depending on the type of the message,
+// either the RKPG shares or the multiply and open messages will be set.
+type PresignMessage struct {
+	FromID, ToID mpcutil.ID
+	Instance     params.InstanceID
+	Type         PresignMessageType
+
+	RKPGShares shamir.Shares
+	Messages   []mulopen.Message
+}
+
+// From implements the mpcutil.Message interface.
+func (msg PresignMessage) From() mpcutil.ID { return msg.FromID }
+
+// To implements the mpcutil.Message interface.
+func (msg PresignMessage) To() mpcutil.ID { return msg.ToID }
+
+// SizeHint implements the surge.SizeHinter interface.
+func (msg PresignMessage) SizeHint() int {
+	return msg.FromID.SizeHint() +
+		msg.ToID.SizeHint() +
+		msg.Instance.SizeHint() +
+		surge.SizeHint(uint8(msg.Type)) +
+		msg.RKPGShares.SizeHint() +
+		surge.SizeHint(msg.Messages)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (msg PresignMessage) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := msg.FromID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.ToID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.Instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.MarshalU8(uint8(msg.Type), buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.RKPGShares.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(msg.Messages, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (msg *PresignMessage) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := msg.FromID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.ToID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.Instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.UnmarshalU8((*uint8)(&msg.Type), buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.RKPGShares.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&msg.Messages, buf, rem)
+}
@@ -0,0 +1,35 @@
+package ecdsautil
+
+// MachineType represents a type of player in the network.
+type MachineType byte
+
+const (
+	// Honest represents a player that follows the signing protocol as
+	// specified.
+	Honest = MachineType(iota)
+
+	// Offline represents a player that is offline.
+	Offline
+
+	// Malicious represents a player that deviates from the signing protocol
+	// by sending shares or commitments with incorrect values.
+	Malicious
+)
+
+// PresignMessageType represents the step of the presigning protocol that a
+// message belongs to.
+type PresignMessageType byte
+
+const (
+	// RKPGMessage is a message that contains a share batch for the RKPG step
+	// of the presigning protocol.
+	RKPGMessage = PresignMessageType(iota)
+
+	// InvMessage is a message that contains a message batch for the inversion
+	// step of the presigning protocol.
+	InvMessage
+
+	// MulOpenMessage is a message that contains a message batch for the
+	// multiply and open step of the presigning protocol.
+	MulOpenMessage
+)
@@ -0,0 +1,422 @@
+package ecdsa
+
+import (
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/mpc/inv"
+	"github.com/renproject/mpc/mulopen"
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+	"github.com/renproject/surge"
+)
+
+// SizeHint implements the surge.SizeHinter interface.
+func (signer Signer) SizeHint() int {
+	return signer.mulopener.SizeHint() +
+		surge.SizeHint(signer.rBatch)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (signer Signer) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := signer.mulopener.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(signer.rBatch, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (signer *Signer) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := signer.mulopener.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&signer.rBatch, buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (signer Signer) Generate(rand *rand.Rand, size int) reflect.Value {
+	size /= 2
+	mulopener := mulopen.MulOpener{}.Generate(rand, size).Interface().(mulopen.MulOpener)
+	b := rand.Intn(size/4+1) + 1
+	rBatch := make([]secp256k1.Fn, b)
+	for i := range rBatch {
+		rBatch[i] = secp256k1.RandomFn()
+	}
+	return reflect.ValueOf(Signer{mulopener, rBatch})
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (sig Signature) SizeHint() int {
+	return sig.R.SizeHint() + sig.S.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (sig Signature) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := sig.R.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return sig.S.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (sig *Signature) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := sig.R.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return sig.S.Unmarshal(buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (sig Signature) Generate(_ *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(Signature{
+		R: secp256k1.RandomFn(),
+		S: secp256k1.RandomFn(),
+	})
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (presig Presignature) SizeHint() int {
+	return presig.Nonce.SizeHint() +
+		presig.KInvShare.SizeHint() +
+		presig.KInvXShare.SizeHint() +
+		presig.KInvCommitment.SizeHint() +
+		presig.KInvXCommitment.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (presig Presignature) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := presig.Nonce.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = presig.KInvShare.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = presig.KInvXShare.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = presig.KInvCommitment.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return presig.KInvXCommitment.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (presig *Presignature) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := presig.Nonce.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = presig.KInvShare.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = presig.KInvXShare.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = presig.KInvCommitment.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return presig.KInvXCommitment.Unmarshal(buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (presig Presignature) Generate(rand *rand.Rand, size int) reflect.Value {
+	size /= 2
+	return reflect.ValueOf(Presignature{
+		Nonce:           secp256k1.RandomPoint(),
+		KInvShare:       randomVShares(1)[0],
+		KInvXShare:      randomVShares(1)[0],
+		KInvCommitment:  shamir.Commitment{}.Generate(rand, size).Interface().(shamir.Commitment),
+		KInvXCommitment: shamir.Commitment{}.Generate(rand, size).Interface().(shamir.Commitment),
+	})
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (presigner Presigner) SizeHint() int {
+	return presigner.rkpger.SizeHint() +
+		presigner.inverter.SizeHint() +
+		presigner.mulopener.SizeHint() +
+		surge.SizeHint(presigner.started) +
+		surge.SizeHint(presigner.pending) +
+		surge.SizeHint(presigner.rejected) +
+		surge.SizeHint(presigner.nonces) +
+		surge.SizeHint(presigner.kInvShares) +
+		surge.SizeHint(presigner.kInvCommitments) +
+		surge.SizeHint(presigner.presignatures) +
+		presigner.instance.SizeHint() +
+		surge.SizeHint(presigner.keyShares) +
+		surge.SizeHint(presigner.maskShares) +
+		surge.SizeHint(presigner.alphaShares) +
+		surge.SizeHint(presigner.keyCommitments) +
+		surge.SizeHint(presigner.maskCommitments) +
+		surge.SizeHint(presigner.alphaCommitments) +
+		surge.SizeHint(presigner.indices) +
+		presigner.h.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (presigner Presigner) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := presigner.rkpger.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = presigner.inverter.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = presigner.mulopener.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.MarshalBool(presigner.started, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(presigner.pending, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(presigner.rejected, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(presigner.nonces, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(presigner.kInvShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(presigner.kInvCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(presigner.presignatures, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = presigner.instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(presigner.keyShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(presigner.maskShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(presigner.alphaShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(presigner.keyCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(presigner.maskCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(presigner.alphaCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(presigner.indices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return presigner.h.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (presigner *Presigner) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := presigner.rkpger.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = presigner.inverter.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = presigner.mulopener.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.UnmarshalBool(&presigner.started, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&presigner.pending, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&presigner.rejected, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&presigner.nonces, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&presigner.kInvShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&presigner.kInvCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&presigner.presignatures, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = presigner.instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&presigner.keyShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&presigner.maskShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&presigner.alphaShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&presigner.keyCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&presigner.maskCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&presigner.alphaCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&presigner.indices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return presigner.h.Unmarshal(buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (presigner Presigner) Generate(rand *rand.Rand, size int) reflect.Value {
+	rkpger := rkpg.RKPGer{}.Generate(rand, size).Interface().(rkpg.RKPGer)
+	inverter := inv.Inverter{}.Generate(rand, size).Interface().(inv.Inverter)
+	mulopener := mulopen.MulOpener{}.Generate(rand, size).Interface().(mulopen.MulOpener)
+	size /= 16
+	b := rand.Intn(size/4+1) + 1
+	pending := make([][]mulopen.Message, rand.Intn(4))
+	for i := range pending {
+		pending[i] = make([]mulopen.Message, b)
+		for j := range pending[i] {
+			pending[i][j] = mulopen.Message{}.Generate(rand, size).Interface().(mulopen.Message)
+		}
+	}
+	nonces := make([]secp256k1.Point, b)
+	presignatures := make([]Presignature, b)
+	for i := range nonces {
+		nonces[i] = secp256k1.RandomPoint()
+		presignatures[i] = Presignature{}.Generate(rand, size).Interface().(Presignature)
+	}
+	presigner = Presigner{
+		rkpger:          rkpger,
+		inverter:        inverter,
+		mulopener:       mulopener,
+		started:         rand.Int()&1 == 1,
+		pending:         pending,
+		rejected:        shamirutil.RandomIndices(rand.Intn(4)),
+		nonces:          nonces,
+		kInvShares:      randomVShares(b),
+		kInvCommitments: randomCommitments(rand, b, size),
+		presignatures:   presignatures,
+
+		instance:         params.InstanceID{}.Generate(rand, size).Interface().(params.InstanceID),
+		keyShares:        randomVShares(b),
+		maskShares:       randomVShares(b),
+		alphaShares:      randomVShares(b),
+		keyCommitments:   randomCommitments(rand, b, size),
+		maskCommitments:  randomCommitments(rand, b, size),
+		alphaCommitments: randomCommitments(rand, b, size),
+
+		indices: shamirutil.RandomIndices(rand.Intn(20)),
+		h:       secp256k1.RandomPoint(),
+	}
+	return reflect.ValueOf(presigner)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (signer PresignedSigner) SizeHint() int {
+	return signer.opener.SizeHint() +
+		surge.SizeHint(signer.rBatch)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (signer PresignedSigner) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := signer.opener.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(signer.rBatch, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (signer *PresignedSigner) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := signer.opener.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&signer.rBatch, buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (signer PresignedSigner) Generate(rand *rand.Rand, size int) reflect.Value {
+	opener := open.Opener{}.Generate(rand, size).Interface().(open.Opener)
+	rBatch := make([]secp256k1.Fn, opener.BatchSize())
+	for i := range rBatch {
+		rBatch[i] = secp256k1.RandomFn()
+	}
+	return reflect.ValueOf(PresignedSigner{opener, rBatch})
+}
+
+func randomVShares(b int) shamir.VerifiableShares {
+	vshares := make(shamir.VerifiableShares, b)
+	for i := range vshares {
+		vshares[i] = shamir.NewVerifiableShare(
+			shamir.NewShare(secp256k1.RandomFn(), secp256k1.RandomFn()),
+			secp256k1.RandomFn(),
+		)
+	}
+	return vshares
+}
+
+func randomCommitments(rand *rand.Rand, b, size int) []shamir.Commitment {
+	commitments := make([]shamir.Commitment, b)
+	for i := range commitments {
+		commitments[i] = shamir.Commitment{}.Generate(rand, size).Interface().(shamir.Commitment)
+	}
+	return commitments
+}
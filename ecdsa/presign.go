@@ -0,0 +1,375 @@
+package ecdsa
+
+import (
+	"fmt"
+
+	"github.com/renproject/mpc/inv"
+	"github.com/renproject/mpc/mulopen"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// A Presignature is the output of the presigning protocol that can be used to
+// create a single signature. It consists of the nonce point R = kG, a sharing
+// of the inverse of the nonce k and a sharing of k^-1 * x, where x is the
+// private key.
+type Presignature struct {
+	Nonce                 secp256k1.Point
+	KInvShare, KInvXShare shamir.VerifiableShare
+	KInvCommitment        shamir.Commitment
+	KInvXCommitment       shamir.Commitment
+}
+
+// A Presigner is a state machine that implements the presigning protocol for
+// threshold ECDSA. The presigning protocol can be run before the messages to
+// be signed are known, and its output allows a signature to be computed with a
+// single open (see PresignedSigner).
+//
+// The protocol consists of the following steps.
+//	1. RKPG is run on a random sharing of the nonce k to obtain R = kG.
+//	2. Concurrently, the inversion protocol is run on the same sharing to
+//		obtain a sharing of k^-1.
+//	3. Once the inversion has completed, multiply and open is run on the
+//		sharing of k^-1 and the sharing of the private key x. The product is
+//		masked by a random value alpha, so that the opened value is
+//		k^-1 * x + alpha. Subtracting a sharing of alpha then gives a sharing
+//		of k^-1 * x.
+// The mask for the multiply and open is the sum of the sharing of alpha and
+// a sharing of zero with threshold 2k-1, which together form a random sharing
+// of alpha with threshold 2k-1.
+//
+// Since the multiply and open messages from other players can arrive before
+// the inversion has completed, they are buffered and handled once the
+// multiply and open starts. The indices of the players whose buffered messages
+// turn out to be invalid are recorded (see RejectedIndices).
+//
+// Each of the steps is run with its own instance ID, which is derived from the
+// instance ID of the presigner using params.SubInstance, so that messages for
+// one step can not be replayed into another.
+//
+// The state machine supports batching. Each element in the batch can use a
+// different private key, but all sharings must have been created with the same
+// indices, reconstruction threshold (k) and Pedersen parameter (h).
+type Presigner struct {
+	// State
+	rkpger          rkpg.RKPGer
+	inverter        inv.Inverter
+	mulopener       mulopen.MulOpener
+	started         bool
+	pending         [][]mulopen.Message
+	rejected        []secp256k1.Fn
+	nonces          []secp256k1.Point
+	kInvShares      shamir.VerifiableShares
+	kInvCommitments []shamir.Commitment
+	presignatures   []Presignature
+
+	// Instance parameters
+	instance                                          params.InstanceID
+	keyShares, maskShares, alphaShares                shamir.VerifiableShares
+	keyCommitments, maskCommitments, alphaCommitments []shamir.Commitment
+
+	// Global parameters
+	indices []secp256k1.Fn
+	h       secp256k1.Point
+}
+
+// NewPresigner returns a new Presigner state machine for the given instance ID
+// along with the initial messages for RKPG and the inversion that are to be
+// broadcast to the other parties. The state machine will handle these messages
+// before being returned. Messages for all of the steps of the protocol are
+// tagged with the given instance ID.
+//
+// The inputs are the sharings of the private keys, and for each of the steps
+// of the protocol the outputs of the RNG and RZG protocols that it consumes:
+//	- RKPG: a random sharing of the nonce (RNG) and a sharing of zero with
+//		threshold k (RZG).
+//	- Inversion: a random sharing of the mask (RNG) and a sharing of zero with
+//		threshold 2k-1 (RZG).
+//	- Multiply and open: a random sharing of alpha (RNG) and a sharing of zero
+//		with threshold 2k-1 (RZG).
+//
+// Panics: This function will panic if any of the following conditions are
+// met.
+//	- The batch size is less than 1.
+//	- The inputs have different batch sizes.
+//	- Any of the conditions for which rkpg.New or inv.New would panic.
+func NewPresigner(
+	instance params.InstanceID,
+	keyShareBatch shamir.VerifiableShares, keyCommitmentBatch []shamir.Commitment,
+	nonceShareBatch, nonceRZGShareBatch shamir.VerifiableShares, nonceCommitmentBatch []shamir.Commitment,
+	invMaskShareBatch, invRZGShareBatch shamir.VerifiableShares,
+	invMaskCommitmentBatch, invRZGCommitmentBatch []shamir.Commitment,
+	alphaShareBatch, mulRZGShareBatch shamir.VerifiableShares,
+	alphaCommitmentBatch, mulRZGCommitmentBatch []shamir.Commitment,
+	indices []secp256k1.Fn, h secp256k1.Point,
+) (Presigner, shamir.Shares, []mulopen.Message) {
+	if !params.ValidPedersenParameter(h) {
+		panic("insecure choice of pedersen parameter")
+	}
+	b := len(keyShareBatch)
+	if b < 1 {
+		panic(fmt.Sprintf("batch size should be at least 1: got %v", b))
+	}
+	if len(keyCommitmentBatch) != b ||
+		len(alphaShareBatch) != b ||
+		len(alphaCommitmentBatch) != b ||
+		len(mulRZGShareBatch) != b ||
+		len(mulRZGCommitmentBatch) != b {
+		panic("inconsistent batch size")
+	}
+
+	rkpger, rkpgShares := rkpg.New(
+		params.SubInstance(instance, rkpgLabel),
+		indices, h, nonceShareBatch, nonceRZGShareBatch, nonceCommitmentBatch,
+	)
+	inverter, invMessages := inv.New(
+		params.SubInstance(instance, invLabel),
+		nonceShareBatch, invMaskShareBatch, invRZGShareBatch,
+		nonceCommitmentBatch, invMaskCommitmentBatch, invRZGCommitmentBatch,
+		indices, h,
+	)
+
+	// Compute the mask for the multiply and open, which is a sharing of alpha
+	// with threshold 2k-1.
+	maskShares := make(shamir.VerifiableShares, b)
+	maskCommitments := make([]shamir.Commitment, b)
+	for i := 0; i < b; i++ {
+		if mulRZGCommitmentBatch[i].Len() < alphaCommitmentBatch[i].Len() {
+			panic("inconsistent threshold (k)")
+		}
+		maskShares[i].Add(&alphaShareBatch[i], &mulRZGShareBatch[i])
+		maskCommitments[i].Set(mulRZGCommitmentBatch[i])
+		for j := 0; j < alphaCommitmentBatch[i].Len(); j++ {
+			maskCommitments[i][j].Add(&maskCommitments[i][j], &alphaCommitmentBatch[i][j])
+		}
+	}
+
+	keyShares := make(shamir.VerifiableShares, b)
+	alphaShares := make(shamir.VerifiableShares, b)
+	keyCommitments := make([]shamir.Commitment, b)
+	alphaCommitments := make([]shamir.Commitment, b)
+	copy(keyShares, keyShareBatch)
+	copy(alphaShares, alphaShareBatch)
+	for i := 0; i < b; i++ {
+		keyCommitments[i].Set(keyCommitmentBatch[i])
+		alphaCommitments[i].Set(alphaCommitmentBatch[i])
+	}
+	indicesCopy := make([]secp256k1.Fn, len(indices))
+	copy(indicesCopy, indices)
+
+	presigner := Presigner{
+		rkpger:   rkpger,
+		inverter: inverter,
+		started:  false,
+
+		instance:         instance,
+		keyShares:        keyShares,
+		maskShares:       maskShares,
+		alphaShares:      alphaShares,
+		keyCommitments:   keyCommitments,
+		maskCommitments:  maskCommitments,
+		alphaCommitments: alphaCommitments,
+
+		indices: indicesCopy,
+		h:       h,
+	}
+	return presigner, rkpgShares, invMessages
+}
+
+// Instance returns the instance ID of the presigner.
+func (presigner Presigner) Instance() params.InstanceID {
+	return presigner.instance
+}
+
+// RejectedIndices returns the indices of the players whose multiply and open
+// message batches were received before the multiply and open step started,
+// and were found to be invalid once it started. Such message batches have
+// passed all of the checks other than the validity of the shares and ZKPs, so
+// the sender has misbehaved.
+func (presigner Presigner) RejectedIndices() []secp256k1.Fn {
+	rejected := make([]secp256k1.Fn, len(presigner.rejected))
+	copy(rejected, presigner.rejected)
+	return rejected
+}
+
+// HandleRKPGShareBatch applies a state transition upon receiving the given
+// shares from another party during the RKPG step of the presigning protocol.
+// If the share batch is invalid in any way, an error is returned. Once all
+// steps of the protocol have completed, the presignatures are returned,
+// otherwise the return value is nil.
+func (presigner *Presigner) HandleRKPGShareBatch(
+	instance params.InstanceID,
+	shares shamir.Shares,
+) ([]Presignature, error) {
+	nonces, _, err := presigner.rkpger.HandleShareBatch(params.SubInstance(instance, rkpgLabel), shares)
+	if err != nil {
+		return nil, err
+	}
+	if nonces != nil && presigner.nonces == nil {
+		presigner.nonces = nonces
+	}
+	return presigner.output(), nil
+}
+
+// HandleInvMessageBatch applies a state transition upon receiving the given
+// messages from another party during the inversion step of the presigning
+// protocol. If the message batch is invalid in any way, an error is returned.
+// Once the inversion has completed, the multiply and open step is started and
+// its initial messages, which are to be broadcast to the other parties, are
+// returned; otherwise the returned messages are nil. If all steps of the
+// protocol have completed, the presignatures are also returned, otherwise they
+// are nil.
+func (presigner *Presigner) HandleInvMessageBatch(
+	instance params.InstanceID,
+	messageBatch []mulopen.Message,
+) ([]mulopen.Message, []Presignature, error) {
+	if presigner.started {
+		return nil, nil, nil
+	}
+	kInvShares, kInvCommitments, err := presigner.inverter.HandleMulOpenMessageBatch(
+		params.SubInstance(instance, invLabel),
+		messageBatch,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if kInvShares == nil {
+		return nil, nil, nil
+	}
+	presigner.kInvShares = kInvShares
+	presigner.kInvCommitments = kInvCommitments
+
+	mulopener, messages := mulopen.New(
+		params.SubInstance(instance, mulopenLabel),
+		presigner.kInvShares, presigner.keyShares, presigner.maskShares,
+		presigner.kInvCommitments, presigner.keyCommitments, presigner.maskCommitments,
+		presigner.indices, presigner.h,
+	)
+	presigner.mulopener = mulopener
+	presigner.started = true
+
+	// Handle the messages that were received before the multiply and open
+	// started. These have already been checked for everything except the
+	// validity of the shares and proofs, and so if they are rejected, the
+	// sender is recorded.
+	for _, pending := range presigner.pending {
+		if err := presigner.handleMulOpenMessageBatch(instance, pending); err != nil {
+			presigner.rejected = append(presigner.rejected, pending[0].VShare.Share.Index)
+		}
+	}
+	presigner.pending = nil
+
+	return messages, presigner.output(), nil
+}
+
+// HandleMulOpenMessageBatch applies a state transition upon receiving the
+// given messages from another party during the multiply and open step of the
+// presigning protocol. If the message batch is invalid in any way, an error is
+// returned. Once all steps of the protocol have completed, the presignatures
+// are returned, otherwise the return value is nil.
+func (presigner *Presigner) HandleMulOpenMessageBatch(
+	instance params.InstanceID,
+	messageBatch []mulopen.Message,
+) ([]Presignature, error) {
+	if !presigner.started {
+		if err := presigner.checkPending(instance, messageBatch); err != nil {
+			return nil, err
+		}
+		presigner.pending = append(presigner.pending, messageBatch)
+		return nil, nil
+	}
+	if err := presigner.handleMulOpenMessageBatch(instance, messageBatch); err != nil {
+		return nil, err
+	}
+	return presigner.output(), nil
+}
+
+func (presigner *Presigner) handleMulOpenMessageBatch(instance params.InstanceID, messageBatch []mulopen.Message) error {
+	output, err := presigner.mulopener.HandleShareBatch(params.SubInstance(instance, mulopenLabel), messageBatch)
+	if err != nil {
+		return err
+	}
+	if output == nil {
+		return nil
+	}
+
+	// The output is k^-1 * x + alpha, and so a sharing of k^-1 * x is
+	// obtained by subtracting the sharing of alpha.
+	var negOne secp256k1.Fn
+	negOne.SetU16(1)
+	negOne.Negate(&negOne)
+	b := len(output)
+	presignatures := make([]Presignature, b)
+	for i := 0; i < b; i++ {
+		var uG secp256k1.Point
+		uG.BaseExp(&output[i])
+
+		presignatures[i].KInvXShare.Scale(&presigner.alphaShares[i], &negOne)
+		presignatures[i].KInvXShare.Share.Value.Add(&presignatures[i].KInvXShare.Share.Value, &output[i])
+		presignatures[i].KInvXCommitment = shamir.NewCommitmentWithCapacity(presigner.alphaCommitments[i].Len())
+		presignatures[i].KInvXCommitment.Scale(presigner.alphaCommitments[i], &negOne)
+		presignatures[i].KInvXCommitment[0].Add(&presignatures[i].KInvXCommitment[0], &uG)
+
+		presignatures[i].KInvShare = presigner.kInvShares[i]
+		presignatures[i].KInvCommitment.Set(presigner.kInvCommitments[i])
+	}
+	presigner.presignatures = presignatures
+	return nil
+}
+
+// The labels that are used to derive the instance IDs of the steps of the
+// presigning protocol.
+const (
+	rkpgLabel    = "rkpg"
+	invLabel     = "inv"
+	mulopenLabel = "mulopen"
+)
+
+// checkPending performs the checks that the multiply and open state machine
+// would perform on a message batch, other than the validity of the shares and
+// proofs, for a message batch that is received before it has started.
+func (presigner *Presigner) checkPending(instance params.InstanceID, messageBatch []mulopen.Message) error {
+	if instance != presigner.Instance() {
+		return mulopen.ErrIncorrectInstance
+	}
+	if len(messageBatch) != len(presigner.keyShares) {
+		return mulopen.ErrIncorrectBatchSize
+	}
+	index := messageBatch[0].VShare.Share.Index
+	exists := false
+	for i := range presigner.indices {
+		if index.Eq(&presigner.indices[i]) {
+			exists = true
+		}
+	}
+	if !exists {
+		return mulopen.ErrInvalidIndex
+	}
+	for i := range messageBatch {
+		if !messageBatch[i].VShare.Share.IndexEq(&index) {
+			return mulopen.ErrInconsistentShares
+		}
+	}
+	for _, pending := range presigner.pending {
+		if pending[0].VShare.Share.IndexEq(&index) {
+			return mulopen.ErrDuplicateIndex
+		}
+	}
+	return nil
+}
+
+// output returns the presignatures if all steps of the protocol have
+// completed, and nil otherwise.
+func (presigner *Presigner) output() []Presignature {
+	if presigner.nonces == nil || presigner.presignatures == nil {
+		return nil
+	}
+	presignatures := make([]Presignature, len(presigner.presignatures))
+	for i := range presignatures {
+		presignatures[i] = presigner.presignatures[i]
+		presignatures[i].Nonce = presigner.nonces[i]
+	}
+	return presignatures
+}
@@ -0,0 +1,124 @@
+package ecdsa
+
+import (
+	"fmt"
+
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// A PresignedSigner is a state machine that implements the online phase of
+// threshold ECDSA signing when presignatures are available. For a message
+// digest z and presignature (R, [k^-1], [k^-1 * x]), the signature is (r, s)
+// where r is the x coordinate of R and
+//	s = z * k^-1 + r * (k^-1 * x).
+// Since z and r are public, a sharing of s can be computed locally, and so the
+// online phase consists of a single open.
+//
+// The state machine supports batching, with one presignature being consumed
+// for each message digest in the batch.
+type PresignedSigner struct {
+	opener open.Opener
+	rBatch []secp256k1.Fn
+}
+
+// NewPresignedSigner returns a new PresignedSigner state machine for the given
+// instance ID along with the share batch that is to be broadcast to the other
+// parties. The state machine will handle this share batch before being
+// returned. Each presignature must only ever be used to sign a single message
+// digest, as otherwise the private key can be recovered from the signatures.
+//
+// Panics: This function will panic if any of the following conditions are
+// met.
+//	- The batch size is less than 1.
+//	- The digests and presignatures have different batch sizes.
+//	- Any of the nonce points is the point at infinity.
+//	- Any of the conditions for which open.NewPublic would panic.
+func NewPresignedSigner(
+	instance params.InstanceID,
+	digestBatch [][32]byte,
+	presignatureBatch []Presignature,
+	indices []secp256k1.Fn, h secp256k1.Point,
+) (PresignedSigner, shamir.VerifiableShares) {
+	if !params.ValidPedersenParameter(h) {
+		panic("insecure choice of pedersen parameter")
+	}
+	b := len(digestBatch)
+	if b < 1 {
+		panic(fmt.Sprintf("batch size should be at least 1: got %v", b))
+	}
+	if len(presignatureBatch) != b {
+		panic("inconsistent batch size")
+	}
+
+	rBatch := make([]secp256k1.Fn, b)
+	shares := make(shamir.VerifiableShares, b)
+	commitments := make([]shamir.Commitment, b)
+	for i := 0; i < b; i++ {
+		presig := &presignatureBatch[i]
+		if presig.Nonce.IsInfinity() {
+			panic("nonce point is the point at infinity")
+		}
+		rBatch[i] = xCoordinate(&presig.Nonce)
+
+		var z secp256k1.Fn
+		_ = z.SetB32(digestBatch[i][:])
+
+		var rShare shamir.VerifiableShare
+		shares[i].Scale(&presig.KInvShare, &z)
+		rShare.Scale(&presig.KInvXShare, &rBatch[i])
+		shares[i].Add(&shares[i], &rShare)
+
+		rCommitment := shamir.NewCommitmentWithCapacity(presig.KInvXCommitment.Len())
+		commitments[i] = shamir.NewCommitmentWithCapacity(presig.KInvCommitment.Len())
+		commitments[i].Scale(presig.KInvCommitment, &z)
+		rCommitment.Scale(presig.KInvXCommitment, &rBatch[i])
+		commitments[i].Add(commitments[i], rCommitment)
+	}
+
+	opener := open.NewPublic(instance, commitments, indices, h)
+	secrets, _, err := opener.HandleShareBatch(instance, shares)
+	if err != nil {
+		panic(fmt.Sprintf("unexpected error handling own share: %v", err))
+	}
+	if secrets != nil {
+		panic("opener should not have reconstructed after one share")
+	}
+
+	signer := PresignedSigner{
+		opener: opener,
+		rBatch: rBatch,
+	}
+	return signer, shares
+}
+
+// Instance returns the instance ID of the signer.
+func (signer PresignedSigner) Instance() params.InstanceID {
+	return signer.opener.Instance()
+}
+
+// HandleShareBatch applies a state transition upon receiving the given shares
+// from another party during the open. Once enough valid shares have been
+// received to reconstruct, the output signatures are computed and returned. If
+// not enough shares have been received, the return value will be nil. If the
+// share batch is invalid in any way, an error will be returned along with a
+// nil value.
+func (signer *PresignedSigner) HandleShareBatch(
+	instance params.InstanceID,
+	shareBatch shamir.VerifiableShares,
+) ([]Signature, error) {
+	secrets, _, err := signer.opener.HandleShareBatch(instance, shareBatch)
+	if err != nil {
+		return nil, err
+	}
+	if secrets == nil {
+		return nil, nil
+	}
+	sigs := make([]Signature, len(secrets))
+	for i := range secrets {
+		sigs[i] = NewSignature(signer.rBatch[i], secrets[i])
+	}
+	return sigs, nil
+}
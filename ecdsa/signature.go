@@ -0,0 +1,27 @@
+package ecdsa
+
+import "github.com/renproject/secp256k1"
+
+// A Signature is an ECDSA signature over the secp256k1 curve. Signatures
+// created by this package are always normalised to have a low s value.
+type Signature struct {
+	R, S secp256k1.Fn
+}
+
+// NewSignature constructs a new signature from the given r and s values,
+// normalising s so that it is not greater than half of the group order.
+func NewSignature(r, s secp256k1.Fn) Signature {
+	if isHigh(&s) {
+		s.Negate(&s)
+	}
+	return Signature{R: r, S: s}
+}
+
+// Bytes returns the 64 byte representation of the signature, that is, the
+// concatenation of the big endian encodings of r and s.
+func (sig Signature) Bytes() [64]byte {
+	var bs [64]byte
+	sig.R.PutB32(bs[:32])
+	sig.S.PutB32(bs[32:])
+	return bs
+}
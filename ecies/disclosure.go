@@ -0,0 +1,91 @@
+package ecies
+
+import (
+	"github.com/renproject/mpc/transcript"
+	"github.com/renproject/secp256k1"
+)
+
+// A Disclosure is the shared secret for a ciphertext along with a proof that it
+// was computed correctly by the owner of the private key that the ciphertext
+// was encrypted for. Anyone can use the shared secret to decrypt the
+// ciphertext (see DecryptWithSecret), and so a disclosure can be used to prove
+// that a ciphertext can not be decrypted.
+//
+// The proof is a Chaum-Pedersen proof that the discrete logarithm of the public
+// key with respect to G is equal to the discrete logarithm of the shared
+// secret with respect to the ephemeral public key of the ciphertext, made
+// non-interactive using the Fiat-Shamir transformation.
+type Disclosure struct {
+	Secret secp256k1.Point
+	Proof  Proof
+}
+
+// A Proof is a proof of correct computation of the shared secret in a
+// Disclosure.
+type Proof struct {
+	a1, a2 secp256k1.Point
+	z      secp256k1.Fn
+}
+
+// Disclose returns the disclosure of the shared secret for the given
+// ciphertext, which must have been encrypted for the public key that
+// corresponds to the given private key. An error is returned if the ciphertext
+// is malformed.
+func Disclose(privKey *secp256k1.Fn, ciphertext []byte) (Disclosure, error) {
+	ephemeral, _, err := split(ciphertext)
+	if err != nil {
+		return Disclosure{}, err
+	}
+	pubKey := PublicKey(privKey)
+	var secret secp256k1.Point
+	secret.Scale(&ephemeral, privKey)
+
+	w := secp256k1.RandomFn()
+	var proof Proof
+	proof.a1.BaseExp(&w)
+	proof.a2.Scale(&ephemeral, &w)
+	c := challenge(&pubKey, &ephemeral, &secret, &proof)
+	proof.z.Mul(&c, privKey)
+	proof.z.Add(&proof.z, &w)
+
+	return Disclosure{Secret: secret, Proof: proof}, nil
+}
+
+// Verify returns true if the disclosure is a valid disclosure of the shared
+// secret for the given ciphertext by the owner of the given public key, and
+// false otherwise.
+func (disclosure Disclosure) Verify(pubKey *secp256k1.Point, ciphertext []byte) bool {
+	ephemeral, _, err := split(ciphertext)
+	if err != nil {
+		return false
+	}
+	proof := &disclosure.Proof
+	c := challenge(pubKey, &ephemeral, &disclosure.Secret, proof)
+
+	// zG = a1 + c(pubKey)
+	var lhs, rhs secp256k1.Point
+	lhs.BaseExp(&proof.z)
+	rhs.Scale(pubKey, &c)
+	rhs.Add(&rhs, &proof.a1)
+	if !lhs.Eq(&rhs) {
+		return false
+	}
+
+	// z(ephemeral) = a2 + c(secret)
+	lhs.Scale(&ephemeral, &proof.z)
+	rhs.Scale(&disclosure.Secret, &c)
+	rhs.Add(&rhs, &proof.a2)
+	return lhs.Eq(&rhs)
+}
+
+// challenge computes the Fiat-Shamir challenge for the proof from a transcript
+// of the statement and the commitments of the proof.
+func challenge(pubKey, ephemeral, secret *secp256k1.Point, proof *Proof) secp256k1.Fn {
+	t := transcript.New("renproject/mpc/ecies/disclosure")
+	t.AppendPoint("pubkey", pubKey)
+	t.AppendPoint("ephemeral", ephemeral)
+	t.AppendPoint("secret", secret)
+	t.AppendPoint("a1", &proof.a1)
+	t.AppendPoint("a2", &proof.a2)
+	return t.Challenge("c")
+}
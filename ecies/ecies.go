@@ -0,0 +1,122 @@
+// Package ecies implements the elliptic curve integrated encryption scheme
+// (ECIES) over secp256k1, which is used to encrypt data for a player so that
+// it can be sent over a public channel, such as a consensus algorithm.
+//
+// A private key is a scalar x and the corresponding public key is the point
+// xG. To encrypt for the public key P, a random scalar r is chosen and the
+// shared secret rP is used to derive a symmetric key, with which the data is
+// encrypted using AES-256-GCM. The ciphertext consists of the point rG
+// followed by the symmetric ciphertext, and the recipient can compute the
+// shared secret as x(rG).
+//
+// If the recipient is unable to decrypt a ciphertext, it can disclose the
+// shared secret for that ciphertext along with a proof that it was computed
+// correctly (see Disclose), which allows anyone to check that the ciphertext
+// can not be decrypted without learning the private key of the recipient.
+package ecies
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/secp256k1"
+)
+
+// PublicKey returns the public key for the given private key.
+func PublicKey(privKey *secp256k1.Fn) secp256k1.Point {
+	var pubKey secp256k1.Point
+	pubKey.BaseExp(privKey)
+	return pubKey
+}
+
+// Encrypt encrypts the given plaintext for the given public key.
+func Encrypt(pubKey *secp256k1.Point, plaintext []byte) []byte {
+	r := secp256k1.RandomFn()
+	var ephemeral, secret secp256k1.Point
+	ephemeral.BaseExp(&r)
+	secret.Scale(pubKey, &r)
+
+	// The ephemeral key is encoded using msm.PutPoint, which is
+	// compatible with the surge marshaling, but always encodes the same point
+	// in the same way.
+	ciphertext := make([]byte, secp256k1.PointSizeMarshalled)
+	msm.PutPoint(ciphertext, &ephemeral)
+	return newAEAD(&ephemeral, &secret).Seal(ciphertext, nonce[:], plaintext, nil)
+}
+
+// Decrypt decrypts the given ciphertext using the given private key. An error
+// is returned if the ciphertext is malformed or was not encrypted for the
+// corresponding public key.
+func Decrypt(privKey *secp256k1.Fn, ciphertext []byte) ([]byte, error) {
+	ephemeral, rest, err := split(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	var secret secp256k1.Point
+	secret.Scale(&ephemeral, privKey)
+	return open(&ephemeral, &secret, rest)
+}
+
+// DecryptWithSecret decrypts the given ciphertext using the given shared
+// secret, which is usually obtained from a Disclosure. An error is returned if
+// the ciphertext is malformed or the shared secret is not the one for the
+// ciphertext.
+func DecryptWithSecret(secret *secp256k1.Point, ciphertext []byte) ([]byte, error) {
+	ephemeral, rest, err := split(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return open(&ephemeral, secret, rest)
+}
+
+// nonce is the nonce used for AES-GCM. Since a new symmetric key is derived
+// for every encryption, the nonce does not need to be unique.
+var nonce [12]byte
+
+// split splits the given ciphertext into the ephemeral public key and the
+// symmetric ciphertext.
+func split(ciphertext []byte) (secp256k1.Point, []byte, error) {
+	if len(ciphertext) < secp256k1.PointSizeMarshalled {
+		return secp256k1.Point{}, nil, ErrMalformedCiphertext
+	}
+	var ephemeral secp256k1.Point
+	if err := ephemeral.SetBytes(ciphertext[:secp256k1.PointSizeMarshalled]); err != nil || ephemeral.IsInfinity() {
+		return secp256k1.Point{}, nil, ErrMalformedCiphertext
+	}
+	return ephemeral, ciphertext[secp256k1.PointSizeMarshalled:], nil
+}
+
+// open decrypts the symmetric ciphertext using the key derived from the given
+// ephemeral public key and shared secret.
+func open(ephemeral, secret *secp256k1.Point, ciphertext []byte) ([]byte, error) {
+	plaintext, err := newAEAD(ephemeral, secret).Open(nil, nonce[:], ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+// newAEAD returns the AES-256-GCM cipher keyed by the hash of the given
+// ephemeral public key and shared secret.
+func newAEAD(ephemeral, secret *secp256k1.Point) cipher.AEAD {
+	// The points are encoded using msm.PutPoint, so that the sender
+	// and the recipient derive the same key even though they compute the
+	// shared secret in different ways.
+	var buf [2 * secp256k1.PointSizeMarshalled]byte
+	msm.PutPoint(buf[:secp256k1.PointSizeMarshalled], ephemeral)
+	msm.PutPoint(buf[secp256k1.PointSizeMarshalled:], secret)
+	key := sha256.Sum256(buf[:])
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		panic(fmt.Sprintf("creating block cipher: %v", err))
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(fmt.Sprintf("creating aead: %v", err))
+	}
+	return aead
+}
@@ -0,0 +1,13 @@
+package ecies_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEcies(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Ecies Suite")
+}
@@ -0,0 +1,144 @@
+package ecies_test
+
+import (
+	"math/rand"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/mpc/ecies"
+
+	"github.com/renproject/secp256k1"
+)
+
+var _ = Describe("ECIES", func() {
+	rand.Seed(int64(time.Now().Nanosecond()))
+
+	trials := 20
+
+	RandomKeyPair := func() (secp256k1.Fn, secp256k1.Point) {
+		privKey := secp256k1.RandomFn()
+		return privKey, PublicKey(&privKey)
+	}
+
+	RandomPlaintext := func() []byte {
+		plaintext := make([]byte, rand.Intn(200))
+		rand.Read(plaintext)
+		return plaintext
+	}
+
+	Context("encryption", func() {
+		It("should decrypt to the original plaintext", func() {
+			for i := 0; i < trials; i++ {
+				privKey, pubKey := RandomKeyPair()
+				plaintext := RandomPlaintext()
+				ciphertext := Encrypt(&pubKey, plaintext)
+				decrypted, err := Decrypt(&privKey, ciphertext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(decrypted).To(HaveLen(len(plaintext)))
+				for j := range plaintext {
+					Expect(decrypted[j]).To(Equal(plaintext[j]))
+				}
+			}
+		})
+
+		It("should fail to decrypt with the wrong key", func() {
+			for i := 0; i < trials; i++ {
+				_, pubKey := RandomKeyPair()
+				otherPrivKey, _ := RandomKeyPair()
+				ciphertext := Encrypt(&pubKey, RandomPlaintext())
+				_, err := Decrypt(&otherPrivKey, ciphertext)
+				Expect(err).To(Equal(ErrDecryptionFailed))
+			}
+		})
+
+		It("should fail to decrypt a modified ciphertext", func() {
+			for i := 0; i < trials; i++ {
+				privKey, pubKey := RandomKeyPair()
+				ciphertext := Encrypt(&pubKey, RandomPlaintext())
+				ciphertext[len(ciphertext)-1-rand.Intn(16)] ^= byte(rand.Intn(255) + 1)
+				_, err := Decrypt(&privKey, ciphertext)
+				Expect(err).To(Equal(ErrDecryptionFailed))
+			}
+		})
+
+		It("should fail to decrypt a malformed ciphertext", func() {
+			privKey, _ := RandomKeyPair()
+			_, err := Decrypt(&privKey, []byte{})
+			Expect(err).To(Equal(ErrMalformedCiphertext))
+		})
+	})
+
+	Context("disclosures", func() {
+		It("should be valid for the recipient and allow decryption", func() {
+			for i := 0; i < trials; i++ {
+				privKey, pubKey := RandomKeyPair()
+				plaintext := RandomPlaintext()
+				ciphertext := Encrypt(&pubKey, plaintext)
+				disclosure, err := Disclose(&privKey, ciphertext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(disclosure.Verify(&pubKey, ciphertext)).To(BeTrue())
+
+				decrypted, err := DecryptWithSecret(&disclosure.Secret, ciphertext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(decrypted).To(HaveLen(len(plaintext)))
+				for j := range plaintext {
+					Expect(decrypted[j]).To(Equal(plaintext[j]))
+				}
+			}
+		})
+
+		It("should be valid for a ciphertext that can not be decrypted", func() {
+			for i := 0; i < trials; i++ {
+				privKey, pubKey := RandomKeyPair()
+				_, otherPubKey := RandomKeyPair()
+				ciphertext := Encrypt(&otherPubKey, RandomPlaintext())
+				disclosure, err := Disclose(&privKey, ciphertext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(disclosure.Verify(&pubKey, ciphertext)).To(BeTrue())
+
+				_, err = DecryptWithSecret(&disclosure.Secret, ciphertext)
+				Expect(err).To(Equal(ErrDecryptionFailed))
+			}
+		})
+
+		It("should be invalid for a different public key", func() {
+			for i := 0; i < trials; i++ {
+				privKey, pubKey := RandomKeyPair()
+				_, otherPubKey := RandomKeyPair()
+				ciphertext := Encrypt(&pubKey, RandomPlaintext())
+				disclosure, err := Disclose(&privKey, ciphertext)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(disclosure.Verify(&otherPubKey, ciphertext)).To(BeFalse())
+			}
+		})
+
+		It("should be invalid for an incorrect secret", func() {
+			for i := 0; i < trials; i++ {
+				privKey, pubKey := RandomKeyPair()
+				ciphertext := Encrypt(&pubKey, RandomPlaintext())
+				disclosure, err := Disclose(&privKey, ciphertext)
+				Expect(err).ToNot(HaveOccurred())
+				disclosure.Secret = secp256k1.RandomPoint()
+				Expect(disclosure.Verify(&pubKey, ciphertext)).To(BeFalse())
+			}
+		})
+
+		It("should be invalid for a different ciphertext", func() {
+			for i := 0; i < trials; i++ {
+				privKey, pubKey := RandomKeyPair()
+				ciphertext := Encrypt(&pubKey, RandomPlaintext())
+				disclosure, err := Disclose(&privKey, ciphertext)
+				Expect(err).ToNot(HaveOccurred())
+				otherCiphertext := Encrypt(&pubKey, RandomPlaintext())
+				Expect(disclosure.Verify(&pubKey, otherCiphertext)).To(BeFalse())
+			}
+		})
+
+		It("should not be created for a malformed ciphertext", func() {
+			privKey, _ := RandomKeyPair()
+			_, err := Disclose(&privKey, []byte{})
+			Expect(err).To(Equal(ErrMalformedCiphertext))
+		})
+	})
+})
@@ -0,0 +1,14 @@
+package ecies
+
+import "errors"
+
+var (
+	// ErrMalformedCiphertext is returned when a ciphertext does not start with
+	// a valid ephemeral public key.
+	ErrMalformedCiphertext = errors.New("malformed ciphertext")
+
+	// ErrDecryptionFailed is returned when a ciphertext could not be
+	// decrypted, which means that it was not encrypted for the given key or
+	// has been modified.
+	ErrDecryptionFailed = errors.New("decryption failed")
+)
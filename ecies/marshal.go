@@ -0,0 +1,98 @@
+package ecies
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/secp256k1"
+)
+
+// Generate implements the quick.Generator interface.
+func (proof Proof) Generate(_ *rand.Rand, _ int) reflect.Value {
+	p := Proof{
+		a1: secp256k1.RandomPoint(),
+		a2: secp256k1.RandomPoint(),
+		z:  secp256k1.RandomFn(),
+	}
+	return reflect.ValueOf(p)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (proof Proof) SizeHint() int {
+	return proof.a1.SizeHint() + proof.a2.SizeHint() + proof.z.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (proof Proof) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := proof.a1.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling a1: %v", err)
+	}
+	buf, rem, err = proof.a2.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling a2: %v", err)
+	}
+	buf, rem, err = proof.z.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling z: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (proof *Proof) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := proof.a1.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling a1: %v", err)
+	}
+	buf, rem, err = proof.a2.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling a2: %v", err)
+	}
+	buf, rem, err = proof.z.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling z: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Generate implements the quick.Generator interface.
+func (disclosure Disclosure) Generate(_ *rand.Rand, size int) reflect.Value {
+	d := Disclosure{
+		Secret: secp256k1.RandomPoint(),
+		Proof:  Proof{}.Generate(nil, size).Interface().(Proof),
+	}
+	return reflect.ValueOf(d)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (disclosure Disclosure) SizeHint() int {
+	return disclosure.Secret.SizeHint() + disclosure.Proof.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (disclosure Disclosure) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := disclosure.Secret.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling secret: %v", err)
+	}
+	buf, rem, err = disclosure.Proof.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling proof: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (disclosure *Disclosure) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := disclosure.Secret.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling secret: %v", err)
+	}
+	buf, rem, err = disclosure.Proof.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling proof: %v", err)
+	}
+	return buf, rem, nil
+}
@@ -0,0 +1,11 @@
+package inv
+
+import "errors"
+
+var (
+	// ErrInvalidMessage is returned in Montgomery mode when a message does not
+	// have the form of a message for the step of the protocol that it is
+	// tagged for, that is, it has the wrong number of shares or multiply and
+	// open messages.
+	ErrInvalidMessage = errors.New("invalid message")
+)
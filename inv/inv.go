@@ -8,33 +8,68 @@ import (
 )
 
 // An Inverter is a state machine that implements the inversion protocol.
+//
+// By default, each element of the batch is multiplied by its mask and opened
+// using a single batched multiply and open, and the sharing of the inverse is
+// computed from the opened value and the mask. Alternatively, the whole batch
+// can be inverted by opening only a single value; see Montgomery.
 type Inverter struct {
 	mulopener        mulopen.MulOpener
 	rShareBatch      shamir.VerifiableShares
 	rCommitmentBatch []shamir.Commitment
+	montgomery       bool
+	tree             tree
 }
 
-// New returns a new Inverter state machine along with the initial message that
-// is to be broadcast to the other parties. The state machine will handle this
-// message before being returned.
+// New returns a new Inverter state machine for the given instance ID along with
+// the initial message that is to be broadcast to the other parties, tagged
+// with the instance ID. The state machine will handle this message before
+// being returned. The options change how the inverses are computed; in
+// Montgomery mode the returned messages are nil, and the initial message is
+// instead returned by Outgoing.
+//
+// Panics: This function will panic if the Pedersen parameter is insecure, or
+// in Montgomery mode if any of the following conditions are met.
+//	- The batch size is less than 1.
+//	- The input commitments have a different batch size to the input shares.
+//	- The mask and RZG batches do not have a batch size of 1.
+//	- The number of triples is not 3(b - 1), where b is the batch size.
+//	- Any of the conditions for which triple.Multiply or mulopen.New would
+//		panic.
 func New(
+	instance params.InstanceID,
 	aShareBatch, rShareBatch, rzgShareBatch shamir.VerifiableShares,
 	aCommitmentBatch, rCommitmentBatch, rzgCommitmentBatch []shamir.Commitment,
 	indices []secp256k1.Fn, h secp256k1.Point,
+	opts ...Option,
 ) (Inverter, []mulopen.Message) {
 	if !params.ValidPedersenParameter(h) {
 		panic("insecure choice of pedersen parameter")
 	}
+	var inverter Inverter
+	for _, opt := range opts {
+		opt(&inverter)
+	}
+	if inverter.montgomery {
+		inverter.newMontgomery(
+			instance,
+			aShareBatch, rShareBatch, rzgShareBatch,
+			aCommitmentBatch, rCommitmentBatch, rzgCommitmentBatch,
+			indices, h,
+		)
+		return inverter, nil
+	}
 	rShareBatchCopy := make(shamir.VerifiableShares, len(rShareBatch))
 	rCommitmentBatchCopy := make([]shamir.Commitment, len(rCommitmentBatch))
 	copy(rShareBatchCopy, rShareBatch)
 	copy(rCommitmentBatchCopy, rCommitmentBatch)
 	mulopener, messages := mulopen.New(
+		instance,
 		aShareBatch, rShareBatch, rzgShareBatch,
 		aCommitmentBatch, rCommitmentBatch, rzgCommitmentBatch,
 		indices, h,
 	)
-	inverter := Inverter{
+	inverter = Inverter{
 		mulopener:        mulopener,
 		rShareBatch:      rShareBatchCopy,
 		rCommitmentBatch: rCommitmentBatchCopy,
@@ -42,18 +77,34 @@ func New(
 	return inverter, messages
 }
 
+// Instance returns the instance ID of the state machine.
+func (inverter Inverter) Instance() params.InstanceID {
+	if inverter.montgomery {
+		return inverter.tree.instance
+	}
+	return inverter.mulopener.Instance()
+}
+
 // HandleMulOpenMessageBatch applies a state transition upon receiveing the
-// given shares from another party during the  multiply and open step in the
-// inversion protocol. Once enough valid messages have been received to
-// complete the inversion protocol, the output, i.e.  shares and commitments
-// that correspond to the multiplicative inverse of the input secret, is
-// computed and returned. If not enough messages have been received, the return
-// value will be nil. If the message batch is invalid in any way, an error will
-// be returned along with a nil value.
-func (inverter *Inverter) HandleMulOpenMessageBatch(messageBatch []mulopen.Message) (
+// given shares, tagged with the given instance ID, from another party during
+// the  multiply and open step in the inversion protocol. Once enough valid
+// messages have been received to complete the inversion protocol, the output,
+// i.e.  shares and commitments that correspond to the multiplicative inverse
+// of the input secret, is computed and returned. If not enough messages have
+// been received, the return value will be nil. If the message batch is invalid
+// in any way, an error will be returned along with a nil value; in particular,
+// mulopen.ErrIncorrectInstance is returned if the message batch is tagged for
+// a different instance.
+//
+// Panics: This function will panic if the state machine is in Montgomery mode,
+// in which case HandleMessage should be used instead.
+func (inverter *Inverter) HandleMulOpenMessageBatch(instance params.InstanceID, messageBatch []mulopen.Message) (
 	shamir.VerifiableShares, []shamir.Commitment, error,
 ) {
-	output, err := inverter.mulopener.HandleShareBatch(messageBatch)
+	if inverter.montgomery {
+		panic("inverter is in montgomery mode")
+	}
+	output, err := inverter.mulopener.HandleShareBatch(instance, messageBatch)
 	if err != nil {
 		return nil, nil, err
 	}
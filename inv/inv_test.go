@@ -6,15 +6,144 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/renproject/mpc/inv"
 	"github.com/renproject/mpc/inv/invutil"
 	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/mulopen"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/mpc/rkpg/rkpgutil"
+	"github.com/renproject/mpc/triple"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
 	"github.com/renproject/shamir/shamirutil"
 )
 
 var _ = Describe("inverter", func() {
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
+	// RandomTriples deals a batch of triples directly, so that triplesBatch[p]
+	// are the triples for the player with index indices[p].
+	RandomTriples := func(indices []secp256k1.Fn, k, b int, h secp256k1.Point) [][]triple.Triple {
+		triplesBatch := make([][]triple.Triple, len(indices))
+		for p := range triplesBatch {
+			triplesBatch[p] = make([]triple.Triple, b)
+		}
+		for i := 0; i < b; i++ {
+			a, bb := secp256k1.RandomFn(), secp256k1.RandomFn()
+			var c secp256k1.Fn
+			c.Mul(&a, &bb)
+			aShares, aCommitment := rkpgutil.RXGOutput(indices, k, h, a)
+			bShares, bCommitment := rkpgutil.RXGOutput(indices, k, h, bb)
+			cShares, cCommitment := rkpgutil.RXGOutput(indices, k, h, c)
+			for p := range triplesBatch {
+				triplesBatch[p][i] = triple.Triple{
+					A:           aShares[p],
+					B:           bShares[p],
+					C:           cShares[p],
+					ACommitment: aCommitment,
+					BCommitment: bCommitment,
+					CCommitment: cCommitment,
+				}
+			}
+		}
+		return triplesBatch
+	}
+
+	// RandomMachineTypes returns the type of each player, where t randomly
+	// chosen players have the given type and the rest are honest.
+	RandomMachineTypes := func(ids []mpcutil.ID, t int, ty invutil.MachineType) map[mpcutil.ID]invutil.MachineType {
+		shuffledIDs := make([]mpcutil.ID, len(ids))
+		copy(shuffledIDs, ids)
+		rand.Shuffle(len(shuffledIDs), func(i, j int) {
+			shuffledIDs[i], shuffledIDs[j] = shuffledIDs[j], shuffledIDs[i]
+		})
+		machineType := make(map[mpcutil.ID]invutil.MachineType, len(ids))
+		for i, id := range shuffledIDs {
+			if i < t {
+				machineType[id] = ty
+			} else {
+				machineType[id] = invutil.Honest
+			}
+		}
+		return machineType
+	}
+
+	// CheckOutputs checks that the given output shares and commitments of the
+	// honest players are verifiable sharings of the inverses of the given
+	// secrets.
+	CheckOutputs := func(
+		outputShares []shamir.VerifiableShares, outputCommitments [][]shamir.Commitment,
+		secrets []secp256k1.Fn, k int, h secp256k1.Point,
+	) {
+		for i := range secrets {
+			var inv secp256k1.Fn
+			inv.Inverse(&secrets[i])
+
+			// Each player should hold a valid share of the inverse of the
+			// input.
+			shares := make(shamir.Shares, 0, len(outputShares))
+			vshares := make(shamir.VerifiableShares, 0, len(outputShares))
+			for p := range outputShares {
+				Expect(len(outputShares[p])).To(Equal(len(secrets)))
+				output := outputShares[p][i]
+				vshares = append(vshares, output)
+				shares = append(shares, output.Share)
+			}
+			commitment := outputCommitments[0][i]
+			for p := range outputCommitments {
+				Expect(outputCommitments[p][i].Eq(commitment)).To(BeTrue())
+			}
+
+			Expect(shamirutil.VsharesAreConsistent(vshares, k-1)).To(BeFalse())
+			Expect(shamirutil.VsharesAreConsistent(vshares, k)).To(BeTrue())
+			for _, vshare := range vshares {
+				Expect(shamir.IsValid(h, &commitment, &vshare)).To(BeTrue())
+			}
+
+			secret := shamir.Open(shares)
+			Expect(secret.Eq(&inv)).To(BeTrue())
+		}
+	}
+
+	Context("instances", func() {
+		Specify("message batches for a different instance should be rejected", func() {
+			n := 15
+			k := 4
+			b := 3
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+
+			aShares, aCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			rShares, rCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			rzgShares, rzgCommitments := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
+
+			inverter, _ := inv.New(
+				instance,
+				aShares[0], rShares[0], rzgShares[0],
+				aCommitments, rCommitments, rzgCommitments,
+				indices, h,
+			)
+			Expect(inverter.Instance()).To(Equal(instance))
+			_, messages := inv.New(
+				instance,
+				aShares[1], rShares[1], rzgShares[1],
+				aCommitments, rCommitments, rzgCommitments,
+				indices, h,
+			)
+
+			otherInstance := instance
+			otherInstance[rand.Intn(len(otherInstance))]++
+			shares, commitments, err := inverter.HandleMulOpenMessageBatch(otherInstance, messages)
+			Expect(shares).To(BeNil())
+			Expect(commitments).To(BeNil())
+			Expect(err).To(Equal(mulopen.ErrIncorrectInstance))
+
+			_, _, err = inverter.HandleMulOpenMessageBatch(instance, messages)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
 	Context("network", func() {
 		n := 15
 		k := 4
@@ -41,25 +170,7 @@ var _ = Describe("inverter", func() {
 				for i := range ids {
 					ids[i] = mpcutil.ID(i + 1)
 				}
-				dishonestIDs := make(map[mpcutil.ID]struct{}, t)
-				{
-					tmp := make([]mpcutil.ID, n)
-					copy(tmp, ids)
-					rand.Shuffle(len(tmp), func(i, j int) {
-						tmp[i], tmp[j] = tmp[j], tmp[i]
-					})
-					for _, id := range tmp[:t] {
-						dishonestIDs[id] = struct{}{}
-					}
-				}
-				machineType := make(map[mpcutil.ID]invutil.MachineType, n)
-				for _, id := range ids {
-					if _, ok := dishonestIDs[id]; ok {
-						machineType[id] = ty
-					} else {
-						machineType[id] = invutil.Honest
-					}
-				}
+				machineType := RandomMachineTypes(ids, t, ty)
 
 				honestMachines := make([]*invutil.Machine, 0, n-t)
 				for i, id := range ids {
@@ -72,14 +183,14 @@ var _ = Describe("inverter", func() {
 						m := invutil.NewMaliciousMachine(
 							aShares[i], rShares[i], rzgShares[i],
 							aCommitments, rCommitments, rzgCommitments,
-							ids, id, indices, h,
+							ids, id, instance, indices, h,
 						)
 						machine = &m
 					case invutil.Honest:
 						m := invutil.NewMachine(
 							aShares[i], rShares[i], rzgShares[i],
 							aCommitments, rCommitments, rzgCommitments,
-							ids, id, indices, h,
+							ids, id, instance, indices, h,
 						)
 						honestMachines = append(honestMachines, &m)
 						machine = &m
@@ -95,34 +206,213 @@ var _ = Describe("inverter", func() {
 				err := network.Run()
 				Expect(err).ToNot(HaveOccurred())
 
-				for i := 0; i < b; i++ {
-					var inv secp256k1.Fn
-					inv.Inverse(&aSecrets[i])
-
-					// Each player should hold a valid share of the inverse of the
-					// input.
-					shares := make(shamir.Shares, 0, n)
-					vshares := make(shamir.VerifiableShares, 0, n)
-					for _, machine := range honestMachines {
-						output := machine.OutputShares[i]
-						vshares = append(vshares, output)
-						shares = append(shares, output.Share)
-					}
-					commitment := honestMachines[0].OutputCommitments[i]
-					for _, machine := range honestMachines {
-						Expect(machine.OutputCommitments[i].Eq(commitment)).To(BeTrue())
-					}
+				outputShares := make([]shamir.VerifiableShares, len(honestMachines))
+				outputCommitments := make([][]shamir.Commitment, len(honestMachines))
+				for p, machine := range honestMachines {
+					outputShares[p] = machine.OutputShares
+					outputCommitments[p] = machine.OutputCommitments
+				}
+				CheckOutputs(outputShares, outputCommitments, aSecrets, k, h)
+			})
+		}
+	})
+
+	Context("montgomery", func() {
+		n := 15
+		k := 4
+		t := k - 1
+
+		Specify("messages for a different instance or of the wrong form should be rejected", func() {
+			b := shamirutil.RandRange(2, 8)
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+
+			aShares, aCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			rShares, rCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, 1, h)
+			rzgShares, rzgCommitments := rkpgutil.RZGOutputBatch(indices, 2*k-1, 1, h)
+			triplesBatch := RandomTriples(indices, k, 3*(b-1), h)
+
+			inverter, messages := inv.New(
+				instance,
+				aShares[0], rShares[0], rzgShares[0],
+				aCommitments, rCommitments, rzgCommitments,
+				indices, h,
+				inv.Montgomery(triplesBatch[0]),
+			)
+			Expect(messages).To(BeNil())
+			Expect(inverter.Instance()).To(Equal(instance))
+			Expect(len(inverter.Outgoing())).To(Equal(1))
+			Expect(inverter.Outgoing()).To(BeEmpty())
+
+			other, _ := inv.New(
+				instance,
+				aShares[1], rShares[1], rzgShares[1],
+				aCommitments, rCommitments, rzgCommitments,
+				indices, h,
+				inv.Montgomery(triplesBatch[1]),
+			)
+			outgoing := other.Outgoing()
+			Expect(len(outgoing)).To(Equal(1))
+			msg := outgoing[0]
+
+			otherInstance := msg
+			otherInstance.Instance[rand.Intn(len(otherInstance.Instance))]++
+			shares, commitments, err := inverter.HandleMessage(otherInstance)
+			Expect(shares).To(BeNil())
+			Expect(commitments).To(BeNil())
+			Expect(err).To(Equal(mulopen.ErrIncorrectInstance))
+
+			wrongForm := msg
+			wrongForm.ShareBatch = msg.ShareBatch[1:]
+			shares, commitments, err = inverter.HandleMessage(wrongForm)
+			Expect(shares).To(BeNil())
+			Expect(commitments).To(BeNil())
+			Expect(err).To(Equal(inv.ErrInvalidMessage))
+
+			wrongForm = msg
+			wrongForm.MulOpen = []mulopen.Message{{}}
+			_, _, err = inverter.HandleMessage(wrongForm)
+			Expect(err).To(Equal(inv.ErrInvalidMessage))
+
+			_, _, err = inverter.HandleMessage(msg)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		tys := []invutil.MachineType{
+			invutil.Offline,
+			invutil.Malicious,
+		}
+		for _, ty := range tys {
+			ty := ty
 
-					Expect(shamirutil.VsharesAreConsistent(vshares, k-1)).To(BeFalse())
-					Expect(shamirutil.VsharesAreConsistent(vshares, k)).To(BeTrue())
-					for _, vshare := range vshares {
-						Expect(shamir.IsValid(h, &commitment, &vshare)).To(BeTrue())
+			Specify("all honest nodes should reconstruct the inverses of the secrets", func() {
+				b := shamirutil.RandRange(1, 8)
+				indices := shamirutil.RandomIndices(n)
+				h := secp256k1.RandomPoint()
+				machines := make([]mpcutil.Machine, n)
+
+				aShares, aCommitments, aSecrets := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				rShares, rCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, 1, h)
+				rzgShares, rzgCommitments := rkpgutil.RZGOutputBatch(indices, 2*k-1, 1, h)
+				triplesBatch := RandomTriples(indices, k, 3*(b-1), h)
+
+				ids := make([]mpcutil.ID, n)
+				for i := range ids {
+					ids[i] = mpcutil.ID(i + 1)
+				}
+				machineType := RandomMachineTypes(ids, t, ty)
+
+				honestMachines := make([]*invutil.MontgomeryMachine, 0, n-t)
+				for i, id := range ids {
+					var machine mpcutil.Machine
+					switch machineType[id] {
+					case invutil.Offline:
+						m := mpcutil.OfflineMachine(ids[i])
+						machine = &m
+					case invutil.Malicious:
+						m := invutil.NewMaliciousMontgomeryMachine(
+							aShares[i], rShares[i], rzgShares[i],
+							aCommitments, rCommitments, rzgCommitments,
+							triplesBatch[i],
+							ids, id, instance, indices, h,
+						)
+						machine = &m
+					case invutil.Honest:
+						m := invutil.NewMontgomeryMachine(
+							aShares[i], rShares[i], rzgShares[i],
+							aCommitments, rCommitments, rzgCommitments,
+							triplesBatch[i],
+							ids, id, instance, indices, h,
+						)
+						honestMachines = append(honestMachines, &m)
+						machine = &m
+					default:
+						panic("unexpected machine type")
 					}
+					machines[i] = machine
+				}
 
-					secret := shamir.Open(shares)
-					Expect(secret.Eq(&inv)).To(BeTrue())
+				shuffleMsgs, _ := mpcutil.MessageShufflerDropper(ids, 0)
+				network := mpcutil.NewNetwork(machines, shuffleMsgs)
+				network.SetCaptureHist(true)
+				err := network.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				outputShares := make([]shamir.VerifiableShares, len(honestMachines))
+				outputCommitments := make([][]shamir.Commitment, len(honestMachines))
+				for p, machine := range honestMachines {
+					outputShares[p] = machine.OutputShares
+					outputCommitments[p] = machine.OutputCommitments
 				}
+				CheckOutputs(outputShares, outputCommitments, aSecrets, k, h)
 			})
 		}
+
+		Context("panics", func() {
+			b := 3
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+
+			aShares, aCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			rShares, rCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			rzgShares, rzgCommitments := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
+			triplesBatch := RandomTriples(indices, k, 3*(b-1), h)
+
+			Specify("wrong number of triples", func() {
+				Expect(func() {
+					inv.New(
+						instance,
+						aShares[0], rShares[0][:1], rzgShares[0][:1],
+						aCommitments, rCommitments[:1], rzgCommitments[:1],
+						indices, h,
+						inv.Montgomery(triplesBatch[0][1:]),
+					)
+				}).To(Panic())
+			})
+
+			Specify("mask or rzg batch size not 1", func() {
+				Expect(func() {
+					inv.New(
+						instance,
+						aShares[0], rShares[0], rzgShares[0][:1],
+						aCommitments, rCommitments, rzgCommitments[:1],
+						indices, h,
+						inv.Montgomery(triplesBatch[0]),
+					)
+				}).To(Panic())
+				Expect(func() {
+					inv.New(
+						instance,
+						aShares[0], rShares[0][:1], rzgShares[0],
+						aCommitments, rCommitments[:1], rzgCommitments,
+						indices, h,
+						inv.Montgomery(triplesBatch[0]),
+					)
+				}).To(Panic())
+			})
+
+			Specify("handling messages for the wrong mode", func() {
+				inverter, _ := inv.New(
+					instance,
+					aShares[0], rShares[0][:1], rzgShares[0][:1],
+					aCommitments, rCommitments[:1], rzgCommitments[:1],
+					indices, h,
+					inv.Montgomery(triplesBatch[0]),
+				)
+				Expect(func() {
+					inverter.HandleMulOpenMessageBatch(instance, nil)
+				}).To(Panic())
+
+				inverter, _ = inv.New(
+					instance,
+					aShares[0], rShares[0], rzgShares[0],
+					aCommitments, rCommitments, rzgCommitments,
+					indices, h,
+				)
+				Expect(func() {
+					inverter.HandleMessage(inv.Message{})
+				}).To(Panic())
+			})
+		})
 	})
 })
@@ -3,6 +3,8 @@ package invutil
 import (
 	"github.com/renproject/mpc/inv"
 	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/triple"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
 	"github.com/renproject/surge"
@@ -23,9 +25,10 @@ type Machine struct {
 func NewMachine(
 	aShareBatch, bShareBatch, rzgShareBatch shamir.VerifiableShares,
 	aCommitmentBatch, bCommitmentBatch, rzgCommitmentBatch []shamir.Commitment,
-	ids []mpcutil.ID, ownID mpcutil.ID, indices []secp256k1.Fn, h secp256k1.Point,
+	ids []mpcutil.ID, ownID mpcutil.ID, instance params.InstanceID, indices []secp256k1.Fn, h secp256k1.Point,
 ) Machine {
 	inverter, msgs := inv.New(
+		instance,
 		aShareBatch, bShareBatch, rzgShareBatch,
 		aCommitmentBatch, bCommitmentBatch, rzgCommitmentBatch,
 		indices, h,
@@ -36,6 +39,7 @@ func NewMachine(
 			continue
 		}
 		initialMessages = append(initialMessages, Message{
+			Instance: instance,
 			FromID:   ownID,
 			ToID:     id,
 			Messages: msgs,
@@ -62,7 +66,8 @@ func (m Machine) InitialMessages() []mpcutil.Message {
 
 // Handle implements the Machine interface.
 func (m *Machine) Handle(msg mpcutil.Message) []mpcutil.Message {
-	outputShares, outputCommitments, _ := m.Inverter.HandleMulOpenMessageBatch(msg.(*Message).Messages)
+	message := msg.(*Message)
+	outputShares, outputCommitments, _ := m.Inverter.HandleMulOpenMessageBatch(message.Instance, message.Messages)
 	if outputShares != nil && outputCommitments != nil {
 		m.OutputShares = outputShares
 		m.OutputCommitments = outputCommitments
@@ -120,3 +125,145 @@ func (m *Machine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	}
 	return surge.Unmarshal(&m.OutputCommitments, buf, rem)
 }
+
+// MontgomeryMachine represents a player that honestly carries out the
+// inversion protocol in Montgomery mode.
+type MontgomeryMachine struct {
+	OwnID mpcutil.ID
+	IDs   []mpcutil.ID
+	inv.Inverter
+	InitMsgs          []MontgomeryMessage
+	OutputShares      shamir.VerifiableShares
+	OutputCommitments []shamir.Commitment
+}
+
+// NewMontgomeryMachine constructs a new honest machine for an inversion
+// network test in Montgomery mode. It will have the given inputs and ID.
+func NewMontgomeryMachine(
+	aShareBatch, bShareBatch, rzgShareBatch shamir.VerifiableShares,
+	aCommitmentBatch, bCommitmentBatch, rzgCommitmentBatch []shamir.Commitment,
+	tripleBatch []triple.Triple,
+	ids []mpcutil.ID, ownID mpcutil.ID, instance params.InstanceID, indices []secp256k1.Fn, h secp256k1.Point,
+) MontgomeryMachine {
+	inverter, _ := inv.New(
+		instance,
+		aShareBatch, bShareBatch, rzgShareBatch,
+		aCommitmentBatch, bCommitmentBatch, rzgCommitmentBatch,
+		indices, h,
+		inv.Montgomery(tripleBatch),
+	)
+	m := MontgomeryMachine{
+		OwnID:    ownID,
+		IDs:      ids,
+		Inverter: inverter,
+	}
+	m.InitMsgs = broadcast(ownID, ids, m.Inverter.Outgoing())
+	return m
+}
+
+// broadcast returns the messages that send each of the given messages to
+// every other player.
+func broadcast(ownID mpcutil.ID, ids []mpcutil.ID, msgs []inv.Message) []MontgomeryMessage {
+	messages := make([]MontgomeryMessage, 0, len(msgs)*(len(ids)-1))
+	for _, msg := range msgs {
+		for _, id := range ids {
+			if id == ownID {
+				continue
+			}
+			messages = append(messages, MontgomeryMessage{
+				FromID:  ownID,
+				ToID:    id,
+				Message: msg,
+			})
+		}
+	}
+	return messages
+}
+
+// ID implements the Machine interface.
+func (m MontgomeryMachine) ID() mpcutil.ID { return m.OwnID }
+
+// InitialMessages implements the Machine interface.
+func (m MontgomeryMachine) InitialMessages() []mpcutil.Message {
+	msgs := make([]mpcutil.Message, len(m.InitMsgs))
+	for i := range m.InitMsgs {
+		msgs[i] = &m.InitMsgs[i]
+	}
+	return msgs
+}
+
+// Handle implements the Machine interface.
+func (m *MontgomeryMachine) Handle(msg mpcutil.Message) []mpcutil.Message {
+	message := msg.(*MontgomeryMessage)
+	outputShares, outputCommitments, _ := m.Inverter.HandleMessage(message.Message)
+	if outputShares != nil && outputCommitments != nil {
+		m.OutputShares = outputShares
+		m.OutputCommitments = outputCommitments
+	}
+	outgoing := broadcast(m.OwnID, m.IDs, m.Inverter.Outgoing())
+	msgs := make([]mpcutil.Message, len(outgoing))
+	for i := range outgoing {
+		msgs[i] = &outgoing[i]
+	}
+	return msgs
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (m MontgomeryMachine) SizeHint() int {
+	return m.OwnID.SizeHint() +
+		surge.SizeHint(m.IDs) +
+		m.Inverter.SizeHint() +
+		surge.SizeHint(m.InitMsgs) +
+		surge.SizeHint(m.OutputShares) +
+		surge.SizeHint(m.OutputCommitments)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (m MontgomeryMachine) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.IDs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = m.Inverter.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.InitMsgs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.OutputShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(m.OutputCommitments, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (m *MontgomeryMachine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.IDs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = m.Inverter.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.InitMsgs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.OutputShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&m.OutputCommitments, buf, rem)
+}
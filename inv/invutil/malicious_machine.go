@@ -6,6 +6,8 @@ import (
 	"github.com/renproject/mpc/inv"
 	"github.com/renproject/mpc/mpcutil"
 	"github.com/renproject/mpc/mulopen"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/triple"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
 	"github.com/renproject/shamir/shamirutil"
@@ -24,9 +26,10 @@ type MaliciousMachine struct {
 func NewMaliciousMachine(
 	aShareBatch, bShareBatch, rzgShareBatch shamir.VerifiableShares,
 	aCommitmentBatch, bCommitmentBatch, rzgCommitmentBatch []shamir.Commitment,
-	ids []mpcutil.ID, ownID mpcutil.ID, indices []secp256k1.Fn, h secp256k1.Point,
+	ids []mpcutil.ID, ownID mpcutil.ID, instance params.InstanceID, indices []secp256k1.Fn, h secp256k1.Point,
 ) MaliciousMachine {
 	_, msgs := inv.New(
+		instance,
 		aShareBatch, bShareBatch, rzgShareBatch,
 		aCommitmentBatch, bCommitmentBatch, rzgCommitmentBatch,
 		indices, h,
@@ -40,6 +43,7 @@ func NewMaliciousMachine(
 		msgsCopy := make([]mulopen.Message, len(msgs))
 		copy(msgsCopy, msgs)
 		message := Message{
+			Instance: instance,
 			FromID:   ownID,
 			ToID:     id,
 			Messages: msgsCopy,
@@ -123,3 +127,93 @@ func (m *MaliciousMachine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	}
 	return surge.Unmarshal(&m.InitMsgs, buf, rem)
 }
+
+// MaliciousMontgomeryMachine represents a player that deviates from the
+// inversion protocol in Montgomery mode by sending invalid messages.
+type MaliciousMontgomeryMachine struct {
+	OwnID    mpcutil.ID
+	InitMsgs []MontgomeryMessage
+}
+
+// NewMaliciousMontgomeryMachine constructs a new malicious machine for an
+// inversion network test in Montgomery mode. It will have the given inputs and
+// ID, and sends invalid messages for the first step of the protocol to a
+// random subset of the other players.
+func NewMaliciousMontgomeryMachine(
+	aShareBatch, bShareBatch, rzgShareBatch shamir.VerifiableShares,
+	aCommitmentBatch, bCommitmentBatch, rzgCommitmentBatch []shamir.Commitment,
+	tripleBatch []triple.Triple,
+	ids []mpcutil.ID, ownID mpcutil.ID, instance params.InstanceID, indices []secp256k1.Fn, h secp256k1.Point,
+) MaliciousMontgomeryMachine {
+	inverter, _ := inv.New(
+		instance,
+		aShareBatch, bShareBatch, rzgShareBatch,
+		aCommitmentBatch, bCommitmentBatch, rzgCommitmentBatch,
+		indices, h,
+		inv.Montgomery(tripleBatch),
+	)
+	toBeModified := randomIDSubset(ids)
+	initialMessages := broadcast(ownID, ids, inverter.Outgoing())
+	for i := range initialMessages {
+		if _, ok := toBeModified[initialMessages[i].ToID]; !ok {
+			continue
+		}
+		msg := &initialMessages[i].Message
+		if len(msg.ShareBatch) > 0 {
+			shareBatch := make(shamir.VerifiableShares, len(msg.ShareBatch))
+			copy(shareBatch, msg.ShareBatch)
+			shareBatch[0].Share.Value = secp256k1.RandomFn()
+			msg.ShareBatch = shareBatch
+		} else {
+			mulOpen := make([]mulopen.Message, len(msg.MulOpen))
+			copy(mulOpen, msg.MulOpen)
+			modifyMessageBatch(mulOpen)
+			msg.MulOpen = mulOpen
+		}
+	}
+	return MaliciousMontgomeryMachine{
+		OwnID:    ownID,
+		InitMsgs: initialMessages,
+	}
+}
+
+// ID implements the Machine interface.
+func (m MaliciousMontgomeryMachine) ID() mpcutil.ID { return m.OwnID }
+
+// InitialMessages implements the Machine interface.
+func (m MaliciousMontgomeryMachine) InitialMessages() []mpcutil.Message {
+	msgs := make([]mpcutil.Message, len(m.InitMsgs))
+	for i := range m.InitMsgs {
+		msgs[i] = &m.InitMsgs[i]
+	}
+	return msgs
+}
+
+// Handle implements the Machine interface.
+func (m *MaliciousMontgomeryMachine) Handle(msg mpcutil.Message) []mpcutil.Message {
+	return nil
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (m MaliciousMontgomeryMachine) SizeHint() int {
+	return m.OwnID.SizeHint() +
+		surge.SizeHint(m.InitMsgs)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (m MaliciousMontgomeryMachine) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(m.InitMsgs, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (m *MaliciousMontgomeryMachine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&m.InitMsgs, buf, rem)
+}
@@ -1,14 +1,17 @@
 package invutil
 
 import (
+	"github.com/renproject/mpc/inv"
 	"github.com/renproject/mpc/mpcutil"
 	"github.com/renproject/mpc/mulopen"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/surge"
 )
 
 // Message is the message type that players send to eachother during an
 // instance of inversion.
 type Message struct {
+	Instance     params.InstanceID
 	FromID, ToID mpcutil.ID
 	Messages     []mulopen.Message
 }
@@ -21,14 +24,19 @@ func (msg Message) To() mpcutil.ID { return msg.ToID }
 
 // SizeHint implements the surge.SizeHinter interface.
 func (msg Message) SizeHint() int {
-	return msg.FromID.SizeHint() +
+	return msg.Instance.SizeHint() +
+		msg.FromID.SizeHint() +
 		msg.ToID.SizeHint() +
 		surge.SizeHint(msg.Messages)
 }
 
 // Marshal implements the surge.Marshaler interface.
 func (msg Message) Marshal(buf []byte, rem int) ([]byte, int, error) {
-	buf, rem, err := msg.FromID.Marshal(buf, rem)
+	buf, rem, err := msg.Instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.FromID.Marshal(buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
@@ -41,7 +49,11 @@ func (msg Message) Marshal(buf []byte, rem int) ([]byte, int, error) {
 
 // Unmarshal implements the surge.Unmarshaler interface.
 func (msg *Message) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
-	buf, rem, err := msg.FromID.Unmarshal(buf, rem)
+	buf, rem, err := msg.Instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.FromID.Unmarshal(buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
@@ -51,3 +63,49 @@ func (msg *Message) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	}
 	return surge.Unmarshal(&msg.Messages, buf, rem)
 }
+
+// MontgomeryMessage is the message type that players send to eachother during
+// an instance of inversion in Montgomery mode.
+type MontgomeryMessage struct {
+	FromID, ToID mpcutil.ID
+	Message      inv.Message
+}
+
+// From implements the mpcutil.Message interface.
+func (msg MontgomeryMessage) From() mpcutil.ID { return msg.FromID }
+
+// To implements the mpcutil.Message interface.
+func (msg MontgomeryMessage) To() mpcutil.ID { return msg.ToID }
+
+// SizeHint implements the surge.SizeHinter interface.
+func (msg MontgomeryMessage) SizeHint() int {
+	return msg.FromID.SizeHint() +
+		msg.ToID.SizeHint() +
+		msg.Message.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (msg MontgomeryMessage) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := msg.FromID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.ToID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return msg.Message.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (msg *MontgomeryMessage) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := msg.FromID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.ToID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return msg.Message.Unmarshal(buf, rem)
+}
@@ -5,6 +5,8 @@ import (
 	"reflect"
 
 	"github.com/renproject/mpc/mulopen"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/triple"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
 	"github.com/renproject/surge"
@@ -12,60 +14,329 @@ import (
 
 // SizeHint implements the surge.SizeHinter interface.
 func (inverter Inverter) SizeHint() int {
-	return inverter.mulopener.SizeHint() +
-		surge.SizeHint(inverter.rShareBatch) +
-		surge.SizeHint(inverter.rCommitmentBatch)
+	size := surge.SizeHint(inverter.rShareBatch) +
+		surge.SizeHint(inverter.rCommitmentBatch) +
+		surge.SizeHint(inverter.montgomery)
+	if inverter.montgomery {
+		size += inverter.tree.SizeHint()
+	}
+	return size + inverter.mulopener.SizeHint()
 }
 
-// Marshal implements the surge.Marshaler interface.
+// Marshal implements the surge.Marshaler interface. The tree is only marshaled
+// in Montgomery mode, and the multiply and open state machine is marshaled
+// last, since unmarshaling it computes a table for the Pedersen parameter,
+// which is expensive.
 func (inverter Inverter) Marshal(buf []byte, rem int) ([]byte, int, error) {
-	buf, rem, err := inverter.mulopener.Marshal(buf, rem)
+	buf, rem, err := surge.Marshal(inverter.rShareBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(inverter.rCommitmentBatch, buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
-	buf, rem, err = surge.Marshal(inverter.rShareBatch, buf, rem)
+	buf, rem, err = surge.MarshalBool(inverter.montgomery, buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
-	return surge.Marshal(inverter.rCommitmentBatch, buf, rem)
+	if inverter.montgomery {
+		buf, rem, err = inverter.tree.Marshal(buf, rem)
+		if err != nil {
+			return buf, rem, err
+		}
+	}
+	return inverter.mulopener.Marshal(buf, rem)
 }
 
 // Unmarshal implements the surge.Unmarshaler interface.
 func (inverter *Inverter) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
-	buf, rem, err := inverter.mulopener.Unmarshal(buf, rem)
+	buf, rem, err := surge.Unmarshal(&inverter.rShareBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&inverter.rCommitmentBatch, buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
-	buf, rem, err = surge.Unmarshal(&inverter.rShareBatch, buf, rem)
+	buf, rem, err = surge.UnmarshalBool(&inverter.montgomery, buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
-	return surge.Unmarshal(&inverter.rCommitmentBatch, buf, rem)
+	if inverter.montgomery {
+		buf, rem, err = inverter.tree.Unmarshal(buf, rem)
+		if err != nil {
+			return buf, rem, err
+		}
+	}
+	return inverter.mulopener.Unmarshal(buf, rem)
 }
 
 // Generate implements the quick.Generator interface.
 func (inverter Inverter) Generate(rand *rand.Rand, size int) reflect.Value {
+	inv := Inverter{montgomery: rand.Int()&1 == 1}
+	if inv.montgomery {
+		inv.tree = tree{}.Generate(rand, size).Interface().(tree)
+	}
 	size /= 4
 	b := rand.Intn(size/2) + 1
-	mulopener := mulopen.MulOpener{}.Generate(rand, size).Interface().(mulopen.MulOpener)
-	rShareBatch := make(shamir.VerifiableShares, b)
+	inv.mulopener = mulopen.MulOpener{}.Generate(rand, size).Interface().(mulopen.MulOpener)
+	inv.rShareBatch, inv.rCommitmentBatch = randomSharings(rand, b, size/2-1)
+	return reflect.ValueOf(inv)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (msg Message) SizeHint() int {
+	return msg.Instance.SizeHint() +
+		surge.SizeHint(msg.ShareBatch) +
+		surge.SizeHint(msg.MulOpen)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (msg Message) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := msg.Instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(msg.ShareBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(msg.MulOpen, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (msg *Message) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := msg.Instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&msg.ShareBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&msg.MulOpen, buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (msg Message) Generate(rand *rand.Rand, size int) reflect.Value {
+	m := Message{
+		Instance:   params.InstanceID{}.Generate(rand, size).Interface().(params.InstanceID),
+		ShareBatch: shamir.VerifiableShares{},
+		MulOpen:    []mulopen.Message{},
+	}
+	if rand.Int()&1 == 1 {
+		m.ShareBatch, _ = randomSharings(rand, rand.Intn(size/2+1), 0)
+	} else {
+		m.MulOpen = append(m.MulOpen, mulopen.Message{}.Generate(rand, size).Interface().(mulopen.Message))
+	}
+	return reflect.ValueOf(m)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (t tree) SizeHint() int {
+	return surge.SizeHint(t.step) +
+		surge.SizeHint(t.nodeShares) +
+		surge.SizeHint(t.nodeCommitments) +
+		surge.SizeHint(t.invShares) +
+		surge.SizeHint(t.invCommitments) +
+		surge.SizeHint(t.tripleBatch) +
+		t.rzgShare.SizeHint() +
+		t.rzgCommitment.SizeHint() +
+		surge.SizeHint(t.pending) +
+		surge.SizeHint(t.outbox) +
+		surge.SizeHint(t.done) +
+		t.instance.SizeHint() +
+		surge.SizeHint(t.indices) +
+		t.h.SizeHint() +
+		t.multiplier.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface. Like the multiply and open
+// state machine for an Inverter, the multiplier is marshaled last.
+func (t tree) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.MarshalU32(t.step, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(t.nodeShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(t.nodeCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(t.invShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(t.invCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(t.tripleBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = t.rzgShare.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = t.rzgCommitment.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(t.pending, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(t.outbox, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.MarshalBool(t.done, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = t.instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(t.indices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = t.h.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return t.multiplier.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (t *tree) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.UnmarshalU32(&t.step, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&t.nodeShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&t.nodeCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&t.invShares, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&t.invCommitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&t.tripleBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = t.rzgShare.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = t.rzgCommitment.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&t.pending, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&t.outbox, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.UnmarshalBool(&t.done, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = t.instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&t.indices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = t.h.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return t.multiplier.Unmarshal(buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (t tree) Generate(rand *rand.Rand, size int) reflect.Value {
+	multiplier := triple.Multiplier{}.Generate(rand, size/2).Interface().(triple.Multiplier)
+	size /= 16
+	levels := rand.Intn(2) + 1
+	nodeShares := make([]shamir.VerifiableShares, levels)
+	nodeCommitments := make([][]shamir.Commitment, levels)
+	for i := range nodeShares {
+		nodeShares[i], nodeCommitments[i] = randomSharings(rand, rand.Intn(size+1), size)
+	}
+	invShares, invCommitments := randomSharings(rand, rand.Intn(size+1), size)
+	tripleBatch := make([]triple.Triple, rand.Intn(2))
+	for i := range tripleBatch {
+		tripleBatch[i] = triple.Triple{}.Generate(rand, size).Interface().(triple.Triple)
+	}
+	pending := make([]Message, rand.Intn(2))
+	for i := range pending {
+		pending[i] = Message{}.Generate(rand, size).Interface().(Message)
+	}
+	outbox := make([]Message, rand.Intn(2))
+	for i := range outbox {
+		outbox[i] = Message{}.Generate(rand, size).Interface().(Message)
+	}
+	indices := make([]secp256k1.Fn, rand.Intn(size+1))
+	for i := range indices {
+		indices[i] = secp256k1.RandomFn()
+	}
+	rzgShares, rzgCommitments := randomSharings(rand, 1, size)
+	t = tree{
+		step:            rand.Uint32(),
+		nodeShares:      nodeShares,
+		nodeCommitments: nodeCommitments,
+		invShares:       invShares,
+		invCommitments:  invCommitments,
+		multiplier:      multiplier,
+		tripleBatch:     tripleBatch,
+		rzgShare:        rzgShares[0],
+		rzgCommitment:   rzgCommitments[0],
+		pending:         pending,
+		outbox:          outbox,
+		done:            rand.Int()&1 == 1,
+		instance:        params.InstanceID{}.Generate(rand, size).Interface().(params.InstanceID),
+		indices:         indices,
+		h:               secp256k1.RandomPoint(),
+	}
+	return reflect.ValueOf(t)
+}
+
+// randomSharings returns a random batch of shares of the given size, along
+// with random commitments.
+func randomSharings(rand *rand.Rand, b, size int) (shamir.VerifiableShares, []shamir.Commitment) {
+	shareBatch := make(shamir.VerifiableShares, b)
+	commitmentBatch := make([]shamir.Commitment, b)
 	for i := 0; i < b; i++ {
-		rShareBatch[i] = shamir.VerifiableShare{
+		shareBatch[i] = shamir.VerifiableShare{
 			Share: shamir.Share{
 				Index: secp256k1.RandomFn(),
 				Value: secp256k1.RandomFn(),
 			},
 			Decommitment: secp256k1.RandomFn(),
 		}
+		commitmentBatch[i] = shamir.Commitment{}.Generate(rand, size+1).Interface().(shamir.Commitment)
 	}
-	rCommitmentBatch := make([]shamir.Commitment, b)
-	for i := 0; i < b; i++ {
-		rCommitmentBatch[i] = shamir.Commitment{}.Generate(rand, size/2).Interface().(shamir.Commitment)
-	}
-	inv := Inverter{
-		mulopener,
-		rShareBatch,
-		rCommitmentBatch,
-	}
-	return reflect.ValueOf(inv)
+	return shareBatch, commitmentBatch
 }
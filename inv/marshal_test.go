@@ -15,6 +15,7 @@ var _ = Describe("Surge marshalling", func() {
 	trials := 10
 	ts := []reflect.Type{
 		reflect.TypeOf(inv.Inverter{}),
+		reflect.TypeOf(inv.Message{}),
 	}
 
 	for _, t := range ts {
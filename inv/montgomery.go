@@ -0,0 +1,410 @@
+package inv
+
+import (
+	"fmt"
+
+	"github.com/renproject/mpc/mulopen"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/triple"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// An Option changes how an Inverter computes the inverses; see New.
+type Option func(*Inverter)
+
+// Montgomery returns the option for New that inverts the whole batch using
+// Montgomery's trick, so that only a single value is opened by a multiply and
+// open, instead of one value for each element of the batch.
+//
+// The inputs a_1, ..., a_b are the leaves of a binary product tree, and the
+// protocol proceeds as follows.
+//	1. Up-sweep: the sharings of the products of the pairs of nodes at each
+//	level of the tree are computed, one level at a time, until a sharing of
+//	the product P = a_1 * ... * a_b is obtained at the root.
+//	2. The product P is multiplied by the mask r and opened using a single
+//	multiply and open, and the sharing of the inverse of P is computed as
+//	(P r)^-1 * [r].
+//	3. Down-sweep: for each node N with children L and R, the sharings of the
+//	inverses of the children are computed as [L^-1] = [N^-1][R] and [R^-1] =
+//	[N^-1][L], one level at a time, until the sharings of the inverses of the
+//	leaves are obtained.
+//
+// The multiplications in the tree are done using the given multiplication
+// triples (see triple.Multiply). There are b - 1 multiplications in the
+// up-sweep and 2(b - 1) multiplications in the down-sweep, and so 3(b - 1)
+// triples are needed, each of which is only used once. The values opened
+// during these multiplications are masked by the triples and do not need
+// ZKPs, and so only one ZKP is needed for the whole batch, at the cost of 2
+// log(b) + 1 sequential steps instead of one.
+//
+// In this mode the mask and RZG sharings passed to New must be batches of size
+// one, and the messages are handled using HandleMessage and sent using
+// Outgoing.
+func Montgomery(tripleBatch []triple.Triple) Option {
+	tripleBatchCopy := make([]triple.Triple, len(tripleBatch))
+	for i := range tripleBatch {
+		tripleBatchCopy[i] = triple.Triple{
+			A: tripleBatch[i].A,
+			B: tripleBatch[i].B,
+			C: tripleBatch[i].C,
+		}
+		tripleBatchCopy[i].ACommitment.Set(tripleBatch[i].ACommitment)
+		tripleBatchCopy[i].BCommitment.Set(tripleBatch[i].BCommitment)
+		tripleBatchCopy[i].CCommitment.Set(tripleBatch[i].CCommitment)
+	}
+	return func(inverter *Inverter) {
+		inverter.montgomery = true
+		inverter.tree.tripleBatch = tripleBatchCopy
+	}
+}
+
+// A Message is a message that is broadcast to the other parties in Montgomery
+// mode, tagged with the instance ID of the step of the protocol that it is
+// for. The messages for the multiplications in the product tree contain the
+// share batch for the multiplication (see triple.Multiply), and the messages
+// for the multiply and open of the product of the inputs contain the multiply
+// and open messages.
+type Message struct {
+	Instance   params.InstanceID
+	ShareBatch shamir.VerifiableShares
+	MulOpen    []mulopen.Message
+}
+
+// A tree is the state of an Inverter in Montgomery mode. The step is the
+// current step of the protocol, where for a tree with l levels above the
+// leaves, the steps 0 to l - 1 are the up-sweep, step l is the multiply and
+// open, and the steps l + 1 to 2l are the down-sweep.
+type tree struct {
+	step            uint32
+	nodeShares      []shamir.VerifiableShares
+	nodeCommitments [][]shamir.Commitment
+	invShares       shamir.VerifiableShares
+	invCommitments  []shamir.Commitment
+	multiplier      triple.Multiplier
+	tripleBatch     []triple.Triple
+	rzgShare        shamir.VerifiableShare
+	rzgCommitment   shamir.Commitment
+	pending         []Message
+	outbox          []Message
+	done            bool
+
+	instance params.InstanceID
+	indices  []secp256k1.Fn
+	h        secp256k1.Point
+}
+
+// newMontgomery sets up the state machine for Montgomery mode and starts the
+// first step of the protocol.
+func (inverter *Inverter) newMontgomery(
+	instance params.InstanceID,
+	aShareBatch, rShareBatch, rzgShareBatch shamir.VerifiableShares,
+	aCommitmentBatch, rCommitmentBatch, rzgCommitmentBatch []shamir.Commitment,
+	indices []secp256k1.Fn, h secp256k1.Point,
+) {
+	b := len(aShareBatch)
+	if b < 1 {
+		panic(fmt.Sprintf("batch size should be at least 1: got %v", b))
+	}
+	if len(aCommitmentBatch) != b {
+		panic("inconsistent batch size")
+	}
+	if len(rShareBatch) != 1 ||
+		len(rCommitmentBatch) != 1 ||
+		len(rzgShareBatch) != 1 ||
+		len(rzgCommitmentBatch) != 1 {
+		panic("mask and rzg batch size should be 1 in montgomery mode")
+	}
+	t := &inverter.tree
+	if len(t.tripleBatch) != 3*(b-1) {
+		panic(fmt.Sprintf(
+			"expected 3(b-1) = %v triples: got %v",
+			3*(b-1), len(t.tripleBatch),
+		))
+	}
+
+	leafShares := make(shamir.VerifiableShares, b)
+	leafCommitments := make([]shamir.Commitment, b)
+	copy(leafShares, aShareBatch)
+	for i := range leafCommitments {
+		leafCommitments[i].Set(aCommitmentBatch[i])
+	}
+	inverter.rShareBatch = shamir.VerifiableShares{rShareBatch[0]}
+	inverter.rCommitmentBatch = make([]shamir.Commitment, 1)
+	inverter.rCommitmentBatch[0].Set(rCommitmentBatch[0])
+	indicesCopy := make([]secp256k1.Fn, len(indices))
+	copy(indicesCopy, indices)
+
+	t.nodeShares = []shamir.VerifiableShares{leafShares}
+	t.nodeCommitments = [][]shamir.Commitment{leafCommitments}
+	t.invShares = shamir.VerifiableShares{}
+	t.invCommitments = []shamir.Commitment{}
+	t.rzgShare = rzgShareBatch[0]
+	t.rzgCommitment.Set(rzgCommitmentBatch[0])
+	t.pending = []Message{}
+	t.outbox = []Message{}
+	t.instance = instance
+	t.indices = indicesCopy
+	t.h = h
+	inverter.start()
+}
+
+// Outgoing returns the messages that are to be broadcast to the other parties
+// in Montgomery mode, and removes them from the state machine. New and
+// HandleMessage can start new steps of the protocol, so this should be called
+// after each call to them.
+func (inverter *Inverter) Outgoing() []Message {
+	msgs := inverter.tree.outbox
+	inverter.tree.outbox = []Message{}
+	return msgs
+}
+
+// HandleMessage applies a state transition upon receiving the given message
+// from another party in Montgomery mode. Messages for later steps of the
+// protocol are stored and handled once the step starts, and messages for
+// earlier steps are ignored. Once the last step of the protocol has completed,
+// the output, i.e. shares and commitments that correspond to the
+// multiplicative inverses of the input secrets, is computed and returned.
+// Otherwise, the return value will be nil. If the message is invalid in any
+// way, an error will be returned along with a nil value; in particular,
+// mulopen.ErrIncorrectInstance is returned if the message is not tagged for
+// any of the steps of the protocol. Invalid messages that were stored are
+// dropped once their step starts.
+//
+// Panics: This function will panic if the state machine is not in Montgomery
+// mode.
+func (inverter *Inverter) HandleMessage(msg Message) (shamir.VerifiableShares, []shamir.Commitment, error) {
+	if !inverter.montgomery {
+		panic("inverter is not in montgomery mode")
+	}
+	t := &inverter.tree
+	step, ok := t.stepOf(msg.Instance)
+	if !ok {
+		return nil, nil, mulopen.ErrIncorrectInstance
+	}
+	if !t.isValidMessage(step, msg) {
+		return nil, nil, ErrInvalidMessage
+	}
+	if t.done || step < t.step {
+		return nil, nil, nil
+	}
+	if step > t.step {
+		t.pending = append(t.pending, msg)
+		return nil, nil, nil
+	}
+
+	done, err := inverter.handleStep(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	for done {
+		t.step++
+		if t.step > 2*t.levels() {
+			t.done = true
+			invShares := make(shamir.VerifiableShares, len(t.invShares))
+			invCommitments := make([]shamir.Commitment, len(t.invCommitments))
+			copy(invShares, t.invShares)
+			for i := range invCommitments {
+				invCommitments[i].Set(t.invCommitments[i])
+			}
+			return invShares, invCommitments, nil
+		}
+		inverter.start()
+		done = inverter.handlePending()
+	}
+	return nil, nil, nil
+}
+
+// start starts the current step of the protocol, adding its initial message
+// to the outgoing messages.
+func (inverter *Inverter) start() {
+	t := &inverter.tree
+	l := t.levels()
+	instance := t.stepInstance(t.step)
+
+	if t.step == l {
+		root := t.nodeShares[l][0]
+		rootCommitment := t.nodeCommitments[l][0]
+		mulopener, messages := mulopen.New(
+			instance,
+			shamir.VerifiableShares{root}, inverter.rShareBatch, shamir.VerifiableShares{t.rzgShare},
+			[]shamir.Commitment{rootCommitment}, inverter.rCommitmentBatch, []shamir.Commitment{t.rzgCommitment},
+			t.indices, t.h,
+		)
+		inverter.mulopener = mulopener
+		t.outbox = append(t.outbox, Message{
+			Instance:   instance,
+			ShareBatch: shamir.VerifiableShares{},
+			MulOpen:    messages,
+		})
+		return
+	}
+
+	var xShares, yShares shamir.VerifiableShares
+	var xCommitments, yCommitments []shamir.Commitment
+	if t.step < l {
+		// Multiply the pairs of nodes at the current level.
+		shares, commitments := t.nodeShares[t.step], t.nodeCommitments[t.step]
+		for i := 0; i+1 < len(shares); i += 2 {
+			xShares = append(xShares, shares[i])
+			yShares = append(yShares, shares[i+1])
+			xCommitments = append(xCommitments, commitments[i])
+			yCommitments = append(yCommitments, commitments[i+1])
+		}
+	} else {
+		// The inverse of each node in a pair is the inverse of its parent
+		// multiplied by the other node in the pair.
+		level := 2*l - t.step
+		shares, commitments := t.nodeShares[level], t.nodeCommitments[level]
+		for i := 0; i+1 < len(shares); i += 2 {
+			parent := i / 2
+			xShares = append(xShares, t.invShares[parent], t.invShares[parent])
+			yShares = append(yShares, shares[i+1], shares[i])
+			xCommitments = append(xCommitments, t.invCommitments[parent], t.invCommitments[parent])
+			yCommitments = append(yCommitments, commitments[i+1], commitments[i])
+		}
+	}
+	m := len(xShares)
+	multiplier, shareBatch := triple.Multiply(
+		instance,
+		xShares, yShares, xCommitments, yCommitments,
+		t.tripleBatch[:m],
+		t.indices, t.h,
+	)
+	t.tripleBatch = t.tripleBatch[m:]
+	t.multiplier = multiplier
+	t.outbox = append(t.outbox, Message{
+		Instance:   instance,
+		ShareBatch: shareBatch,
+		MulOpen:    []mulopen.Message{},
+	})
+}
+
+// handleStep handles a message for the current step of the protocol. The
+// return value is true if the message completed the step.
+func (inverter *Inverter) handleStep(msg Message) (bool, error) {
+	t := &inverter.tree
+	l := t.levels()
+	instance := t.stepInstance(t.step)
+
+	if t.step == l {
+		output, err := inverter.mulopener.HandleShareBatch(instance, msg.MulOpen)
+		if err != nil {
+			return false, err
+		}
+		if output == nil {
+			return false, nil
+		}
+		var inv secp256k1.Fn
+		inv.Inverse(&output[0])
+		t.invShares = make(shamir.VerifiableShares, 1)
+		t.invCommitments = []shamir.Commitment{
+			shamir.NewCommitmentWithCapacity(inverter.rCommitmentBatch[0].Len()),
+		}
+		t.invShares[0].Scale(&inverter.rShareBatch[0], &inv)
+		t.invCommitments[0].Scale(inverter.rCommitmentBatch[0], &inv)
+		return true, nil
+	}
+
+	productShares, productCommitments, err := t.multiplier.HandleShareBatch(instance, msg.ShareBatch)
+	if err != nil {
+		return false, err
+	}
+	if productShares == nil {
+		return false, nil
+	}
+	if t.step < l {
+		// The last node at a level with an odd number of nodes is carried up
+		// to the next level.
+		shares, commitments := t.nodeShares[t.step], t.nodeCommitments[t.step]
+		if len(shares)%2 == 1 {
+			productShares = append(productShares, shares[len(shares)-1])
+			productCommitments = append(productCommitments, commitments[len(shares)-1])
+		}
+		t.nodeShares = append(t.nodeShares, productShares)
+		t.nodeCommitments = append(t.nodeCommitments, productCommitments)
+	} else {
+		// A node that was carried up has the same inverse as its parent.
+		level := 2*l - t.step
+		n := len(t.nodeShares[level])
+		if n%2 == 1 {
+			productShares = append(productShares, t.invShares[n/2])
+			productCommitments = append(productCommitments, t.invCommitments[n/2])
+		}
+		t.invShares = productShares
+		t.invCommitments = productCommitments
+	}
+	return true, nil
+}
+
+// handlePending handles the stored messages for the current step of the
+// protocol. The return value is true if the messages completed the step.
+func (inverter *Inverter) handlePending() bool {
+	t := &inverter.tree
+	pending := t.pending
+	t.pending = []Message{}
+	done := false
+	for _, msg := range pending {
+		step, _ := t.stepOf(msg.Instance)
+		if step > t.step {
+			t.pending = append(t.pending, msg)
+			continue
+		}
+		if done || step < t.step {
+			continue
+		}
+		done, _ = inverter.handleStep(msg)
+	}
+	return done
+}
+
+// levels returns the number of levels of the tree above the leaves.
+func (t *tree) levels() uint32 {
+	l := uint32(0)
+	for n := len(t.nodeShares[0]); n > 1; n = (n + 1) / 2 {
+		l++
+	}
+	return l
+}
+
+// levelSize returns the number of nodes at the given level of the tree.
+func (t *tree) levelSize(level uint32) int {
+	n := len(t.nodeShares[0])
+	for i := uint32(0); i < level; i++ {
+		n = (n + 1) / 2
+	}
+	return n
+}
+
+// stepInstance returns the instance ID for the given step of the protocol.
+func (t *tree) stepInstance(step uint32) params.InstanceID {
+	return params.SubInstance(t.instance, fmt.Sprintf("montgomery/%v", step))
+}
+
+// stepOf returns the step of the protocol that the given instance ID is for,
+// and false if it is not for any of the steps.
+func (t *tree) stepOf(instance params.InstanceID) (uint32, bool) {
+	for step := uint32(0); step <= 2*t.levels(); step++ {
+		if t.stepInstance(step) == instance {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// isValidMessage returns true if the message has the form of a message for
+// the given step of the protocol.
+func (t *tree) isValidMessage(step uint32, msg Message) bool {
+	l := t.levels()
+	if step == l {
+		return len(msg.ShareBatch) == 0 && len(msg.MulOpen) == 1
+	}
+	var multiplications int
+	if step < l {
+		multiplications = t.levelSize(step) / 2
+	} else {
+		multiplications = 2 * (t.levelSize(2*l-step) / 2)
+	}
+	// Each multiplication opens two values.
+	return len(msg.MulOpen) == 0 && len(msg.ShareBatch) == 2*multiplications
+}
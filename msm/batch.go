@@ -0,0 +1,106 @@
+package msm
+
+import (
+	"github.com/renproject/mpc/parallel"
+	"github.com/renproject/secp256k1"
+)
+
+// An Equation is a verification equation of the form
+//	(g)G + (h)H = s_0 P_0 + s_1 P_1 + ... + s_n P_n,
+// where G is the generator of the secp256k1 curve and H is the Pedersen
+// parameter.
+type Equation struct {
+	G, H    secp256k1.Fn
+	Points  []secp256k1.Point
+	Scalars []secp256k1.Fn
+}
+
+// VerifyBatch checks a batch of n elements, each of which holds if all of its
+// verification equations hold, and h is the fixed-base table for the Pedersen
+// parameter. The equations for the ith element are given by equations(i), and
+// verify(i) should return true if the ith element holds, and false otherwise.
+// If all of the elements hold, the return values will be -1 and true.
+// Otherwise, the first return value will be the position in the batch of the
+// first element that does not hold, and the second return value will be false.
+//
+// Each equation is multiplied by an independent random weight, and the
+// weighted equations are summed into a single equation, which is checked using
+// a single multi-scalar multiplication. If all of the equations hold, the
+// combined equation holds, and if any of them do not hold, the combined
+// equation will not hold with overwhelming probability. Only if this check
+// fails are the elements checked individually using verify, which is run using
+// the worker pool from the parallel package, to find the first element that
+// does not hold.
+//
+// The combined coefficients of G and H are computed using the fixed-base
+// tables, and so the coefficients of G and H in the equations must be public;
+// otherwise, use VerifyBatchSecret instead.
+//
+// Panics: This function will panic if any of the equations has a different
+// number of points and scalars.
+func VerifyBatch(
+	h *FixedBase,
+	n int,
+	equations func(i int) []Equation,
+	verify func(i int) bool,
+) (int, bool) {
+	return verifyBatch(n, equations, verify, func(g, hCoeff *secp256k1.Fn) secp256k1.Point {
+		return PedersenCommit(h, g, hCoeff)
+	})
+}
+
+// VerifyBatchSecret is the same as VerifyBatch, except that the combined
+// coefficients of G and H are computed in constant time using
+// PedersenCommitSecret, and so it can be used when the coefficients of G and H
+// in the equations are secret, such as when checking a secret share against
+// its commitment.
+func VerifyBatchSecret(
+	h *secp256k1.Point,
+	n int,
+	equations func(i int) []Equation,
+	verify func(i int) bool,
+) (int, bool) {
+	return verifyBatch(n, equations, verify, func(g, hCoeff *secp256k1.Fn) secp256k1.Point {
+		return PedersenCommitSecret(h, g, hCoeff)
+	})
+}
+
+func verifyBatch(
+	n int,
+	equations func(i int) []Equation,
+	verify func(i int) bool,
+	commit func(g, h *secp256k1.Fn) secp256k1.Point,
+) (int, bool) {
+	if n == 0 {
+		return -1, true
+	}
+
+	var gCoeff, hCoeff, tmp secp256k1.Fn
+	var points []secp256k1.Point
+	var scalars []secp256k1.Fn
+	for i := 0; i < n; i++ {
+		for _, eq := range equations(i) {
+			if len(eq.Points) != len(eq.Scalars) {
+				panic("inconsistent number of points and scalars")
+			}
+			r := secp256k1.RandomFn()
+			tmp.Mul(&r, &eq.G)
+			gCoeff.Add(&gCoeff, &tmp)
+			tmp.Mul(&r, &eq.H)
+			hCoeff.Add(&hCoeff, &tmp)
+			for j := range eq.Points {
+				tmp.Mul(&r, &eq.Scalars[j])
+				points = append(points, eq.Points[j])
+				scalars = append(scalars, tmp)
+			}
+		}
+	}
+	expected := MultiExp(points, scalars)
+	actual := commit(&gCoeff, &hCoeff)
+	if actual.Eq(&expected) {
+		return -1, true
+	}
+
+	i := parallel.First(n, func(i int) bool { return !verify(i) })
+	return i, i < 0
+}
@@ -0,0 +1,80 @@
+package msm
+
+import (
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// Powers returns the first k powers of x, that is, 1, x, x^2, ..., x^(k-1).
+func Powers(x *secp256k1.Fn, k int) []secp256k1.Fn {
+	powers := make([]secp256k1.Fn, k)
+	if k == 0 {
+		return powers
+	}
+	powers[0].SetU16(1)
+	for i := 1; i < k; i++ {
+		powers[i].Mul(&powers[i-1], x)
+	}
+	return powers
+}
+
+// EvalCommitment evaluates the polynomial in the exponent given by the
+// commitment at the given point. This is the commitment to the share of the
+// player with index x.
+//
+// Panics: This function will panic if the commitment is empty.
+func EvalCommitment(commitment shamir.Commitment, x *secp256k1.Fn) secp256k1.Point {
+	if commitment.Len() == 0 {
+		panic("empty commitment")
+	}
+	return MultiExp(commitment, Powers(x, commitment.Len()))
+}
+
+// EvalCommitmentAll evaluates the polynomial in the exponent given by the
+// commitment at each of the given points. The returned slice has the same
+// length as the given points, and the ith element is the same as the result of
+// EvalCommitment for the ith point. The precomputation for the commitment is
+// done only once and shared between all of the evaluations, which makes this
+// considerably cheaper than evaluating the commitment for each point
+// separately.
+//
+// Panics: This function will panic if the commitment is empty.
+func EvalCommitmentAll(commitment shamir.Commitment, xs []secp256k1.Fn) []secp256k1.Point {
+	if commitment.Len() == 0 {
+		panic("empty commitment")
+	}
+	tables := newTables(commitment)
+	evals := make([]secp256k1.Point, len(xs))
+	for i := range xs {
+		evals[i] = straus(tables, Powers(&xs[i], commitment.Len()))
+	}
+	return evals
+}
+
+// IsValid returns true if the given share is valid with respect to the given
+// commitment and the Pedersen parameter that the given table was computed for,
+// and false otherwise. It is equivalent to shamir.IsValid, but evaluates the
+// commitment using a multi-scalar multiplication and computes the Pedersen
+// commitment to the share using the fixed-base tables. The share must
+// therefore be public, such as a share that has been broadcast; for secret
+// shares, use IsValidSecret instead.
+func IsValid(h *FixedBase, commitment *shamir.Commitment, share *shamir.VerifiableShare) bool {
+	if commitment.Len() == 0 {
+		return false
+	}
+	expected := EvalCommitment(*commitment, &share.Share.Index)
+	actual := PedersenCommit(h, &share.Share.Value, &share.Decommitment)
+	return actual.Eq(&expected)
+}
+
+// IsValidSecret is the same as IsValid, except that the Pedersen commitment to
+// the share is computed in constant time using PedersenCommitSecret, and so it
+// can be used when the share is secret.
+func IsValidSecret(h *secp256k1.Point, commitment *shamir.Commitment, share *shamir.VerifiableShare) bool {
+	if commitment.Len() == 0 {
+		return false
+	}
+	expected := EvalCommitment(*commitment, &share.Share.Index)
+	actual := PedersenCommitSecret(h, &share.Share.Value, &share.Decommitment)
+	return actual.Eq(&expected)
+}
@@ -0,0 +1,57 @@
+package msm
+
+import (
+	"fmt"
+
+	"github.com/renproject/secp256k1"
+)
+
+// XY returns the normalized affine coordinates of the given point. The
+// coordinates returned by Point.XY are not necessarily normalized, so two
+// representations of the same point can give different coordinates, and in
+// particular their encodings and parities can differ. The given point is not
+// modified.
+//
+// Panics: This function will panic if the point is the point at infinity.
+func XY(p *secp256k1.Point) (secp256k1.Fp, secp256k1.Fp) {
+	if p.IsInfinity() {
+		panic("point at infinity has no affine coordinates")
+	}
+	// Computing the affine coordinates modifies the representation of the
+	// point, so a copy is used in case the point is shared.
+	q := *p
+	x, y := q.XY()
+	// Addition normalizes the result.
+	var zero secp256k1.Fp
+	x.Add(&x, &zero)
+	y.Add(&y, &zero)
+	return x, y
+}
+
+// PutPoint writes the canonical encoding of the given point to the given
+// slice. The encoding has the same 33 byte compressed form as the surge
+// marshaling of the point, but is computed from the normalized coordinates
+// (see XY), and so unlike the surge marshaling it does not depend on the
+// representation of the point. It should therefore be used whenever a point is
+// hashed. The given point is not modified.
+//
+// Panics: This function will panic if the slice has length less than 33.
+func PutPoint(dst []byte, p *secp256k1.Point) {
+	if len(dst) < secp256k1.PointSizeMarshalled {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 33, got %v", len(dst)))
+	}
+	if p.IsInfinity() {
+		dst[0] = 0xFF
+		for i := 1; i < secp256k1.PointSizeMarshalled; i++ {
+			dst[i] = 0
+		}
+		return
+	}
+	x, y := XY(p)
+	if y.IsEven() {
+		dst[0] = 0
+	} else {
+		dst[0] = 1
+	}
+	x.PutB32(dst[1:secp256k1.PointSizeMarshalled])
+}
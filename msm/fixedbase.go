@@ -0,0 +1,115 @@
+package msm
+
+import (
+	"sync"
+
+	"github.com/renproject/secp256k1"
+)
+
+// fixedBaseWindow is the window size, in bits, used for fixed-base
+// multiplication.
+const fixedBaseWindow = 4
+
+// numFixedBaseWindows is the number of windows in a 256 bit scalar.
+const numFixedBaseWindows = 256 / fixedBaseWindow
+
+// A FixedBase is a table of precomputed multiples of a fixed point, which
+// makes scaling that point considerably cheaper than using Point.Scale. For a
+// window size w, the table holds the multiples d 2^(wi) P for every digit d
+// and window i, so that scaling P requires only one point addition per window
+// and no doublings.
+//
+// Computing the table costs roughly as much as a handful of scalar
+// multiplications, so a FixedBase should be computed once for a point that is
+// used many times, such as the Pedersen parameter h, and then reused. State
+// machines that use the Pedersen parameter compute its table once when they
+// are constructed or unmarshaled.
+//
+// NOTE: Exp is not constant time, and there is no way to make it constant
+// time using the operations that are exposed by the secp256k1 package, since
+// point addition is not constant time. It must therefore only be used with
+// public scalars, such as opened values and the responses in ZKPs, and never
+// with secret scalars such as the value or decommitment of a share.
+type FixedBase struct {
+	point   secp256k1.Point
+	windows [numFixedBaseWindows][1<<fixedBaseWindow - 1]secp256k1.Point
+}
+
+// NewFixedBase computes the table of precomputed multiples for the given
+// point.
+func NewFixedBase(point secp256k1.Point) *FixedBase {
+	fb := FixedBase{point: point}
+	base := point
+	for i := range fb.windows {
+		fb.windows[i][0] = base
+		for d := 1; d < len(fb.windows[i]); d++ {
+			fb.windows[i][d].Add(&fb.windows[i][d-1], &base)
+		}
+		// The base for the next window is 2^w times the current base.
+		for j := 0; j < fixedBaseWindow; j++ {
+			base.Add(&base, &base)
+		}
+	}
+	return &fb
+}
+
+// Point returns the point that the table was computed for.
+func (fb *FixedBase) Point() secp256k1.Point {
+	return fb.point
+}
+
+// Exp returns the point that the table was computed for scaled by the given
+// scalar.
+func (fb *FixedBase) Exp(scalar *secp256k1.Fn) secp256k1.Point {
+	var b [32]byte
+	scalar.PutB32(b[:])
+
+	acc := secp256k1.NewPointInfinity()
+	for i := range fb.windows {
+		digit := digitAt(&b, uint(i*fixedBaseWindow), fixedBaseWindow)
+		if digit != 0 {
+			acc.Add(&acc, &fb.windows[i][digit-1])
+		}
+	}
+	return acc
+}
+
+var (
+	baseTable     *FixedBase
+	baseTableOnce sync.Once
+)
+
+// Base returns the fixed-base table for the generator G of the secp256k1
+// curve. The table is computed the first time that this function is called.
+func Base() *FixedBase {
+	baseTableOnce.Do(func() {
+		var g secp256k1.Point
+		one := secp256k1.NewFnFromU16(1)
+		g.BaseExp(&one)
+		baseTable = NewFixedBase(g)
+	})
+	return baseTable
+}
+
+// PedersenCommit returns the Pedersen commitment vG + rH to the value v with
+// decommitment r, where H is the point that the given table was computed for.
+// The fixed-base tables are used, and so the value and decommitment must be
+// public; for secret values, use PedersenCommitSecret instead.
+func PedersenCommit(h *FixedBase, value, decommitment *secp256k1.Fn) secp256k1.Point {
+	commitment := Base().Exp(value)
+	hPow := h.Exp(decommitment)
+	commitment.Add(&commitment, &hPow)
+	return commitment
+}
+
+// PedersenCommitSecret returns the Pedersen commitment vG + rH to the value v
+// with decommitment r. Unlike PedersenCommit, the scalar multiplications are
+// constant time, and so it can be used when the value and decommitment are
+// secret.
+func PedersenCommitSecret(h *secp256k1.Point, value, decommitment *secp256k1.Fn) secp256k1.Point {
+	var commitment, hPow secp256k1.Point
+	commitment.BaseExp(value)
+	hPow.Scale(h, decommitment)
+	commitment.Add(&commitment, &hPow)
+	return commitment
+}
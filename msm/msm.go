@@ -0,0 +1,200 @@
+// Package msm implements multi-scalar multiplication for secp256k1 points,
+// that is, the computation of a sum of the form s_0 P_0 + s_1 P_1 + ... + s_n
+// P_n for scalars s_i and points P_i, along with the evaluation of polynomials
+// in the exponent (i.e. Pedersen commitments to polynomials) that is built on
+// top of it.
+//
+// Computing the sum as a single multi-scalar multiplication is considerably
+// cheaper than computing each of the terms separately, since the doublings are
+// shared between all of the terms. For small numbers of terms, Straus' method
+// with a fixed window is used, and for larger numbers of terms, Pippenger's
+// bucket method is used.
+//
+// NOTE: The running time of the functions in this package, other than
+// PedersenCommitSecret and the field arithmetic helpers EvalPoly and
+// LagrangeCoefficients, depends on the values of the scalars, and so they
+// should only be used when the scalars are public. When verifying a share
+// against its commitment, the index of the share and the commitment are
+// public, but the share itself may be secret, and so the commitment to the
+// share has to be computed using PedersenCommitSecret (see IsValid and
+// VerifyBatchSecret).
+package msm
+
+import (
+	"math/bits"
+
+	"github.com/renproject/mpc/parallel"
+	"github.com/renproject/secp256k1"
+)
+
+// PippengerThreshold is the number of terms at and above which MultiExp uses
+// Pippenger's method instead of Straus' method.
+const PippengerThreshold = 32
+
+// strausWindow is the window size, in bits, used for Straus' method.
+const strausWindow = 4
+
+// MultiExp computes the sum of the given points, each scaled by the
+// corresponding scalar. If there are no points, the point at infinity is
+// returned.
+//
+// Panics: This function will panic if the number of points is not equal to
+// the number of scalars.
+func MultiExp(points []secp256k1.Point, scalars []secp256k1.Fn) secp256k1.Point {
+	if len(points) != len(scalars) {
+		panic("inconsistent number of points and scalars")
+	}
+
+	// Large multiplications are split into chunks that are computed using the
+	// worker pool from the parallel package, and the results are summed.
+	numChunks := parallel.Workers()
+	if maxChunks := len(points) / PippengerThreshold; numChunks > maxChunks {
+		numChunks = maxChunks
+	}
+	if numChunks > 1 {
+		sums := make([]secp256k1.Point, numChunks)
+		parallel.ForEach(numChunks, func(i int) {
+			lo, hi := i*len(points)/numChunks, (i+1)*len(points)/numChunks
+			sums[i] = multiExp(points[lo:hi], scalars[lo:hi])
+		})
+		acc := sums[0]
+		for i := 1; i < numChunks; i++ {
+			acc.Add(&acc, &sums[i])
+		}
+		return acc
+	}
+	return multiExp(points, scalars)
+}
+
+// multiExp computes the multi-scalar multiplication of the given points and
+// scalars on the calling goroutine.
+func multiExp(points []secp256k1.Point, scalars []secp256k1.Fn) secp256k1.Point {
+	if len(points) < PippengerThreshold {
+		return straus(newTables(points), scalars)
+	}
+	return pippenger(points, scalars)
+}
+
+// A table holds the multiples 0P, 1P, ..., (2^w - 1)P of a point P, where w is
+// the Straus window size.
+type table [1 << strausWindow]secp256k1.Point
+
+// newTables computes the tables of multiples for each of the given points.
+func newTables(points []secp256k1.Point) []table {
+	tables := make([]table, len(points))
+	for i := range points {
+		tables[i][0] = secp256k1.NewPointInfinity()
+		tables[i][1] = points[i]
+		for j := 2; j < len(tables[i]); j++ {
+			tables[i][j].Add(&tables[i][j-1], &points[i])
+		}
+	}
+	return tables
+}
+
+// straus computes the multi-scalar multiplication of the points that the given
+// tables were computed from with the given scalars, using Straus' method with
+// a fixed window. The tables can be reused across calls, which amortises the
+// cost of computing them when the same points are used with many different
+// sets of scalars.
+func straus(tables []table, scalars []secp256k1.Fn) secp256k1.Point {
+	bs := make([][32]byte, len(scalars))
+	for i := range scalars {
+		scalars[i].PutB32(bs[i][:])
+	}
+
+	// The windows are processed from the most significant to the least
+	// significant. Since the window size divides 8, each window lies within a
+	// single byte of the big endian representation of the scalar.
+	acc := secp256k1.NewPointInfinity()
+	for w := 0; w < 256/strausWindow; w++ {
+		if w != 0 {
+			for j := 0; j < strausWindow; j++ {
+				acc.Add(&acc, &acc)
+			}
+		}
+		shift := uint(8 - strausWindow - (w*strausWindow)%8)
+		for i := range bs {
+			digit := (bs[i][(w*strausWindow)/8] >> shift) & (1<<strausWindow - 1)
+			if digit != 0 {
+				acc.Add(&acc, &tables[i][digit])
+			}
+		}
+	}
+	return acc
+}
+
+// pippenger computes the multi-scalar multiplication of the given points and
+// scalars using Pippenger's bucket method.
+func pippenger(points []secp256k1.Point, scalars []secp256k1.Fn) secp256k1.Point {
+	c := pippengerWindow(len(points))
+	bs := make([][32]byte, len(scalars))
+	for i := range scalars {
+		scalars[i].PutB32(bs[i][:])
+	}
+
+	buckets := make([]secp256k1.Point, 1<<c)
+	used := make([]bool, 1<<c)
+	acc := secp256k1.NewPointInfinity()
+	numWindows := (256 + c - 1) / c
+	for w := numWindows - 1; w >= 0; w-- {
+		for j := 0; j < c; j++ {
+			acc.Add(&acc, &acc)
+		}
+
+		// Add each point to the bucket given by its digit for this window.
+		for j := range used {
+			used[j] = false
+		}
+		for i := range points {
+			digit := digitAt(&bs[i], uint(w*c), uint(c))
+			if digit == 0 {
+				continue
+			}
+			if used[digit] {
+				buckets[digit].Add(&buckets[digit], &points[i])
+			} else {
+				buckets[digit] = points[i]
+				used[digit] = true
+			}
+		}
+
+		// The sum of j times the jth bucket is computed as the sum of the
+		// running sums of the buckets, from the highest bucket down.
+		sum := secp256k1.NewPointInfinity()
+		windowSum := secp256k1.NewPointInfinity()
+		for j := len(buckets) - 1; j > 0; j-- {
+			if used[j] {
+				sum.Add(&sum, &buckets[j])
+			}
+			windowSum.Add(&windowSum, &sum)
+		}
+		acc.Add(&acc, &windowSum)
+	}
+	return acc
+}
+
+// pippengerWindow returns the window size, in bits, to use for Pippenger's
+// method with the given number of terms.
+func pippengerWindow(n int) int {
+	c := bits.Len(uint(n)) - 2
+	if c < 4 {
+		return 4
+	}
+	if c > 16 {
+		return 16
+	}
+	return c
+}
+
+// digitAt returns the width bits of the given big endian 256 bit scalar
+// starting at the given bit offset, where the bits are numbered from the least
+// significant bit.
+func digitAt(b *[32]byte, offset, width uint) uint {
+	var digit uint
+	for i := uint(0); i < width && offset+i < 256; i++ {
+		bit := offset + i
+		digit |= uint((b[31-bit/8]>>(bit%8))&1) << i
+	}
+	return digit
+}
@@ -0,0 +1,13 @@
+package msm_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestMsm(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Msm Suite")
+}
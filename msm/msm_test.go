@@ -0,0 +1,445 @@
+package msm_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/mpc/msm"
+
+	"github.com/renproject/mpc/parallel"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+)
+
+var _ = Describe("Multi-scalar multiplication", func() {
+	trials := 5
+
+	// NaiveMultiExp computes the multi-scalar multiplication by scaling each
+	// point separately.
+	NaiveMultiExp := func(points []secp256k1.Point, scalars []secp256k1.Fn) secp256k1.Point {
+		var term secp256k1.Point
+		acc := secp256k1.NewPointInfinity()
+		for i := range points {
+			term.Scale(&points[i], &scalars[i])
+			acc.Add(&acc, &term)
+		}
+		return acc
+	}
+
+	// HornerEval evaluates the commitment at the given point using Horner's
+	// rule.
+	HornerEval := func(commitment shamir.Commitment, x secp256k1.Fn) secp256k1.Point {
+		acc := commitment[len(commitment)-1]
+		for i := len(commitment) - 2; i >= 0; i-- {
+			acc.Scale(&acc, &x)
+			acc.Add(&acc, &commitment[i])
+		}
+		return acc
+	}
+
+	RandomPointsAndScalars := func(n int) ([]secp256k1.Point, []secp256k1.Fn) {
+		points := make([]secp256k1.Point, n)
+		scalars := make([]secp256k1.Fn, n)
+		for i := range points {
+			points[i] = secp256k1.RandomPoint()
+			scalars[i] = secp256k1.RandomFn()
+		}
+		return points, scalars
+	}
+
+	Context("multi-exponentiation", func() {
+		It("should agree with scaling each point separately", func() {
+			for _, n := range []int{1, 2, 7, PippengerThreshold - 1, PippengerThreshold, 2*PippengerThreshold + 3} {
+				points, scalars := RandomPointsAndScalars(n)
+				actual := MultiExp(points, scalars)
+				expected := NaiveMultiExp(points, scalars)
+				Expect(actual.Eq(&expected)).To(BeTrue())
+			}
+		})
+
+		It("should agree with scaling each point separately when using a worker pool", func() {
+			parallel.SetWorkers(4)
+			defer parallel.SetWorkers(1)
+			for _, n := range []int{PippengerThreshold, 3*PippengerThreshold + 1} {
+				points, scalars := RandomPointsAndScalars(n)
+				actual := MultiExp(points, scalars)
+				expected := NaiveMultiExp(points, scalars)
+				Expect(actual.Eq(&expected)).To(BeTrue())
+			}
+		})
+
+		It("should return the point at infinity when there are no terms", func() {
+			sum := MultiExp(nil, nil)
+			Expect(sum.IsInfinity()).To(BeTrue())
+		})
+
+		It("should handle zero scalars, small scalars and repeated points", func() {
+			for _, n := range []int{5, PippengerThreshold + 1} {
+				for t := 0; t < trials; t++ {
+					points, scalars := RandomPointsAndScalars(n)
+					for i := range points {
+						switch rand.Intn(4) {
+						case 0:
+							scalars[i] = secp256k1.NewFnFromU16(0)
+						case 1:
+							scalars[i] = secp256k1.NewFnFromU16(uint16(rand.Intn(16)))
+						case 2:
+							points[i] = points[0]
+						}
+					}
+					actual := MultiExp(points, scalars)
+					expected := NaiveMultiExp(points, scalars)
+					Expect(actual.Eq(&expected)).To(BeTrue())
+				}
+			}
+		})
+
+		It("should return the point at infinity when the terms cancel", func() {
+			for _, n := range []int{3, PippengerThreshold} {
+				points, scalars := RandomPointsAndScalars(n)
+				points[n-1] = NaiveMultiExp(points[:n-1], scalars[:n-1])
+				scalars[n-1] = secp256k1.NewFnFromU16(1)
+				scalars[n-1].Negate(&scalars[n-1])
+				sum := MultiExp(points, scalars)
+				Expect(sum.IsInfinity()).To(BeTrue())
+			}
+		})
+
+		It("should panic if the number of points and scalars are different", func() {
+			points, scalars := RandomPointsAndScalars(3)
+			Expect(func() { MultiExp(points[1:], scalars) }).To(Panic())
+		})
+	})
+
+	Context("commitment evaluation", func() {
+		n := 10
+		k := 4
+
+		RandomCommitment := func() (shamir.VerifiableShares, shamir.Commitment, []secp256k1.Fn, secp256k1.Point) {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			shares := make(shamir.VerifiableShares, n)
+			commitment := shamir.NewCommitmentWithCapacity(k)
+			shamir.VShareSecret(&shares, &commitment, indices, h, secp256k1.RandomFn(), k)
+			return shares, commitment, indices, h
+		}
+
+		It("should compute powers correctly", func() {
+			x := secp256k1.RandomFn()
+			powers := Powers(&x, k)
+			Expect(len(powers)).To(Equal(k))
+			expected := secp256k1.NewFnFromU16(1)
+			for i := range powers {
+				Expect(powers[i].Eq(&expected)).To(BeTrue())
+				expected.Mul(&expected, &x)
+			}
+			Expect(Powers(&x, 0)).To(BeEmpty())
+		})
+
+		It("should agree with Horner's rule", func() {
+			for t := 0; t < trials; t++ {
+				_, commitment, indices, _ := RandomCommitment()
+				x := secp256k1.RandomFn()
+				actual := EvalCommitment(commitment, &x)
+				expected := HornerEval(commitment, x)
+				Expect(actual.Eq(&expected)).To(BeTrue())
+
+				evals := EvalCommitmentAll(commitment, indices)
+				Expect(len(evals)).To(Equal(n))
+				for i := range indices {
+					expected := HornerEval(commitment, indices[i])
+					Expect(evals[i].Eq(&expected)).To(BeTrue())
+				}
+			}
+		})
+
+		It("should panic if the commitment is empty", func() {
+			x := secp256k1.RandomFn()
+			Expect(func() { EvalCommitment(shamir.Commitment{}, &x) }).To(Panic())
+			Expect(func() { EvalCommitmentAll(shamir.Commitment{}, []secp256k1.Fn{x}) }).To(Panic())
+		})
+
+		It("should agree with shamir.IsValid", func() {
+			for t := 0; t < trials; t++ {
+				shares, commitment, _, h := RandomCommitment()
+				hTable := NewFixedBase(h)
+				for i := range shares {
+					Expect(IsValid(hTable, &commitment, &shares[i])).To(BeTrue())
+					Expect(IsValidSecret(&h, &commitment, &shares[i])).To(BeTrue())
+				}
+
+				share := shares[rand.Intn(n)]
+				switch rand.Intn(3) {
+				case 0:
+					share.Share.Value = secp256k1.RandomFn()
+				case 1:
+					share.Decommitment = secp256k1.RandomFn()
+				case 2:
+					share.Share.Index = secp256k1.RandomFn()
+				}
+				Expect(IsValid(hTable, &commitment, &share)).To(BeFalse())
+				Expect(IsValidSecret(&h, &commitment, &share)).To(BeFalse())
+				Expect(shamir.IsValid(h, &commitment, &share)).To(BeFalse())
+				Expect(IsValid(hTable, &shamir.Commitment{}, &share)).To(BeFalse())
+				Expect(IsValidSecret(&h, &shamir.Commitment{}, &share)).To(BeFalse())
+			}
+		})
+	})
+
+	Context("polynomial helpers", func() {
+		It("should evaluate polynomials correctly", func() {
+			for t := 0; t < trials; t++ {
+				k := rand.Intn(10) + 1
+				coeffs := make([]secp256k1.Fn, k)
+				for i := range coeffs {
+					coeffs[i] = secp256k1.RandomFn()
+				}
+				x := secp256k1.RandomFn()
+				var expected, term secp256k1.Fn
+				for i, power := range Powers(&x, k) {
+					term.Mul(&coeffs[i], &power)
+					expected.Add(&expected, &term)
+				}
+				actual := EvalPoly(coeffs, &x)
+				Expect(actual.Eq(&expected)).To(BeTrue())
+			}
+		})
+
+		It("should interpolate the constant term correctly", func() {
+			for t := 0; t < trials; t++ {
+				k := rand.Intn(10) + 1
+				coeffs := make([]secp256k1.Fn, k)
+				for i := range coeffs {
+					coeffs[i] = secp256k1.RandomFn()
+				}
+				indices := shamirutil.RandomIndices(k)
+				lambdas := LagrangeCoefficients(indices)
+				var actual, term secp256k1.Fn
+				for i := range indices {
+					term = EvalPoly(coeffs, &indices[i])
+					term.Mul(&term, &lambdas[i])
+					actual.Add(&actual, &term)
+				}
+				Expect(actual.Eq(&coeffs[0])).To(BeTrue())
+			}
+		})
+	})
+
+	Context("fixed-base multiplication", func() {
+		It("should agree with scaling the point", func() {
+			for t := 0; t < trials; t++ {
+				point := secp256k1.RandomPoint()
+				table := NewFixedBase(point)
+				tablePoint := table.Point()
+				Expect(tablePoint.Eq(&point)).To(BeTrue())
+
+				scalars := []secp256k1.Fn{
+					secp256k1.RandomFn(),
+					secp256k1.NewFnFromU16(0),
+					secp256k1.NewFnFromU16(1),
+					secp256k1.NewFnFromU16(uint16(rand.Intn(1 << 16))),
+				}
+				scalars[1].Negate(&scalars[2])
+				for i := range scalars {
+					var expected secp256k1.Point
+					expected.Scale(&point, &scalars[i])
+					actual := table.Exp(&scalars[i])
+					Expect(actual.Eq(&expected)).To(BeTrue())
+				}
+			}
+		})
+
+		It("should compute Pedersen commitments correctly", func() {
+			for t := 0; t < trials; t++ {
+				h := secp256k1.RandomPoint()
+				value, decommitment := secp256k1.RandomFn(), secp256k1.RandomFn()
+				var expected, hPow secp256k1.Point
+				expected.BaseExp(&value)
+				hPow.Scale(&h, &decommitment)
+				expected.Add(&expected, &hPow)
+				actual := PedersenCommit(NewFixedBase(h), &value, &decommitment)
+				Expect(actual.Eq(&expected)).To(BeTrue())
+			}
+		})
+
+		It("should compute Pedersen commitments to secret values correctly", func() {
+			for t := 0; t < trials; t++ {
+				h := secp256k1.RandomPoint()
+				value, decommitment := secp256k1.RandomFn(), secp256k1.RandomFn()
+				expected := PedersenCommit(NewFixedBase(h), &value, &decommitment)
+				actual := PedersenCommitSecret(&h, &value, &decommitment)
+				Expect(actual.Eq(&expected)).To(BeTrue())
+			}
+		})
+
+		It("should use the generator for the base table", func() {
+			var g secp256k1.Point
+			one := secp256k1.NewFnFromU16(1)
+			g.BaseExp(&one)
+			base := Base().Point()
+			Expect(base.Eq(&g)).To(BeTrue())
+			Expect(Base() == Base()).To(BeTrue())
+		})
+	})
+
+	Context("point encoding", func() {
+		It("should encode points independently of their representation", func() {
+			for t := 0; t < trials; t++ {
+				// The same point is computed in two different ways, which
+				// will in general give different representations of it.
+				a, b := secp256k1.RandomFn(), secp256k1.RandomFn()
+				var sum secp256k1.Fn
+				sum.Add(&a, &b)
+				var p1, p2, tmp secp256k1.Point
+				p1.BaseExp(&sum)
+				p2.BaseExp(&a)
+				tmp.BaseExp(&b)
+				p2.Add(&p2, &tmp)
+
+				bs1 := make([]byte, secp256k1.PointSizeMarshalled)
+				bs2 := make([]byte, secp256k1.PointSizeMarshalled)
+				PutPoint(bs1, &p1)
+				PutPoint(bs2, &p2)
+				Expect(bs1).To(Equal(bs2))
+
+				x1, y1 := XY(&p1)
+				x2, y2 := XY(&p2)
+				Expect(x1.Eq(&x2)).To(BeTrue())
+				Expect(y1.IsEven()).To(Equal(y2.IsEven()))
+
+				// The encoding should be the same as the marshaling of the
+				// point after it has been unmarshaled, which is normalized.
+				var p3 secp256k1.Point
+				bs := make([]byte, secp256k1.PointSizeMarshalled)
+				p1.PutBytes(bs)
+				Expect(p3.SetBytes(bs)).To(Succeed())
+				p3.PutBytes(bs)
+				Expect(bs1).To(Equal(bs))
+			}
+		})
+
+		It("should encode the point at infinity", func() {
+			inf := secp256k1.NewPointInfinity()
+			bs := make([]byte, secp256k1.PointSizeMarshalled)
+			PutPoint(bs, &inf)
+			Expect(bs).To(Equal(append([]byte{0xFF}, make([]byte, 32)...)))
+			Expect(func() { XY(&inf) }).To(Panic())
+		})
+
+		It("should panic if the slice is too short", func() {
+			p := secp256k1.RandomPoint()
+			Expect(func() { PutPoint(make([]byte, 32), &p) }).To(Panic())
+		})
+	})
+
+	Context("batch verification", func() {
+		// RandomEquations returns n elements that each have two random
+		// equations that hold.
+		RandomEquations := func(h secp256k1.Point, n int) [][]Equation {
+			elements := make([][]Equation, n)
+			for i := range elements {
+				elements[i] = make([]Equation, 2)
+				for j := range elements[i] {
+					g, hCoeff := secp256k1.RandomFn(), secp256k1.RandomFn()
+					s := secp256k1.RandomFn()
+					var sInv secp256k1.Fn
+					sInv.Inverse(&s)
+					// The single point is chosen so that s P = gG + hH.
+					p := PedersenCommitSecret(&h, &g, &hCoeff)
+					p.Scale(&p, &sInv)
+					elements[i][j] = Equation{
+						G:       g,
+						H:       hCoeff,
+						Points:  []secp256k1.Point{p, secp256k1.RandomPoint()},
+						Scalars: []secp256k1.Fn{s, secp256k1.NewFnFromU16(0)},
+					}
+				}
+			}
+			return elements
+		}
+
+		Holds := func(h secp256k1.Point, eqs []Equation) bool {
+			for _, eq := range eqs {
+				lhs := PedersenCommitSecret(&h, &eq.G, &eq.H)
+				rhs := NaiveMultiExp(eq.Points, eq.Scalars)
+				if !lhs.Eq(&rhs) {
+					return false
+				}
+			}
+			return true
+		}
+
+		It("should accept elements whose equations hold", func() {
+			for t := 0; t < trials; t++ {
+				h := secp256k1.RandomPoint()
+				elements := RandomEquations(h, rand.Intn(10)+1)
+				equations := func(i int) []Equation { return elements[i] }
+				verify := func(i int) bool { return Holds(h, elements[i]) }
+
+				position, ok := VerifyBatch(NewFixedBase(h), len(elements), equations, verify)
+				Expect(ok).To(BeTrue())
+				Expect(position).To(Equal(-1))
+				position, ok = VerifyBatchSecret(&h, len(elements), equations, verify)
+				Expect(ok).To(BeTrue())
+				Expect(position).To(Equal(-1))
+			}
+		})
+
+		It("should return the position of the first element that does not hold", func() {
+			for t := 0; t < trials; t++ {
+				h := secp256k1.RandomPoint()
+				n := rand.Intn(10) + 1
+				elements := RandomEquations(h, n)
+				first := rand.Intn(n)
+				elements[first][rand.Intn(2)].Scalars[1] = secp256k1.RandomFn()
+				for i := first + 1; i < n; i++ {
+					if rand.Intn(2) == 0 {
+						elements[i][0].G = secp256k1.RandomFn()
+					}
+				}
+				equations := func(i int) []Equation { return elements[i] }
+				verify := func(i int) bool { return Holds(h, elements[i]) }
+
+				position, ok := VerifyBatch(NewFixedBase(h), n, equations, verify)
+				Expect(ok).To(BeFalse())
+				Expect(position).To(Equal(first))
+				position, ok = VerifyBatchSecret(&h, n, equations, verify)
+				Expect(ok).To(BeFalse())
+				Expect(position).To(Equal(first))
+			}
+		})
+
+		It("should reject equations that cancel out when summed", func() {
+			h := secp256k1.RandomPoint()
+			elements := RandomEquations(h, 2)
+			delta := secp256k1.RandomFn()
+			var negDelta secp256k1.Fn
+			negDelta.Negate(&delta)
+			elements[0][0].G.Add(&elements[0][0].G, &delta)
+			elements[1][0].G.Add(&elements[1][0].G, &negDelta)
+			equations := func(i int) []Equation { return elements[i] }
+			verify := func(i int) bool { return Holds(h, elements[i]) }
+			position, ok := VerifyBatch(NewFixedBase(h), 2, equations, verify)
+			Expect(ok).To(BeFalse())
+			Expect(position).To(Equal(0))
+		})
+
+		It("should accept empty batches", func() {
+			h := secp256k1.RandomPoint()
+			position, ok := VerifyBatch(NewFixedBase(h), 0, nil, nil)
+			Expect(ok).To(BeTrue())
+			Expect(position).To(Equal(-1))
+		})
+
+		It("should panic if an equation has a different number of points and scalars", func() {
+			h := secp256k1.RandomPoint()
+			elements := RandomEquations(h, 1)
+			elements[0][1].Scalars = elements[0][1].Scalars[1:]
+			Expect(func() {
+				VerifyBatch(NewFixedBase(h), 1, func(i int) []Equation { return elements[i] }, nil)
+			}).To(Panic())
+		})
+	})
+})
@@ -0,0 +1,42 @@
+package msm
+
+import (
+	"github.com/renproject/secp256k1"
+)
+
+// EvalPoly evaluates the polynomial with the given coefficients at the given
+// point using Horner's method. The coefficients are given in increasing order
+// of degree.
+//
+// Panics: This function will panic if there are no coefficients.
+func EvalPoly(coeffs []secp256k1.Fn, x *secp256k1.Fn) secp256k1.Fn {
+	acc := coeffs[len(coeffs)-1]
+	for i := len(coeffs) - 2; i >= 0; i-- {
+		acc.Mul(&acc, x)
+		acc.Add(&acc, &coeffs[i])
+	}
+	return acc
+}
+
+// LagrangeCoefficients returns the Lagrange basis polynomials for the given
+// indices evaluated at zero. The indices are assumed to be distinct.
+func LagrangeCoefficients(indices []secp256k1.Fn) []secp256k1.Fn {
+	lambdas := make([]secp256k1.Fn, len(indices))
+	for i := range indices {
+		var num, den, diff secp256k1.Fn
+		num.SetU16(1)
+		den.SetU16(1)
+		for j := range indices {
+			if i == j {
+				continue
+			}
+			diff.Negate(&indices[i])
+			diff.Add(&diff, &indices[j])
+			num.Mul(&num, &indices[j])
+			den.Mul(&den, &diff)
+		}
+		den.Inverse(&den)
+		lambdas[i].Mul(&num, &den)
+	}
+	return lambdas
+}
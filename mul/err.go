@@ -0,0 +1,54 @@
+package mul
+
+import "errors"
+
+var (
+	// ErrIncorrectCommitmentsBatchSize is returned when the batch size of the
+	// given commitments is not equal to the batch size of the Multiplier.
+	ErrIncorrectCommitmentsBatchSize = errors.New("incorrect commitments batch size")
+
+	// ErrIncorrectSharesBatchSize is returned when the batch size of the given
+	// shares is not equal to the batch size of the Multiplier.
+	ErrIncorrectSharesBatchSize = errors.New("incorrect shares batch size")
+
+	// ErrIncorrectProofsBatchSize is returned when the batch size of the given
+	// proofs is not equal to the batch size of the Multiplier.
+	ErrIncorrectProofsBatchSize = errors.New("incorrect proofs batch size")
+
+	// ErrInvalidCommitmentDimensions is returned when the batch of commitments
+	// has inconsistent dimensions. This can occur when not all slices in the
+	// batch have the same length as the number of dealers, or when not all
+	// commitments have the threshold of the input sharings.
+	ErrInvalidCommitmentDimensions = errors.New("invalid commitment dimensions")
+
+	// ErrInvalidShareDimensions is returned when not all slices in the batch
+	// of shares have the same length as the number of dealers.
+	ErrInvalidShareDimensions = errors.New("invalid share dimensions")
+
+	// ErrInvalidProofDimensions is returned when not all slices in the batch
+	// of proofs have the same length as the number of dealers.
+	ErrInvalidProofDimensions = errors.New("invalid proof dimensions")
+
+	// ErrInvalidDealerIndex is returned when a dealer index is not in the set
+	// of indices, or when the same dealer index appears more than once.
+	ErrInvalidDealerIndex = errors.New("invalid dealer index")
+
+	// ErrInvalidZKP is returned when a proof does not show that the resharing
+	// of the corresponding dealer is a resharing of the product of its shares
+	// of the two input secrets. The invalid proof can be found using
+	// Multiplier.InvalidZKP.
+	ErrInvalidZKP = errors.New("invalid zkp")
+
+	// ErrInvalidShares is returned when not all of the given shares are valid
+	// with respect to their corresponding commitments.
+	ErrInvalidShares = errors.New("invalid shares")
+
+	// ErrIncorrectIndex is returned when not all of the shares have index
+	// equal to the index of the Multiplier.
+	ErrIncorrectIndex = errors.New("incorrect index")
+
+	// ErrNotEnoughContributions is returned when the number of dealers is
+	// smaller than the reconstruction threshold of the product sharing before
+	// degree reduction, that is, 2k-1.
+	ErrNotEnoughContributions = errors.New("not enough contributions")
+)
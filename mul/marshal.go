@@ -0,0 +1,124 @@
+package mul
+
+import (
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// Generate implements the quick.Generator interface.
+func (multiplier Multiplier) Generate(rand *rand.Rand, size int) reflect.Value {
+	size /= 2
+	indices := make([]secp256k1.Fn, rand.Intn(size/4+1))
+	for i := range indices {
+		indices[i] = secp256k1.RandomFn()
+	}
+	b := rand.Intn(size/8+1) + 1
+	aCommitmentBatch := make([]shamir.Commitment, b)
+	bCommitmentBatch := make([]shamir.Commitment, b)
+	for i := 0; i < b; i++ {
+		aCommitmentBatch[i] = shamir.Commitment{}.Generate(rand, size/8).Interface().(shamir.Commitment)
+		bCommitmentBatch[i] = shamir.Commitment{}.Generate(rand, size/8).Interface().(shamir.Commitment)
+	}
+	h := secp256k1.RandomPoint()
+	m := Multiplier{
+		instance:         params.InstanceID{}.Generate(rand, size).Interface().(params.InstanceID),
+		batchSize:        rand.Uint32(),
+		k:                rand.Uint32(),
+		index:            secp256k1.RandomFn(),
+		indices:          indices,
+		aCommitmentBatch: aCommitmentBatch,
+		bCommitmentBatch: bCommitmentBatch,
+		h:                h,
+		hTable:           msm.NewFixedBase(h),
+	}
+	return reflect.ValueOf(m)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (multiplier Multiplier) SizeHint() int {
+	return multiplier.instance.SizeHint() +
+		surge.SizeHint(multiplier.batchSize) +
+		surge.SizeHint(multiplier.k) +
+		multiplier.index.SizeHint() +
+		surge.SizeHint(multiplier.indices) +
+		surge.SizeHint(multiplier.aCommitmentBatch) +
+		surge.SizeHint(multiplier.bCommitmentBatch) +
+		multiplier.h.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (multiplier Multiplier) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := multiplier.instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.MarshalU32(multiplier.batchSize, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.MarshalU32(multiplier.k, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = multiplier.index.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(multiplier.indices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(multiplier.aCommitmentBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(multiplier.bCommitmentBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return multiplier.h.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (multiplier *Multiplier) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := multiplier.instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.UnmarshalU32(&multiplier.batchSize, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.UnmarshalU32(&multiplier.k, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = multiplier.index.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&multiplier.indices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&multiplier.aCommitmentBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&multiplier.bCommitmentBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = multiplier.h.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	multiplier.hTable = msm.NewFixedBase(multiplier.h)
+	return buf, rem, nil
+}
@@ -0,0 +1,289 @@
+package mul
+
+import (
+	"fmt"
+
+	"github.com/renproject/mpc/brng"
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/mulopen/mulzkp"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/reshare"
+	"github.com/renproject/mpc/transcript"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// A Multiplier is a state machine that implements multiplication of two
+// secret shared values with degree reduction. Unlike mulopen.MulOpener, the
+// product is not revealed; instead the output is a verifiable sharing of the
+// product with the same reconstruction threshold (k) as the inputs, which can
+// be used as the input to other protocols.
+//
+// The protocol proceeds as follows.
+//	1. Each player multiplies its shares of the two inputs, which gives a
+//	share of the product on a polynomial of degree 2k-2. It then creates a
+//	Pedersen commitment to this product share along with a ZKP (see mulzkp)
+//	that the committed value is the product of its input shares, and creates
+//	a verifiable sharing of the product share with threshold k whose
+//	commitment has the product share commitment as its first element. This is
+//	all done by New.
+//	2. The players submit their resharings and proofs to a consensus
+//	algorithm, which decides on at least 2k-1 resharings that are valid for
+//	enough players. Players use IsValid to check the resharings during
+//	consensus.
+//	3. Each player computes its share of the product and the corresponding
+//	commitment using HandleConsensusOutput, which combines the resharings using
+//	Lagrange interpolation at zero over the indices of the dealers.
+//
+// The state machine supports batching, in which case all of the input sharings
+// must have the same indices and reconstruction threshold (k).
+type Multiplier struct {
+	instance                           params.InstanceID
+	batchSize, k                       uint32
+	index                              secp256k1.Fn
+	indices                            []secp256k1.Fn
+	aCommitmentBatch, bCommitmentBatch []shamir.Commitment
+	h                                  secp256k1.Point
+
+	// Precomputed fixed-base table for h. This is not marshaled, and is
+	// computed again when unmarshaling.
+	hTable *msm.FixedBase
+}
+
+// New returns a new Multiplier state machine for the given instance ID along
+// with the resharings of the product shares and the corresponding proofs,
+// which are to be submitted to consensus. The index of the player is taken to
+// be the index of the given shares. The instance ID, the index of the player
+// and the position in the batch are bound into the proofs (see
+// ProofTranscript), so that they are only valid in the same context.
+//
+// Panics: This function will panic if any of the following conditions are
+// met.
+//	- The Pedersen parameter is insecure.
+//	- The batch size is less than 1.
+//	- The share and commitment batches have different batch sizes.
+//	- Not all of the commitments have the same reconstruction threshold (k).
+//	- The number of indices is less than 2k-1.
+//	- Not all of the shares have the same index.
+func New(
+	instance params.InstanceID,
+	aShareBatch, bShareBatch shamir.VerifiableShares,
+	aCommitmentBatch, bCommitmentBatch []shamir.Commitment,
+	indices []secp256k1.Fn, h secp256k1.Point,
+) (Multiplier, []brng.Sharing, []mulzkp.Proof) {
+	if !params.ValidPedersenParameter(h) {
+		panic("insecure choice of pedersen parameter")
+	}
+	b := len(aShareBatch)
+	if b < 1 {
+		panic(fmt.Sprintf("batch size should be at least 1: got %v", b))
+	}
+	if len(bShareBatch) != b ||
+		len(aCommitmentBatch) != b ||
+		len(bCommitmentBatch) != b {
+		panic("inconsistent batch size")
+	}
+	k := aCommitmentBatch[0].Len()
+	for i := 0; i < b; i++ {
+		if aCommitmentBatch[i].Len() != k || bCommitmentBatch[i].Len() != k {
+			panic("inconsistent threshold (k)")
+		}
+	}
+	if len(indices) < 2*k-1 {
+		panic(fmt.Sprintf("not enough indices: expected at least 2*%v-1 = %v, got %v", k, 2*k-1, len(indices)))
+	}
+	index := aShareBatch[0].Share.Index
+	for i := 0; i < b; i++ {
+		if !aShareBatch[i].Share.IndexEq(&index) || !bShareBatch[i].Share.IndexEq(&index) {
+			panic("inconsistent share index")
+		}
+	}
+
+	productShareBatch := make(shamir.VerifiableShares, b)
+	proofs := make([]mulzkp.Proof, b)
+	for i := 0; i < b; i++ {
+		var product secp256k1.Fn
+		product.Mul(&aShareBatch[i].Share.Value, &bShareBatch[i].Share.Value)
+		tau := secp256k1.RandomFn()
+		productShareBatch[i] = shamir.NewVerifiableShare(shamir.NewShare(index, product), tau)
+
+		aShareCommitment := msm.PedersenCommitSecret(&h, &aShareBatch[i].Share.Value, &aShareBatch[i].Decommitment)
+		bShareCommitment := msm.PedersenCommitSecret(&h, &bShareBatch[i].Share.Value, &bShareBatch[i].Decommitment)
+		productShareCommitment := msm.PedersenCommitSecret(&h, &product, &tau)
+		proofs[i] = mulzkp.CreateProof(ProofTranscript(instance, &index, i), &h, &aShareCommitment, &bShareCommitment, &productShareCommitment,
+			aShareBatch[i].Share.Value, bShareBatch[i].Share.Value,
+			aShareBatch[i].Decommitment, bShareBatch[i].Decommitment, tau,
+		)
+	}
+	sharings := reshare.Subshare(productShareBatch, indices, k, h)
+
+	indicesCopy := make([]secp256k1.Fn, len(indices))
+	copy(indicesCopy, indices)
+	aCommitmentBatchCopy := make([]shamir.Commitment, b)
+	bCommitmentBatchCopy := make([]shamir.Commitment, b)
+	for i := 0; i < b; i++ {
+		aCommitmentBatchCopy[i].Set(aCommitmentBatch[i])
+		bCommitmentBatchCopy[i].Set(bCommitmentBatch[i])
+	}
+	multiplier := Multiplier{
+		instance:         instance,
+		batchSize:        uint32(b),
+		k:                uint32(k),
+		index:            index,
+		indices:          indicesCopy,
+		aCommitmentBatch: aCommitmentBatchCopy,
+		bCommitmentBatch: bCommitmentBatchCopy,
+		h:                h,
+		hTable:           msm.NewFixedBase(h),
+	}
+	return multiplier, sharings, proofs
+}
+
+// IsValid checks the validity of the given potential consensus output. The
+// dealer indices are the indices of the players whose resharings were chosen,
+// and sharesBatch[i][j], commitmentsBatch[i][j] and proofsBatch[i][j] are the
+// share for this player, the commitment and the proof for the resharing of
+// the product share for the ith element of the batch by the jth dealer. A
+// return value of nil means that the consensus output can be used to construct
+// the share of the product and its commitment. Otherwise, an error is returned
+// that describes how the output is invalid; in particular, an invalid proof
+// results in ErrInvalidZKP. If the shares are nil, only the commitments and
+// proofs are checked.
+func (multiplier *Multiplier) IsValid(
+	dealerIndices []secp256k1.Fn,
+	sharesBatch []shamir.VerifiableShares,
+	commitmentsBatch [][]shamir.Commitment,
+	proofsBatch [][]mulzkp.Proof,
+) error {
+	// Dealer validity.
+	numDealers := len(dealerIndices)
+	if numDealers < int(2*multiplier.k-1) {
+		return ErrNotEnoughContributions
+	}
+	for i := range dealerIndices {
+		exists := false
+		for j := range multiplier.indices {
+			if dealerIndices[i].Eq(&multiplier.indices[j]) {
+				exists = true
+			}
+		}
+		if !exists {
+			return ErrInvalidDealerIndex
+		}
+		for j := 0; j < i; j++ {
+			if dealerIndices[i].Eq(&dealerIndices[j]) {
+				return ErrInvalidDealerIndex
+			}
+		}
+	}
+
+	// Commitments and proofs validity.
+	if uint32(len(commitmentsBatch)) != multiplier.batchSize {
+		return ErrIncorrectCommitmentsBatchSize
+	}
+	for _, commitments := range commitmentsBatch {
+		if len(commitments) != numDealers {
+			return ErrInvalidCommitmentDimensions
+		}
+		for _, commitment := range commitments {
+			if uint32(commitment.Len()) != multiplier.k {
+				return ErrInvalidCommitmentDimensions
+			}
+		}
+	}
+	if uint32(len(proofsBatch)) != multiplier.batchSize {
+		return ErrIncorrectProofsBatchSize
+	}
+	for _, proofs := range proofsBatch {
+		if len(proofs) != numDealers {
+			return ErrInvalidProofDimensions
+		}
+	}
+	if i, _ := multiplier.InvalidZKP(dealerIndices, commitmentsBatch, proofsBatch); i >= 0 {
+		return ErrInvalidZKP
+	}
+
+	if sharesBatch == nil {
+		return nil
+	}
+
+	// Shares validity. The shares are secret, and so they are checked in
+	// constant time.
+	if uint32(len(sharesBatch)) != multiplier.batchSize {
+		return ErrIncorrectSharesBatchSize
+	}
+	for i, shares := range sharesBatch {
+		if len(shares) != numDealers {
+			return ErrInvalidShareDimensions
+		}
+		for j, share := range shares {
+			if !share.Share.IndexEq(&multiplier.index) {
+				return ErrIncorrectIndex
+			}
+			if !msm.IsValidSecret(&multiplier.h, &commitmentsBatch[i][j], &share) {
+				return ErrInvalidShares
+			}
+		}
+	}
+
+	return nil
+}
+
+// InvalidZKP returns the position in the batch and the position in the dealer
+// indices of the first invalid proof in the given potential consensus output,
+// or -1 and -1 if all of the proofs are valid. The arguments have the same
+// form as for IsValid, and it can be used to identify the invalid proof once
+// IsValid has returned ErrInvalidZKP. The commitments and proofs are assumed
+// to have passed the other checks in IsValid that come before the proofs are
+// checked.
+func (multiplier *Multiplier) InvalidZKP(
+	dealerIndices []secp256k1.Fn,
+	commitmentsBatch [][]shamir.Commitment,
+	proofsBatch [][]mulzkp.Proof,
+) (int, int) {
+	productShareCommitments := make([]secp256k1.Point, len(dealerIndices))
+	transcripts := make([]transcript.Transcript, len(dealerIndices))
+	for i, commitments := range commitmentsBatch {
+		aShareCommitments := msm.EvalCommitmentAll(multiplier.aCommitmentBatch[i], dealerIndices)
+		bShareCommitments := msm.EvalCommitmentAll(multiplier.bCommitmentBatch[i], dealerIndices)
+		for j, commitment := range commitments {
+			productShareCommitments[j] = commitment[0]
+		}
+		for j := range transcripts {
+			transcripts[j] = ProofTranscript(multiplier.instance, &dealerIndices[j], i)
+		}
+		if j, ok := mulzkp.VerifyBatch(
+			transcripts, multiplier.hTable, aShareCommitments, bShareCommitments, productShareCommitments,
+			proofsBatch[i],
+		); !ok {
+			return i, j
+		}
+	}
+	return -1, -1
+}
+
+// HandleConsensusOutput computes the shares of the products and the
+// corresponding commitments from the output of the consensus algorithm. The
+// arguments have the same form as for IsValid, and it is assumed that they
+// have been checked using IsValid. The output sharings have the same
+// reconstruction threshold (k) as the input sharings. If the shares in the
+// consensus output were not valid for this player, the shares argument should
+// be nil, in which case the returned shares will also be nil.
+func HandleConsensusOutput(
+	dealerIndices []secp256k1.Fn,
+	sharesBatch []shamir.VerifiableShares,
+	commitmentsBatch [][]shamir.Commitment,
+) (shamir.VerifiableShares, []shamir.Commitment) {
+	return reshare.HandleConsensusOutput(dealerIndices, sharesBatch, commitmentsBatch)
+}
+
+// ProofTranscript returns the transcript for the proof that is created by the
+// player with the given index for the ith element of the batch in the given
+// instance.
+func ProofTranscript(instance params.InstanceID, index *secp256k1.Fn, i int) transcript.Transcript {
+	t := transcript.New("renproject/mpc/mul")
+	t.AppendBytes("instance", instance[:])
+	t.AppendScalar("index", index)
+	t.AppendU32("position", uint32(i))
+	return t
+}
@@ -0,0 +1,393 @@
+package mul_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/mpc/mul"
+
+	"github.com/renproject/mpc/mulopen/mulzkp"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg/rkpgutil"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+)
+
+var _ = Describe("Multiplier", func() {
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
+	RandomTestParams := func() (int, int, int, []secp256k1.Fn, secp256k1.Point) {
+		n := shamirutil.RandRange(7, 15)
+		k := shamirutil.RandRange(1, (n+1)/2)
+		b := shamirutil.RandRange(1, 5)
+		indices := shamirutil.RandomIndices(n)
+		h := secp256k1.RandomPoint()
+		return n, k, b, indices, h
+	}
+
+	// Setup returns the multipliers for all players, along with the input
+	// secrets and the consensus output when the given number of random players
+	// act as dealers. In the consensus output, sharesBatches[i] are the shares
+	// for the player with index indices[i]. The dealers create their proofs
+	// for the given dealer instance.
+	Setup := func(dealerInstance params.InstanceID, n, k, b, t int, indices []secp256k1.Fn, h secp256k1.Point) (
+		[]Multiplier, []secp256k1.Fn, []secp256k1.Fn,
+		[]secp256k1.Fn, [][]shamir.VerifiableShares, [][]shamir.Commitment, [][]mulzkp.Proof,
+	) {
+		aShares, aCommitments, aSecrets := rkpgutil.RNGOutputBatch(indices, k, b, h)
+		bShares, bCommitments, bSecrets := rkpgutil.RNGOutputBatch(indices, k, b, h)
+
+		multipliers := make([]Multiplier, n)
+		dealers := rand.Perm(n)[:t]
+		dealerIndices := make([]secp256k1.Fn, t)
+		sharesBatches := make([][]shamir.VerifiableShares, n)
+		for i := range sharesBatches {
+			sharesBatches[i] = make([]shamir.VerifiableShares, b)
+			for j := range sharesBatches[i] {
+				sharesBatches[i][j] = make(shamir.VerifiableShares, t)
+			}
+		}
+		commitmentsBatch := make([][]shamir.Commitment, b)
+		proofsBatch := make([][]mulzkp.Proof, b)
+		for i := 0; i < b; i++ {
+			commitmentsBatch[i] = make([]shamir.Commitment, t)
+			proofsBatch[i] = make([]mulzkp.Proof, t)
+		}
+
+		for p := range multipliers {
+			multiplier, sharings, proofs := New(
+				dealerInstance, aShares[p], bShares[p], aCommitments, bCommitments, indices, h,
+			)
+			if dealerInstance != instance {
+				// The multipliers that check the consensus output are always
+				// for the default instance.
+				multiplier, _, _ = New(instance, aShares[p], bShares[p], aCommitments, bCommitments, indices, h)
+			}
+			multipliers[p] = multiplier
+			for d, dealer := range dealers {
+				if dealer != p {
+					continue
+				}
+				dealerIndices[d] = indices[p]
+				for i, sharing := range sharings {
+					commitmentsBatch[i][d] = sharing.Commitment
+					proofsBatch[i][d] = proofs[i]
+					for j := range indices {
+						sharesBatches[j][i][d] = sharing.Shares[j]
+					}
+				}
+			}
+		}
+		return multipliers, aSecrets, bSecrets,
+			dealerIndices, sharesBatches, commitmentsBatch, proofsBatch
+	}
+
+	Context("creating a new multiplier", func() {
+		Specify("the resharings should be valid and commit to the product share", func() {
+			n, k, b, indices, h := RandomTestParams()
+			aShares, aCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			bShares, bCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			p := rand.Intn(n)
+
+			_, sharings, proofs := New(instance, aShares[p], bShares[p], aCommitments, bCommitments, indices, h)
+			Expect(len(sharings)).To(Equal(b))
+			Expect(len(proofs)).To(Equal(b))
+			for i, sharing := range sharings {
+				Expect(sharing.Commitment.Len()).To(Equal(k))
+				Expect(shamirutil.VsharesAreConsistent(sharing.Shares, k)).To(BeTrue())
+				for _, share := range sharing.Shares {
+					Expect(shamir.IsValid(h, &sharing.Commitment, &share)).To(BeTrue())
+				}
+
+				// The constant term of the resharing is the product share.
+				var product secp256k1.Fn
+				product.Mul(&aShares[p][i].Share.Value, &bShares[p][i].Share.Value)
+				secret := shamir.Open(sharingShares(sharing.Shares[:k]))
+				Expect(secret.Eq(&product)).To(BeTrue())
+
+				// The proof should be valid for the first element of the
+				// commitment.
+				var aCom, bCom secp256k1.Point
+				aCom.BaseExp(&aShares[p][i].Share.Value)
+				var hPow secp256k1.Point
+				hPow.Scale(&h, &aShares[p][i].Decommitment)
+				aCom.Add(&aCom, &hPow)
+				bCom.BaseExp(&bShares[p][i].Share.Value)
+				hPow.Scale(&h, &bShares[p][i].Decommitment)
+				bCom.Add(&bCom, &hPow)
+				Expect(mulzkp.Verify(
+					ProofTranscript(instance, &indices[p], i), &h, &aCom, &bCom, &sharing.Commitment[0], &proofs[i],
+				)).To(BeTrue())
+			}
+		})
+	})
+
+	Context("checking if consensus outputs are valid", func() {
+		Specify("valid share, commitment and proof batches", func() {
+			n, k, b, indices, h := RandomTestParams()
+			t := shamirutil.RandRange(2*k-1, n)
+			multipliers, _, _, dealerIndices, sharesBatches, commitmentsBatch, proofsBatch :=
+				Setup(instance, n, k, b, t, indices, h)
+			for i, multiplier := range multipliers {
+				Expect(multiplier.IsValid(dealerIndices, sharesBatches[i], commitmentsBatch, proofsBatch)).To(Succeed())
+				Expect(multiplier.IsValid(dealerIndices, nil, commitmentsBatch, proofsBatch)).To(Succeed())
+			}
+		})
+
+		Context("error cases", func() {
+			var n, k, b, t int
+			var dealerIndices []secp256k1.Fn
+			var sharesBatch []shamir.VerifiableShares
+			var commitmentsBatch [][]shamir.Commitment
+			var proofsBatch [][]mulzkp.Proof
+			var multiplier Multiplier
+
+			BeforeEach(func() {
+				var indices []secp256k1.Fn
+				var h secp256k1.Point
+				var multipliers []Multiplier
+				var sharesBatches [][]shamir.VerifiableShares
+				n, k, b, indices, h = RandomTestParams()
+				t = shamirutil.RandRange(2*k-1, n)
+				multipliers, _, _, dealerIndices, sharesBatches, commitmentsBatch, proofsBatch =
+					Setup(instance, n, k, b, t, indices, h)
+				i := rand.Intn(n)
+				multiplier = multipliers[i]
+				sharesBatch = sharesBatches[i]
+			})
+
+			Specify("incorrect batch size", func() {
+				err := multiplier.IsValid(dealerIndices, sharesBatch[1:], commitmentsBatch, proofsBatch)
+				Expect(err).To(Equal(ErrIncorrectSharesBatchSize))
+
+				err = multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch[1:], proofsBatch)
+				Expect(err).To(Equal(ErrIncorrectCommitmentsBatchSize))
+
+				err = multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch, proofsBatch[1:])
+				Expect(err).To(Equal(ErrIncorrectProofsBatchSize))
+			})
+
+			Specify("not enough contributions", func() {
+				dealerIndices = dealerIndices[:2*k-2]
+				err := multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch, proofsBatch)
+				Expect(err).To(Equal(ErrNotEnoughContributions))
+			})
+
+			Specify("dealer index not in the indices", func() {
+				dealerIndices[rand.Intn(t)] = secp256k1.RandomFn()
+				err := multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch, proofsBatch)
+				Expect(err).To(Equal(ErrInvalidDealerIndex))
+			})
+
+			Specify("duplicate dealer index", func() {
+				// This test only makes sense if there is more than one dealer.
+				if t == 1 {
+					return
+				}
+				dealerIndices[1] = dealerIndices[0]
+				err := multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch, proofsBatch)
+				Expect(err).To(Equal(ErrInvalidDealerIndex))
+			})
+
+			Specify("commitment contributions length", func() {
+				commitmentsBatch[b-1] = commitmentsBatch[b-1][1:]
+				err := multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch, proofsBatch)
+				Expect(err).To(Equal(ErrInvalidCommitmentDimensions))
+			})
+
+			Specify("commitment threshold", func() {
+				commitmentsBatch[0][0] = shamir.NewCommitmentWithCapacity(k + 1)
+				err := multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch, proofsBatch)
+				Expect(err).To(Equal(ErrInvalidCommitmentDimensions))
+			})
+
+			Specify("proof contributions length", func() {
+				proofsBatch[b-1] = proofsBatch[b-1][1:]
+				err := multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch, proofsBatch)
+				Expect(err).To(Equal(ErrInvalidProofDimensions))
+			})
+
+			Specify("resharing of a value that is not the product share", func() {
+				position, dealer := rand.Intn(b), rand.Intn(t)
+				commitmentsBatch[position][dealer][0] = secp256k1.RandomPoint()
+				err := multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch, proofsBatch)
+				Expect(err).To(Equal(ErrInvalidZKP))
+				i, j := multiplier.InvalidZKP(dealerIndices, commitmentsBatch, proofsBatch)
+				Expect(i).To(Equal(position))
+				Expect(j).To(Equal(dealer))
+			})
+
+			Specify("proofs for a different instance", func() {
+				n, k, b, indices, h := RandomTestParams()
+				t := shamirutil.RandRange(2*k-1, n)
+				otherInstance := instance
+				otherInstance[rand.Intn(len(otherInstance))]++
+				multipliers, _, _, dealerIndices, sharesBatches, commitmentsBatch, proofsBatch :=
+					Setup(otherInstance, n, k, b, t, indices, h)
+				err := multipliers[0].IsValid(dealerIndices, sharesBatches[0], commitmentsBatch, proofsBatch)
+				Expect(err).To(Equal(ErrInvalidZKP))
+			})
+
+			Specify("proof from a different dealer", func() {
+				// This test only makes sense if there is more than one dealer.
+				if t == 1 {
+					return
+				}
+				proofsBatch[0][0], proofsBatch[0][1] = proofsBatch[0][1], proofsBatch[0][0]
+				err := multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch, proofsBatch)
+				Expect(err).To(Equal(ErrInvalidZKP))
+			})
+
+			Specify("share contributions length", func() {
+				sharesBatch[b-1] = sharesBatch[b-1][1:]
+				err := multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch, proofsBatch)
+				Expect(err).To(Equal(ErrInvalidShareDimensions))
+			})
+
+			Specify("incorrect share index", func() {
+				sharesBatch[0][0].Share.Index = secp256k1.RandomFn()
+				err := multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch, proofsBatch)
+				Expect(err).To(Equal(ErrIncorrectIndex))
+			})
+
+			Specify("invalid shares", func() {
+				sharesBatch[0][0].Share.Value = secp256k1.RandomFn()
+				err := multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch, proofsBatch)
+				Expect(err).To(Equal(ErrInvalidShares))
+			})
+		})
+	})
+
+	Context("constructing output shares and commitments", func() {
+		It("should return nil shares when the corresponding argument is nil", func() {
+			n, k, b, indices, h := RandomTestParams()
+			_, _, _, dealerIndices, _, commitmentsBatch, _ := Setup(instance, n, k, b, 2*k-1, indices, h)
+			shares, commitments := HandleConsensusOutput(dealerIndices, nil, commitmentsBatch)
+			Expect(shares).To(BeNil())
+			Expect(len(commitments)).To(Equal(b))
+		})
+
+		It("should compute a verifiable sharing of the product with threshold k", func() {
+			n, k, b, indices, h := RandomTestParams()
+			t := shamirutil.RandRange(2*k-1, n)
+			_, aSecrets, bSecrets, dealerIndices, sharesBatches, commitmentsBatch, _ :=
+				Setup(instance, n, k, b, t, indices, h)
+
+			productSharesBatch := make([]shamir.VerifiableShares, b)
+			for i := range productSharesBatch {
+				productSharesBatch[i] = make(shamir.VerifiableShares, n)
+			}
+			var productCommitmentBatch []shamir.Commitment
+			for j := range indices {
+				shareBatch, commitmentBatch := HandleConsensusOutput(dealerIndices, sharesBatches[j], commitmentsBatch)
+				Expect(len(shareBatch)).To(Equal(b))
+				Expect(len(commitmentBatch)).To(Equal(b))
+				for i := range shareBatch {
+					Expect(shareBatch[i].Share.IndexEq(&indices[j])).To(BeTrue())
+					productSharesBatch[i][j] = shareBatch[i]
+				}
+				if productCommitmentBatch != nil {
+					for i := range commitmentBatch {
+						Expect(commitmentBatch[i].Eq(productCommitmentBatch[i])).To(BeTrue())
+					}
+				}
+				productCommitmentBatch = commitmentBatch
+			}
+
+			for i := 0; i < b; i++ {
+				Expect(productCommitmentBatch[i].Len()).To(Equal(k))
+				Expect(shamirutil.VsharesAreConsistent(productSharesBatch[i], k)).To(BeTrue())
+				for _, share := range productSharesBatch[i] {
+					Expect(shamir.IsValid(h, &productCommitmentBatch[i], &share)).To(BeTrue())
+				}
+
+				shares := make(shamir.VerifiableShares, k)
+				for j, l := range rand.Perm(n)[:k] {
+					shares[j] = productSharesBatch[i][l]
+				}
+				var product secp256k1.Fn
+				product.Mul(&aSecrets[i], &bSecrets[i])
+				secret := shamir.Open(sharingShares(shares))
+				Expect(secret.Eq(&product)).To(BeTrue())
+			}
+		})
+	})
+
+	Context("panics", func() {
+		Specify("insecure pedersen parameter", func() {
+			n, k, b, indices, h := RandomTestParams()
+			aShares, aCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			bShares, bCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			p := rand.Intn(n)
+			inf := secp256k1.NewPointInfinity()
+			Expect(func() {
+				New(instance, aShares[p], bShares[p], aCommitments, bCommitments, indices, inf)
+			}).To(Panic())
+		})
+
+		Specify("batch size too small or inconsistent", func() {
+			n, k, b, indices, h := RandomTestParams()
+			aShares, aCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			bShares, bCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			p := rand.Intn(n)
+			Expect(func() {
+				New(instance, aShares[p][:0], bShares[p], aCommitments, bCommitments, indices, h)
+			}).To(Panic())
+			Expect(func() {
+				New(instance, aShares[p], bShares[p][1:], aCommitments, bCommitments, indices, h)
+			}).To(Panic())
+			Expect(func() {
+				New(instance, aShares[p], bShares[p], aCommitments[1:], bCommitments, indices, h)
+			}).To(Panic())
+			Expect(func() {
+				New(instance, aShares[p], bShares[p], aCommitments, bCommitments[1:], indices, h)
+			}).To(Panic())
+		})
+
+		Specify("inconsistent k", func() {
+			n, k, b, indices, h := RandomTestParams()
+			aShares, aCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			bShares, bCommitments, _ := rkpgutil.RNGOutputBatch(indices, k+1, b, h)
+			p := rand.Intn(n)
+			Expect(func() {
+				New(instance, aShares[p], bShares[p], aCommitments, bCommitments, indices, h)
+			}).To(Panic())
+		})
+
+		Specify("not enough indices", func() {
+			n := 5
+			k := 4
+			b := 2
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			aShares, aCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			bShares, bCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			Expect(func() {
+				New(instance, aShares[0], bShares[0], aCommitments, bCommitments, indices, h)
+			}).To(Panic())
+		})
+
+		Specify("inconsistent share index", func() {
+			n, k, b, indices, h := RandomTestParams()
+			aShares, aCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			bShares, bCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			p := rand.Intn(n)
+			bShares[p][0].Share.Index = secp256k1.RandomFn()
+			Expect(func() {
+				New(instance, aShares[p], bShares[p], aCommitments, bCommitments, indices, h)
+			}).To(Panic())
+		})
+	})
+})
+
+// sharingShares returns the underlying shares of the given verifiable shares.
+func sharingShares(vshares shamir.VerifiableShares) shamir.Shares {
+	shares := make(shamir.Shares, len(vshares))
+	for i := range vshares {
+		shares[i] = vshares[i].Share
+	}
+	return shares
+}
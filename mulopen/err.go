@@ -3,6 +3,11 @@ package mulopen
 import "errors"
 
 var (
+	// ErrIncorrectInstance is returned when the given message batch is tagged
+	// with an instance ID that is different to that of the multiply and open
+	// instance.
+	ErrIncorrectInstance = errors.New("incorrect instance")
+
 	// ErrIncorrectBatchSize is returned when the batch size of the given
 	// message is not equal to the batch size of the multiply and open
 	// instance.
@@ -22,7 +27,8 @@ var (
 	ErrDuplicateIndex = errors.New("duplicate index")
 
 	// ErrInvalidZKP is returned when not all of the given ZKPs in the message
-	// are valid.
+	// are valid. The position of the first invalid ZKP can be found using
+	// MulOpener.InvalidZKPPosition.
 	ErrInvalidZKP = errors.New("invalid zkp")
 
 	// ErrInvalidShares is returned when not all of the given shares are valid
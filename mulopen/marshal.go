@@ -4,7 +4,9 @@ import (
 	"math/rand"
 	"reflect"
 
+	"github.com/renproject/mpc/msm"
 	"github.com/renproject/mpc/mulopen/mulzkp"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
 	"github.com/renproject/shamir/shamirutil"
@@ -14,6 +16,7 @@ import (
 // SizeHint implements the surge.SizeHinter interface.
 func (mulopener MulOpener) SizeHint() int {
 	return surge.SizeHint(mulopener.shareBufs) +
+		mulopener.instance.SizeHint() +
 		surge.SizeHint(mulopener.batchSize) +
 		surge.SizeHint(mulopener.k) +
 		surge.SizeHint(mulopener.aCommitmentBatch) +
@@ -29,6 +32,10 @@ func (mulopener MulOpener) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, err
 	}
+	buf, rem, err = mulopener.instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
 	buf, rem, err = surge.MarshalU32(mulopener.batchSize, buf, rem)
 	if err != nil {
 		return buf, rem, err
@@ -62,6 +69,10 @@ func (mulopener *MulOpener) Unmarshal(buf []byte, rem int) ([]byte, int, error)
 	if err != nil {
 		return buf, rem, err
 	}
+	buf, rem, err = mulopener.instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
 	buf, rem, err = surge.UnmarshalU32(&mulopener.batchSize, buf, rem)
 	if err != nil {
 		return buf, rem, err
@@ -86,11 +97,16 @@ func (mulopener *MulOpener) Unmarshal(buf []byte, rem int) ([]byte, int, error)
 	if err != nil {
 		return buf, rem, err
 	}
-	return mulopener.h.Unmarshal(buf, rem)
+	buf, rem, err = mulopener.h.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	mulopener.hTable = msm.NewFixedBase(mulopener.h)
+	return buf, rem, nil
 }
 
 // Generate implements the quick.Generator interface.
-func (mulopener MulOpener) Generate(_ *rand.Rand, size int) reflect.Value {
+func (mulopener MulOpener) Generate(r *rand.Rand, size int) reflect.Value {
 	size /= 5
 	n := rand.Intn(size/2) + 1
 	k := uint32(rand.Intn(size/2) + 2)
@@ -125,6 +141,7 @@ func (mulopener MulOpener) Generate(_ *rand.Rand, size int) reflect.Value {
 	h := secp256k1.RandomPoint()
 	mo := MulOpener{
 		shareBufs,
+		params.InstanceID{}.Generate(r, size).Interface().(params.InstanceID),
 		batchSize,
 		k,
 		aCommitmentBatch,
@@ -132,6 +149,7 @@ func (mulopener MulOpener) Generate(_ *rand.Rand, size int) reflect.Value {
 		rzgCommitmentBatch,
 		indices,
 		h,
+		msm.NewFixedBase(h),
 	}
 	return reflect.ValueOf(mo)
 }
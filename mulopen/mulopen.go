@@ -3,8 +3,11 @@ package mulopen
 import (
 	"fmt"
 
+	"github.com/renproject/mpc/msm"
 	"github.com/renproject/mpc/mulopen/mulzkp"
+	"github.com/renproject/mpc/parallel"
 	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/transcript"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
 )
@@ -14,17 +17,26 @@ import (
 type MulOpener struct {
 	shareBufs []shamir.Shares
 
+	instance                                               params.InstanceID
 	batchSize, k                                           uint32
 	aCommitmentBatch, bCommitmentBatch, rzgCommitmentBatch []shamir.Commitment
 
 	indices []secp256k1.Fn
 	h       secp256k1.Point
+
+	// Precomputed fixed-base table for h. This is not marshaled, and is
+	// computed again when unmarshaling.
+	hTable *msm.FixedBase
 }
 
-// New returns a new MulOpener state machine along with the initial message
-// that is to be broadcast to the other parties. The state machine will handle
-// this message before being returned.
+// New returns a new MulOpener state machine for the given instance ID along
+// with the initial message that is to be broadcast to the other parties,
+// tagged with the instance ID. The state machine will handle this message
+// before being returned. The instance ID, the index of the player and the
+// position in the batch are bound into the ZKPs in the messages (see
+// ProofTranscript), so that they can not be used in a different context.
 func New(
+	instance params.InstanceID,
 	aShareBatch, bShareBatch, rzgShareBatch shamir.VerifiableShares,
 	aCommitmentBatch, bCommitmentBatch, rzgCommitmentBatch []shamir.Commitment,
 	indices []secp256k1.Fn, h secp256k1.Point,
@@ -82,6 +94,7 @@ func New(
 
 	mulopener := MulOpener{
 		shareBufs:          shareBufs,
+		instance:           instance,
 		batchSize:          uint32(batchSize),
 		k:                  uint32(2*k - 1),
 		aCommitmentBatch:   aCommitmentBatch,
@@ -89,6 +102,7 @@ func New(
 		rzgCommitmentBatch: rzgCommitmentBatch,
 		indices:            indices,
 		h:                  h,
+		hTable:             msm.NewFixedBase(h),
 	}
 
 	var product secp256k1.Fn
@@ -96,10 +110,10 @@ func New(
 	for i := 0; i < batchSize; i++ {
 		product.Mul(&aShareBatch[i].Share.Value, &bShareBatch[i].Share.Value)
 		tau := secp256k1.RandomFn()
-		aShareCommitment := pedersenCommit(&aShareBatch[i].Share.Value, &aShareBatch[i].Decommitment, &h)
-		bShareCommitment := pedersenCommit(&bShareBatch[i].Share.Value, &bShareBatch[i].Decommitment, &h)
-		productShareCommitment := pedersenCommit(&product, &tau, &h)
-		proof := mulzkp.CreateProof(&h, &aShareCommitment, &bShareCommitment, &productShareCommitment,
+		aShareCommitment := msm.PedersenCommitSecret(&h, &aShareBatch[i].Share.Value, &aShareBatch[i].Decommitment)
+		bShareCommitment := msm.PedersenCommitSecret(&h, &bShareBatch[i].Share.Value, &bShareBatch[i].Decommitment)
+		productShareCommitment := msm.PedersenCommitSecret(&h, &product, &tau)
+		proof := mulzkp.CreateProof(ProofTranscript(instance, &index, i), &h, &aShareCommitment, &bShareCommitment, &productShareCommitment,
 			aShareBatch[i].Share.Value, bShareBatch[i].Share.Value,
 			aShareBatch[i].Decommitment, bShareBatch[i].Decommitment, tau,
 		)
@@ -119,7 +133,7 @@ func New(
 	}
 
 	// Handle own message immediately.
-	output, err := mulopener.HandleShareBatch(messageBatch)
+	output, err := mulopener.HandleShareBatch(instance, messageBatch)
 	if output != nil || err != nil {
 		panic("unexpected result handling own message")
 	}
@@ -127,14 +141,26 @@ func New(
 	return mulopener, messageBatch
 }
 
-// HandleShareBatch applies a state transition upon receiveing the given shares
-// from another party during the open in the multiply and open protocol. Once
-// enough valid shares have been received to reconstruct, the output, i.e. the
-// product of the two input secrets, is computed and returned. If not enough
-// shares have been received, the return value will be nil. If the message
-// batch id invalid in any way, an error will be returned along with a nil
-// value.
-func (mulopener *MulOpener) HandleShareBatch(messageBatch []Message) ([]secp256k1.Fn, error) {
+// Instance returns the instance ID of the state machine.
+func (mulopener MulOpener) Instance() params.InstanceID {
+	return mulopener.instance
+}
+
+// HandleShareBatch applies a state transition upon receiveing the given shares,
+// tagged with the given instance ID, from another party during the open in the
+// multiply and open protocol. Once enough valid shares have been received to
+// reconstruct, the output, i.e. the product of the two input secrets, is
+// computed and returned. If not enough shares have been received, the return
+// value will be nil. If the message batch id invalid in any way, an error will
+// be returned along with a nil value. In particular, if the message batch is
+// tagged for a different instance, ErrIncorrectInstance is returned, and if any
+// of the ZKPs are invalid, ErrInvalidZKP is returned.
+func (mulopener *MulOpener) HandleShareBatch(instance params.InstanceID, messageBatch []Message) (
+	[]secp256k1.Fn, error,
+) {
+	if instance != mulopener.instance {
+		return nil, ErrIncorrectInstance
+	}
 	if uint32(len(messageBatch)) != mulopener.batchSize {
 		return nil, ErrIncorrectBatchSize
 	}
@@ -162,26 +188,22 @@ func (mulopener *MulOpener) HandleShareBatch(messageBatch []Message) ([]secp256k
 		}
 	}
 
-	for i := uint32(0); i < mulopener.batchSize; i++ {
-		aShareCommitment := polyEvalPoint(mulopener.aCommitmentBatch[i], index)
-		bShareCommitment := polyEvalPoint(mulopener.bCommitmentBatch[i], index)
-		if !mulzkp.Verify(
-			&mulopener.h, &aShareCommitment, &bShareCommitment, &messageBatch[i].Commitment,
-			&messageBatch[i].Proof,
-		) {
-			return nil, ErrInvalidZKP
-		}
+	if mulopener.InvalidZKPPosition(messageBatch) >= 0 {
+		return nil, ErrInvalidZKP
+	}
+
+	if parallel.First(int(mulopener.batchSize), func(i int) bool {
 		var shareCommitment secp256k1.Point
-		rzgShareCommitment := polyEvalPoint(mulopener.rzgCommitmentBatch[i], index)
+		rzgShareCommitment := msm.EvalCommitment(mulopener.rzgCommitmentBatch[i], &index)
 		shareCommitment.Add(&messageBatch[i].Commitment, &rzgShareCommitment)
 
-		com := pedersenCommit(
+		com := msm.PedersenCommit(
+			mulopener.hTable,
 			&messageBatch[i].VShare.Share.Value, &messageBatch[i].VShare.Decommitment,
-			&mulopener.h,
 		)
-		if !shareCommitment.Eq(&com) {
-			return nil, ErrInvalidShares
-		}
+		return !shareCommitment.Eq(&com)
+	}) >= 0 {
+		return nil, ErrInvalidShares
 	}
 
 	// Shares are valid so we add them to the buffers.
@@ -201,22 +223,41 @@ func (mulopener *MulOpener) HandleShareBatch(messageBatch []Message) ([]secp256k
 	return nil, nil
 }
 
-// TODO: This should probably be a function inside the shamir package.
-func polyEvalPoint(commitment shamir.Commitment, index secp256k1.Fn) secp256k1.Point {
-	var acc secp256k1.Point
-	acc = commitment[len(commitment)-1]
-	for l := len(commitment) - 2; l >= 0; l-- {
-		acc.Scale(&acc, &index)
-		acc.Add(&acc, &commitment[l])
+// InvalidZKPPosition returns the position in the batch of the first message in
+// the given message batch whose ZKP is invalid, or -1 if all of the ZKPs are
+// valid. It can be used to identify the invalid ZKP once HandleShareBatch has
+// returned ErrInvalidZKP for the message batch. The message batch is assumed
+// to have passed the other checks in HandleShareBatch that come before the
+// ZKPs are checked.
+func (mulopener *MulOpener) InvalidZKPPosition(messageBatch []Message) int {
+	index := messageBatch[0].VShare.Share.Index
+	aShareCommitments := make([]secp256k1.Point, mulopener.batchSize)
+	bShareCommitments := make([]secp256k1.Point, mulopener.batchSize)
+	productShareCommitments := make([]secp256k1.Point, mulopener.batchSize)
+	proofs := make([]mulzkp.Proof, mulopener.batchSize)
+	transcripts := make([]transcript.Transcript, mulopener.batchSize)
+	parallel.ForEach(int(mulopener.batchSize), func(i int) {
+		transcripts[i] = ProofTranscript(mulopener.instance, &index, i)
+		aShareCommitments[i] = msm.EvalCommitment(mulopener.aCommitmentBatch[i], &index)
+		bShareCommitments[i] = msm.EvalCommitment(mulopener.bCommitmentBatch[i], &index)
+		productShareCommitments[i] = messageBatch[i].Commitment
+		proofs[i] = messageBatch[i].Proof
+	})
+	if i, ok := mulzkp.VerifyBatch(
+		transcripts, mulopener.hTable, aShareCommitments, bShareCommitments, productShareCommitments, proofs,
+	); !ok {
+		return i
 	}
-	return acc
+	return -1
 }
 
-// TODO: This should probably be a function inside the shamir package.
-func pedersenCommit(value, decommitment *secp256k1.Fn, h *secp256k1.Point) secp256k1.Point {
-	var commitment, hPow secp256k1.Point
-	commitment.BaseExp(value)
-	hPow.Scale(h, decommitment)
-	commitment.Add(&commitment, &hPow)
-	return commitment
+// ProofTranscript returns the transcript for the ZKP that is created by the
+// player with the given index for the ith element of the batch in the given
+// instance.
+func ProofTranscript(instance params.InstanceID, index *secp256k1.Fn, i int) transcript.Transcript {
+	t := transcript.New("renproject/mpc/mulopen")
+	t.AppendBytes("instance", instance[:])
+	t.AppendScalar("index", index)
+	t.AppendU32("position", uint32(i))
+	return t
 }
@@ -11,12 +11,16 @@ import (
 	"github.com/renproject/mpc/mpcutil"
 	"github.com/renproject/mpc/mulopen/mulopenutil"
 	"github.com/renproject/mpc/mulopen/mulzkp"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/mpc/rkpg/rkpgutil"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir/shamirutil"
 )
 
 var _ = Describe("MulOpener", func() {
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
 	RandomTestParams := func() (int, int, int, []secp256k1.Fn, secp256k1.Point) {
 		n := shamirutil.RandRange(9, 20)
 		k := shamirutil.RandRange(2, n/3-1)
@@ -47,7 +51,7 @@ var _ = Describe("MulOpener", func() {
 	}
 
 	MessageBatchFromPlayer := func(
-		b int, h secp256k1.Point, index secp256k1.Fn,
+		instance params.InstanceID, b int, h secp256k1.Point, index secp256k1.Fn,
 		aShareBatch, bShareBatch, rzgShareBatch shamir.VerifiableShares,
 		aCommitmentBatch, bCommitmentBatch []shamir.Commitment,
 	) []Message {
@@ -59,7 +63,7 @@ var _ = Describe("MulOpener", func() {
 			aShareCommitment := PolyEvalPoint(aCommitmentBatch[i], index)
 			bShareCommitment := PolyEvalPoint(bCommitmentBatch[i], index)
 			productShareCommitment := PedersenCommit(&product, &tau, &h)
-			proof := mulzkp.CreateProof(&h, &aShareCommitment, &bShareCommitment, &productShareCommitment,
+			proof := mulzkp.CreateProof(ProofTranscript(instance, &index, i), &h, &aShareCommitment, &bShareCommitment, &productShareCommitment,
 				aShareBatch[i].Share.Value, bShareBatch[i].Share.Value,
 				aShareBatch[i].Decommitment, bShareBatch[i].Decommitment, tau,
 			)
@@ -90,6 +94,7 @@ var _ = Describe("MulOpener", func() {
 			rzgShares, rzgCommitments := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
 
 			_, messages := New(
+				instance,
 				aShares[playerInd], bShares[playerInd], rzgShares[playerInd],
 				aCommitments, bCommitments, rzgCommitments,
 				indices, h,
@@ -100,7 +105,7 @@ var _ = Describe("MulOpener", func() {
 				aShareCommitment := PolyEvalPoint(aCommitments[i], index)
 				bShareCommitment := PolyEvalPoint(bCommitments[i], index)
 				Expect(mulzkp.Verify(
-					&h, &aShareCommitment, &bShareCommitment, &message.Commitment, &message.Proof,
+					ProofTranscript(instance, &index, i), &h, &aShareCommitment, &bShareCommitment, &message.Commitment, &message.Proof,
 				)).To(BeTrue())
 
 				// The share should be valid with respect to the associated
@@ -126,6 +131,7 @@ var _ = Describe("MulOpener", func() {
 					rzgShares, rzgCommitments := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
 
 					mulopener, _ := New(
+						instance,
 						aShares[playerInd], bShares[playerInd], rzgShares[playerInd],
 						aCommitments, bCommitments, rzgCommitments,
 						indices, h,
@@ -139,12 +145,12 @@ var _ = Describe("MulOpener", func() {
 							continue
 						}
 						messageBatch := MessageBatchFromPlayer(
-							b, h, ind,
+							instance, b, h, ind,
 							aShares[i], bShares[i], rzgShares[i],
 							aCommitments, bCommitments,
 						)
 
-						output, err := mulopener.HandleShareBatch(messageBatch)
+						output, err := mulopener.HandleShareBatch(instance, messageBatch)
 						count++
 						Expect(err).To(BeNil())
 						if count == 2*k-1 {
@@ -176,6 +182,7 @@ var _ = Describe("MulOpener", func() {
 				rzgShares, rzgCommitments := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
 
 				mulopener, _ := New(
+					instance,
 					aShares[playerInd], bShares[playerInd], rzgShares[playerInd],
 					aCommitments, bCommitments, rzgCommitments,
 					indices, h,
@@ -187,16 +194,51 @@ var _ = Describe("MulOpener", func() {
 				}
 				otherIndex := indices[otherPlayerInd]
 				messageBatch := MessageBatchFromPlayer(
-					b, h, otherIndex,
+					instance, b, h, otherIndex,
 					aShares[otherPlayerInd], bShares[otherPlayerInd], rzgShares[otherPlayerInd],
 					aCommitments, bCommitments,
 				)
 
-				output, err := mulopener.HandleShareBatch(modifyMessages(messageBatch, index))
+				output, actualErr := mulopener.HandleShareBatch(instance, modifyMessages(messageBatch, index))
 				Expect(output).To(BeNil())
-				Expect(err).To(Equal(err))
+				Expect(actualErr).To(Equal(err))
 			}
 
+			Specify("incorrect instance", func() {
+				n, k, b, indices, h := RandomTestParams()
+				aShares, aCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				bShares, bCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				rzgShares, rzgCommitments := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
+
+				mulopener, _ := New(
+					instance,
+					aShares[0], bShares[0], rzgShares[0],
+					aCommitments, bCommitments, rzgCommitments,
+					indices, h,
+				)
+
+				otherInstance := instance
+				otherInstance[rand.Intn(len(otherInstance))]++
+				otherPlayerInd := shamirutil.RandRange(1, n-1)
+				messageBatch := MessageBatchFromPlayer(
+					otherInstance, b, h, indices[otherPlayerInd],
+					aShares[otherPlayerInd], bShares[otherPlayerInd], rzgShares[otherPlayerInd],
+					aCommitments, bCommitments,
+				)
+
+				// The batch is rejected if it is tagged for the other instance.
+				output, err := mulopener.HandleShareBatch(otherInstance, messageBatch)
+				Expect(output).To(BeNil())
+				Expect(err).To(Equal(ErrIncorrectInstance))
+
+				// The batch is also rejected if it is tagged for this instance,
+				// as the ZKPs were created for the other instance.
+				output, err = mulopener.HandleShareBatch(instance, messageBatch)
+				Expect(output).To(BeNil())
+				Expect(err).To(Equal(ErrInvalidZKP))
+				Expect(mulopener.InvalidZKPPosition(messageBatch)).To(Equal(0))
+			})
+
 			Specify("incorrect batch size", func() {
 				TestErrorCase(ErrIncorrectBatchSize, 1,
 					func(messageBatch []Message, _ secp256k1.Fn) []Message {
@@ -238,6 +280,35 @@ var _ = Describe("MulOpener", func() {
 					})
 			})
 
+			Specify("position of the invalid zkp", func() {
+				n, k, b, indices, h := RandomTestParams()
+				aShares, aCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				bShares, bCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				rzgShares, rzgCommitments := rkpgutil.RZGOutputBatch(indices, 2*k-1, b, h)
+
+				mulopener, _ := New(
+					instance,
+					aShares[0], bShares[0], rzgShares[0],
+					aCommitments, bCommitments, rzgCommitments,
+					indices, h,
+				)
+
+				otherPlayerInd := shamirutil.RandRange(1, n-1)
+				messageBatch := MessageBatchFromPlayer(
+					instance, b, h, indices[otherPlayerInd],
+					aShares[otherPlayerInd], bShares[otherPlayerInd], rzgShares[otherPlayerInd],
+					aCommitments, bCommitments,
+				)
+				Expect(mulopener.InvalidZKPPosition(messageBatch)).To(Equal(-1))
+
+				position := rand.Intn(b)
+				messageBatch[position].Commitment = secp256k1.RandomPoint()
+				output, err := mulopener.HandleShareBatch(instance, messageBatch)
+				Expect(output).To(BeNil())
+				Expect(err).To(Equal(ErrInvalidZKP))
+				Expect(mulopener.InvalidZKPPosition(messageBatch)).To(Equal(position))
+			})
+
 			Specify("invalid share", func() {
 				TestErrorCase(ErrInvalidShares, 1,
 					func(messageBatch []Message, _ secp256k1.Fn) []Message {
@@ -259,6 +330,7 @@ var _ = Describe("MulOpener", func() {
 			inf := secp256k1.NewPointInfinity()
 			Expect(func() {
 				New(
+					instance,
 					aShares[playerInd], bShares[playerInd], rzgShares[playerInd],
 					aCommitments, bCommitments, rzgCommitments,
 					indices, inf,
@@ -275,6 +347,7 @@ var _ = Describe("MulOpener", func() {
 
 			Expect(func() {
 				New(
+					instance,
 					aShares[playerInd][:0], bShares[playerInd], rzgShares[playerInd],
 					aCommitments, bCommitments, rzgCommitments,
 					indices, h,
@@ -282,6 +355,7 @@ var _ = Describe("MulOpener", func() {
 			}).To(Panic())
 			Expect(func() {
 				New(
+					instance,
 					aShares[playerInd], bShares[playerInd][:0], rzgShares[playerInd],
 					aCommitments, bCommitments, rzgCommitments,
 					indices, h,
@@ -289,6 +363,7 @@ var _ = Describe("MulOpener", func() {
 			}).To(Panic())
 			Expect(func() {
 				New(
+					instance,
 					aShares[playerInd], bShares[playerInd], rzgShares[playerInd][:0],
 					aCommitments, bCommitments, rzgCommitments,
 					indices, h,
@@ -296,6 +371,7 @@ var _ = Describe("MulOpener", func() {
 			}).To(Panic())
 			Expect(func() {
 				New(
+					instance,
 					aShares[playerInd], bShares[playerInd], rzgShares[playerInd],
 					aCommitments[:0], bCommitments, rzgCommitments,
 					indices, h,
@@ -303,6 +379,7 @@ var _ = Describe("MulOpener", func() {
 			}).To(Panic())
 			Expect(func() {
 				New(
+					instance,
 					aShares[playerInd], bShares[playerInd], rzgShares[playerInd],
 					aCommitments, bCommitments[:0], rzgCommitments,
 					indices, h,
@@ -310,6 +387,7 @@ var _ = Describe("MulOpener", func() {
 			}).To(Panic())
 			Expect(func() {
 				New(
+					instance,
 					aShares[playerInd], bShares[playerInd], rzgShares[playerInd],
 					aCommitments, bCommitments, rzgCommitments[:0],
 					indices, h,
@@ -327,6 +405,7 @@ var _ = Describe("MulOpener", func() {
 			aCommitments[0] = shamir.Commitment{secp256k1.Point{}}
 			Expect(func() {
 				New(
+					instance,
 					aShares[playerInd], bShares[playerInd], rzgShares[playerInd],
 					aCommitments, bCommitments, rzgCommitments,
 					indices, h,
@@ -343,6 +422,7 @@ var _ = Describe("MulOpener", func() {
 
 			Expect(func() {
 				New(
+					instance,
 					aShares[playerInd], bShares[playerInd], rzgShares[playerInd],
 					aCommitments, bCommitments, rzgCommitments,
 					indices, h,
@@ -359,6 +439,7 @@ var _ = Describe("MulOpener", func() {
 
 			Expect(func() {
 				New(
+					instance,
 					aShares[playerInd], bShares[playerInd], rzgShares[playerInd],
 					aCommitments, bCommitments, rzgCommitments,
 					indices, h,
@@ -390,7 +471,7 @@ var _ = Describe("MulOpener", func() {
 				machine := mulopenutil.NewMachine(
 					aShares[i], bShares[i], rzgShares[i],
 					aCommitments, bCommitments, rzgCommitments,
-					ids, id, indices, h,
+					ids, id, instance, indices, h,
 				)
 				machines[i] = &machine
 			}
@@ -3,6 +3,7 @@ package mulopenutil
 import (
 	"github.com/renproject/mpc/mpcutil"
 	"github.com/renproject/mpc/mulopen"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
 	"github.com/renproject/surge"
@@ -22,9 +23,10 @@ type Machine struct {
 func NewMachine(
 	aShareBatch, bShareBatch, rzgShareBatch shamir.VerifiableShares,
 	aCommitmentBatch, bCommitmentBatch, rzgCommitmentBatch []shamir.Commitment,
-	ids []mpcutil.ID, ownID mpcutil.ID, indices []secp256k1.Fn, h secp256k1.Point,
+	ids []mpcutil.ID, ownID mpcutil.ID, instance params.InstanceID, indices []secp256k1.Fn, h secp256k1.Point,
 ) Machine {
 	mulopener, msgs := mulopen.New(
+		instance,
 		aShareBatch, bShareBatch, rzgShareBatch,
 		aCommitmentBatch, bCommitmentBatch, rzgCommitmentBatch,
 		indices, h,
@@ -35,6 +37,7 @@ func NewMachine(
 			continue
 		}
 		initialMessages = append(initialMessages, Message{
+			Instance: instance,
 			FromID:   ownID,
 			ToID:     id,
 			Messages: msgs,
@@ -103,7 +106,8 @@ func (m Machine) InitialMessages() []mpcutil.Message {
 
 // Handle implements the Machine interface.
 func (m *Machine) Handle(msg mpcutil.Message) []mpcutil.Message {
-	output, _ := m.MulOpener.HandleShareBatch(msg.(*Message).Messages)
+	message := msg.(*Message)
+	output, _ := m.MulOpener.HandleShareBatch(message.Instance, message.Messages)
 	if output != nil {
 		m.Output = output
 	}
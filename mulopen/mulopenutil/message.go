@@ -3,12 +3,14 @@ package mulopenutil
 import (
 	"github.com/renproject/mpc/mpcutil"
 	"github.com/renproject/mpc/mulopen"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/surge"
 )
 
 // Message is the message type that players send to eachother during an
 // instance of multiply and open.
 type Message struct {
+	Instance     params.InstanceID
 	FromID, ToID mpcutil.ID
 	Messages     []mulopen.Message
 }
@@ -21,14 +23,19 @@ func (msg Message) To() mpcutil.ID { return msg.ToID }
 
 // SizeHint implements the surge.SizeHinter interface.
 func (msg Message) SizeHint() int {
-	return msg.FromID.SizeHint() +
+	return msg.Instance.SizeHint() +
+		msg.FromID.SizeHint() +
 		msg.ToID.SizeHint() +
 		surge.SizeHint(msg.Messages)
 }
 
 // Marshal implements the surge.Marshaler interface.
 func (msg Message) Marshal(buf []byte, rem int) ([]byte, int, error) {
-	buf, rem, err := msg.FromID.Marshal(buf, rem)
+	buf, rem, err := msg.Instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.FromID.Marshal(buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
@@ -41,7 +48,11 @@ func (msg Message) Marshal(buf []byte, rem int) ([]byte, int, error) {
 
 // Unmarshal implements the surge.Unmarshaler interface.
 func (msg *Message) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
-	buf, rem, err := msg.FromID.Unmarshal(buf, rem)
+	buf, rem, err := msg.Instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.FromID.Unmarshal(buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
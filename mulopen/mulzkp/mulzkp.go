@@ -12,20 +12,33 @@
 package mulzkp
 
 import (
-	"crypto/sha256"
-
+	"github.com/renproject/mpc/msm"
 	"github.com/renproject/mpc/mulopen/mulzkp/zkp"
+	"github.com/renproject/mpc/parallel"
+	"github.com/renproject/mpc/transcript"
 	"github.com/renproject/secp256k1"
 )
 
+// domain is the domain separator that is appended to the transcript for each
+// proof.
+const domain = "renproject/mpc/mulzkp"
+
 // CreateProof constructs a new ZKP that attests to the fact that
 // 		c = (alpha*beta)G + (tau)H,
 // where
 //		a = (alpha)G + (rho)H, and
 //		b = (beta)G + (sigma)H.
-func CreateProof(h, a, b, c *secp256k1.Point, alpha, beta, rho, sigma, tau secp256k1.Fn) Proof {
+// The Fiat Shamir challenge is derived from the given transcript, which should
+// contain the context for the proof, such as the instance ID, the index of the
+// prover and the position of the proof in a batch. The proof will only be
+// valid for a transcript with the same context.
+func CreateProof(
+	t transcript.Transcript,
+	h, a, b, c *secp256k1.Point,
+	alpha, beta, rho, sigma, tau secp256k1.Fn,
+) Proof {
 	msg, w := zkp.New(h, b, alpha, beta, rho, sigma, tau)
-	e := computeChallenge(a, b, c, &msg)
+	e := computeChallenge(t, h, a, b, c, &msg)
 	res := zkp.ResponseForChallenge(&w, &e)
 
 	return Proof{msg, res}
@@ -36,40 +49,56 @@ func CreateProof(h, a, b, c *secp256k1.Point, alpha, beta, rho, sigma, tau secp2
 // where
 //		a = (alpha)G + (rho)H, and
 //		b = (beta)G + (sigma)H
-// for some alpha, beta, rho, sigma, tau. Otherwise, the return value will be
-// false.
-func Verify(h, a, b, c *secp256k1.Point, p *Proof) bool {
-	e := computeChallenge(a, b, c, &p.msg)
+// for some alpha, beta, rho, sigma, tau, and the proof was created with a
+// transcript with the same context as the given transcript. Otherwise, the
+// return value will be false.
+func Verify(t transcript.Transcript, h, a, b, c *secp256k1.Point, p *Proof) bool {
+	e := computeChallenge(t, h, a, b, c, &p.msg)
 	return zkp.Verify(h, a, b, c, &p.msg, &p.res, &e)
 }
 
-func computeChallenge(a, b, c *secp256k1.Point, msg *zkp.Message) secp256k1.Fn {
-	l := a.SizeHint() + b.SizeHint() + c.SizeHint() + msg.SizeHint()
-	buf := make([]byte, l)
-
-	var tail []byte
-	rem := l
-	var err error
-
-	tail, rem, err = a.Marshal(buf, rem)
-	if err != nil {
-		panic("unreachable")
-	}
-	tail, rem, err = b.Marshal(tail, rem)
-	if err != nil {
-		panic("unreachable")
-	}
-	tail, rem, err = c.Marshal(tail, rem)
-	if err != nil {
-		panic("unreachable")
-	}
-	tail, rem, err = msg.Marshal(tail, rem)
-	if err != nil {
-		panic("unreachable")
+// VerifyBatch verifies the given batch of proofs, where the ith proof is for
+// the ith elements of the given slices of transcripts and points, and h is the
+// fixed-base table for the Pedersen parameter. If all of the proofs are valid,
+// the return values will be -1 and true. Otherwise, the first return value
+// will be the position in the batch of the first invalid proof, and the second
+// return value will be false.
+//
+// The proofs are checked together using zkp.VerifyBatch.
+//
+// Panics: This function will panic if the slices do not all have the same
+// length.
+func VerifyBatch(
+	ts []transcript.Transcript,
+	h *msm.FixedBase,
+	as, bs, cs []secp256k1.Point,
+	proofs []Proof,
+) (int, bool) {
+	n := len(proofs)
+	if len(ts) != n || len(as) != n || len(bs) != n || len(cs) != n {
+		panic("inconsistent batch size")
 	}
-	hash := sha256.Sum256(buf)
+	hPoint := h.Point()
+	msgs := make([]zkp.Message, n)
+	ress := make([]zkp.Response, n)
+	es := make([]secp256k1.Fn, n)
+	parallel.ForEach(n, func(i int) {
+		msgs[i] = proofs[i].msg
+		ress[i] = proofs[i].res
+		es[i] = computeChallenge(ts[i], &hPoint, &as[i], &bs[i], &cs[i], &proofs[i].msg)
+	})
+	return zkp.VerifyBatch(h, as, bs, cs, msgs, ress, es)
+}
 
-	var e secp256k1.Fn
-	_ = e.SetB32(hash[:])
-	return e
+// computeChallenge appends the statement and the message of the proof to the
+// given transcript and derives the challenge from it. The transcript is
+// passed by value, and so the transcript of the caller is not modified.
+func computeChallenge(t transcript.Transcript, h, a, b, c *secp256k1.Point, msg *zkp.Message) secp256k1.Fn {
+	t.AppendBytes("dom-sep", []byte(domain))
+	t.AppendPoint("h", h)
+	t.AppendPoint("a", a)
+	t.AppendPoint("b", b)
+	t.AppendPoint("c", c)
+	t.AppendValue("msg", msg)
+	return t.Challenge("e")
 }
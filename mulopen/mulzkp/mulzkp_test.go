@@ -1,16 +1,29 @@
 package mulzkp_test
 
 import (
+	"math/rand"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/renproject/mpc/mulopen/mulzkp"
 
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/transcript"
 	"github.com/renproject/secp256k1"
 )
 
 var _ = Describe("NIZK", func() {
 	trials := 100
 
+	// RandomTranscript returns a transcript with a random context.
+	RandomTranscript := func() transcript.Transcript {
+		t := transcript.New("mulzkp test")
+		var context [32]byte
+		rand.Read(context[:])
+		t.AppendBytes("context", context[:])
+		return t
+	}
+
 	RandomTestParams := func() (
 		secp256k1.Fn, secp256k1.Fn, secp256k1.Fn, secp256k1.Fn, secp256k1.Fn,
 		secp256k1.Point, secp256k1.Point, secp256k1.Point,
@@ -51,8 +64,9 @@ var _ = Describe("NIZK", func() {
 				alpha, beta, rho, sigma, tau, a, b, h := RandomTestParams()
 				c := RandomCorrectC(alpha, beta, tau, h)
 
-				proof := CreateProof(&h, &a, &b, &c, alpha, beta, rho, sigma, tau)
-				Expect(Verify(&h, &a, &b, &c, &proof)).To(BeTrue())
+				t := RandomTranscript()
+				proof := CreateProof(t, &h, &a, &b, &c, alpha, beta, rho, sigma, tau)
+				Expect(Verify(t, &h, &a, &b, &c, &proof)).To(BeTrue())
 			}
 		})
 
@@ -61,9 +75,97 @@ var _ = Describe("NIZK", func() {
 				alpha, beta, rho, sigma, tau, a, b, h := RandomTestParams()
 				c := secp256k1.RandomPoint()
 
-				proof := CreateProof(&h, &a, &b, &c, alpha, beta, rho, sigma, tau)
-				Expect(Verify(&h, &a, &b, &c, &proof)).To(BeFalse())
+				t := RandomTranscript()
+				proof := CreateProof(t, &h, &a, &b, &c, alpha, beta, rho, sigma, tau)
+				Expect(Verify(t, &h, &a, &b, &c, &proof)).To(BeFalse())
 			}
 		})
+
+		It("should reject proofs for a different transcript", func() {
+			for i := 0; i < trials; i++ {
+				alpha, beta, rho, sigma, tau, a, b, h := RandomTestParams()
+				c := RandomCorrectC(alpha, beta, tau, h)
+
+				t := RandomTranscript()
+				proof := CreateProof(t, &h, &a, &b, &c, alpha, beta, rho, sigma, tau)
+				Expect(Verify(RandomTranscript(), &h, &a, &b, &c, &proof)).To(BeFalse())
+
+				// Appending further context should also invalidate the proof.
+				other := t
+				other.AppendU32("position", uint32(i))
+				Expect(Verify(other, &h, &a, &b, &c, &proof)).To(BeFalse())
+			}
+		})
+	})
+
+	Context("verifying batches of proofs", func() {
+		batchSize := 10
+
+		// RandomBatch returns the commitments and proofs for a batch of
+		// correct proofs that use the same Pedersen parameter.
+		RandomBatch := func(h secp256k1.Point) (
+			[]transcript.Transcript, []secp256k1.Point, []secp256k1.Point, []secp256k1.Point, []Proof,
+		) {
+			ts := make([]transcript.Transcript, batchSize)
+			as := make([]secp256k1.Point, batchSize)
+			bs := make([]secp256k1.Point, batchSize)
+			cs := make([]secp256k1.Point, batchSize)
+			proofs := make([]Proof, batchSize)
+			var hPow secp256k1.Point
+			for i := 0; i < batchSize; i++ {
+				alpha, beta, rho, sigma, tau, _, _, _ := RandomTestParams()
+				hPow.Scale(&h, &rho)
+				as[i].BaseExp(&alpha)
+				as[i].Add(&as[i], &hPow)
+				hPow.Scale(&h, &sigma)
+				bs[i].BaseExp(&beta)
+				bs[i].Add(&bs[i], &hPow)
+				cs[i] = RandomCorrectC(alpha, beta, tau, h)
+				ts[i] = RandomTranscript()
+				proofs[i] = CreateProof(ts[i], &h, &as[i], &bs[i], &cs[i], alpha, beta, rho, sigma, tau)
+			}
+			return ts, as, bs, cs, proofs
+		}
+
+		It("should accept batches of correct proofs", func() {
+			for i := 0; i < trials/10; i++ {
+				h := secp256k1.RandomPoint()
+				ts, as, bs, cs, proofs := RandomBatch(h)
+				position, ok := VerifyBatch(ts, msm.NewFixedBase(h), as, bs, cs, proofs)
+				Expect(ok).To(BeTrue())
+				Expect(position).To(Equal(-1))
+			}
+		})
+
+		It("should return the position of the first incorrect proof", func() {
+			for i := 0; i < trials/10; i++ {
+				h := secp256k1.RandomPoint()
+				ts, as, bs, cs, proofs := RandomBatch(h)
+				first := i % (batchSize - 1)
+				cs[first] = secp256k1.RandomPoint()
+				cs[batchSize-1] = secp256k1.RandomPoint()
+				position, ok := VerifyBatch(ts, msm.NewFixedBase(h), as, bs, cs, proofs)
+				Expect(ok).To(BeFalse())
+				Expect(position).To(Equal(first))
+			}
+		})
+
+		It("should reject proofs for different commitments", func() {
+			h := secp256k1.RandomPoint()
+			ts, as, bs, cs, proofs := RandomBatch(h)
+			proofs[0], proofs[1] = proofs[1], proofs[0]
+			position, ok := VerifyBatch(ts, msm.NewFixedBase(h), as, bs, cs, proofs)
+			Expect(ok).To(BeFalse())
+			Expect(position).To(Equal(0))
+		})
+
+		It("should reject proofs for different transcripts", func() {
+			h := secp256k1.RandomPoint()
+			ts, as, bs, cs, proofs := RandomBatch(h)
+			ts[1] = RandomTranscript()
+			position, ok := VerifyBatch(ts, msm.NewFixedBase(h), as, bs, cs, proofs)
+			Expect(ok).To(BeFalse())
+			Expect(position).To(Equal(1))
+		})
 	})
 })
@@ -10,7 +10,10 @@
 // https://doi.org/10.1145/277697.277716
 package zkp
 
-import "github.com/renproject/secp256k1"
+import (
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/secp256k1"
+)
 
 // New constructs a new message and witness for the ZKP for the given
 // parameters.
@@ -113,3 +116,61 @@ func Verify(h, a, b, c *secp256k1.Point, msg *Message, res *Response, e *secp256
 
 	return true
 }
+
+// VerifyBatch verifies the given batch of messages, challenges and responses,
+// where the ith element of each of the slices corresponds to the ith proof, and
+// h is the fixed-base table for the Pedersen parameter. The proofs are checked
+// together using msm.VerifyBatch, and the return values are the same.
+//
+// Panics: This function will panic if the slices do not all have the same
+// length.
+func VerifyBatch(
+	h *msm.FixedBase,
+	as, bs, cs []secp256k1.Point,
+	msgs []Message, ress []Response, es []secp256k1.Fn,
+) (int, bool) {
+	n := len(as)
+	if len(bs) != n || len(cs) != n || len(msgs) != n || len(ress) != n || len(es) != n {
+		panic("inconsistent batch size")
+	}
+	hPoint := h.Point()
+	return msm.VerifyBatch(h, n,
+		func(i int) []msm.Equation {
+			return equations(&as[i], &bs[i], &cs[i], &msgs[i], &ress[i], &es[i])
+		},
+		func(i int) bool {
+			return Verify(&hPoint, &as[i], &bs[i], &cs[i], &msgs[i], &ress[i], &es[i])
+		},
+	)
+}
+
+// equations returns the verification equations that are checked by Verify in
+// the form used by msm.VerifyBatch, that is,
+//	(y)G + (w)H = m + (e)b,
+//	(z)G + (w1)H = m1 + (e)a, and
+//	(w2)H = m2 + (e)c - (z)b.
+func equations(a, b, c *secp256k1.Point, msg *Message, res *Response, e *secp256k1.Fn) []msm.Equation {
+	var zero, negZ secp256k1.Fn
+	one := secp256k1.NewFnFromU16(1)
+	negZ.Negate(&res.z)
+	return []msm.Equation{
+		{
+			G:       res.y,
+			H:       res.w,
+			Points:  []secp256k1.Point{msg.m, *b},
+			Scalars: []secp256k1.Fn{one, *e},
+		},
+		{
+			G:       res.z,
+			H:       res.w1,
+			Points:  []secp256k1.Point{msg.m1, *a},
+			Scalars: []secp256k1.Fn{one, *e},
+		},
+		{
+			G:       zero,
+			H:       res.w2,
+			Points:  []secp256k1.Point{msg.m2, *c, *b},
+			Scalars: []secp256k1.Fn{one, *e, negZ},
+		},
+	}
+}
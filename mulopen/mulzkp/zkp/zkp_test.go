@@ -5,6 +5,7 @@ import (
 	. "github.com/onsi/gomega"
 	. "github.com/renproject/mpc/mulopen/mulzkp/zkp"
 
+	"github.com/renproject/mpc/msm"
 	"github.com/renproject/secp256k1"
 )
 
@@ -130,4 +131,78 @@ var _ = Describe("ZKP", func() {
 			}
 		})
 	})
+
+	Context("batch verification", func() {
+		batchSize := 10
+
+		// RandomBatch returns the commitments, messages, responses and
+		// challenges for a batch of correct proofs that use the same
+		// Pedersen parameter.
+		RandomBatch := func(h secp256k1.Point) (
+			[]secp256k1.Point, []secp256k1.Point, []secp256k1.Point,
+			[]Message, []Response, []secp256k1.Fn,
+		) {
+			as := make([]secp256k1.Point, batchSize)
+			bs := make([]secp256k1.Point, batchSize)
+			cs := make([]secp256k1.Point, batchSize)
+			msgs := make([]Message, batchSize)
+			ress := make([]Response, batchSize)
+			es := make([]secp256k1.Fn, batchSize)
+			var hPow secp256k1.Point
+			for i := 0; i < batchSize; i++ {
+				alpha, beta, rho, sigma, tau, _, _, _ := RandomTestParams()
+				hPow.Scale(&h, &rho)
+				as[i].BaseExp(&alpha)
+				as[i].Add(&as[i], &hPow)
+				hPow.Scale(&h, &sigma)
+				bs[i].BaseExp(&beta)
+				bs[i].Add(&bs[i], &hPow)
+				cs[i] = RandomCorrectC(alpha, beta, tau, h)
+
+				var w Witness
+				msgs[i], w = New(&h, &bs[i], alpha, beta, rho, sigma, tau)
+				es[i] = secp256k1.RandomFn()
+				ress[i] = ResponseForChallenge(&w, &es[i])
+			}
+			return as, bs, cs, msgs, ress, es
+		}
+
+		It("should verify batches of correct proofs", func() {
+			for i := 0; i < trials/10; i++ {
+				h := secp256k1.RandomPoint()
+				as, bs, cs, msgs, ress, es := RandomBatch(h)
+				position, ok := VerifyBatch(msm.NewFixedBase(h), as, bs, cs, msgs, ress, es)
+				Expect(ok).To(BeTrue())
+				Expect(position).To(Equal(-1))
+			}
+		})
+
+		It("should reject batches that contain an incorrect proof", func() {
+			for i := 0; i < trials/10; i++ {
+				h := secp256k1.RandomPoint()
+				as, bs, cs, msgs, ress, es := RandomBatch(h)
+				j := i % batchSize
+				switch i % 4 {
+				case 0:
+					as[j] = secp256k1.RandomPoint()
+				case 1:
+					bs[j] = secp256k1.RandomPoint()
+				case 2:
+					cs[j] = secp256k1.RandomPoint()
+				case 3:
+					es[j] = secp256k1.RandomFn()
+				}
+				position, ok := VerifyBatch(msm.NewFixedBase(h), as, bs, cs, msgs, ress, es)
+				Expect(ok).To(BeFalse())
+				Expect(position).To(Equal(j))
+			}
+		})
+
+		It("should panic if the slices have different lengths", func() {
+			h := secp256k1.RandomPoint()
+			as, bs, cs, msgs, ress, es := RandomBatch(h)
+			Expect(func() { VerifyBatch(msm.NewFixedBase(h), as[1:], bs, cs, msgs, ress, es) }).To(Panic())
+			Expect(func() { VerifyBatch(msm.NewFixedBase(h), as, bs, cs, msgs, ress, es[1:]) }).To(Panic())
+		})
+	})
 })
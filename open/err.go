@@ -21,4 +21,8 @@ var (
 	// ErrIncorrectBatchSize signifies that the batch size of the received
 	// shares is different to that specified by the opener instance.
 	ErrIncorrectBatchSize = errors.New("incorrect batch size")
+
+	// ErrIncorrectInstance signifies that the received shares are tagged with
+	// an instance ID that is different to that of the opener instance.
+	ErrIncorrectInstance = errors.New("incorrect instance")
 )
@@ -0,0 +1,72 @@
+package open
+
+import (
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// A Fault is a record of a share batch that was rejected by an Opener because
+// at least one of its shares was invalid. It identifies the sender of the
+// batch and the share that caused the batch to be rejected, so that the sender
+// can be held accountable.
+type Fault struct {
+	// Index is the index of the sender of the rejected batch, as given to
+	// HandleShareBatchFrom.
+	Index secp256k1.Fn
+
+	// Position is the position in the batch of the offending share.
+	Position uint32
+
+	// Share is the offending share.
+	Share shamir.VerifiableShare
+}
+
+// Faults returns the faults that have been recorded by the opener, in the
+// order in which the corresponding share batches were handled. A fault is
+// recorded when HandleShareBatchFrom returns ErrInvalidShares, that is, when
+// one of the shares does not have the index of the sender or is not valid with
+// respect to its commitment. At most one fault is recorded for each index in
+// the index set, so that the number of faults (and hence the size of the
+// marshaled opener) is bounded by the number of players. Since the sender is
+// authenticated by the caller, a player can not cause a fault to be recorded
+// against another player.
+//
+// No faults are recorded for the other errors: an incorrect batch size or
+// instance does not have an offending share, a duplicate index can occur for
+// honest players, for example if a message is delivered more than once, and a
+// sender that is not in the index set is not a player.
+func (opener Opener) Faults() []Fault {
+	faults := make([]Fault, len(opener.faults))
+	copy(faults, opener.faults)
+	return faults
+}
+
+// fault records a fault against the given sender for the share at the given
+// position in the given share batch, and returns ErrInvalidShares. The fault
+// is not recorded if the sender is nil or is not in the index set, or if a
+// fault has already been recorded for the sender.
+func (opener *Opener) fault(from *secp256k1.Fn, shareBatch shamir.VerifiableShares, position int) error {
+	if from == nil {
+		return ErrInvalidShares
+	}
+	exists := false
+	for i := range opener.indices {
+		if from.Eq(&opener.indices[i]) {
+			exists = true
+		}
+	}
+	if !exists {
+		return ErrInvalidShares
+	}
+	for i := range opener.faults {
+		if from.Eq(&opener.faults[i].Index) {
+			return ErrInvalidShares
+		}
+	}
+	opener.faults = append(opener.faults, Fault{
+		Index:    *from,
+		Position: uint32(position),
+		Share:    shareBatch[position],
+	})
+	return ErrInvalidShares
+}
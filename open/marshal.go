@@ -5,6 +5,8 @@ import (
 	"math/rand"
 	"reflect"
 
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
 	"github.com/renproject/shamir/shamirutil"
@@ -12,7 +14,7 @@ import (
 )
 
 // Generate implements the quick.Generator interface.
-func (opener Opener) Generate(_ *rand.Rand, size int) reflect.Value {
+func (opener Opener) Generate(r *rand.Rand, size int) reflect.Value {
 	// A curve point is more or less 3 field elements that contain 4 uint64s.
 	size /= 12
 
@@ -27,15 +29,28 @@ func (opener Opener) Generate(_ *rand.Rand, size int) reflect.Value {
 	}
 	indices := shamirutil.RandomIndices(rand.Intn(20))
 	h := secp256k1.RandomPoint()
-	return reflect.ValueOf(New(commitmentBatch, indices, h))
+	instance := params.InstanceID{}.Generate(r, size).Interface().(params.InstanceID)
+	if rand.Intn(2) == 0 {
+		opener = New(instance, commitmentBatch, indices, h)
+	} else {
+		opener = NewPublic(instance, commitmentBatch, indices, h)
+	}
+	opener.faults = make([]Fault, rand.Intn(5))
+	for i := range opener.faults {
+		opener.faults[i] = Fault{}.Generate(nil, size).Interface().(Fault)
+	}
+	return reflect.ValueOf(opener)
 }
 
 // SizeHint implements the surge.SizeHinter interface.
 func (opener Opener) SizeHint() int {
-	return surge.SizeHint(opener.commitmentBatch) +
+	return opener.instance.SizeHint() +
+		surge.SizeHint(opener.commitmentBatch) +
 		surge.SizeHint(opener.shareBufs) +
+		surge.SizeHint(opener.faults) +
 		opener.h.SizeHint() +
-		surge.SizeHint(opener.indices)
+		surge.SizeHint(opener.indices) +
+		surge.SizeHintBool
 }
 
 // Marshal implements the surge.Marshaler interface.
@@ -44,6 +59,14 @@ func (opener Opener) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, fmt.Errorf("marshaling share buffers: %v", err)
 	}
+	buf, rem, err = surge.Marshal(opener.faults, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling faults: %v", err)
+	}
+	buf, rem, err = opener.instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling instance: %v", err)
+	}
 	buf, rem, err = surge.Marshal(opener.commitmentBatch, buf, rem)
 	if err != nil {
 		return buf, rem, fmt.Errorf("marshaling commitmentBatch: %v", err)
@@ -56,6 +79,10 @@ func (opener Opener) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, fmt.Errorf("marshaling indices: %v", err)
 	}
+	buf, rem, err = surge.MarshalBool(opener.hTable != nil, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling public: %v", err)
+	}
 	return buf, rem, nil
 }
 
@@ -65,6 +92,14 @@ func (opener *Opener) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, fmt.Errorf("unmarshaling share buffers: %v", err)
 	}
+	buf, rem, err = surge.Unmarshal(&opener.faults, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling faults: %v", err)
+	}
+	buf, rem, err = opener.instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling instance: %v", err)
+	}
 	buf, rem, err = surge.Unmarshal(&opener.commitmentBatch, buf, rem)
 	if err != nil {
 		return buf, rem, fmt.Errorf("unmarshaling commitment: %v", err)
@@ -77,5 +112,68 @@ func (opener *Opener) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, fmt.Errorf("unmarshaling indices: %v", err)
 	}
+	var public bool
+	buf, rem, err = surge.UnmarshalBool(&public, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling public: %v", err)
+	}
+	opener.hTable = nil
+	if public {
+		opener.hTable = msm.NewFixedBase(opener.h)
+	}
+	return buf, rem, nil
+}
+
+// Generate implements the quick.Generator interface.
+func (fault Fault) Generate(_ *rand.Rand, _ int) reflect.Value {
+	f := Fault{
+		Index:    secp256k1.RandomFn(),
+		Position: rand.Uint32(),
+		Share: shamir.NewVerifiableShare(
+			shamir.NewShare(secp256k1.RandomFn(), secp256k1.RandomFn()),
+			secp256k1.RandomFn(),
+		),
+	}
+	return reflect.ValueOf(f)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (fault Fault) SizeHint() int {
+	return fault.Index.SizeHint() +
+		surge.SizeHint(fault.Position) +
+		fault.Share.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (fault Fault) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := fault.Index.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling index: %v", err)
+	}
+	buf, rem, err = surge.MarshalU32(fault.Position, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling position: %v", err)
+	}
+	buf, rem, err = fault.Share.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling share: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (fault *Fault) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := fault.Index.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling index: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalU32(&fault.Position, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling position: %v", err)
+	}
+	buf, rem, err = fault.Share.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling share: %v", err)
+	}
 	return buf, rem, nil
 }
@@ -15,6 +15,7 @@ var _ = Describe("Surge marshalling", func() {
 	trials := 10
 	tys := []reflect.Type{
 		reflect.TypeOf(open.Opener{}),
+		reflect.TypeOf(open.Fault{}),
 	}
 
 	for _, t := range tys {
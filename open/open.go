@@ -3,6 +3,7 @@ package open
 import (
 	"fmt"
 
+	"github.com/renproject/mpc/msm"
 	"github.com/renproject/mpc/params"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
@@ -11,8 +12,9 @@ import (
 // Opener is a state machine that is responsible for opening the secret value
 // for a verifiable sharing. An instance of this state machine has a specific
 // commitment used for share verification, a specific value of the Pedersen
-// parameter (known as "h"), and a specific set of indices for the participating
-// players.
+// parameter (known as "h"), a specific set of indices for the participating
+// players, and an instance ID that identifies the invocation of the opening
+// protocol.
 //
 // The description of the state machine is simple: the state is a buffer of
 // shares that have been validated. When a share is received, it is checked
@@ -30,13 +32,20 @@ import (
 type Opener struct {
 	// State
 	shareBufs []shamir.VerifiableShares
+	faults    []Fault
 
 	// Instance parameters
+	instance        params.InstanceID
 	commitmentBatch []shamir.Commitment
 
 	// Global parameters
 	indices []secp256k1.Fn
 	h       secp256k1.Point
+
+	// Precomputed fixed-base table for h, which is only used if the opening
+	// is public, and is nil otherwise. The table itself is not marshaled, and
+	// is computed again when unmarshaling a public opener.
+	hTable *msm.FixedBase
 }
 
 // K returns the number of shares required to open secrets. It assumes that all
@@ -51,6 +60,11 @@ func (opener Opener) BatchSize() int {
 	return len(opener.commitmentBatch)
 }
 
+// Instance returns the instance ID of the opener.
+func (opener Opener) Instance() params.InstanceID {
+	return opener.instance
+}
+
 // I returns the current number of valid shares that the opener has received. It
 // assumes that all batches contain the same number of shares (this assumption
 // is enforced by all other methods).
@@ -59,16 +73,51 @@ func (opener Opener) I() int {
 }
 
 // New returns a new instance of the Opener state machine for the given
-// Pedersen commitments for the verifiable sharing(s), indices, and Pedersen
-// commitment system parameter. The length of the commitment slice determines
-// the batch size.
+// instance ID, Pedersen commitments for the verifiable sharing(s), indices,
+// and Pedersen commitment system parameter. The length of the commitment slice
+// determines the batch size. The received shares are checked in constant time,
+// and so the opener can be used when the shares are secret, such as when they
+// are sent to only one player; for openings in which all of the players
+// receive the shares, use NewPublic instead.
 //
 // Panics: This function will panic if any of the following conditions are met.
 //	- The batch size is less than 1.
 //	- The reconstruction threshold (k) is less than 1.
 //	- Not all commitments in the batch of commitments have the same
 //		reconstruction threshold (k).
-func New(commitmentBatch []shamir.Commitment, indices []secp256k1.Fn, h secp256k1.Point) Opener {
+func New(
+	instance params.InstanceID,
+	commitmentBatch []shamir.Commitment,
+	indices []secp256k1.Fn,
+	h secp256k1.Point,
+) Opener {
+	return newOpener(instance, commitmentBatch, indices, h, false)
+}
+
+// NewPublic is the same as New, except that the opening is public, which is
+// the case when every player sends their shares to all of the other players,
+// so that the shares, and the secrets that they open, become public. The
+// received shares are then checked using a fixed-base table for the Pedersen
+// commitment system parameter, which is computed here, rather than in
+// constant time.
+//
+// Panics: This function will panic for the same conditions as New.
+func NewPublic(
+	instance params.InstanceID,
+	commitmentBatch []shamir.Commitment,
+	indices []secp256k1.Fn,
+	h secp256k1.Point,
+) Opener {
+	return newOpener(instance, commitmentBatch, indices, h, true)
+}
+
+func newOpener(
+	instance params.InstanceID,
+	commitmentBatch []shamir.Commitment,
+	indices []secp256k1.Fn,
+	h secp256k1.Point,
+	public bool,
+) Opener {
 	if !params.ValidPedersenParameter(h) {
 		panic("insecure choice of pedersen parameter")
 	}
@@ -100,37 +149,87 @@ func New(commitmentBatch []shamir.Commitment, indices []secp256k1.Fn, h secp256k
 	indicesCopy := make([]secp256k1.Fn, len(indices))
 	copy(indicesCopy, indices)
 
+	var hTable *msm.FixedBase
+	if public {
+		hTable = msm.NewFixedBase(h)
+	}
+
 	return Opener{
 		shareBufs:       shareBufs,
+		instance:        instance,
 		commitmentBatch: comBatchCopy,
 		indices:         indicesCopy,
 		h:               h,
+		hTable:          hTable,
 	}
 }
 
 // HandleShareBatch handles the state transition logic upon receiving a batch
-// of shares. If enough shares have been received to reconstruct the secret,
-// then this is returned, otherwise the corresponding return value is nil.
+// of shares that is tagged with the given instance ID. If enough shares have
+// been received to reconstruct the secret, then this is returned, otherwise
+// the corresponding return value is nil.
 // Similarly, the decommitment (or hiding) value for the verifiable sharing
 // will also be returned. If the share batch was invalid in any way, an error
-// is returned.
-func (opener *Opener) HandleShareBatch(shareBatch shamir.VerifiableShares) (
+// is returned. If the batch is tagged for a different instance,
+// ErrIncorrectInstance is returned. No faults are recorded, since the sender
+// of the share batch is not known; see HandleShareBatchFrom.
+func (opener *Opener) HandleShareBatch(instance params.InstanceID, shareBatch shamir.VerifiableShares) (
+	[]secp256k1.Fn,
+	[]secp256k1.Fn,
+	error,
+) {
+	return opener.handleShareBatch(instance, nil, shareBatch)
+}
+
+// HandleShareBatchFrom is the same as HandleShareBatch, except that the index
+// of the sender of the share batch, which must have been authenticated by the
+// caller, is also given. All of the shares in the batch must have the index of
+// the sender, and if the share batch is rejected with ErrInvalidShares, a
+// fault is recorded against the sender; see Faults.
+func (opener *Opener) HandleShareBatchFrom(
+	instance params.InstanceID,
+	from secp256k1.Fn,
+	shareBatch shamir.VerifiableShares,
+) (
 	[]secp256k1.Fn,
 	[]secp256k1.Fn,
 	error,
 ) {
+	return opener.handleShareBatch(instance, &from, shareBatch)
+}
+
+// handleShareBatch implements HandleShareBatch and HandleShareBatchFrom. The
+// index of the sender is nil if it is not known.
+func (opener *Opener) handleShareBatch(
+	instance params.InstanceID,
+	from *secp256k1.Fn,
+	shareBatch shamir.VerifiableShares,
+) (
+	[]secp256k1.Fn,
+	[]secp256k1.Fn,
+	error,
+) {
+	// The shares should be for this instance.
+	if instance != opener.instance {
+		return nil, nil, ErrIncorrectInstance
+	}
+
 	// The number of shares should equal the batch size.
 	if len(shareBatch) != int(opener.BatchSize()) {
 		return nil, nil, ErrIncorrectBatchSize
 	}
 
-	// All shares should have the same index.
-	for i := 1; i < len(shareBatch); i++ {
-		if !shareBatch[i].Share.IndexEq(&shareBatch[0].Share.Index) {
-			return nil, nil, ErrInvalidShares
+	// All shares should have the same index, which is the index of the sender
+	// if it is known.
+	index := shareBatch[0].Share.Index
+	if from != nil {
+		index = *from
+	}
+	for i := range shareBatch {
+		if !shareBatch[i].Share.IndexEq(&index) {
+			return nil, nil, opener.fault(from, shareBatch, i)
 		}
 	}
-	index := shareBatch[0].Share.Index
 
 	// The share index must be in the index set.
 	{
@@ -154,10 +253,8 @@ func (opener *Opener) HandleShareBatch(shareBatch shamir.VerifiableShares) (
 
 	// No shares should be invalid. If even a single share is invalid, we mark
 	// the entire batch of shares to be invalid.
-	for i, share := range shareBatch {
-		if !shamir.IsValid(opener.h, &opener.commitmentBatch[i], &share) {
-			return nil, nil, ErrInvalidShares
-		}
+	if i, ok := opener.verifyShareBatch(shareBatch); !ok {
+		return nil, nil, opener.fault(from, shareBatch, i)
 	}
 
 	// At this stage we know that the shares are allowed to be added to the
@@ -192,3 +289,12 @@ func (opener *Opener) HandleShareBatch(shareBatch shamir.VerifiableShares) (
 	// able to reconstruct the secrets.
 	return nil, nil, nil
 }
+
+// verifyShareBatch checks the given batch of shares against the commitments,
+// using the fixed-base table if the opening is public.
+func (opener *Opener) verifyShareBatch(shareBatch shamir.VerifiableShares) (int, bool) {
+	if opener.hTable != nil {
+		return VerifyShareBatch(opener.hTable, opener.commitmentBatch, shareBatch)
+	}
+	return VerifyShareBatchSecret(&opener.h, opener.commitmentBatch, shareBatch)
+}
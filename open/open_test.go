@@ -7,6 +7,7 @@ import (
 
 	"github.com/renproject/mpc/open"
 	"github.com/renproject/mpc/open/openutil"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
 	"github.com/renproject/shamir/shamirutil"
@@ -23,6 +24,10 @@ var _ = Describe("Opener", func() {
 	// but in a real world use case this should be chosen appropriately.
 	h := secp256k1.RandomPoint()
 
+	// The instance ID for the openers.
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
 	TransposeShares := func(shares []shamir.VerifiableShares) []shamir.VerifiableShares {
 		numRows := len(shares)
 		numCols := len(shares[0])
@@ -114,7 +119,7 @@ var _ = Describe("Opener", func() {
 			indices := shamirutil.RandomIndices(n)
 			shareBatchesByPlayer, commitments, secrets, decommitments :=
 				RandomVerifiableSharingBatch(indices, k, b)
-			opener := open.New(commitments, indices, h)
+			opener := open.New(instance, commitments, indices, h)
 			return indices, opener, secrets, decommitments, shareBatchesByPlayer, commitments
 		}
 
@@ -122,7 +127,7 @@ var _ = Describe("Opener", func() {
 			opener *open.Opener, invalidBatch shamir.VerifiableShares, err error,
 		) {
 			initialBufCount := opener.I()
-			secrets, decommitments, err := opener.HandleShareBatch(invalidBatch)
+			secrets, decommitments, err := opener.HandleShareBatch(instance, invalidBatch)
 			Expect(secrets).To(BeNil())
 			Expect(decommitments).To(BeNil())
 			Expect(err).To(Equal(err))
@@ -134,7 +139,7 @@ var _ = Describe("Opener", func() {
 				_, opener, secrets, decommitments, shareBatchesByPlayer, _ := Setup(n, k, b)
 
 				for i, shareBatch := range shareBatchesByPlayer {
-					reconstructedSecrets, reconstructedDecommitments, err := opener.HandleShareBatch(shareBatch)
+					reconstructedSecrets, reconstructedDecommitments, err := opener.HandleShareBatch(instance, shareBatch)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(opener.I()).To(Equal(i + 1))
 					if opener.I() == k {
@@ -165,7 +170,7 @@ var _ = Describe("Opener", func() {
 				// used with only the first n shar batches and indices.
 				indicesEx, _, _, _, shareBatchesByPlayerEx, commitmentBatch := Setup(n+1, k, b)
 				indices := indicesEx[:len(indicesEx)-1]
-				opener := open.New(commitmentBatch, indices, h)
+				opener := open.New(instance, commitmentBatch, indices, h)
 				shareBatchesByPlayer := shareBatchesByPlayerEx[:len(shareBatchesByPlayerEx)-1]
 				extraShareBatch := shareBatchesByPlayerEx[len(shareBatchesByPlayerEx)-1]
 
@@ -177,7 +182,7 @@ var _ = Describe("Opener", func() {
 					invalidBatch := PerturbRandomShareInBatch(shareBatch)
 					CheckInvalidBatchBehaviour(&opener, invalidBatch, open.ErrInvalidShares)
 
-					_, _, _ = opener.HandleShareBatch(shareBatch)
+					_, _, _ = opener.HandleShareBatch(instance, shareBatch)
 					Expect(opener.I()).To(Equal(i + 1))
 
 					// Share batch with an index that has already been handled.
@@ -188,23 +193,117 @@ var _ = Describe("Opener", func() {
 				// perscribed index set.
 				CheckInvalidBatchBehaviour(&opener, extraShareBatch, open.ErrIndexOutOfRange)
 			})
+
+			It("should return an error when the share batch is for a different instance", func() {
+				_, opener, _, _, shareBatchesByPlayer, _ := Setup(n, k, b)
+
+				otherInstance := instance
+				otherInstance[rand.Intn(len(otherInstance))]++
+				secrets, decommitments, err := opener.HandleShareBatch(otherInstance, shareBatchesByPlayer[0])
+				Expect(secrets).To(BeNil())
+				Expect(decommitments).To(BeNil())
+				Expect(err).To(Equal(open.ErrIncorrectInstance))
+				Expect(opener.I()).To(Equal(0))
+				Expect(opener.Faults()).To(BeEmpty())
+
+				_, _, err = opener.HandleShareBatch(instance, shareBatchesByPlayer[0])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(opener.I()).To(Equal(1))
+			})
+
+			It("should record a fault for each sender with a rejected share batch", func() {
+				indicesEx, _, _, _, shareBatchesByPlayerEx, commitmentBatch := Setup(n+1, k, b)
+				indices := indicesEx[:n]
+				opener := open.New(instance, commitmentBatch, indices, h)
+				Expect(opener.Faults()).To(BeEmpty())
+
+				// Incorrect batch sizes are not recorded.
+				_, _, err := opener.HandleShareBatchFrom(instance, indices[0], shareBatchesByPlayerEx[0][1:])
+				Expect(err).To(Equal(open.ErrIncorrectBatchSize))
+				Expect(opener.Faults()).To(BeEmpty())
+
+				// Invalid shares are not recorded if the sender is not known.
+				position := rand.Intn(b)
+				invalidBatch := make(shamir.VerifiableShares, b)
+				copy(invalidBatch, shareBatchesByPlayerEx[0])
+				invalidBatch[position].Share.Value = secp256k1.RandomFn()
+				_, _, err = opener.HandleShareBatch(instance, invalidBatch)
+				Expect(err).To(Equal(open.ErrInvalidShares))
+				Expect(opener.Faults()).To(BeEmpty())
+
+				// Invalid share value.
+				_, _, err = opener.HandleShareBatchFrom(instance, indices[0], invalidBatch)
+				Expect(err).To(Equal(open.ErrInvalidShares))
+
+				// Inconsistent index.
+				inconsistentBatch := make(shamir.VerifiableShares, b)
+				copy(inconsistentBatch, shareBatchesByPlayerEx[1])
+				inconsistentBatch[b-1].Share.Index = secp256k1.RandomFn()
+				_, _, err = opener.HandleShareBatchFrom(instance, indices[1], inconsistentBatch)
+				Expect(err).To(Equal(open.ErrInvalidShares))
+
+				// A valid batch with the index of a different player is
+				// recorded against the sender.
+				_, _, err = opener.HandleShareBatchFrom(instance, indices[2], shareBatchesByPlayerEx[3])
+				Expect(err).To(Equal(open.ErrInvalidShares))
+
+				// A second invalid batch from the same sender is not recorded.
+				_, _, err = opener.HandleShareBatchFrom(instance, indices[0], invalidBatch)
+				Expect(err).To(Equal(open.ErrInvalidShares))
+
+				// A sender that is not in the index set is not recorded.
+				outOfRangeBatch := make(shamir.VerifiableShares, b)
+				copy(outOfRangeBatch, shareBatchesByPlayerEx[n])
+				outOfRangeBatch[0].Share.Value = secp256k1.RandomFn()
+				_, _, err = opener.HandleShareBatchFrom(instance, indicesEx[n], outOfRangeBatch)
+				Expect(err).To(Equal(open.ErrIndexOutOfRange))
+				_, _, err = opener.HandleShareBatchFrom(instance, indicesEx[n], shareBatchesByPlayerEx[0])
+				Expect(err).To(Equal(open.ErrInvalidShares))
+
+				// Duplicate indices are not recorded.
+				_, _, err = opener.HandleShareBatchFrom(instance, indices[4], shareBatchesByPlayerEx[4])
+				Expect(err).ToNot(HaveOccurred())
+				_, _, err = opener.HandleShareBatchFrom(instance, indices[4], shareBatchesByPlayerEx[4])
+				Expect(err).To(Equal(open.ErrDuplicateIndex))
+
+				faults := opener.Faults()
+				Expect(len(faults)).To(Equal(3))
+				expected := []struct {
+					index    secp256k1.Fn
+					position int
+					share    shamir.VerifiableShare
+				}{
+					{indices[0], position, invalidBatch[position]},
+					{indices[1], b - 1, inconsistentBatch[b-1]},
+					{indices[2], 0, shareBatchesByPlayerEx[3][0]},
+				}
+				for i, fault := range faults {
+					Expect(fault.Index.Eq(&expected[i].index)).To(BeTrue())
+					Expect(fault.Position).To(Equal(uint32(expected[i].position)))
+					Expect(fault.Share.Eq(&expected[i].share)).To(BeTrue())
+				}
+
+				// The returned faults should be a copy.
+				faults[0].Position++
+				Expect(opener.Faults()[0].Position).To(Equal(uint32(position)))
+			})
 		})
 
 		Context("panics", func() {
 			Specify("insecure pedersen parameter", func() {
 				indices := []secp256k1.Fn{}
 				inf := secp256k1.NewPointInfinity()
-				Expect(func() { open.New([]shamir.Commitment{}, indices, inf) }).To(Panic())
+				Expect(func() { open.New(instance, []shamir.Commitment{}, indices, inf) }).To(Panic())
 			})
 
 			Specify("invalid batch size", func() {
 				indices := []secp256k1.Fn{}
-				Expect(func() { open.New([]shamir.Commitment{}, indices, h) }).To(Panic())
+				Expect(func() { open.New(instance, []shamir.Commitment{}, indices, h) }).To(Panic())
 			})
 
 			Specify("invalid reconstruction threshold (k)", func() {
 				indices := []secp256k1.Fn{}
-				Expect(func() { open.New(make([]shamir.Commitment, b), indices, h) }).To(Panic())
+				Expect(func() { open.New(instance, make([]shamir.Commitment, b), indices, h) }).To(Panic())
 			})
 
 			Specify("commitment batch with inconsistent reconstruction thresholds", func() {
@@ -215,7 +314,7 @@ var _ = Describe("Opener", func() {
 				}
 				// First commitment will have k = 2, others will have k = 1.
 				commitmentBatch[0].Append(secp256k1.RandomPoint())
-				Expect(func() { open.New(commitmentBatch, indices, h) }).To(Panic())
+				Expect(func() { open.New(instance, commitmentBatch, indices, h) }).To(Panic())
 			})
 		})
 	})
@@ -238,7 +337,7 @@ var _ = Describe("Opener", func() {
 		for i := range indices {
 			id := ID(i + 1)
 			machine := openutil.NewMachine(id, ids, uint32(n), shareBatchesByPlayer[i], commitments,
-				open.New(commitments, indices, h))
+				open.New(instance, commitments, indices, h))
 			machines[i] = &machine
 			ids[i] = id
 		}
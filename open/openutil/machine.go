@@ -28,7 +28,7 @@ func NewMachine(
 	commitments []shamir.Commitment,
 	opener open.Opener,
 ) Machine {
-	secrets, decommitments, _ := opener.HandleShareBatch(shares)
+	secrets, decommitments, _ := opener.HandleShareBatch(opener.Instance(), shares)
 	return Machine{ownID, ids, n, shares, commitments, opener, secrets, decommitments}
 }
 
@@ -45,9 +45,10 @@ func (m Machine) InitialMessages() []mpcutil.Message {
 			continue
 		}
 		messages = append(messages, &Message{
-			shares: m.shares,
-			from:   m.ownID,
-			to:     id,
+			instance: m.opener.Instance(),
+			shares:   m.shares,
+			from:     m.ownID,
+			to:       id,
 		})
 	}
 	return messages
@@ -56,7 +57,7 @@ func (m Machine) InitialMessages() []mpcutil.Message {
 // Handle implements the mpcutil.Machine interface.
 func (m *Machine) Handle(msg mpcutil.Message) []mpcutil.Message {
 	message := msg.(*Message)
-	secrets, decommitments, _ := m.opener.HandleShareBatch(message.shares)
+	secrets, decommitments, _ := m.opener.HandleShareBatch(message.instance, message.shares)
 	if secrets != nil && decommitments != nil {
 		m.Secrets, m.Decommitments = secrets, decommitments
 	}
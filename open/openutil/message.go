@@ -2,11 +2,13 @@ package openutil
 
 import (
 	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/shamir"
 )
 
 // The Message type used for network testing the opener.
 type Message struct {
+	instance params.InstanceID
 	shares   shamir.VerifiableShares
 	from, to mpcutil.ID
 }
@@ -19,12 +21,16 @@ func (msg Message) To() mpcutil.ID { return msg.to }
 
 // SizeHint implements the surge.SizeHinter interface.
 func (msg Message) SizeHint() int {
-	return msg.shares.SizeHint() + msg.from.SizeHint() + msg.to.SizeHint()
+	return msg.instance.SizeHint() + msg.shares.SizeHint() + msg.from.SizeHint() + msg.to.SizeHint()
 }
 
 // Marshal implements the surge.Marshaler interface.
 func (msg Message) Marshal(buf []byte, rem int) ([]byte, int, error) {
-	buf, rem, err := msg.shares.Marshal(buf, rem)
+	buf, rem, err := msg.instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.shares.Marshal(buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
@@ -38,7 +44,11 @@ func (msg Message) Marshal(buf []byte, rem int) ([]byte, int, error) {
 
 // Unmarshal implements the surge.Unmarshaler interface.
 func (msg *Message) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
-	buf, rem, err := msg.shares.Unmarshal(buf, rem)
+	buf, rem, err := msg.instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.shares.Unmarshal(buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
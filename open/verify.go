@@ -0,0 +1,96 @@
+package open
+
+import (
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/parallel"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// VerifyShareBatch checks that each share in the given batch is valid with
+// respect to the corresponding commitment in the given batch of commitments
+// and the Pedersen parameter that the given table was computed for. The shares
+// are checked together using msm.VerifyBatch, and the return values are the
+// same. Since the table is used, the shares must be public; for secret shares,
+// use VerifyShareBatchSecret instead.
+//
+// Panics: This function will panic if the batches of shares and commitments
+// have different lengths.
+func VerifyShareBatch(
+	h *msm.FixedBase,
+	commitmentBatch []shamir.Commitment,
+	shareBatch shamir.VerifiableShares,
+) (int, bool) {
+	if len(commitmentBatch) != len(shareBatch) {
+		panic("inconsistent batch size")
+	}
+	valid := func(i int) bool {
+		return msm.IsValid(h, &commitmentBatch[i], &shareBatch[i])
+	}
+	if hasEmpty(commitmentBatch) {
+		return verifyEach(len(shareBatch), valid)
+	}
+	return msm.VerifyBatch(h, len(shareBatch), shareEquations(commitmentBatch, shareBatch), valid)
+}
+
+// VerifyShareBatchSecret is the same as VerifyShareBatch, except that the
+// shares are checked using msm.VerifyBatchSecret, and so they can be secret.
+//
+// Panics: This function will panic if the batches of shares and commitments
+// have different lengths.
+func VerifyShareBatchSecret(
+	h *secp256k1.Point,
+	commitmentBatch []shamir.Commitment,
+	shareBatch shamir.VerifiableShares,
+) (int, bool) {
+	if len(commitmentBatch) != len(shareBatch) {
+		panic("inconsistent batch size")
+	}
+	valid := func(i int) bool {
+		return msm.IsValidSecret(h, &commitmentBatch[i], &shareBatch[i])
+	}
+	if hasEmpty(commitmentBatch) {
+		return verifyEach(len(shareBatch), valid)
+	}
+	return msm.VerifyBatchSecret(h, len(shareBatch), shareEquations(commitmentBatch, shareBatch), valid)
+}
+
+// shareEquations returns the verification equations for the given shares.
+func shareEquations(
+	commitmentBatch []shamir.Commitment,
+	shareBatch shamir.VerifiableShares,
+) func(i int) []msm.Equation {
+	return func(i int) []msm.Equation {
+		// The verification equation is
+		//	(s)G + (d)H = C_0 + (x)C_1 + ... + (x^(k-1))C_(k-1),
+		// where s, d and x are the value, decommitment and index of the
+		// share and C_j is the jth element of the commitment.
+		return []msm.Equation{{
+			G:       shareBatch[i].Share.Value,
+			H:       shareBatch[i].Decommitment,
+			Points:  commitmentBatch[i],
+			Scalars: msm.Powers(&shareBatch[i].Share.Index, commitmentBatch[i].Len()),
+		}}
+	}
+}
+
+// hasEmpty returns true if any of the given commitments is empty. A share is
+// never valid for an empty commitment, but the verification equation would
+// hold for a share with a zero value and decommitment, and so in this case the
+// shares need to be checked individually.
+func hasEmpty(commitmentBatch []shamir.Commitment) bool {
+	for i := range commitmentBatch {
+		if commitmentBatch[i].Len() == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyEach checks each of the n shares individually using the given
+// function, using the worker pool from the parallel package. The return
+// values are the same as for VerifyShareBatch.
+func verifyEach(n int, valid func(i int) bool) (int, bool) {
+	i := parallel.First(n, func(i int) bool { return !valid(i) })
+	return i, i < 0
+}
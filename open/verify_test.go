@@ -0,0 +1,140 @@
+package open_test
+
+import (
+	"math/rand"
+
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/mpc/parallel"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Share batch verification", func() {
+	n := 10
+	k := 4
+	b := 8
+
+	// RandomShareBatch returns a batch of valid shares for the player with
+	// the given index, along with the corresponding commitments.
+	RandomShareBatch := func(indices []secp256k1.Fn, player int, h secp256k1.Point) (
+		shamir.VerifiableShares, []shamir.Commitment,
+	) {
+		shareBatch := make(shamir.VerifiableShares, b)
+		commitmentBatch := make([]shamir.Commitment, b)
+		shares := make(shamir.VerifiableShares, len(indices))
+		for i := range shareBatch {
+			commitmentBatch[i] = shamir.NewCommitmentWithCapacity(k)
+			shamir.VShareSecret(&shares, &commitmentBatch[i], indices, h, secp256k1.RandomFn(), k)
+			shareBatch[i] = shares[player]
+		}
+		return shareBatch, commitmentBatch
+	}
+
+	// VerifyBoth checks the given shares using both VerifyShareBatch and
+	// VerifyShareBatchSecret, which should agree, and returns the result.
+	VerifyBoth := func(
+		h secp256k1.Point,
+		commitmentBatch []shamir.Commitment,
+		shareBatch shamir.VerifiableShares,
+	) (int, bool) {
+		position, ok := open.VerifyShareBatch(msm.NewFixedBase(h), commitmentBatch, shareBatch)
+		secretPosition, secretOk := open.VerifyShareBatchSecret(&h, commitmentBatch, shareBatch)
+		Expect(secretOk).To(Equal(ok))
+		Expect(secretPosition).To(Equal(position))
+		return position, ok
+	}
+
+	It("should accept batches of valid shares", func() {
+		indices := shamirutil.RandomIndices(n)
+		h := secp256k1.RandomPoint()
+		shareBatch, commitmentBatch := RandomShareBatch(indices, rand.Intn(n), h)
+		position, ok := VerifyBoth(h, commitmentBatch, shareBatch)
+		Expect(ok).To(BeTrue())
+		Expect(position).To(Equal(-1))
+
+		position, ok = VerifyBoth(h, commitmentBatch[:1], shareBatch[:1])
+		Expect(ok).To(BeTrue())
+		Expect(position).To(Equal(-1))
+	})
+
+	It("should return the position of the first invalid share", func() {
+		indices := shamirutil.RandomIndices(n)
+		h := secp256k1.RandomPoint()
+		shareBatch, commitmentBatch := RandomShareBatch(indices, rand.Intn(n), h)
+		first := rand.Intn(b)
+		shareBatch[first].Share.Value = secp256k1.RandomFn()
+		for i := first + 1; i < b; i++ {
+			if rand.Intn(2) == 0 {
+				shareBatch[i].Decommitment = secp256k1.RandomFn()
+			}
+		}
+		position, ok := VerifyBoth(h, commitmentBatch, shareBatch)
+		Expect(ok).To(BeFalse())
+		Expect(position).To(Equal(first))
+	})
+
+	It("should reject invalid shares that cancel out when summed", func() {
+		indices := shamirutil.RandomIndices(n)
+		h := secp256k1.RandomPoint()
+		shareBatch, commitmentBatch := RandomShareBatch(indices, rand.Intn(n), h)
+		delta := secp256k1.RandomFn()
+		var negDelta secp256k1.Fn
+		negDelta.Negate(&delta)
+		shareBatch[0].Share.Value.Add(&shareBatch[0].Share.Value, &delta)
+		shareBatch[1].Share.Value.Add(&shareBatch[1].Share.Value, &negDelta)
+		position, ok := VerifyBoth(h, commitmentBatch, shareBatch)
+		Expect(ok).To(BeFalse())
+		Expect(position).To(Equal(0))
+	})
+
+	It("should check shares with different indices individually", func() {
+		indices := shamirutil.RandomIndices(n)
+		h := secp256k1.RandomPoint()
+		shareBatch, commitmentBatch := RandomShareBatch(indices, 0, h)
+		otherShareBatch, otherCommitmentBatch := RandomShareBatch(indices, 1, h)
+		shareBatch[b-1], commitmentBatch[b-1] = otherShareBatch[0], otherCommitmentBatch[0]
+		position, ok := VerifyBoth(h, commitmentBatch, shareBatch)
+		Expect(ok).To(BeTrue())
+		Expect(position).To(Equal(-1))
+
+		shareBatch[b-1].Share.Value = secp256k1.RandomFn()
+		position, ok = VerifyBoth(h, commitmentBatch, shareBatch)
+		Expect(ok).To(BeFalse())
+		Expect(position).To(Equal(b - 1))
+	})
+
+	It("should give the same results when using a worker pool", func() {
+		parallel.SetWorkers(4)
+		defer parallel.SetWorkers(1)
+
+		indices := shamirutil.RandomIndices(n)
+		h := secp256k1.RandomPoint()
+		shareBatch, commitmentBatch := RandomShareBatch(indices, 0, h)
+		otherShareBatch, otherCommitmentBatch := RandomShareBatch(indices, 1, h)
+		shareBatch[0], commitmentBatch[0] = otherShareBatch[0], otherCommitmentBatch[0]
+		position, ok := VerifyBoth(h, commitmentBatch, shareBatch)
+		Expect(ok).To(BeTrue())
+		Expect(position).To(Equal(-1))
+
+		first := rand.Intn(b-1) + 1
+		for i := first; i < b; i++ {
+			shareBatch[i].Share.Value = secp256k1.RandomFn()
+		}
+		position, ok = VerifyBoth(h, commitmentBatch, shareBatch)
+		Expect(ok).To(BeFalse())
+		Expect(position).To(Equal(first))
+	})
+
+	It("should panic if the batch sizes are different", func() {
+		indices := shamirutil.RandomIndices(n)
+		h := secp256k1.RandomPoint()
+		shareBatch, commitmentBatch := RandomShareBatch(indices, 0, h)
+		Expect(func() { open.VerifyShareBatch(msm.NewFixedBase(h), commitmentBatch[1:], shareBatch) }).To(Panic())
+		Expect(func() { open.VerifyShareBatchSecret(&h, commitmentBatch[1:], shareBatch) }).To(Panic())
+	})
+})
@@ -0,0 +1,144 @@
+// Package parallel provides a package-level executor that the state machines
+// in this module use to spread independent work, such as the verification of
+// each element of a batch, across a bounded pool of worker goroutines.
+//
+// By default the executor uses a single worker, in which case all work is done
+// sequentially on the calling goroutine. The number of workers can be changed
+// using SetWorkers. The functions in this package are written so that the
+// results are identical to the sequential path regardless of the number of
+// workers; in particular, when looking for a failure, the failure with the
+// smallest position is always the one that is reported.
+package parallel
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	mu      sync.Mutex
+	workers = 1
+
+	// helpers holds one token for each worker other than the calling
+	// goroutine. A token is taken for each helper goroutine that is started,
+	// and returned when it finishes, so that the total number of helper
+	// goroutines is bounded even when calls to First are nested.
+	helpers = make(chan struct{})
+)
+
+// SetWorkers sets the number of workers that are used by the executor. The
+// goroutine that calls into the executor is always one of the workers, so a
+// value of 1 means that all work is done sequentially on the calling
+// goroutine. It is safe to call this function concurrently with the other
+// functions in this package, but it is intended to be called once during
+// initialisation.
+//
+// Panics: This function will panic if the given number of workers is less
+// than 1.
+func SetWorkers(n int) {
+	if n < 1 {
+		panic("number of workers must be at least 1")
+	}
+	tokens := make(chan struct{}, n-1)
+	for i := 0; i < n-1; i++ {
+		tokens <- struct{}{}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	workers = n
+	helpers = tokens
+}
+
+// Workers returns the number of workers that are used by the executor.
+func Workers() int {
+	mu.Lock()
+	defer mu.Unlock()
+	return workers
+}
+
+// ForEach calls f(i) for each i in [0, n), and returns once all of the calls
+// have returned. The calls are spread across the worker pool, and so f must be
+// safe to call concurrently for different values of i.
+func ForEach(n int, f func(i int)) {
+	First(n, func(i int) bool {
+		f(i)
+		return false
+	})
+}
+
+// First calls f(i) for each i in [0, n), and returns the smallest i for which
+// f(i) returns true, or -1 if there is no such i. The calls are spread across
+// the worker pool, and so f must be safe to call concurrently for different
+// values of i.
+//
+// Once f(i) has returned true, f is not called for any greater values of i
+// that have not yet been started, but it is always called for every value
+// smaller than the returned value. When no other workers are available, f is
+// called in order on the calling goroutine and no calls are made after the
+// first that returns true, exactly as in a sequential loop.
+func First(n int, f func(i int) bool) int {
+	mu.Lock()
+	tokens := helpers
+	mu.Unlock()
+	numHelpers := 0
+acquire:
+	for numHelpers < n-1 {
+		select {
+		case <-tokens:
+			numHelpers++
+		default:
+			break acquire
+		}
+	}
+
+	if numHelpers == 0 {
+		for i := 0; i < n; i++ {
+			if f(i) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	// The indices are handed out in increasing order, so by the time any
+	// worker finds that f(i) is true, every index smaller than i has already
+	// been handed out, and will be checked since the smallest index found so
+	// far can only decrease.
+	next := int64(-1)
+	found := int64(n)
+	work := func() {
+		for {
+			i := atomic.AddInt64(&next, 1)
+			if i >= atomic.LoadInt64(&found) {
+				return
+			}
+			if !f(int(i)) {
+				continue
+			}
+			for {
+				current := atomic.LoadInt64(&found)
+				if i >= current || atomic.CompareAndSwapInt64(&found, current, i) {
+					break
+				}
+			}
+		}
+	}
+	var wg sync.WaitGroup
+	wg.Add(numHelpers)
+	for j := 0; j < numHelpers; j++ {
+		go func() {
+			defer func() {
+				tokens <- struct{}{}
+				wg.Done()
+			}()
+			work()
+		}()
+	}
+	work()
+	wg.Wait()
+
+	if found == int64(n) {
+		return -1
+	}
+	return int(found)
+}
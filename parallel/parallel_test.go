@@ -0,0 +1,100 @@
+package parallel_test
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/mpc/parallel"
+)
+
+var _ = Describe("Parallel executor", func() {
+	trials := 100
+
+	BeforeEach(func() {
+		SetWorkers(1)
+	})
+
+	for _, w := range []int{1, 2, 8} {
+		w := w
+
+		Context(fmt.Sprintf("with %v workers", w), func() {
+			BeforeEach(func() {
+				SetWorkers(w)
+			})
+
+			It("should call the function for every index", func() {
+				n := rand.Intn(100) + 1
+				calls := make([]int32, n)
+				ForEach(n, func(i int) {
+					atomic.AddInt32(&calls[i], 1)
+				})
+				for i := range calls {
+					Expect(calls[i]).To(Equal(int32(1)))
+				}
+			})
+
+			It("should return the first index that satisfies the predicate", func() {
+				for t := 0; t < trials; t++ {
+					n := rand.Intn(100) + 1
+					marked := make([]bool, n)
+					expected := -1
+					for i := range marked {
+						marked[i] = rand.Intn(10) == 0
+						if marked[i] && expected < 0 {
+							expected = i
+						}
+					}
+					called := make([]int32, n)
+					actual := First(n, func(i int) bool {
+						atomic.AddInt32(&called[i], 1)
+						return marked[i]
+					})
+					Expect(actual).To(Equal(expected))
+					last := n
+					if expected >= 0 {
+						last = expected + 1
+					}
+					for i := 0; i < last; i++ {
+						Expect(called[i]).To(Equal(int32(1)))
+					}
+				}
+			})
+
+			It("should handle nested calls", func() {
+				n := 10
+				var total int32
+				ForEach(n, func(i int) {
+					ForEach(n, func(j int) {
+						atomic.AddInt32(&total, 1)
+					})
+				})
+				Expect(total).To(Equal(int32(n * n)))
+			})
+
+			It("should return -1 when there are no indices", func() {
+				Expect(First(0, func(int) bool { return true })).To(Equal(-1))
+			})
+		})
+	}
+
+	It("should not call the function after the first index when sequential", func() {
+		var calls int32
+		Expect(First(10, func(i int) bool {
+			atomic.AddInt32(&calls, 1)
+			return i == 3
+		})).To(Equal(3))
+		Expect(calls).To(Equal(int32(4)))
+	})
+
+	It("should report the number of workers", func() {
+		SetWorkers(4)
+		Expect(Workers()).To(Equal(4))
+	})
+
+	It("should panic for fewer than one worker", func() {
+		Expect(func() { SetWorkers(0) }).To(Panic())
+	})
+})
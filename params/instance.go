@@ -0,0 +1,64 @@
+package params
+
+import (
+	"crypto/sha256"
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/surge"
+)
+
+// An InstanceID identifies a single invocation of a protocol. State machines
+// are constructed with the instance ID of the invocation that they are a part
+// of, and reject messages that are tagged with a different instance ID, so
+// that messages from one invocation can not be replayed into another
+// invocation that has the same parameters. It is up to the caller to make sure
+// that different invocations use different instance IDs, for example by
+// hashing a unique session identifier.
+type InstanceID [32]byte
+
+// SubInstance returns the instance ID for the sub-protocol with the given
+// label in the invocation with the given instance ID. Protocols that are
+// composed of other protocols use this to give each of their sub-protocols a
+// distinct instance ID, so that messages for one sub-protocol can not be
+// replayed into another. The labels must be distinct for the sub-protocols of
+// a given protocol.
+func SubInstance(instance InstanceID, label string) InstanceID {
+	h := sha256.New()
+	h.Write([]byte("renproject/mpc/params/subinstance"))
+	h.Write(instance[:])
+	h.Write([]byte(label))
+	var sub InstanceID
+	copy(sub[:], h.Sum(nil))
+	return sub
+}
+
+// Generate implements the quick.Generator interface. The bytes of the instance
+// ID are read from the given source of randomness.
+func (id InstanceID) Generate(r *rand.Rand, _ int) reflect.Value {
+	r.Read(id[:])
+	return reflect.ValueOf(id)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (id InstanceID) SizeHint() int {
+	return len(id)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (id InstanceID) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	if len(buf) < len(id) || rem < len(id) {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+	copy(buf, id[:])
+	return buf[len(id):], rem - len(id), nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (id *InstanceID) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	if len(buf) < len(id) || rem < len(id) {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+	copy(id[:], buf)
+	return buf[len(id):], rem - len(id), nil
+}
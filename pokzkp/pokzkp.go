@@ -0,0 +1,136 @@
+// Package pokzkp provides an implementation of a ZKP of knowledge of the
+// opening of a Pedersen commitment, made non interactive by using the Fiat
+// Shamir transform. That is, a prover can show that it knows v and r such that
+// 		c = (v)G + (r)H,
+// without revealing anything about v or r.
+//
+// The ZKP is the standard Schnorr style sigma protocol, also due to Okamoto
+// [1]. The prover sends m = (a)G + (b)H for random a and b, receives a
+// challenge e, and responds with z1 = a + e*v and z2 = b + e*r. The verifier
+// accepts if
+// 		(z1)G + (z2)H = m + (e)c.
+//
+// [1] Tatsuaki Okamoto. 1992.
+// Provably Secure and Practical Identification Schemes and Corresponding
+// Signature Schemes.
+// In Advances in Cryptology - CRYPTO '92. Lecture Notes in Computer Science,
+// vol 740. Springer, Berlin, Heidelberg, 31-53.
+// https://doi.org/10.1007/3-540-48071-4_3
+package pokzkp
+
+import (
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/parallel"
+	"github.com/renproject/mpc/transcript"
+	"github.com/renproject/secp256k1"
+)
+
+// domain is the domain separator that is appended to the transcript for each
+// proof.
+const domain = "renproject/mpc/pokzkp"
+
+// CreateProof constructs a new ZKP that attests to the fact that the prover
+// knows v and r such that
+// 		c = (v)G + (r)H.
+// The Fiat Shamir challenge is derived from the given transcript, which should
+// contain the context for the proof, such as the instance ID and the index of
+// the prover. The proof will only be valid for a transcript with the same
+// context.
+func CreateProof(t transcript.Transcript, h, c *secp256k1.Point, v, r secp256k1.Fn) Proof {
+	a, b := secp256k1.RandomFn(), secp256k1.RandomFn()
+
+	var p Proof
+	var hPow secp256k1.Point
+	hPow.Scale(h, &b)
+	p.m.BaseExp(&a)
+	p.m.Add(&p.m, &hPow)
+
+	e := computeChallenge(t, h, c, &p.m)
+	p.z1.Mul(&e, &v)
+	p.z1.Add(&p.z1, &a)
+	p.z2.Mul(&e, &r)
+	p.z2.Add(&p.z2, &b)
+
+	return p
+}
+
+// Verify the given proof. The return value will be true if the prover knows v
+// and r such that
+// 		c = (v)G + (r)H,
+// and the proof was created with a transcript with the same context as the
+// given transcript. Otherwise, the return value will be false.
+func Verify(t transcript.Transcript, h, c *secp256k1.Point, p *Proof) bool {
+	e := computeChallenge(t, h, c, &p.m)
+	return verify(h, c, p, &e)
+}
+
+// VerifyBatch verifies the given batch of proofs, where the ith proof is for
+// the ith elements of the given slices of transcripts and commitments, and h
+// is the fixed-base table for the Pedersen parameter. The proofs are checked
+// together using msm.VerifyBatch, and the return values are the same.
+//
+// Panics: This function will panic if the slices do not all have the same
+// length.
+func VerifyBatch(
+	ts []transcript.Transcript,
+	h *msm.FixedBase,
+	cs []secp256k1.Point,
+	proofs []Proof,
+) (int, bool) {
+	n := len(proofs)
+	if len(ts) != n || len(cs) != n {
+		panic("inconsistent batch size")
+	}
+	hPoint := h.Point()
+	es := make([]secp256k1.Fn, n)
+	parallel.ForEach(n, func(i int) {
+		es[i] = computeChallenge(ts[i], &hPoint, &cs[i], &proofs[i].m)
+	})
+	return msm.VerifyBatch(h, n,
+		func(i int) []msm.Equation {
+			return equations(&cs[i], &proofs[i], &es[i])
+		},
+		func(i int) bool {
+			return verify(&hPoint, &cs[i], &proofs[i], &es[i])
+		},
+	)
+}
+
+// equations returns the verification equation for the given proof,
+// commitment and challenge in the form used by msm.VerifyBatch, that is,
+//	(z1)G + (z2)H = m + (e)c.
+func equations(c *secp256k1.Point, p *Proof, e *secp256k1.Fn) []msm.Equation {
+	one := secp256k1.NewFnFromU16(1)
+	return []msm.Equation{{
+		G:       p.z1,
+		H:       p.z2,
+		Points:  []secp256k1.Point{p.m, *c},
+		Scalars: []secp256k1.Fn{one, *e},
+	}}
+}
+
+// verify returns true if the given proof is valid for the given commitment and
+// challenge, and false otherwise.
+func verify(h, c *secp256k1.Point, p *Proof, e *secp256k1.Fn) bool {
+	var actual, expected, hPow secp256k1.Point
+
+	expected.BaseExp(&p.z1)
+	hPow.Scale(h, &p.z2)
+	expected.Add(&expected, &hPow)
+
+	actual.Scale(c, e)
+	actual.Add(&actual, &p.m)
+
+	return actual.Eq(&expected)
+}
+
+// computeChallenge appends the statement and the first message of the proof to
+// the given transcript and derives the challenge from it. The transcript is
+// passed by value, and so the transcript of the caller is not modified.
+func computeChallenge(t transcript.Transcript, h, c, m *secp256k1.Point) secp256k1.Fn {
+	t.AppendBytes("dom-sep", []byte(domain))
+	t.AppendPoint("h", h)
+	t.AppendPoint("c", c)
+	t.AppendPoint("m", m)
+	return t.Challenge("e")
+}
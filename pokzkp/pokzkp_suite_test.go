@@ -0,0 +1,13 @@
+package pokzkp_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestPokZkp(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PokZkp Suite")
+}
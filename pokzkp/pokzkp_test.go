@@ -0,0 +1,176 @@
+package pokzkp_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/mpc/pokzkp"
+
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/transcript"
+	"github.com/renproject/secp256k1"
+)
+
+var _ = Describe("NIZK", func() {
+	trials := 100
+
+	// RandomTranscript returns a transcript with a random context.
+	RandomTranscript := func() transcript.Transcript {
+		t := transcript.New("pokzkp test")
+		var context [32]byte
+		rand.Read(context[:])
+		t.AppendBytes("context", context[:])
+		return t
+	}
+
+	// RandomCommitment returns a random value and decommitment along with the
+	// Pedersen commitment to them for the given parameter.
+	RandomCommitment := func(h secp256k1.Point) (secp256k1.Fn, secp256k1.Fn, secp256k1.Point) {
+		v := secp256k1.RandomFn()
+		r := secp256k1.RandomFn()
+		var c, hPow secp256k1.Point
+		hPow.Scale(&h, &r)
+		c.BaseExp(&v)
+		c.Add(&c, &hPow)
+		return v, r, c
+	}
+
+	Context("verifying proofs", func() {
+		It("should accept correct proofs", func() {
+			for i := 0; i < trials; i++ {
+				h := secp256k1.RandomPoint()
+				v, r, c := RandomCommitment(h)
+
+				t := RandomTranscript()
+				proof := CreateProof(t, &h, &c, v, r)
+				Expect(Verify(t, &h, &c, &proof)).To(BeTrue())
+			}
+		})
+
+		It("should reject proofs for a different commitment", func() {
+			for i := 0; i < trials; i++ {
+				h := secp256k1.RandomPoint()
+				v, r, c := RandomCommitment(h)
+
+				t := RandomTranscript()
+				proof := CreateProof(t, &h, &c, v, r)
+				c = secp256k1.RandomPoint()
+				Expect(Verify(t, &h, &c, &proof)).To(BeFalse())
+			}
+		})
+
+		It("should reject proofs with an incorrect opening", func() {
+			for i := 0; i < trials; i++ {
+				h := secp256k1.RandomPoint()
+				_, r, c := RandomCommitment(h)
+
+				t := RandomTranscript()
+				proof := CreateProof(t, &h, &c, secp256k1.RandomFn(), r)
+				Expect(Verify(t, &h, &c, &proof)).To(BeFalse())
+			}
+		})
+
+		It("should reject proofs for a different Pedersen parameter", func() {
+			for i := 0; i < trials; i++ {
+				h := secp256k1.RandomPoint()
+				v, r, c := RandomCommitment(h)
+
+				t := RandomTranscript()
+				proof := CreateProof(t, &h, &c, v, r)
+				h = secp256k1.RandomPoint()
+				Expect(Verify(t, &h, &c, &proof)).To(BeFalse())
+			}
+		})
+
+		It("should reject proofs for a different transcript", func() {
+			for i := 0; i < trials; i++ {
+				h := secp256k1.RandomPoint()
+				v, r, c := RandomCommitment(h)
+
+				t := RandomTranscript()
+				proof := CreateProof(t, &h, &c, v, r)
+				Expect(Verify(RandomTranscript(), &h, &c, &proof)).To(BeFalse())
+
+				// Appending further context should also invalidate the proof.
+				other := t
+				other.AppendU32("position", uint32(i))
+				Expect(Verify(other, &h, &c, &proof)).To(BeFalse())
+			}
+		})
+	})
+
+	Context("verifying batches of proofs", func() {
+		batchSize := 10
+
+		// RandomBatch returns the transcripts, commitments and proofs for a
+		// batch of correct proofs that use the same Pedersen parameter.
+		RandomBatch := func(h secp256k1.Point) ([]transcript.Transcript, []secp256k1.Point, []Proof) {
+			ts := make([]transcript.Transcript, batchSize)
+			cs := make([]secp256k1.Point, batchSize)
+			proofs := make([]Proof, batchSize)
+			for i := 0; i < batchSize; i++ {
+				var v, r secp256k1.Fn
+				v, r, cs[i] = RandomCommitment(h)
+				ts[i] = RandomTranscript()
+				proofs[i] = CreateProof(ts[i], &h, &cs[i], v, r)
+			}
+			return ts, cs, proofs
+		}
+
+		It("should accept batches of correct proofs", func() {
+			for i := 0; i < trials/10; i++ {
+				h := secp256k1.RandomPoint()
+				ts, cs, proofs := RandomBatch(h)
+				position, ok := VerifyBatch(ts, msm.NewFixedBase(h), cs, proofs)
+				Expect(ok).To(BeTrue())
+				Expect(position).To(Equal(-1))
+			}
+		})
+
+		It("should accept empty batches", func() {
+			h := secp256k1.RandomPoint()
+			position, ok := VerifyBatch(nil, msm.NewFixedBase(h), nil, nil)
+			Expect(ok).To(BeTrue())
+			Expect(position).To(Equal(-1))
+		})
+
+		It("should return the position of the first incorrect proof", func() {
+			for i := 0; i < trials/10; i++ {
+				h := secp256k1.RandomPoint()
+				ts, cs, proofs := RandomBatch(h)
+				first := i % (batchSize - 1)
+				cs[first] = secp256k1.RandomPoint()
+				cs[batchSize-1] = secp256k1.RandomPoint()
+				position, ok := VerifyBatch(ts, msm.NewFixedBase(h), cs, proofs)
+				Expect(ok).To(BeFalse())
+				Expect(position).To(Equal(first))
+			}
+		})
+
+		It("should reject proofs for different commitments", func() {
+			h := secp256k1.RandomPoint()
+			ts, cs, proofs := RandomBatch(h)
+			proofs[0], proofs[1] = proofs[1], proofs[0]
+			position, ok := VerifyBatch(ts, msm.NewFixedBase(h), cs, proofs)
+			Expect(ok).To(BeFalse())
+			Expect(position).To(Equal(0))
+		})
+
+		It("should reject proofs for different transcripts", func() {
+			h := secp256k1.RandomPoint()
+			ts, cs, proofs := RandomBatch(h)
+			ts[1] = RandomTranscript()
+			position, ok := VerifyBatch(ts, msm.NewFixedBase(h), cs, proofs)
+			Expect(ok).To(BeFalse())
+			Expect(position).To(Equal(1))
+		})
+
+		It("should panic for inconsistent batch sizes", func() {
+			h := secp256k1.RandomPoint()
+			ts, cs, proofs := RandomBatch(h)
+			Expect(func() { VerifyBatch(ts[1:], msm.NewFixedBase(h), cs, proofs) }).To(Panic())
+			Expect(func() { VerifyBatch(ts, msm.NewFixedBase(h), cs[1:], proofs) }).To(Panic())
+		})
+	})
+})
@@ -0,0 +1,56 @@
+package pokzkp
+
+import (
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/secp256k1"
+)
+
+// A Proof for the ZKP. The commitment is the first message of the sigma
+// protocol, and the responses are for the value and the decommitment
+// respectively.
+type Proof struct {
+	m      secp256k1.Point
+	z1, z2 secp256k1.Fn
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (p Proof) SizeHint() int {
+	return p.m.SizeHint() + p.z1.SizeHint() + p.z2.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (p Proof) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := p.m.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = p.z1.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return p.z2.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (p *Proof) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := p.m.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = p.z1.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return p.z2.Unmarshal(buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (p Proof) Generate(_ *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(Proof{
+		m:  secp256k1.RandomPoint(),
+		z1: secp256k1.RandomFn(),
+		z2: secp256k1.RandomFn(),
+	})
+}
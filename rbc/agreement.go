@@ -0,0 +1,429 @@
+package rbc
+
+import (
+	"fmt"
+
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/surge"
+
+	"github.com/renproject/mpc/brng"
+	"github.com/renproject/mpc/params"
+)
+
+// AgreementMessageType is the type of a message in the agreement protocol.
+type AgreementMessageType uint8
+
+const (
+	// TypeRow is the type of a message for the reliable broadcast of the row
+	// of a dealer. The reliable broadcast message is given by the Message
+	// field.
+	TypeRow = AgreementMessageType(1 + iota)
+
+	// TypeVote is the type of the message that a player sends to every player
+	// once it has output the row of a dealer and found its column of the row
+	// to be valid.
+	TypeVote
+
+	// TypeEst is the type of the message that a player sends to every player
+	// with its estimate in a round of the binary agreement for the row of a
+	// dealer.
+	TypeEst
+
+	// TypeAux is the type of the message that a player sends to every player
+	// with the first value that it has accepted from the estimates in a round
+	// of the binary agreement for the row of a dealer.
+	TypeAux
+
+	// TypeConf is the type of the message that a player sends to every player
+	// with the values that it has seen in the aux messages in a round of the
+	// binary agreement for the row of a dealer.
+	TypeConf
+
+	// TypeTerm is the type of the message that a player sends to every player
+	// once it has decided on the output of the binary agreement for the row of
+	// a dealer.
+	TypeTerm
+)
+
+// maxRoundsAhead is the number of rounds ahead of the current round of a
+// binary agreement for which messages are accepted. This bounds the amount of
+// state that malicious players can make the other players store. A player that
+// falls further behind still decides, as its decision only depends on the
+// term messages of the other players in that case.
+const maxRoundsAhead = 8
+
+// An AgreementMessage is a message in the agreement protocol, tagged with the
+// instance ID of the agreement and the index of the dealer whose row it is
+// about. For messages in the binary agreements, the round is the round that
+// the message is for and the value is a set of values (see Value). Like the
+// messages for reliable broadcast, every message is to be sent to all of the
+// players.
+type AgreementMessage struct {
+	Instance params.InstanceID
+	Type     AgreementMessageType
+	Dealer   secp256k1.Fn
+	Round    uint32
+	Value    Value
+	Message  Message
+}
+
+// Value is a subset of the binary values {0, 1}, where the value b is in the
+// set if bit b is set. The estimate, aux and term messages contain exactly one
+// value, while conf messages contain one or both of the values.
+type Value uint8
+
+// valueOf returns the set that contains only the given binary value.
+func valueOf(b uint8) Value {
+	return Value(1) << b
+}
+
+// single returns the value in the set if the set contains exactly one value.
+func (value Value) single() (uint8, bool) {
+	switch value {
+	case valueOf(0):
+		return 0, true
+	case valueOf(1):
+		return 1, true
+	default:
+		return 0, false
+	}
+}
+
+// subsetOf returns true if every value in the set is also in the other set.
+func (value Value) subsetOf(other Value) bool {
+	return value&^other == 0
+}
+
+// Agreement is a state machine that allows the players in the BRNG protocol to
+// agree on a table of sharings using reliable broadcast and binary agreement,
+// and can be used in place of a consensus algorithm; it implements the
+// brng.Consensus interface. An instance of the state machine has a specific
+// instance ID, and the same parameters as a Broadcaster, along with the number
+// of rows that the table should have, which is the required contributions
+// argument for BRNGer.IsValid.
+//
+// The protocol is the agreement on a common subset protocol from HoneyBadger
+// BFT, with the rows of the dealers as the inputs, and proceeds as follows.
+//	1. Each player reliably broadcasts its row of sharings; see HandleRow.
+//	2. Once a player has output the row of a dealer and found its column of
+//	the row to be valid (using BRNGer.IsValidTable), it sends a vote for the
+//	row to every player.
+//	3. There is one binary agreement for the row of each dealer, which decides
+//	whether the row is in the table. Once a player has received votes for a
+//	row from n - t players, it inputs 1 to the binary agreement for the row.
+//	Once the binary agreements for the required number of rows have decided
+//	1, it inputs 0 to all of the binary agreements that it has not yet input
+//	to.
+//	4. Once all of the binary agreements have decided, and the player has
+//	output all of the rows whose binary agreements decided 1, it outputs the
+//	table consisting of the first of these rows, in the order of the indices,
+//	up to the required number of rows.
+//
+// The binary agreements are the signature-free binary agreement of Mostéfaoui,
+// Moumen and Raynal, with the additional conf messages from Cobalt that are
+// needed for liveness, and term messages so that a player can stop once
+// enough players have decided. Unlike a leader based protocol, the players
+// make progress as long as at most t players are malicious or offline.
+//
+// The binary agreements need a common coin. Since there is no threshold
+// signature scheme available to make an unpredictable coin, the coin for each
+// round is derived from the instance ID, the dealer and the round. This is
+// common to all of the players, which is all that is needed for the players to
+// agree, but since it is predictable, an adversary that controls the
+// scheduling of all of the messages in the network could prevent the binary
+// agreements from terminating.
+//
+// A row is only in the table if at least one honest player input 1 to its
+// binary agreement, and so at least n - 2t honest players have found their
+// column of the row to be valid. The column of the table for the other players
+// may not be valid if some of the dealers are malicious, in which case these
+// players should complain (see brng.ShareComplaint) or pass nil shares to
+// brng.HandleConsensusOutput.
+type Agreement struct {
+	// State
+	rows   []Broadcaster
+	votes  [][]secp256k1.Fn
+	bas    []binaryAgreement
+	outbox []AgreementMessage
+	table  brng.Table
+	done   bool
+
+	// Instance parameters
+	instance params.InstanceID
+
+	// Global parameters
+	brnger                brng.BRNGer
+	index                 secp256k1.Fn
+	indices               []secp256k1.Fn
+	t                     uint32
+	requiredContributions uint32
+}
+
+var _ brng.Consensus = &Agreement{}
+
+// NewAgreement returns a new instance of the Agreement state machine for the
+// given instance ID. The BRNGer is the state machine for the player that owns
+// the state machine in the BRNG protocol, and the index is its index. The
+// indices are those of all of the players, each of which is a dealer, t is
+// the maximum number of malicious players, and the required contributions is
+// the number of rows in the table.
+//
+// Panics: This function will panic if any of the following conditions are
+// met.
+//	- t is negative.
+//	- The number of indices is less than 3t + 1.
+//	- The index of the player is not in the set of indices.
+//	- The required contributions is less than 1, or is greater than n - t
+//		(where n is the number of indices), as otherwise the honest players alone
+//		might not be able to provide enough rows.
+func NewAgreement(
+	instance params.InstanceID,
+	brnger brng.BRNGer,
+	index secp256k1.Fn,
+	indices []secp256k1.Fn,
+	t, requiredContributions int,
+) Agreement {
+	if requiredContributions < 1 || requiredContributions > len(indices)-t {
+		panic(fmt.Sprintf(
+			"required contributions must be between 1 and n-t = %v: got %v",
+			len(indices)-t, requiredContributions,
+		))
+	}
+	n := len(indices)
+	rows := make([]Broadcaster, n)
+	for i := range rows {
+		rows[i] = New(indices[i], index, indices, t)
+	}
+	votes := make([][]secp256k1.Fn, n)
+	for i := range votes {
+		votes[i] = []secp256k1.Fn{}
+	}
+	bas := make([]binaryAgreement, n)
+	for i := range bas {
+		bas[i] = newBinaryAgreement()
+	}
+	indicesCopy := make([]secp256k1.Fn, n)
+	copy(indicesCopy, indices)
+	return Agreement{
+		rows:                  rows,
+		votes:                 votes,
+		bas:                   bas,
+		outbox:                []AgreementMessage{},
+		table:                 brng.Table{},
+		done:                  false,
+		instance:              instance,
+		brnger:                brnger,
+		index:                 index,
+		indices:               indicesCopy,
+		t:                     uint32(t),
+		requiredContributions: uint32(requiredContributions),
+	}
+}
+
+// Instance returns the instance ID of the agreement.
+func (agreement Agreement) Instance() params.InstanceID {
+	return agreement.instance
+}
+
+// Done implements the brng.Consensus interface. It returns true if the state
+// machine has output a table.
+func (agreement Agreement) Done() bool {
+	return agreement.done
+}
+
+// Table implements the brng.Consensus interface. It returns the table that was
+// output by the state machine. This will only be correct if Done returns true.
+func (agreement Agreement) Table() brng.Table {
+	return agreement.table
+}
+
+// HandleRow implements the brng.Consensus interface. The given row of sharings
+// is the row of the player that owns the state machine, and the message that
+// starts its reliable broadcast is added to the messages returned by
+// Outgoing. The return value is true if the state machine has output a table.
+func (agreement *Agreement) HandleRow(row []brng.Sharing) bool {
+	payload, err := surge.ToBinary(row)
+	if err != nil {
+		panic(fmt.Sprintf("marshaling row: %v", err))
+	}
+	j := position(agreement.indices, agreement.index)
+	msg := agreement.message(TypeRow, j, 0, 0)
+	msg.Message = agreement.rows[j].Broadcast(payload)
+	agreement.outbox = append(agreement.outbox, msg)
+	return agreement.done
+}
+
+// Outgoing returns the messages that the state machine needs to send that have
+// not been returned by HandleMessage, that is, the message created by
+// HandleRow, and removes them from the state machine. The messages should be
+// sent to every player.
+func (agreement *Agreement) Outgoing() []AgreementMessage {
+	msgs := agreement.outbox
+	agreement.outbox = []AgreementMessage{}
+	return msgs
+}
+
+// HandleMessage handles the state transition logic upon receiving a message
+// from the player with the given index. The returned messages, if any, should
+// be sent to every player. If the message is invalid, an error is returned and
+// the state of the state machine is unchanged.
+func (agreement *Agreement) HandleMessage(from secp256k1.Fn, msg AgreementMessage) (
+	[]AgreementMessage, error,
+) {
+	if msg.Instance != agreement.instance {
+		return nil, ErrIncorrectInstance
+	}
+	if !contains(agreement.indices, from) {
+		return nil, ErrUnknownSender
+	}
+	j := position(agreement.indices, msg.Dealer)
+	if j == -1 {
+		return nil, ErrIncorrectDealer
+	}
+
+	var msgs []AgreementMessage
+	switch msg.Type {
+	case TypeRow:
+		wasDone := agreement.rows[j].Done()
+		out, err := agreement.rows[j].HandleMessage(from, msg.Message)
+		if err != nil {
+			return nil, err
+		}
+		for i := range out {
+			rowMsg := agreement.message(TypeRow, j, 0, 0)
+			rowMsg.Message = out[i]
+			msgs = append(msgs, rowMsg)
+		}
+		if !wasDone && agreement.rows[j].Done() && agreement.isValidRow(j) {
+			msgs = append(msgs, agreement.message(TypeVote, j, 0, 0))
+		}
+
+	case TypeVote:
+		if contains(agreement.votes[j], from) {
+			return nil, ErrDuplicateMessage
+		}
+		agreement.votes[j] = append(agreement.votes[j], from)
+
+	case TypeEst, TypeAux, TypeConf, TypeTerm:
+		out, err := agreement.handleBinaryMessage(j, from, msg)
+		if err != nil {
+			return nil, err
+		}
+		msgs = out
+
+	default:
+		return nil, ErrInvalidMessageType
+	}
+
+	msgs = append(msgs, agreement.update()...)
+	agreement.finish()
+	return msgs, nil
+}
+
+// isValidRow returns true if the row with the given position, which must have
+// been output, can be decoded and the column of the row for the player is
+// valid.
+func (agreement *Agreement) isValidRow(j int) bool {
+	var row []brng.Sharing
+	if err := surge.FromBinary(&row, agreement.rows[j].Output()); err != nil {
+		return false
+	}
+	return agreement.brnger.IsValidTable(brng.Table{row}, 1) == nil
+}
+
+// update gives the inputs to the binary agreements that the player has not yet
+// input to once the conditions for doing so are met.
+func (agreement *Agreement) update() []AgreementMessage {
+	n := len(agreement.indices)
+	t := int(agreement.t)
+	var msgs []AgreementMessage
+	for {
+		ones := 0
+		for i := range agreement.bas {
+			if agreement.bas[i].decided && agreement.bas[i].decision == 1 {
+				ones++
+			}
+		}
+		input := false
+		for i := range agreement.bas {
+			ba := &agreement.bas[i]
+			if ba.started || ba.decided {
+				continue
+			}
+			if uint32(ones) >= agreement.requiredContributions {
+				msgs = append(msgs, agreement.input(i, 0)...)
+				input = true
+			} else if len(agreement.votes[i]) >= n-t {
+				msgs = append(msgs, agreement.input(i, 1)...)
+				input = true
+			}
+		}
+		// Starting a binary agreement can cause it to decide if enough
+		// messages for it have already been received, in which case the
+		// conditions need to be checked again.
+		if !input {
+			return msgs
+		}
+	}
+}
+
+// finish constructs the table once all of the binary agreements have decided
+// and all of the rows whose binary agreements decided 1 have been output.
+func (agreement *Agreement) finish() {
+	if agreement.done {
+		return
+	}
+	for i := range agreement.bas {
+		if !agreement.bas[i].decided {
+			return
+		}
+		if agreement.bas[i].decision == 1 && !agreement.rows[i].Done() {
+			return
+		}
+	}
+
+	// Every row whose binary agreement decided 1 has been found to be valid
+	// by at least one honest player, and so can be decoded. The check is
+	// still made so that the table is well formed, and since all of the
+	// players output the same rows, they all skip the same rows.
+	table := brng.Table{}
+	for i := range agreement.bas {
+		if uint32(len(table)) == agreement.requiredContributions {
+			break
+		}
+		if agreement.bas[i].decision != 1 {
+			continue
+		}
+		var row []brng.Sharing
+		if err := surge.FromBinary(&row, agreement.rows[i].Output()); err != nil {
+			continue
+		}
+		table = append(table, row)
+	}
+	if uint32(len(table)) < agreement.requiredContributions {
+		return
+	}
+	agreement.table = table
+	agreement.done = true
+}
+
+// message constructs a message of the given type about the row of the dealer
+// with the given position.
+func (agreement *Agreement) message(ty AgreementMessageType, j int, round uint32, value Value) AgreementMessage {
+	return AgreementMessage{
+		Instance: agreement.instance,
+		Type:     ty,
+		Dealer:   agreement.indices[j],
+		Round:    round,
+		Value:    value,
+	}
+}
+
+func position(indices []secp256k1.Fn, index secp256k1.Fn) int {
+	for i := range indices {
+		if indices[i].Eq(&index) {
+			return i
+		}
+	}
+	return -1
+}
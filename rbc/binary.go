@@ -0,0 +1,286 @@
+package rbc
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/renproject/secp256k1"
+
+	"github.com/renproject/mpc/params"
+)
+
+// A valueVote is a message in a binary agreement that has been received from a
+// player.
+type valueVote struct {
+	from  secp256k1.Fn
+	value Value
+}
+
+// A round is the state of a player in one round of a binary agreement.
+type round struct {
+	sentEst   Value
+	ests      []valueVote
+	binValues Value
+	sentAux   bool
+	auxes     []valueVote
+	sentConf  bool
+	confs     []valueVote
+}
+
+// A binaryAgreement is the state of a player in the binary agreement for the
+// row of one dealer. The player has started once it has input a value, and
+// has halted once it knows that every honest player will decide.
+type binaryAgreement struct {
+	started  bool
+	round    uint32
+	est      uint8
+	rounds   []round
+	decided  bool
+	decision uint8
+	sentTerm bool
+	terms    []valueVote
+	halted   bool
+}
+
+func newRound() round {
+	return round{
+		ests:  []valueVote{},
+		auxes: []valueVote{},
+		confs: []valueVote{},
+	}
+}
+
+func newBinaryAgreement() binaryAgreement {
+	return binaryAgreement{
+		rounds: []round{newRound()},
+		terms:  []valueVote{},
+	}
+}
+
+// input gives the value b as the input to the binary agreement for the row of
+// the dealer with the given position.
+func (agreement *Agreement) input(j int, b uint8) []AgreementMessage {
+	ba := &agreement.bas[j]
+	ba.started = true
+	ba.est = b
+	msgs := agreement.sendEst(j)
+	return append(msgs, agreement.progress(j)...)
+}
+
+// handleBinaryMessage handles a message for the binary agreement for the row
+// of the dealer with the given position. The message is checked before any of
+// the state is changed.
+func (agreement *Agreement) handleBinaryMessage(j int, from secp256k1.Fn, msg AgreementMessage) (
+	[]AgreementMessage, error,
+) {
+	ba := &agreement.bas[j]
+	if msg.Type == TypeConf {
+		if msg.Value == 0 || !msg.Value.subsetOf(valueOf(0)|valueOf(1)) {
+			return nil, ErrInvalidValue
+		}
+	} else if _, ok := msg.Value.single(); !ok {
+		return nil, ErrInvalidValue
+	}
+	if msg.Type != TypeTerm && msg.Round > ba.round+maxRoundsAhead {
+		return nil, ErrFutureRound
+	}
+	if ba.halted {
+		return nil, nil
+	}
+	if ba.isDuplicate(from, msg) {
+		return nil, ErrDuplicateMessage
+	}
+	for uint32(len(ba.rounds)) <= msg.Round && msg.Type != TypeTerm {
+		ba.rounds = append(ba.rounds, newRound())
+	}
+
+	t := int(agreement.t)
+	var msgs []AgreementMessage
+	switch msg.Type {
+	case TypeEst:
+		r := &ba.rounds[msg.Round]
+		r.ests = append(r.ests, valueVote{from: from, value: msg.Value})
+		count := countValue(r.ests, msg.Value)
+		// Relaying an estimate once it has been received from t + 1 players,
+		// and so at least one honest player, ensures that if an estimate is
+		// accepted by one honest player then it is accepted by all of them.
+		if count >= t+1 && !msg.Value.subsetOf(r.sentEst) {
+			r.sentEst |= msg.Value
+			msgs = append(msgs, agreement.message(TypeEst, j, msg.Round, msg.Value))
+		}
+		if count >= 2*t+1 {
+			r.binValues |= msg.Value
+		}
+
+	case TypeAux:
+		r := &ba.rounds[msg.Round]
+		r.auxes = append(r.auxes, valueVote{from: from, value: msg.Value})
+
+	case TypeConf:
+		r := &ba.rounds[msg.Round]
+		r.confs = append(r.confs, valueVote{from: from, value: msg.Value})
+
+	case TypeTerm:
+		ba.terms = append(ba.terms, valueVote{from: from, value: msg.Value})
+		count := countValue(ba.terms, msg.Value)
+		if count >= t+1 && !ba.decided {
+			ba.decided = true
+			ba.decision, _ = msg.Value.single()
+		}
+		if count >= 2*t+1 {
+			// At least t + 1 honest players have decided, and so every honest
+			// player will receive enough term messages to decide.
+			ba.halted = true
+		}
+	}
+
+	msgs = append(msgs, agreement.progress(j)...)
+	return msgs, nil
+}
+
+// isDuplicate returns true if a message of the same type has already been
+// received from the same player in the same round. Estimates are only
+// duplicates if they also have the same value, as a player can send an
+// estimate for each value.
+func (ba *binaryAgreement) isDuplicate(from secp256k1.Fn, msg AgreementMessage) bool {
+	if msg.Type == TypeTerm {
+		return hasValueVote(ba.terms, from)
+	}
+	if uint32(len(ba.rounds)) <= msg.Round {
+		return false
+	}
+	r := &ba.rounds[msg.Round]
+	switch msg.Type {
+	case TypeEst:
+		for i := range r.ests {
+			if r.ests[i].from.Eq(&from) && r.ests[i].value == msg.Value {
+				return true
+			}
+		}
+		return false
+	case TypeAux:
+		return hasValueVote(r.auxes, from)
+	default:
+		return hasValueVote(r.confs, from)
+	}
+}
+
+// progress sends the messages for the current round of the binary agreement
+// for the row of the dealer with the given position once the conditions for
+// sending them are met, and moves to the next round once the round is
+// complete.
+func (agreement *Agreement) progress(j int) []AgreementMessage {
+	ba := &agreement.bas[j]
+	n := len(agreement.indices)
+	var msgs []AgreementMessage
+	for ba.started && !ba.halted {
+		r := &ba.rounds[ba.round]
+		if !r.sentAux {
+			if r.binValues == 0 {
+				break
+			}
+			w := ba.est
+			if !valueOf(w).subsetOf(r.binValues) {
+				w, _ = r.binValues.single()
+			}
+			r.sentAux = true
+			msgs = append(msgs, agreement.message(TypeAux, j, ba.round, valueOf(w)))
+		}
+		if !r.sentConf {
+			count, values := countAccepted(r.auxes, r.binValues)
+			if count < n-int(agreement.t) {
+				break
+			}
+			r.sentConf = true
+			msgs = append(msgs, agreement.message(TypeConf, j, ba.round, values))
+		}
+		count, values := countAccepted(r.confs, r.binValues)
+		if count < n-int(agreement.t) {
+			break
+		}
+
+		c := coin(agreement.instance, agreement.indices[j], ba.round)
+		if b, ok := values.single(); ok {
+			ba.est = b
+			if b == c && !ba.decided {
+				ba.decided = true
+				ba.decision = b
+			}
+		} else {
+			ba.est = c
+		}
+		ba.round++
+		if uint32(len(ba.rounds)) <= ba.round {
+			ba.rounds = append(ba.rounds, newRound())
+		}
+		msgs = append(msgs, agreement.sendEst(j)...)
+	}
+	if ba.decided && !ba.sentTerm {
+		ba.sentTerm = true
+		msgs = append(msgs, agreement.message(TypeTerm, j, 0, valueOf(ba.decision)))
+	}
+	return msgs
+}
+
+// sendEst returns the estimate message for the current round of the binary
+// agreement for the row of the dealer with the given position, unless it has
+// already been sent as a relay.
+func (agreement *Agreement) sendEst(j int) []AgreementMessage {
+	ba := &agreement.bas[j]
+	r := &ba.rounds[ba.round]
+	value := valueOf(ba.est)
+	if value.subsetOf(r.sentEst) {
+		return nil
+	}
+	r.sentEst |= value
+	return []AgreementMessage{agreement.message(TypeEst, j, ba.round, value)}
+}
+
+// coin returns the common coin for the given round of the binary agreement for
+// the row of the given dealer. See Agreement for why this does not need to be
+// unpredictable for the players to agree.
+func coin(instance params.InstanceID, dealer secp256k1.Fn, r uint32) uint8 {
+	var buf [4]byte
+	var dealerBytes [32]byte
+	dealer.PutB32(dealerBytes[:])
+	binary.BigEndian.PutUint32(buf[:], r)
+	hash := sha256.New()
+	hash.Write([]byte("renproject/mpc/rbc/coin"))
+	hash.Write(instance[:])
+	hash.Write(dealerBytes[:])
+	hash.Write(buf[:])
+	return hash.Sum(nil)[0] & 1
+}
+
+func hasValueVote(votes []valueVote, from secp256k1.Fn) bool {
+	for i := range votes {
+		if votes[i].from.Eq(&from) {
+			return true
+		}
+	}
+	return false
+}
+
+func countValue(votes []valueVote, value Value) int {
+	count := 0
+	for i := range votes {
+		if votes[i].value == value {
+			count++
+		}
+	}
+	return count
+}
+
+// countAccepted returns the number of votes whose values are all in the given
+// set, along with the union of these values.
+func countAccepted(votes []valueVote, accepted Value) (int, Value) {
+	count := 0
+	values := Value(0)
+	for i := range votes {
+		if votes[i].value.subsetOf(accepted) {
+			count++
+			values |= votes[i].value
+		}
+	}
+	return count, values
+}
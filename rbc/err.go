@@ -0,0 +1,37 @@
+package rbc
+
+import "errors"
+
+var (
+	// ErrIncorrectDealer is returned when a message is for the broadcast by a
+	// dealer other than the dealer for the state machine.
+	ErrIncorrectDealer = errors.New("incorrect dealer")
+
+	// ErrUnknownSender is returned when a message is from a player whose index
+	// is not in the set of indices that the state machine was constructed
+	// with.
+	ErrUnknownSender = errors.New("unknown sender")
+
+	// ErrSendNotFromDealer is returned when a send message is from a player
+	// other than the dealer.
+	ErrSendNotFromDealer = errors.New("send message not from dealer")
+
+	// ErrDuplicateMessage is returned when a player has already received a
+	// message of the same type from the same sender.
+	ErrDuplicateMessage = errors.New("duplicate message")
+
+	// ErrInvalidMessageType is returned when a message has an unknown type.
+	ErrInvalidMessageType = errors.New("invalid message type")
+
+	// ErrIncorrectInstance is returned by an Agreement when a message is for
+	// an instance other than the instance for the state machine.
+	ErrIncorrectInstance = errors.New("incorrect instance")
+
+	// ErrInvalidValue is returned by an Agreement when a message for a binary
+	// agreement does not contain a valid set of values for its type.
+	ErrInvalidValue = errors.New("invalid value")
+
+	// ErrFutureRound is returned by an Agreement when a message for a binary
+	// agreement is for a round that is too far ahead of the current round.
+	ErrFutureRound = errors.New("future round")
+)
@@ -0,0 +1,704 @@
+package rbc
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir/shamirutil"
+	"github.com/renproject/surge"
+
+	"github.com/renproject/mpc/brng"
+	"github.com/renproject/mpc/params"
+)
+
+// Generate implements the quick.Generator interface.
+func (msg Message) Generate(_ *rand.Rand, size int) reflect.Value {
+	payload := make([]byte, rand.Intn(size+1))
+	rand.Read(payload)
+	m := Message{
+		Type:    MessageType(rand.Intn(3) + 1),
+		Dealer:  secp256k1.RandomFn(),
+		Payload: payload,
+	}
+	return reflect.ValueOf(m)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (msg Message) SizeHint() int {
+	return surge.SizeHint(uint8(msg.Type)) +
+		msg.Dealer.SizeHint() +
+		surge.SizeHint(msg.Payload)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (msg Message) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.MarshalU8(uint8(msg.Type), buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling type: %v", err)
+	}
+	buf, rem, err = msg.Dealer.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling dealer: %v", err)
+	}
+	buf, rem, err = surge.Marshal(msg.Payload, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling payload: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (msg *Message) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.UnmarshalU8((*uint8)(&msg.Type), buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling type: %v", err)
+	}
+	buf, rem, err = msg.Dealer.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling dealer: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&msg.Payload, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling payload: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (v vote) SizeHint() int {
+	return v.from.SizeHint() + surge.SizeHint(v.payload)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (v vote) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := v.from.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling from: %v", err)
+	}
+	buf, rem, err = surge.Marshal(v.payload, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling payload: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (v *vote) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := v.from.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling from: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&v.payload, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling payload: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Generate implements the quick.Generator interface.
+func (broadcaster Broadcaster) Generate(_ *rand.Rand, size int) reflect.Value {
+	n := rand.Intn(20) + 1
+	t := rand.Intn((n-1)/3 + 1)
+	indices := shamirutil.RandomIndices(n)
+	broadcaster = New(indices[rand.Intn(n)], indices[rand.Intn(n)], indices, t)
+	broadcaster.sentEcho = rand.Int()&1 == 1
+	broadcaster.sentReady = rand.Int()&1 == 1
+	broadcaster.delivered = rand.Int()&1 == 1
+	for _, index := range indices {
+		payload := make([]byte, rand.Intn(size/n+1))
+		rand.Read(payload)
+		if rand.Int()&1 == 1 {
+			broadcaster.echoes = append(broadcaster.echoes, vote{from: index, payload: payload})
+		}
+		if rand.Int()&1 == 1 {
+			broadcaster.readies = append(broadcaster.readies, vote{from: index, payload: payload})
+		}
+	}
+	if broadcaster.delivered {
+		broadcaster.output = make([]byte, rand.Intn(size+1))
+		rand.Read(broadcaster.output)
+	}
+	return reflect.ValueOf(broadcaster)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (broadcaster Broadcaster) SizeHint() int {
+	return surge.SizeHint(broadcaster.sentEcho) +
+		surge.SizeHint(broadcaster.sentReady) +
+		surge.SizeHint(broadcaster.delivered) +
+		surge.SizeHint(broadcaster.echoes) +
+		surge.SizeHint(broadcaster.readies) +
+		surge.SizeHint(broadcaster.output) +
+		broadcaster.dealer.SizeHint() +
+		broadcaster.index.SizeHint() +
+		surge.SizeHint(broadcaster.indices) +
+		surge.SizeHint(broadcaster.t)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (broadcaster Broadcaster) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.MarshalBool(broadcaster.sentEcho, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling sentEcho: %v", err)
+	}
+	buf, rem, err = surge.MarshalBool(broadcaster.sentReady, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling sentReady: %v", err)
+	}
+	buf, rem, err = surge.MarshalBool(broadcaster.delivered, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling delivered: %v", err)
+	}
+	buf, rem, err = surge.Marshal(broadcaster.echoes, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling echoes: %v", err)
+	}
+	buf, rem, err = surge.Marshal(broadcaster.readies, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling readies: %v", err)
+	}
+	buf, rem, err = surge.Marshal(broadcaster.output, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling output: %v", err)
+	}
+	buf, rem, err = broadcaster.dealer.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling dealer: %v", err)
+	}
+	buf, rem, err = broadcaster.index.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling index: %v", err)
+	}
+	buf, rem, err = surge.Marshal(broadcaster.indices, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling indices: %v", err)
+	}
+	buf, rem, err = surge.MarshalU32(broadcaster.t, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling t: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (broadcaster *Broadcaster) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.UnmarshalBool(&broadcaster.sentEcho, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling sentEcho: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalBool(&broadcaster.sentReady, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling sentReady: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalBool(&broadcaster.delivered, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling delivered: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&broadcaster.echoes, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling echoes: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&broadcaster.readies, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling readies: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&broadcaster.output, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling output: %v", err)
+	}
+	buf, rem, err = broadcaster.dealer.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling dealer: %v", err)
+	}
+	buf, rem, err = broadcaster.index.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling index: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&broadcaster.indices, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling indices: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalU32(&broadcaster.t, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling t: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Generate implements the quick.Generator interface.
+func (msg AgreementMessage) Generate(r *rand.Rand, size int) reflect.Value {
+	m := AgreementMessage{
+		Instance: params.InstanceID{}.Generate(r, size).Interface().(params.InstanceID),
+		Type:     AgreementMessageType(rand.Intn(6) + 1),
+		Dealer:   secp256k1.RandomFn(),
+		Round:    rand.Uint32(),
+		Value:    Value(rand.Intn(4)),
+		Message:  Message{}.Generate(nil, size).Interface().(Message),
+	}
+	return reflect.ValueOf(m)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (msg AgreementMessage) SizeHint() int {
+	return msg.Instance.SizeHint() +
+		surge.SizeHint(uint8(msg.Type)) +
+		msg.Dealer.SizeHint() +
+		surge.SizeHint(msg.Round) +
+		surge.SizeHint(uint8(msg.Value)) +
+		msg.Message.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (msg AgreementMessage) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := msg.Instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling instance: %v", err)
+	}
+	buf, rem, err = surge.MarshalU8(uint8(msg.Type), buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling type: %v", err)
+	}
+	buf, rem, err = msg.Dealer.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling dealer: %v", err)
+	}
+	buf, rem, err = surge.MarshalU32(msg.Round, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling round: %v", err)
+	}
+	buf, rem, err = surge.MarshalU8(uint8(msg.Value), buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling value: %v", err)
+	}
+	buf, rem, err = msg.Message.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling message: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (msg *AgreementMessage) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := msg.Instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling instance: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalU8((*uint8)(&msg.Type), buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling type: %v", err)
+	}
+	buf, rem, err = msg.Dealer.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling dealer: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalU32(&msg.Round, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling round: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalU8((*uint8)(&msg.Value), buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling value: %v", err)
+	}
+	buf, rem, err = msg.Message.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling message: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (v valueVote) SizeHint() int {
+	return v.from.SizeHint() + surge.SizeHint(uint8(v.value))
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (v valueVote) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := v.from.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling from: %v", err)
+	}
+	buf, rem, err = surge.MarshalU8(uint8(v.value), buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling value: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (v *valueVote) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := v.from.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling from: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalU8((*uint8)(&v.value), buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling value: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// randomValueVotes returns random votes from some of the given players.
+func randomValueVotes(indices []secp256k1.Fn) []valueVote {
+	votes := []valueVote{}
+	for _, index := range indices {
+		if rand.Int()&1 == 1 {
+			votes = append(votes, valueVote{from: index, value: Value(rand.Intn(3) + 1)})
+		}
+	}
+	return votes
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (r round) SizeHint() int {
+	return surge.SizeHint(uint8(r.sentEst)) +
+		surge.SizeHint(r.ests) +
+		surge.SizeHint(uint8(r.binValues)) +
+		surge.SizeHint(r.sentAux) +
+		surge.SizeHint(r.auxes) +
+		surge.SizeHint(r.sentConf) +
+		surge.SizeHint(r.confs)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (r round) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.MarshalU8(uint8(r.sentEst), buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling sentEst: %v", err)
+	}
+	buf, rem, err = surge.Marshal(r.ests, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling ests: %v", err)
+	}
+	buf, rem, err = surge.MarshalU8(uint8(r.binValues), buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling binValues: %v", err)
+	}
+	buf, rem, err = surge.MarshalBool(r.sentAux, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling sentAux: %v", err)
+	}
+	buf, rem, err = surge.Marshal(r.auxes, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling auxes: %v", err)
+	}
+	buf, rem, err = surge.MarshalBool(r.sentConf, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling sentConf: %v", err)
+	}
+	buf, rem, err = surge.Marshal(r.confs, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling confs: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (r *round) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.UnmarshalU8((*uint8)(&r.sentEst), buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling sentEst: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&r.ests, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling ests: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalU8((*uint8)(&r.binValues), buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling binValues: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalBool(&r.sentAux, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling sentAux: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&r.auxes, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling auxes: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalBool(&r.sentConf, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling sentConf: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&r.confs, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling confs: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// generateBinaryAgreement returns a random binary agreement with messages from
+// some of the given players.
+func generateBinaryAgreement(indices []secp256k1.Fn) binaryAgreement {
+	ba := newBinaryAgreement()
+	ba.started = rand.Int()&1 == 1
+	ba.est = uint8(rand.Intn(2))
+	ba.decided = rand.Int()&1 == 1
+	ba.decision = uint8(rand.Intn(2))
+	ba.sentTerm = rand.Int()&1 == 1
+	ba.halted = rand.Int()&1 == 1
+	ba.terms = randomValueVotes(indices)
+	ba.rounds = make([]round, rand.Intn(3)+1)
+	for i := range ba.rounds {
+		ba.rounds[i] = round{
+			sentEst:   Value(rand.Intn(4)),
+			ests:      randomValueVotes(indices),
+			binValues: Value(rand.Intn(4)),
+			sentAux:   rand.Int()&1 == 1,
+			auxes:     randomValueVotes(indices),
+			sentConf:  rand.Int()&1 == 1,
+			confs:     randomValueVotes(indices),
+		}
+	}
+	ba.round = uint32(rand.Intn(len(ba.rounds)))
+	return ba
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (ba binaryAgreement) SizeHint() int {
+	return surge.SizeHint(ba.started) +
+		surge.SizeHint(ba.round) +
+		surge.SizeHint(ba.est) +
+		surge.SizeHint(ba.rounds) +
+		surge.SizeHint(ba.decided) +
+		surge.SizeHint(ba.decision) +
+		surge.SizeHint(ba.sentTerm) +
+		surge.SizeHint(ba.terms) +
+		surge.SizeHint(ba.halted)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (ba binaryAgreement) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.MarshalBool(ba.started, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling started: %v", err)
+	}
+	buf, rem, err = surge.MarshalU32(ba.round, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling round: %v", err)
+	}
+	buf, rem, err = surge.MarshalU8(ba.est, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling est: %v", err)
+	}
+	buf, rem, err = surge.Marshal(ba.rounds, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling rounds: %v", err)
+	}
+	buf, rem, err = surge.MarshalBool(ba.decided, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling decided: %v", err)
+	}
+	buf, rem, err = surge.MarshalU8(ba.decision, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling decision: %v", err)
+	}
+	buf, rem, err = surge.MarshalBool(ba.sentTerm, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling sentTerm: %v", err)
+	}
+	buf, rem, err = surge.Marshal(ba.terms, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling terms: %v", err)
+	}
+	buf, rem, err = surge.MarshalBool(ba.halted, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling halted: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (ba *binaryAgreement) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.UnmarshalBool(&ba.started, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling started: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalU32(&ba.round, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling round: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalU8(&ba.est, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling est: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&ba.rounds, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling rounds: %v", err)
+	}
+	if uint32(len(ba.rounds)) <= ba.round {
+		return buf, rem, fmt.Errorf("round %v out of range for %v rounds", ba.round, len(ba.rounds))
+	}
+	buf, rem, err = surge.UnmarshalBool(&ba.decided, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling decided: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalU8(&ba.decision, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling decision: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalBool(&ba.sentTerm, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling sentTerm: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&ba.terms, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling terms: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalBool(&ba.halted, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling halted: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Generate implements the quick.Generator interface.
+func (agreement Agreement) Generate(r *rand.Rand, size int) reflect.Value {
+	n := rand.Intn(10) + 1
+	t := rand.Intn((n-1)/3 + 1)
+	indices := shamirutil.RandomIndices(n)
+	brnger := brng.BRNGer{}.Generate(nil, size).Interface().(brng.BRNGer)
+	instance := params.InstanceID{}.Generate(r, size).Interface().(params.InstanceID)
+	agreement = NewAgreement(
+		instance, brnger, indices[rand.Intn(n)], indices,
+		t, rand.Intn(n-t)+1,
+	)
+	for i := range agreement.rows {
+		agreement.rows[i] = Broadcaster{}.Generate(nil, size/(n+1)).Interface().(Broadcaster)
+		agreement.votes[i] = indices[:rand.Intn(n+1)]
+		agreement.bas[i] = generateBinaryAgreement(indices)
+	}
+	for i := rand.Intn(3); i > 0; i-- {
+		msg := AgreementMessage{}.Generate(r, size/(n+1)).Interface().(AgreementMessage)
+		agreement.outbox = append(agreement.outbox, msg)
+	}
+	agreement.done = rand.Int()&1 == 1
+	return reflect.ValueOf(agreement)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (agreement Agreement) SizeHint() int {
+	return surge.SizeHint(agreement.rows) +
+		surge.SizeHint(agreement.votes) +
+		surge.SizeHint(agreement.bas) +
+		surge.SizeHint(agreement.outbox) +
+		surge.SizeHint(agreement.table) +
+		surge.SizeHint(agreement.done) +
+		agreement.instance.SizeHint() +
+		agreement.brnger.SizeHint() +
+		agreement.index.SizeHint() +
+		surge.SizeHint(agreement.indices) +
+		surge.SizeHint(agreement.t) +
+		surge.SizeHint(agreement.requiredContributions)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (agreement Agreement) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.Marshal(agreement.rows, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling rows: %v", err)
+	}
+	buf, rem, err = surge.Marshal(agreement.votes, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling votes: %v", err)
+	}
+	buf, rem, err = surge.Marshal(agreement.bas, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling bas: %v", err)
+	}
+	buf, rem, err = surge.Marshal(agreement.outbox, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling outbox: %v", err)
+	}
+	buf, rem, err = surge.Marshal(agreement.table, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling table: %v", err)
+	}
+	buf, rem, err = surge.MarshalBool(agreement.done, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling done: %v", err)
+	}
+	buf, rem, err = agreement.instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling instance: %v", err)
+	}
+	buf, rem, err = agreement.brnger.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling brnger: %v", err)
+	}
+	buf, rem, err = agreement.index.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling index: %v", err)
+	}
+	buf, rem, err = surge.Marshal(agreement.indices, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling indices: %v", err)
+	}
+	buf, rem, err = surge.MarshalU32(agreement.t, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling t: %v", err)
+	}
+	buf, rem, err = surge.MarshalU32(agreement.requiredContributions, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling requiredContributions: %v", err)
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (agreement *Agreement) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.Unmarshal(&agreement.rows, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling rows: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&agreement.votes, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling votes: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&agreement.bas, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling bas: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&agreement.outbox, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling outbox: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&agreement.table, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling table: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalBool(&agreement.done, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling done: %v", err)
+	}
+	buf, rem, err = agreement.instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling instance: %v", err)
+	}
+	buf, rem, err = agreement.brnger.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling brnger: %v", err)
+	}
+	buf, rem, err = agreement.index.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling index: %v", err)
+	}
+	buf, rem, err = surge.Unmarshal(&agreement.indices, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling indices: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalU32(&agreement.t, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling t: %v", err)
+	}
+	buf, rem, err = surge.UnmarshalU32(&agreement.requiredContributions, buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling requiredContributions: %v", err)
+	}
+	if len(agreement.rows) != len(agreement.indices) ||
+		len(agreement.votes) != len(agreement.indices) ||
+		len(agreement.bas) != len(agreement.indices) {
+		return buf, rem, fmt.Errorf("expected %v rows, votes and binary agreements", len(agreement.indices))
+	}
+	return buf, rem, nil
+}
@@ -0,0 +1,266 @@
+// Package rbc implements Bracha's reliable broadcast protocol, along with an
+// agreement protocol built on top of it that can be used by the players in the
+// BRNG protocol to agree on a table of sharings when a full consensus
+// algorithm is not available (see Agreement).
+//
+// Reliable broadcast allows a dealer to send a payload to all of the players
+// such that, as long as fewer than a third of the players are malicious,
+//	- if the dealer is honest, every honest player eventually outputs the
+//		dealer's payload,
+//	- if any honest player outputs a payload, every honest player eventually
+//		outputs the same payload,
+// even if the dealer is malicious and sends different payloads to different
+// players. There is no guarantee that a malicious dealer's broadcast will be
+// output at all.
+package rbc
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/renproject/secp256k1"
+)
+
+// MessageType is the type of a message in the reliable broadcast protocol.
+type MessageType uint8
+
+const (
+	// TypeSend is the type of the message that the dealer sends to every
+	// player to start the broadcast.
+	TypeSend = MessageType(1 + iota)
+
+	// TypeEcho is the type of the message that a player sends to every player
+	// upon receiving the payload from the dealer.
+	TypeEcho
+
+	// TypeReady is the type of the message that a player sends to every player
+	// once it knows that enough players have received the same payload.
+	TypeReady
+)
+
+// A Message is a message in the reliable broadcast protocol. Every message is
+// to be sent to all of the players, including the sender, and the channels
+// between the players are assumed to be authenticated, so that the receiver
+// knows the index of the sender.
+type Message struct {
+	Type    MessageType
+	Dealer  secp256k1.Fn
+	Payload []byte
+}
+
+// A vote is an echo or ready message that has been received from a player.
+type vote struct {
+	from    secp256k1.Fn
+	payload []byte
+}
+
+// Broadcaster is a state machine for one instance of the reliable broadcast
+// protocol, that is, the broadcast of a single payload by a given dealer. An
+// instance of the state machine has a specific dealer, a specific set of
+// indices for the participating players, and a specific bound (t) on the
+// number of malicious players, which must satisfy n >= 3t + 1 where n is the
+// number of players.
+//
+// The protocol proceeds as follows.
+//	1. The dealer sends the payload to every player in a send message; see
+//	Broadcast.
+//	2. Upon receiving the send message, a player sends the payload to every
+//	player in an echo message.
+//	3. Upon receiving echo messages for the same payload from more than (n +
+//	t)/2 players, or ready messages for the same payload from t + 1 players,
+//	a player sends the payload to every player in a ready message, if it has
+//	not done so already.
+//	4. Upon receiving ready messages for the same payload from 2t + 1 players,
+//	a player outputs the payload.
+//
+// The messages that the state machine needs to send are returned by
+// HandleMessage, and the output is available from Output once Done returns
+// true.
+type Broadcaster struct {
+	// State
+	sentEcho, sentReady, delivered bool
+	echoes, readies                []vote
+	output                         []byte
+
+	// Instance parameters
+	dealer secp256k1.Fn
+
+	// Global parameters
+	index   secp256k1.Fn
+	indices []secp256k1.Fn
+	t       uint32
+}
+
+// New returns a new instance of the Broadcaster state machine for the
+// broadcast by the given dealer. The index is the index of the player that
+// owns the state machine, and t is the maximum number of malicious players.
+//
+// Panics: This function will panic if any of the following conditions are
+// met.
+//	- t is negative.
+//	- The number of indices is less than 3t + 1.
+//	- The dealer or the index of the player is not in the set of indices.
+func New(dealer, index secp256k1.Fn, indices []secp256k1.Fn, t int) Broadcaster {
+	if t < 0 {
+		panic(fmt.Sprintf("t must be non-negative: got %v", t))
+	}
+	if len(indices) < 3*t+1 {
+		panic(fmt.Sprintf(
+			"not enough indices: expected at least 3*%v+1 = %v, got %v",
+			t, 3*t+1, len(indices),
+		))
+	}
+	if !contains(indices, dealer) {
+		panic("dealer index is not in the set of indices")
+	}
+	if !contains(indices, index) {
+		panic("player index is not in the set of indices")
+	}
+
+	indicesCopy := make([]secp256k1.Fn, len(indices))
+	copy(indicesCopy, indices)
+
+	return Broadcaster{
+		echoes:  []vote{},
+		readies: []vote{},
+		output:  []byte{},
+		dealer:  dealer,
+		index:   index,
+		indices: indicesCopy,
+		t:       uint32(t),
+	}
+}
+
+// Dealer returns the index of the dealer for the broadcast.
+func (broadcaster Broadcaster) Dealer() secp256k1.Fn {
+	return broadcaster.dealer
+}
+
+// Done returns true if the state machine has output a payload.
+func (broadcaster Broadcaster) Done() bool {
+	return broadcaster.delivered
+}
+
+// Output returns the payload that was output by the state machine. This will
+// only be correct if Done returns true.
+func (broadcaster Broadcaster) Output() []byte {
+	return broadcaster.output
+}
+
+// Broadcast returns the send message that starts the broadcast of the given
+// payload. It should be sent to every player.
+//
+// Panics: This function will panic if the player that owns the state machine
+// is not the dealer.
+func (broadcaster Broadcaster) Broadcast(payload []byte) Message {
+	if !broadcaster.index.Eq(&broadcaster.dealer) {
+		panic("only the dealer can broadcast")
+	}
+	return broadcaster.message(TypeSend, payload)
+}
+
+// HandleMessage handles the state transition logic upon receiving a message
+// from the player with the given index. The returned messages, if any, should
+// be sent to every player. If the message is invalid, an error is returned and
+// the state of the state machine is unchanged.
+func (broadcaster *Broadcaster) HandleMessage(from secp256k1.Fn, msg Message) ([]Message, error) {
+	if !msg.Dealer.Eq(&broadcaster.dealer) {
+		return nil, ErrIncorrectDealer
+	}
+	if !contains(broadcaster.indices, from) {
+		return nil, ErrUnknownSender
+	}
+
+	switch msg.Type {
+	case TypeSend:
+		if !from.Eq(&broadcaster.dealer) {
+			return nil, ErrSendNotFromDealer
+		}
+		if broadcaster.sentEcho {
+			return nil, ErrDuplicateMessage
+		}
+		broadcaster.sentEcho = true
+		return []Message{broadcaster.message(TypeEcho, msg.Payload)}, nil
+
+	case TypeEcho:
+		if hasVoted(broadcaster.echoes, from) {
+			return nil, ErrDuplicateMessage
+		}
+		broadcaster.echoes = append(broadcaster.echoes, newVote(from, msg.Payload))
+		n := len(broadcaster.indices)
+		t := int(broadcaster.t)
+		if !broadcaster.sentReady && countVotes(broadcaster.echoes, msg.Payload) > (n+t)/2 {
+			broadcaster.sentReady = true
+			return []Message{broadcaster.message(TypeReady, msg.Payload)}, nil
+		}
+		return nil, nil
+
+	case TypeReady:
+		if hasVoted(broadcaster.readies, from) {
+			return nil, ErrDuplicateMessage
+		}
+		broadcaster.readies = append(broadcaster.readies, newVote(from, msg.Payload))
+		count := countVotes(broadcaster.readies, msg.Payload)
+		t := int(broadcaster.t)
+		var msgs []Message
+		if !broadcaster.sentReady && count >= t+1 {
+			broadcaster.sentReady = true
+			msgs = append(msgs, broadcaster.message(TypeReady, msg.Payload))
+		}
+		if !broadcaster.delivered && count >= 2*t+1 {
+			broadcaster.delivered = true
+			broadcaster.output = make([]byte, len(msg.Payload))
+			copy(broadcaster.output, msg.Payload)
+		}
+		return msgs, nil
+
+	default:
+		return nil, ErrInvalidMessageType
+	}
+}
+
+// message constructs a message of the given type for the broadcast with the
+// given payload.
+func (broadcaster Broadcaster) message(ty MessageType, payload []byte) Message {
+	payloadCopy := make([]byte, len(payload))
+	copy(payloadCopy, payload)
+	return Message{
+		Type:    ty,
+		Dealer:  broadcaster.dealer,
+		Payload: payloadCopy,
+	}
+}
+
+func newVote(from secp256k1.Fn, payload []byte) vote {
+	payloadCopy := make([]byte, len(payload))
+	copy(payloadCopy, payload)
+	return vote{from: from, payload: payloadCopy}
+}
+
+func hasVoted(votes []vote, from secp256k1.Fn) bool {
+	for i := range votes {
+		if votes[i].from.Eq(&from) {
+			return true
+		}
+	}
+	return false
+}
+
+func countVotes(votes []vote, payload []byte) int {
+	count := 0
+	for i := range votes {
+		if bytes.Equal(votes[i].payload, payload) {
+			count++
+		}
+	}
+	return count
+}
+
+func contains(indices []secp256k1.Fn, index secp256k1.Fn) bool {
+	for i := range indices {
+		if indices[i].Eq(&index) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package rbc_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRbc(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rbc Suite")
+}
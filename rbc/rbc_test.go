@@ -0,0 +1,490 @@
+package rbc_test
+
+import (
+	"bytes"
+	"math/rand"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/mpc/rbc"
+
+	"github.com/renproject/mpc/brng"
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rbc/rbcutil"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+	"github.com/renproject/surge"
+)
+
+var _ = Describe("RBC", func() {
+	rand.Seed(int64(time.Now().Nanosecond()))
+
+	RandomTestParameters := func() (int, int, []secp256k1.Fn, secp256k1.Fn, secp256k1.Fn) {
+		t := shamirutil.RandRange(1, 4)
+		n := shamirutil.RandRange(3*t+1, 3*t+3)
+		indices := shamirutil.RandomIndices(n)
+		dealer := indices[rand.Intn(n)]
+		index := indices[rand.Intn(n)]
+		return n, t, indices, dealer, index
+	}
+
+	RandomPayload := func() []byte {
+		payload := make([]byte, rand.Intn(100)+1)
+		rand.Read(payload)
+		return payload
+	}
+
+	type delivery struct {
+		from secp256k1.Fn
+		msg  Message
+	}
+
+	// RunBroadcasters delivers the given messages, and all messages sent in
+	// response, to every broadcaster, where the ith broadcaster is for the
+	// player with the ith index. Messages sent by players that are in the
+	// silent set are dropped.
+	RunBroadcasters := func(
+		broadcasters []Broadcaster, indices []secp256k1.Fn, silent map[int]bool, initial []delivery,
+	) {
+		queue := initial
+		for len(queue) > 0 {
+			next := queue[0]
+			queue = queue[1:]
+			for i := range broadcasters {
+				msgs, err := broadcasters[i].HandleMessage(next.from, next.msg)
+				Expect(err).ToNot(HaveOccurred())
+				if silent[i] {
+					continue
+				}
+				for _, msg := range msgs {
+					queue = append(queue, delivery{from: indices[i], msg: msg})
+				}
+			}
+		}
+	}
+
+	Context("broadcasting", func() {
+		Specify("every player should output the payload of an honest dealer", func() {
+			n, t, indices, _, _ := RandomTestParameters()
+			d := rand.Intn(n)
+			broadcasters := make([]Broadcaster, n)
+			for i := range broadcasters {
+				broadcasters[i] = New(indices[d], indices[i], indices, t)
+			}
+			payload := RandomPayload()
+			msg := broadcasters[d].Broadcast(payload)
+			Expect(msg.Type).To(Equal(TypeSend))
+
+			RunBroadcasters(broadcasters, indices, nil, []delivery{{from: indices[d], msg: msg}})
+			for i := range broadcasters {
+				Expect(broadcasters[i].Done()).To(BeTrue())
+				Expect(broadcasters[i].Output()).To(Equal(payload))
+			}
+		})
+
+		Specify("every player should output the payload when t players are silent", func() {
+			n, t, indices, _, _ := RandomTestParameters()
+			d := rand.Intn(n)
+			broadcasters := make([]Broadcaster, n)
+			for i := range broadcasters {
+				broadcasters[i] = New(indices[d], indices[i], indices, t)
+			}
+			silent := map[int]bool{}
+			for _, i := range rand.Perm(n)[:t] {
+				if i != d {
+					silent[i] = true
+				}
+			}
+			payload := RandomPayload()
+			msg := broadcasters[d].Broadcast(payload)
+
+			RunBroadcasters(broadcasters, indices, silent, []delivery{{from: indices[d], msg: msg}})
+			for i := range broadcasters {
+				Expect(broadcasters[i].Done()).To(BeTrue())
+				Expect(broadcasters[i].Output()).To(Equal(payload))
+			}
+		})
+
+		Specify("players should not output different payloads for an equivocating dealer", func() {
+			n, t, indices, _, _ := RandomTestParameters()
+			d := rand.Intn(n)
+			broadcasters := make([]Broadcaster, n)
+			for i := range broadcasters {
+				broadcasters[i] = New(indices[d], indices[i], indices, t)
+			}
+			payloads := [][]byte{RandomPayload(), RandomPayload()}
+
+			// Each player receives a send message for one of two payloads.
+			var queue []delivery
+			for i := range broadcasters {
+				msg := Message{Type: TypeSend, Dealer: indices[d], Payload: payloads[rand.Intn(2)]}
+				msgs, err := broadcasters[i].HandleMessage(indices[d], msg)
+				Expect(err).ToNot(HaveOccurred())
+				for _, msg := range msgs {
+					queue = append(queue, delivery{from: indices[i], msg: msg})
+				}
+			}
+
+			RunBroadcasters(broadcasters, indices, nil, queue)
+			var output []byte
+			for i := range broadcasters {
+				if !broadcasters[i].Done() {
+					continue
+				}
+				if output == nil {
+					output = broadcasters[i].Output()
+				}
+				Expect(broadcasters[i].Output()).To(Equal(output))
+			}
+		})
+	})
+
+	Context("thresholds", func() {
+		Specify("a ready message should be sent after enough echo messages", func() {
+			n, t, indices, dealer, index := RandomTestParameters()
+			broadcaster := New(dealer, index, indices, t)
+			payload := RandomPayload()
+			msg := Message{Type: TypeEcho, Dealer: dealer, Payload: payload}
+			for i := 0; i < (n+t)/2; i++ {
+				msgs, err := broadcaster.HandleMessage(indices[i], msg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(msgs).To(BeEmpty())
+			}
+			msgs, err := broadcaster.HandleMessage(indices[(n+t)/2], msg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(msgs)).To(Equal(1))
+			Expect(msgs[0].Type).To(Equal(TypeReady))
+			Expect(msgs[0].Payload).To(Equal(payload))
+		})
+
+		Specify("echo messages for different payloads should not be counted together", func() {
+			n, t, indices, dealer, index := RandomTestParameters()
+			broadcaster := New(dealer, index, indices, t)
+			for i := 0; i < n; i++ {
+				// Neither payload is echoed by more than (n+t)/2 players.
+				msg := Message{Type: TypeEcho, Dealer: dealer, Payload: []byte{0}}
+				if i >= (n+t)/2 {
+					msg.Payload = []byte{1}
+				}
+				msgs, err := broadcaster.HandleMessage(indices[i], msg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(msgs).To(BeEmpty())
+			}
+		})
+
+		Specify("a ready message should be sent after t+1 ready messages", func() {
+			_, t, indices, dealer, index := RandomTestParameters()
+			broadcaster := New(dealer, index, indices, t)
+			payload := RandomPayload()
+			msg := Message{Type: TypeReady, Dealer: dealer, Payload: payload}
+			for i := 0; i < t; i++ {
+				msgs, err := broadcaster.HandleMessage(indices[i], msg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(msgs).To(BeEmpty())
+			}
+			msgs, err := broadcaster.HandleMessage(indices[t], msg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(msgs)).To(Equal(1))
+			Expect(msgs[0].Type).To(Equal(TypeReady))
+			Expect(msgs[0].Payload).To(Equal(payload))
+			Expect(broadcaster.Done()).To(BeFalse())
+		})
+
+		Specify("the payload should be output after 2t+1 ready messages", func() {
+			_, t, indices, dealer, index := RandomTestParameters()
+			broadcaster := New(dealer, index, indices, t)
+			payload := RandomPayload()
+			msg := Message{Type: TypeReady, Dealer: dealer, Payload: payload}
+			for i := 0; i < 2*t; i++ {
+				_, err := broadcaster.HandleMessage(indices[i], msg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(broadcaster.Done()).To(BeFalse())
+			}
+			_, err := broadcaster.HandleMessage(indices[2*t], msg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(broadcaster.Done()).To(BeTrue())
+			Expect(broadcaster.Output()).To(Equal(payload))
+		})
+	})
+
+	Context("invalid messages", func() {
+		Specify("message for a different dealer", func() {
+			_, t, indices, dealer, index := RandomTestParameters()
+			broadcaster := New(dealer, index, indices, t)
+			msg := Message{Type: TypeEcho, Dealer: secp256k1.RandomFn(), Payload: RandomPayload()}
+			_, err := broadcaster.HandleMessage(indices[0], msg)
+			Expect(err).To(Equal(ErrIncorrectDealer))
+		})
+
+		Specify("message from an unknown sender", func() {
+			_, t, indices, dealer, index := RandomTestParameters()
+			broadcaster := New(dealer, index, indices, t)
+			msg := Message{Type: TypeEcho, Dealer: dealer, Payload: RandomPayload()}
+			_, err := broadcaster.HandleMessage(secp256k1.RandomFn(), msg)
+			Expect(err).To(Equal(ErrUnknownSender))
+		})
+
+		Specify("send message not from the dealer", func() {
+			_, t, indices, dealer, index := RandomTestParameters()
+			broadcaster := New(dealer, index, indices, t)
+			from := indices[0]
+			if from.Eq(&dealer) {
+				from = indices[1]
+			}
+			msg := Message{Type: TypeSend, Dealer: dealer, Payload: RandomPayload()}
+			_, err := broadcaster.HandleMessage(from, msg)
+			Expect(err).To(Equal(ErrSendNotFromDealer))
+		})
+
+		Specify("duplicate messages", func() {
+			_, t, indices, dealer, index := RandomTestParameters()
+			broadcaster := New(dealer, index, indices, t)
+			for _, ty := range []MessageType{TypeSend, TypeEcho, TypeReady} {
+				msg := Message{Type: ty, Dealer: dealer, Payload: RandomPayload()}
+				_, err := broadcaster.HandleMessage(dealer, msg)
+				Expect(err).ToNot(HaveOccurred())
+				msg.Payload = RandomPayload()
+				_, err = broadcaster.HandleMessage(dealer, msg)
+				Expect(err).To(Equal(ErrDuplicateMessage))
+			}
+		})
+
+		Specify("invalid message type", func() {
+			_, t, indices, dealer, index := RandomTestParameters()
+			broadcaster := New(dealer, index, indices, t)
+			msg := Message{Type: MessageType(4 + rand.Intn(252)), Dealer: dealer, Payload: RandomPayload()}
+			_, err := broadcaster.HandleMessage(dealer, msg)
+			Expect(err).To(Equal(ErrInvalidMessageType))
+		})
+	})
+
+	Context("agreement", func() {
+		RandomInstance := func() params.InstanceID {
+			var instance params.InstanceID
+			rand.Read(instance[:])
+			return instance
+		}
+
+		Specify("handling a row should return the message that starts its broadcast", func() {
+			n, t, indices, _, index := RandomTestParameters()
+			k := shamirutil.RandRange(1, n-t)
+			h := secp256k1.RandomPoint()
+			instance := RandomInstance()
+			brnger, row := brng.New(1, uint32(k), indices, index, h)
+			agreement := NewAgreement(instance, brnger, index, indices, t, k)
+
+			Expect(agreement.HandleRow(row)).To(BeFalse())
+			msgs := agreement.Outgoing()
+			Expect(len(msgs)).To(Equal(1))
+			Expect(msgs[0].Instance).To(Equal(instance))
+			Expect(msgs[0].Type).To(Equal(TypeRow))
+			Expect(msgs[0].Dealer.Eq(&index)).To(BeTrue())
+			Expect(msgs[0].Message.Type).To(Equal(TypeSend))
+			payload, err := surge.ToBinary(row)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bytes.Equal(msgs[0].Message.Payload, payload)).To(BeTrue())
+			Expect(agreement.Outgoing()).To(BeEmpty())
+		})
+
+		Specify("messages for a different instance should be rejected", func() {
+			n, t, indices, dealer, index := RandomTestParameters()
+			k := shamirutil.RandRange(1, n-t)
+			h := secp256k1.RandomPoint()
+			brnger, row := brng.New(1, uint32(k), indices, dealer, h)
+			dealerAgreement := NewAgreement(RandomInstance(), brnger, dealer, indices, t, k)
+			dealerAgreement.HandleRow(row)
+			msg := dealerAgreement.Outgoing()[0]
+
+			brnger, _ = brng.New(1, uint32(k), indices, index, h)
+			agreement := NewAgreement(RandomInstance(), brnger, index, indices, t, k)
+			_, err := agreement.HandleMessage(dealer, msg)
+			Expect(err).To(Equal(ErrIncorrectInstance))
+		})
+
+		Specify("invalid binary agreement messages should be rejected", func() {
+			n, t, indices, dealer, index := RandomTestParameters()
+			k := shamirutil.RandRange(1, n-t)
+			h := secp256k1.RandomPoint()
+			instance := RandomInstance()
+			brnger, _ := brng.New(1, uint32(k), indices, index, h)
+			agreement := NewAgreement(instance, brnger, index, indices, t, k)
+
+			msg := AgreementMessage{Instance: instance, Type: TypeEst, Dealer: dealer, Value: 3}
+			_, err := agreement.HandleMessage(dealer, msg)
+			Expect(err).To(Equal(ErrInvalidValue))
+
+			msg = AgreementMessage{Instance: instance, Type: TypeConf, Dealer: dealer, Value: 0}
+			_, err = agreement.HandleMessage(dealer, msg)
+			Expect(err).To(Equal(ErrInvalidValue))
+
+			msg = AgreementMessage{Instance: instance, Type: TypeAux, Dealer: dealer, Round: 1000, Value: 1}
+			_, err = agreement.HandleMessage(dealer, msg)
+			Expect(err).To(Equal(ErrFutureRound))
+
+			msg = AgreementMessage{Instance: instance, Type: TypeEst, Dealer: secp256k1.RandomFn(), Value: 1}
+			_, err = agreement.HandleMessage(dealer, msg)
+			Expect(err).To(Equal(ErrIncorrectDealer))
+
+			msg = AgreementMessage{Instance: instance, Type: TypeAux, Dealer: dealer, Value: 2}
+			_, err = agreement.HandleMessage(dealer, msg)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = agreement.HandleMessage(dealer, msg)
+			Expect(err).To(Equal(ErrDuplicateMessage))
+		})
+
+		// RunAgreementNetwork runs the agreement network, and checks that every
+		// online player outputs the same table, and that the table is valid for
+		// every online player.
+		RunAgreementNetwork := func(
+			machines []mpcutil.Machine, shuffleMsgs func([]mpcutil.Message),
+			isOffline map[mpcutil.ID]bool, ids []mpcutil.ID, indices []secp256k1.Fn,
+			k, b int, h secp256k1.Point,
+		) {
+			network := mpcutil.NewNetwork(machines, shuffleMsgs)
+			network.SetCaptureHist(true)
+			err := network.Run()
+			Expect(err).ToNot(HaveOccurred())
+
+			// Every online player should output the same table.
+			var reference []byte
+			var table brng.Table
+			for i, id := range ids {
+				if isOffline[id] {
+					continue
+				}
+				machine := machines[i].(*rbcutil.Machine)
+				Expect(machine.Done()).To(BeTrue())
+				Expect(len(machine.Table())).To(Equal(k))
+				buf, err := surge.ToBinary(machine.Table())
+				Expect(err).ToNot(HaveOccurred())
+				if reference == nil {
+					reference = buf
+					table = machine.Table()
+				}
+				Expect(bytes.Equal(buf, reference)).To(BeTrue())
+			}
+
+			// The table should be valid for every online player, and the
+			// resulting BRNG output shares should form consistent sharings.
+			outputShares := make([]shamir.VerifiableShares, b)
+			for i, id := range ids {
+				if isOffline[id] {
+					continue
+				}
+				brnger, _ := brng.New(uint32(b), uint32(k), indices, indices[i], h)
+				Expect(brnger.IsValidTable(table, k)).To(Succeed())
+				sharesBatch, commitmentsBatch := table.Column(indices[i])
+				shares, _ := brng.HandleConsensusOutput(sharesBatch, commitmentsBatch)
+				for j := range outputShares {
+					outputShares[j] = append(outputShares[j], shares[j])
+				}
+			}
+			for j := range outputShares {
+				Expect(shamirutil.VsharesAreConsistent(outputShares[j], k)).To(BeTrue())
+			}
+		}
+
+		Specify("the players should agree on a valid table in a network with offline machines", func() {
+			n, t, indices, _, _ := RandomTestParameters()
+			k := shamirutil.RandRange(1, n-t)
+			b := shamirutil.RandRange(1, 3)
+			h := secp256k1.RandomPoint()
+			instance := RandomInstance()
+
+			ids := make([]mpcutil.ID, n)
+			for i := range ids {
+				ids[i] = mpcutil.ID(i + 1)
+			}
+			shuffleMsgs, isOffline := mpcutil.MessageShufflerDropper(ids, rand.Intn(t+1))
+
+			machines := make([]mpcutil.Machine, n)
+			for i, id := range ids {
+				machine := rbcutil.NewMachine(id, ids, indices, instance, t, k, b, h)
+				machines[i] = &machine
+			}
+
+			RunAgreementNetwork(machines, shuffleMsgs, isOffline, ids, indices, k, b, h)
+		})
+
+		Specify("the row of a dealer with invalid shares for more than t players should not be in the table", func() {
+			n, t, indices, _, _ := RandomTestParameters()
+			k := shamirutil.RandRange(1, n-t)
+			b := shamirutil.RandRange(1, 3)
+			h := secp256k1.RandomPoint()
+			instance := RandomInstance()
+
+			ids := make([]mpcutil.ID, n)
+			for i := range ids {
+				ids[i] = mpcutil.ID(i + 1)
+			}
+			shuffleMsgs, isOffline := mpcutil.MessageShufflerDropper(ids, 0)
+
+			// The malicious dealer sends invalid shares to t + 1 of the other
+			// players, and so at most n - t - 1 players will vote for its
+			// row.
+			m := rand.Intn(n)
+			victims := make([]secp256k1.Fn, 0, t+1)
+			for i := range indices {
+				if i != m && len(victims) < t+1 {
+					victims = append(victims, indices[i])
+				}
+			}
+
+			machines := make([]mpcutil.Machine, n)
+			for i, id := range ids {
+				var machine rbcutil.Machine
+				if i == m {
+					machine = rbcutil.NewMaliciousMachine(id, ids, indices, victims, instance, t, k, b, h)
+				} else {
+					machine = rbcutil.NewMachine(id, ids, indices, instance, t, k, b, h)
+				}
+				machines[i] = &machine
+			}
+
+			// The table is checked to be valid for every player, including the
+			// victims, which would not be the case if the row of the malicious
+			// dealer was in the table.
+			RunAgreementNetwork(machines, shuffleMsgs, isOffline, ids, indices, k, b, h)
+		})
+	})
+
+	Context("panics", func() {
+		Specify("t is negative", func() {
+			_, _, indices, dealer, index := RandomTestParameters()
+			Expect(func() { New(dealer, index, indices, -1) }).To(Panic())
+		})
+
+		Specify("not enough indices", func() {
+			n, _, indices, dealer, index := RandomTestParameters()
+			Expect(func() { New(dealer, index, indices, (n+2)/3) }).To(Panic())
+		})
+
+		Specify("dealer or player index not in the indices", func() {
+			_, t, indices, dealer, index := RandomTestParameters()
+			Expect(func() { New(secp256k1.RandomFn(), index, indices, t) }).To(Panic())
+			Expect(func() { New(dealer, secp256k1.RandomFn(), indices, t) }).To(Panic())
+		})
+
+		Specify("broadcasting by a player that is not the dealer", func() {
+			_, t, indices, dealer, _ := RandomTestParameters()
+			index := indices[0]
+			if index.Eq(&dealer) {
+				index = indices[1]
+			}
+			broadcaster := New(dealer, index, indices, t)
+			Expect(func() { broadcaster.Broadcast(RandomPayload()) }).To(Panic())
+		})
+
+		Specify("invalid required contributions for an agreement", func() {
+			n, t, indices, _, index := RandomTestParameters()
+			h := secp256k1.RandomPoint()
+			brnger, _ := brng.New(1, 1, indices, index, h)
+			var instance params.InstanceID
+			Expect(func() { NewAgreement(instance, brnger, index, indices, t, 0) }).To(Panic())
+			Expect(func() { NewAgreement(instance, brnger, index, indices, t, n-t+1) }).To(Panic())
+		})
+	})
+})
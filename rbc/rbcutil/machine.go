@@ -0,0 +1,175 @@
+package rbcutil
+
+import (
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/surge"
+
+	"github.com/renproject/mpc/brng"
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rbc"
+)
+
+// The Machine type used for the agreement network test. Each machine is a
+// player in the BRNG protocol that uses an rbc.Agreement to agree on the table
+// of sharings.
+type Machine struct {
+	ownID     mpcutil.ID
+	ids       []mpcutil.ID
+	indices   []secp256k1.Fn
+	initial   []rbc.AgreementMessage
+	agreement rbc.Agreement
+}
+
+// NewMachine constructs a new Machine for the agreement with the given
+// instance ID. The ith ID corresponds to the ith index, and the machine creates
+// its row of sharings for BRNG with reconstruction threshold k and batch size
+// b. The table that the machines agree on will have k rows.
+func NewMachine(
+	ownID mpcutil.ID,
+	ids []mpcutil.ID,
+	indices []secp256k1.Fn,
+	instance params.InstanceID,
+	t, k, b int,
+	h secp256k1.Point,
+) Machine {
+	return NewMaliciousMachine(ownID, ids, indices, nil, instance, t, k, b, h)
+}
+
+// NewMaliciousMachine constructs a new Machine in the same way as NewMachine,
+// except that the shares in its row for each of the players with the given
+// victim indices are replaced by random values, so that they are not valid.
+func NewMaliciousMachine(
+	ownID mpcutil.ID,
+	ids []mpcutil.ID,
+	indices, victims []secp256k1.Fn,
+	instance params.InstanceID,
+	t, k, b int,
+	h secp256k1.Point,
+) Machine {
+	var index secp256k1.Fn
+	for i := range ids {
+		if ids[i] == ownID {
+			index = indices[i]
+		}
+	}
+	brnger, row := brng.New(uint32(b), uint32(k), indices, index, h)
+	for i := range row {
+		for j := range row[i].Shares {
+			for _, victim := range victims {
+				if row[i].Shares[j].Share.IndexEq(&victim) {
+					row[i].Shares[j].Share.Value = secp256k1.RandomFn()
+				}
+			}
+		}
+	}
+	agreement := rbc.NewAgreement(instance, brnger, index, indices, t, k)
+	agreement.HandleRow(row)
+	return Machine{
+		ownID:     ownID,
+		ids:       ids,
+		indices:   indices,
+		initial:   agreement.Outgoing(),
+		agreement: agreement,
+	}
+}
+
+// Done returns true if the machine has output a table.
+func (m Machine) Done() bool {
+	return m.agreement.Done()
+}
+
+// Table returns the table that was output by the machine.
+func (m Machine) Table() brng.Table {
+	return m.agreement.Table()
+}
+
+// ID implements the mpcutil.Machine interface.
+func (m Machine) ID() mpcutil.ID {
+	return m.ownID
+}
+
+// InitialMessages implements the mpcutil.Machine interface.
+func (m Machine) InitialMessages() []mpcutil.Message {
+	return m.broadcast(m.initial)
+}
+
+// Handle implements the mpcutil.Machine interface.
+func (m *Machine) Handle(msg mpcutil.Message) []mpcutil.Message {
+	message := msg.(*Message)
+	var from secp256k1.Fn
+	for i := range m.ids {
+		if m.ids[i] == message.from {
+			from = m.indices[i]
+		}
+	}
+	msgs, _ := m.agreement.HandleMessage(from, message.msg)
+	return m.broadcast(msgs)
+}
+
+// broadcast returns the messages that send each of the given messages to every
+// machine, including this one.
+func (m Machine) broadcast(msgs []rbc.AgreementMessage) []mpcutil.Message {
+	messages := make([]mpcutil.Message, 0, len(msgs)*len(m.ids))
+	for _, msg := range msgs {
+		for _, id := range m.ids {
+			messages = append(messages, &Message{
+				msg:  msg,
+				from: m.ownID,
+				to:   id,
+			})
+		}
+	}
+	return messages
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (m Machine) SizeHint() int {
+	return m.ownID.SizeHint() +
+		surge.SizeHint(m.ids) +
+		surge.SizeHint(m.indices) +
+		surge.SizeHint(m.initial) +
+		m.agreement.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (m Machine) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.ownID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.ids, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.indices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.initial, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return m.agreement.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (m *Machine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.ownID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.ids, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.indices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.initial, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return m.agreement.Unmarshal(buf, rem)
+}
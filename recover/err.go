@@ -0,0 +1,46 @@
+package recover
+
+import "errors"
+
+var (
+	// ErrIncorrectCommitmentsBatchSize is returned when the batch size of the
+	// given mask commitments is not equal to the batch size of the Helper.
+	ErrIncorrectCommitmentsBatchSize = errors.New("incorrect commitments batch size")
+
+	// ErrIncorrectSharesBatchSize is returned when the batch size of the given
+	// mask shares is not equal to the batch size of the Helper.
+	ErrIncorrectSharesBatchSize = errors.New("incorrect shares batch size")
+
+	// ErrInvalidCommitmentDimensions is returned when the batch of mask
+	// commitments has inconsistent dimensions. This can occur when not all
+	// slices in the batch have the same length (this length is equal to the
+	// number of contributions for the batch), or when not all commitments
+	// have the threshold of the Helper.
+	ErrInvalidCommitmentDimensions = errors.New("invalid commitment dimensions")
+
+	// ErrInvalidShareDimensions is returned when the batch of mask shares has
+	// inconsistent dimensions. This occurs when not all slices in the batch
+	// have the same length (this length is equal to the number of
+	// contributions for the batch).
+	ErrInvalidShareDimensions = errors.New("invalid share dimensions")
+
+	// ErrMaskDoesNotVanish is returned when a mask commitment is not a
+	// commitment to a polynomial that is zero at the lost index.
+	ErrMaskDoesNotVanish = errors.New("mask does not vanish at lost index")
+
+	// ErrInvalidShares is returned when not all of the given mask shares are
+	// valid with respect to their corresponding commitments.
+	ErrInvalidShares = errors.New("invalid shares")
+
+	// ErrIncorrectIndex is returned when not all of the mask shares have index
+	// equal to the index for the Helper.
+	ErrIncorrectIndex = errors.New("incorrect index")
+
+	// ErrNotEnoughContributions is returned when the number of mask
+	// contributions is smaller than the number of required contributions.
+	ErrNotEnoughContributions = errors.New("not enough contributions")
+
+	// ErrInvalidRecoveredShare is returned when a recovered share is not valid
+	// with respect to the existing commitment for the sharing.
+	ErrInvalidRecoveredShare = errors.New("invalid recovered share")
+)
@@ -0,0 +1,133 @@
+package recover
+
+import (
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// Generate implements the quick.Generator interface.
+func (helper Helper) Generate(_ *rand.Rand, _ int) reflect.Value {
+	h := Helper{
+		batchSize: rand.Uint32(),
+		k:         rand.Uint32(),
+		index:     secp256k1.RandomFn(),
+		lostIndex: secp256k1.RandomFn(),
+		h:         secp256k1.RandomPoint(),
+	}
+	return reflect.ValueOf(h)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (helper Helper) SizeHint() int {
+	return surge.SizeHint(helper.batchSize) +
+		surge.SizeHint(helper.k) +
+		helper.index.SizeHint() +
+		helper.lostIndex.SizeHint() +
+		helper.h.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (helper Helper) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.MarshalU32(helper.batchSize, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.MarshalU32(helper.k, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = helper.index.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = helper.lostIndex.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return helper.h.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (helper *Helper) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.UnmarshalU32(&helper.batchSize, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.UnmarshalU32(&helper.k, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = helper.index.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = helper.lostIndex.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return helper.h.Unmarshal(buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (recoverer Recoverer) Generate(rand *rand.Rand, size int) reflect.Value {
+	size /= 2
+	opener := open.Opener{}.Generate(rand, size).Interface().(open.Opener)
+	commitmentBatch := make([]shamir.Commitment, rand.Intn(size/4+1)+1)
+	for i := range commitmentBatch {
+		commitmentBatch[i] = shamir.Commitment{}.Generate(rand, size/4).Interface().(shamir.Commitment)
+	}
+	r := Recoverer{
+		opener:          opener,
+		lostIndex:       secp256k1.RandomFn(),
+		commitmentBatch: commitmentBatch,
+		h:               secp256k1.RandomPoint(),
+	}
+	return reflect.ValueOf(r)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (recoverer Recoverer) SizeHint() int {
+	return recoverer.opener.SizeHint() +
+		recoverer.lostIndex.SizeHint() +
+		surge.SizeHint(recoverer.commitmentBatch) +
+		recoverer.h.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (recoverer Recoverer) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := recoverer.opener.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = recoverer.lostIndex.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(recoverer.commitmentBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return recoverer.h.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (recoverer *Recoverer) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := recoverer.opener.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = recoverer.lostIndex.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&recoverer.commitmentBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return recoverer.h.Unmarshal(buf, rem)
+}
@@ -0,0 +1,336 @@
+// Package recover implements a protocol that allows a player that has lost
+// its share of a verifiable sharing f to recover it with the help of the other
+// players, without any of the players learning anything about the lost share
+// or about the secret. The protocol proceeds as follows, where j is the lost
+// index.
+//	1. Each helper creates a random verifiable sharing of a polynomial that
+//	is zero at j, along with a decommitment polynomial that is also zero at j.
+//	These are created by NewHelper.
+//	2. The helpers submit their sharings to a consensus algorithm, which
+//	decides on enough of them to ensure that at least one was created by an
+//	honest helper. Helpers use Helper.IsValid to check the sharings during
+//	consensus. The sum z of the agreed upon sharings is the mask.
+//	3. Each helper computes its share of f + z using BlindShares and sends it
+//	to the recovering player. Since z is random apart from being zero at j,
+//	these shares reveal nothing other than f(j).
+//	4. The recovering player uses a Recoverer to check each received share
+//	against the commitment for f + z, which is computed from the existing
+//	commitment and the agreed upon mask commitments, and reconstructs f(j)
+//	once it has received k valid shares.
+package recover
+
+import (
+	"fmt"
+
+	"github.com/renproject/mpc/brng"
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// A Helper is a state machine for a player that is helping to recover a lost
+// share. It is used to check the validity of the mask sharings during the
+// consensus step of the protocol.
+//
+// The state machine supports batching, in which case all sharings in the batch
+// must have the same reconstruction threshold (k).
+type Helper struct {
+	batchSize uint32
+	k         uint32
+	index     secp256k1.Fn
+	lostIndex secp256k1.Fn
+	h         secp256k1.Point
+}
+
+// NewHelper returns a new Helper state machine along with a batch of mask
+// sharings that are to be submitted to the consensus algorithm. The indices
+// are those of the helpers, and so should not include the lost index. Each
+// mask sharing is a verifiable sharing of a random polynomial of degree k-1
+// that is zero at the lost index.
+//
+// Panics: This function will panic if any of the following conditions are
+// met.
+//	- The Pedersen parameter is insecure.
+//	- The batch size or the reconstruction threshold (k) is less than 1.
+//	- The lost index is one of the given indices.
+func NewHelper(
+	b, k int,
+	index, lostIndex secp256k1.Fn,
+	indices []secp256k1.Fn,
+	h secp256k1.Point,
+) (Helper, []brng.Sharing) {
+	if !params.ValidPedersenParameter(h) {
+		panic("insecure choice of pedersen parameter")
+	}
+	if b < 1 {
+		panic(fmt.Sprintf("batch size must be at least 1: got %v", b))
+	}
+	if k < 1 {
+		panic(fmt.Sprintf("k must be at least 1: got %v", k))
+	}
+	for i := range indices {
+		if indices[i].Eq(&lostIndex) {
+			panic("lost index is one of the helper indices")
+		}
+	}
+
+	coeffs := make([]secp256k1.Fn, k)
+	decomCoeffs := make([]secp256k1.Fn, k)
+	sharings := make([]brng.Sharing, b)
+	for i := range sharings {
+		randomVanishingPoly(coeffs, &lostIndex)
+		randomVanishingPoly(decomCoeffs, &lostIndex)
+
+		sharings[i].Commitment = shamir.NewCommitmentWithCapacity(k)
+		for l := 0; l < k; l++ {
+			var point, hPow secp256k1.Point
+			point.BaseExp(&coeffs[l])
+			hPow.Scale(&h, &decomCoeffs[l])
+			point.Add(&point, &hPow)
+			sharings[i].Commitment.Append(point)
+		}
+
+		sharings[i].Shares = make(shamir.VerifiableShares, len(indices))
+		for l, index := range indices {
+			value := msm.EvalPoly(coeffs, &index)
+			decom := msm.EvalPoly(decomCoeffs, &index)
+			sharings[i].Shares[l] = shamir.NewVerifiableShare(shamir.NewShare(index, value), decom)
+		}
+	}
+
+	helper := Helper{
+		batchSize: uint32(b),
+		k:         uint32(k),
+		index:     index,
+		lostIndex: lostIndex,
+		h:         h,
+	}
+	return helper, sharings
+}
+
+// IsValid checks the validity of the given potential consensus output for the
+// mask sharings. The arguments have the same form as for brng.BRNGer.IsValid.
+// A return value of nil means that the consensus output can be used to
+// construct the blinded shares using BlindShares. Otherwise, an error is
+// returned that describes how the output is invalid. If the shares are nil,
+// only the commitments are checked; this can be used by the recovering player.
+func (helper *Helper) IsValid(
+	sharesBatch []shamir.VerifiableShares,
+	commitmentsBatch [][]shamir.Commitment,
+	requiredContributions int,
+) error {
+	// Commitments validity.
+	if uint32(len(commitmentsBatch)) != helper.batchSize {
+		return ErrIncorrectCommitmentsBatchSize
+	}
+	numContributions := len(commitmentsBatch[0])
+	if numContributions < requiredContributions {
+		return ErrNotEnoughContributions
+	}
+	for _, commitments := range commitmentsBatch {
+		if len(commitments) != numContributions {
+			return ErrInvalidCommitmentDimensions
+		}
+		for _, commitment := range commitments {
+			if uint32(commitment.Len()) != helper.k {
+				return ErrInvalidCommitmentDimensions
+			}
+		}
+	}
+	for _, commitments := range commitmentsBatch {
+		for _, commitment := range commitments {
+			eval := msm.EvalCommitment(commitment, &helper.lostIndex)
+			if !eval.IsInfinity() {
+				return ErrMaskDoesNotVanish
+			}
+		}
+	}
+
+	if sharesBatch == nil {
+		return nil
+	}
+
+	// Shares validity.
+	if uint32(len(sharesBatch)) != helper.batchSize {
+		return ErrIncorrectSharesBatchSize
+	}
+	for i, shares := range sharesBatch {
+		if len(shares) != numContributions {
+			return ErrInvalidShareDimensions
+		}
+		for j, share := range shares {
+			if !share.Share.IndexEq(&helper.index) {
+				return ErrIncorrectIndex
+			}
+			if !shamir.IsValid(helper.h, &commitmentsBatch[i][j], &share) {
+				return ErrInvalidShares
+			}
+		}
+	}
+
+	return nil
+}
+
+// BlindShares returns the shares that a helper sends to the recovering player,
+// given the helper's shares of the sharings being recovered and the mask
+// shares from the output of the consensus algorithm. The mask shares are
+// expected to have been checked using Helper.IsValid; a helper whose mask
+// shares were not valid can not help with the recovery.
+func BlindShares(shareBatch shamir.VerifiableShares, maskSharesBatch []shamir.VerifiableShares) shamir.VerifiableShares {
+	blinded := make(shamir.VerifiableShares, len(shareBatch))
+	for i := range blinded {
+		blinded[i] = shareBatch[i]
+		for j := range maskSharesBatch[i] {
+			blinded[i].Add(&blinded[i], &maskSharesBatch[i][j])
+		}
+	}
+	return blinded
+}
+
+// A Recoverer is a state machine for the player that is recovering its lost
+// shares. It receives the blinded shares from the helpers and reconstructs the
+// lost shares once enough valid blinded shares have been received.
+//
+// Internally, the blinded sharing f + z is re-expressed as the sharing of
+// g(x) = (f + z)(x + j), where j is the lost index, so that the lost share is
+// the secret of g and can be opened by an open.Opener. In particular, blinded
+// shares are checked against the commitment for g in the same way as in any
+// other opening, so that a helper that sends an incorrect share is detected.
+type Recoverer struct {
+	opener          open.Opener
+	lostIndex       secp256k1.Fn
+	commitmentBatch []shamir.Commitment
+	h               secp256k1.Point
+}
+
+// New returns a new Recoverer state machine for the given instance ID and lost
+// index. The indices are those of the helpers, the commitments are the existing
+// commitments for the sharings that are being recovered, and the mask
+// commitments are those from the output of the consensus algorithm in the
+// same form as for Helper.IsValid.
+//
+// Panics: This function will panic if any of the following conditions are
+// met.
+//	- The Pedersen parameter is insecure.
+//	- The batch size is less than 1.
+//	- The commitments and mask commitments have different batch sizes.
+//	- The lost index is one of the given indices.
+//	- Any of the conditions for which open.New would panic.
+func New(
+	instance params.InstanceID,
+	lostIndex secp256k1.Fn,
+	indices []secp256k1.Fn,
+	commitmentBatch []shamir.Commitment,
+	maskCommitmentsBatch [][]shamir.Commitment,
+	h secp256k1.Point,
+) Recoverer {
+	if !params.ValidPedersenParameter(h) {
+		panic("insecure choice of pedersen parameter")
+	}
+	b := len(commitmentBatch)
+	if b < 1 {
+		panic(fmt.Sprintf("batch size must be at least 1: got %v", b))
+	}
+	if len(maskCommitmentsBatch) != b {
+		panic("inconsistent batch size")
+	}
+
+	shiftedIndices := make([]secp256k1.Fn, len(indices))
+	for i := range indices {
+		if indices[i].Eq(&lostIndex) {
+			panic("lost index is one of the helper indices")
+		}
+		shiftedIndices[i] = shiftIndex(&indices[i], &lostIndex)
+	}
+
+	shiftedCommitmentBatch := make([]shamir.Commitment, b)
+	commitmentBatchCopy := make([]shamir.Commitment, b)
+	for i := range shiftedCommitmentBatch {
+		commitmentBatchCopy[i].Set(commitmentBatch[i])
+		shiftedCommitmentBatch[i].Set(commitmentBatch[i])
+		for _, maskCommitment := range maskCommitmentsBatch[i] {
+			shiftedCommitmentBatch[i].Add(shiftedCommitmentBatch[i], maskCommitment)
+		}
+		shiftCommitment(shiftedCommitmentBatch[i], &lostIndex)
+	}
+
+	return Recoverer{
+		opener:          open.New(instance, shiftedCommitmentBatch, shiftedIndices, h),
+		lostIndex:       lostIndex,
+		commitmentBatch: commitmentBatchCopy,
+		h:               h,
+	}
+}
+
+// Instance returns the instance ID of the recoverer.
+func (recoverer Recoverer) Instance() params.InstanceID {
+	return recoverer.opener.Instance()
+}
+
+// HandleShareBatch applies a state transition upon receiving a batch of
+// blinded shares from a helper. If the share batch is invalid in any way, an
+// error is returned; the errors are the same as for open.Opener. If the given
+// share batch was the kth valid share batch to be received, the lost shares
+// are reconstructed and returned, otherwise the return value is nil.
+func (recoverer *Recoverer) HandleShareBatch(
+	instance params.InstanceID,
+	shareBatch shamir.VerifiableShares,
+) (shamir.VerifiableShares, error) {
+	shifted := make(shamir.VerifiableShares, len(shareBatch))
+	for i := range shareBatch {
+		shifted[i] = shareBatch[i]
+		shifted[i].Share.Index = shiftIndex(&shareBatch[i].Share.Index, &recoverer.lostIndex)
+	}
+
+	secrets, decommitments, err := recoverer.opener.HandleShareBatch(instance, shifted)
+	if err != nil {
+		return nil, err
+	}
+	if secrets == nil {
+		return nil, nil
+	}
+
+	shares := make(shamir.VerifiableShares, len(secrets))
+	for i := range shares {
+		share := shamir.NewShare(recoverer.lostIndex, secrets[i])
+		shares[i] = shamir.NewVerifiableShare(share, decommitments[i])
+		if !shamir.IsValid(recoverer.h, &recoverer.commitmentBatch[i], &shares[i]) {
+			return nil, ErrInvalidRecoveredShare
+		}
+	}
+	return shares, nil
+}
+
+// randomVanishingPoly sets the given coefficients to those of a random
+// polynomial that is zero at the given point.
+func randomVanishingPoly(coeffs []secp256k1.Fn, x *secp256k1.Fn) {
+	coeffs[0].SetU16(0)
+	for i := 1; i < len(coeffs); i++ {
+		coeffs[i] = secp256k1.RandomFn()
+	}
+	eval := msm.EvalPoly(coeffs, x)
+	coeffs[0].Negate(&eval)
+}
+
+// shiftCommitment replaces the commitment to the polynomial f(x) with the
+// commitment to the polynomial f(x + a) in place.
+func shiftCommitment(commitment shamir.Commitment, a *secp256k1.Fn) {
+	k := len(commitment)
+	var term secp256k1.Point
+	for i := 0; i < k-1; i++ {
+		for j := k - 2; j >= i; j-- {
+			term.Scale(&commitment[j+1], a)
+			commitment[j].Add(&commitment[j], &term)
+		}
+	}
+}
+
+// shiftIndex returns the index x - a.
+func shiftIndex(x, a *secp256k1.Fn) secp256k1.Fn {
+	var shifted secp256k1.Fn
+	shifted.Negate(a)
+	shifted.Add(&shifted, x)
+	return shifted
+}
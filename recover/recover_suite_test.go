@@ -0,0 +1,13 @@
+package recover_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRecover(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Recover Suite")
+}
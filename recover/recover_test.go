@@ -0,0 +1,261 @@
+package recover_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/mpc/recover"
+
+	"github.com/renproject/mpc/brng"
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+)
+
+var _ = Describe("Recover", func() {
+	n := 10
+	k := 4
+	b := 3
+
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
+	// Setup returns the indices of the helpers, the lost index, the sharings
+	// of a batch of random secrets with the lost share being the first share
+	// in each sharing, and the output of the mask consensus step where the
+	// mask sharings from the first k helpers are chosen. In the returned mask
+	// shares, maskShares[i] is the input for the helper with index
+	// helperIndices[i].
+	Setup := func(h secp256k1.Point) (
+		[]secp256k1.Fn, secp256k1.Fn,
+		[]shamir.VerifiableShares, []shamir.Commitment,
+		[]Helper, [][]shamir.VerifiableShares, [][]shamir.Commitment,
+	) {
+		indices := shamirutil.RandomIndices(n)
+		lostIndex := indices[0]
+		helperIndices := indices[1:]
+
+		sharesBatch := make([]shamir.VerifiableShares, b)
+		commitmentBatch := make([]shamir.Commitment, b)
+		for i := range sharesBatch {
+			sharesBatch[i] = make(shamir.VerifiableShares, n)
+			commitmentBatch[i] = shamir.NewCommitmentWithCapacity(k)
+			shamir.VShareSecret(&sharesBatch[i], &commitmentBatch[i], indices, h, secp256k1.RandomFn(), k)
+		}
+
+		helpers := make([]Helper, n-1)
+		table := make([][]shamir.VerifiableShares, n-1)
+		maskCommitmentsBatch := make([][]shamir.Commitment, b)
+		for i := range maskCommitmentsBatch {
+			maskCommitmentsBatch[i] = make([]shamir.Commitment, k)
+		}
+		for i, index := range helperIndices {
+			var sharings []shamir.VerifiableShares
+			helper, maskSharings := NewHelper(b, k, index, lostIndex, helperIndices, h)
+			helpers[i] = helper
+			if i < k {
+				for j, sharing := range maskSharings {
+					maskCommitmentsBatch[j][i] = sharing.Commitment
+					sharings = append(sharings, sharing.Shares)
+				}
+				table[i] = sharings
+			}
+		}
+
+		maskSharesBatches := make([][]shamir.VerifiableShares, n-1)
+		for p := range maskSharesBatches {
+			maskSharesBatches[p] = make([]shamir.VerifiableShares, b)
+			for i := range maskSharesBatches[p] {
+				maskSharesBatches[p][i] = make(shamir.VerifiableShares, k)
+				for j := range maskSharesBatches[p][i] {
+					maskSharesBatches[p][i][j] = table[j][i][p]
+				}
+			}
+		}
+
+		return helperIndices, lostIndex, sharesBatch, commitmentBatch, helpers, maskSharesBatches, maskCommitmentsBatch
+	}
+
+	HelperShares := func(sharesBatch []shamir.VerifiableShares, p int) shamir.VerifiableShares {
+		shareBatch := make(shamir.VerifiableShares, b)
+		for i := range shareBatch {
+			shareBatch[i] = sharesBatch[i][p+1]
+		}
+		return shareBatch
+	}
+
+	Context("mask sharings", func() {
+		Specify("mask sharings should be valid and vanish at the lost index", func() {
+			h := secp256k1.RandomPoint()
+			indices := shamirutil.RandomIndices(n)
+			_, sharings := NewHelper(b, k, indices[1], indices[0], indices[1:], h)
+			Expect(len(sharings)).To(Equal(b))
+			for _, sharing := range sharings {
+				Expect(shamirutil.VsharesAreConsistent(sharing.Shares, k)).To(BeTrue())
+				for _, share := range sharing.Shares {
+					Expect(shamir.IsValid(h, &sharing.Commitment, &share)).To(BeTrue())
+				}
+				zero := shamir.NewVerifiableShare(
+					shamir.NewShare(indices[0], secp256k1.NewFnFromU16(0)),
+					secp256k1.NewFnFromU16(0),
+				)
+				Expect(shamir.IsValid(h, &sharing.Commitment, &zero)).To(BeTrue())
+			}
+		})
+
+		Specify("valid mask consensus outputs", func() {
+			h := secp256k1.RandomPoint()
+			_, _, _, _, helpers, maskShares, maskComs := Setup(h)
+			for i, helper := range helpers {
+				Expect(helper.IsValid(maskShares[i], maskComs, k)).To(Succeed())
+				Expect(helper.IsValid(nil, maskComs, k)).To(Succeed())
+			}
+		})
+
+		Context("error cases", func() {
+			var helper Helper
+			var maskShares []shamir.VerifiableShares
+			var maskComs [][]shamir.Commitment
+			var h secp256k1.Point
+
+			BeforeEach(func() {
+				var helpers []Helper
+				var maskSharesBatches [][]shamir.VerifiableShares
+				h = secp256k1.RandomPoint()
+				_, _, _, _, helpers, maskSharesBatches, maskComs = Setup(h)
+				i := rand.Intn(n - 1)
+				helper = helpers[i]
+				maskShares = maskSharesBatches[i]
+			})
+
+			Specify("incorrect batch size", func() {
+				err := helper.IsValid(maskShares[1:], maskComs, k)
+				Expect(err).To(Equal(ErrIncorrectSharesBatchSize))
+
+				err = helper.IsValid(maskShares, maskComs[1:], k)
+				Expect(err).To(Equal(ErrIncorrectCommitmentsBatchSize))
+			})
+
+			Specify("not enough contributions", func() {
+				err := helper.IsValid(maskShares, maskComs, k+1)
+				Expect(err).To(Equal(ErrNotEnoughContributions))
+			})
+
+			Specify("incorrect input dimensions", func() {
+				maskComs[1] = maskComs[1][1:]
+				err := helper.IsValid(maskShares, maskComs, k-1)
+				Expect(err).To(Equal(ErrInvalidCommitmentDimensions))
+			})
+
+			Specify("mask that does not vanish at the lost index", func() {
+				indices := shamirutil.RandomIndices(n)
+				_, sharings := brng.New(uint32(b), uint32(k), indices, indices[0], h)
+				maskComs[0][0] = sharings[0].Commitment
+				err := helper.IsValid(maskShares, maskComs, k)
+				Expect(err).To(Equal(ErrMaskDoesNotVanish))
+			})
+
+			Specify("share contributions length", func() {
+				maskShares[1] = maskShares[1][1:]
+				err := helper.IsValid(maskShares, maskComs, k)
+				Expect(err).To(Equal(ErrInvalidShareDimensions))
+			})
+
+			Specify("incorrect share index", func() {
+				maskShares[0][0].Share.Index = secp256k1.RandomFn()
+				err := helper.IsValid(maskShares, maskComs, k)
+				Expect(err).To(Equal(ErrIncorrectIndex))
+			})
+
+			Specify("invalid shares", func() {
+				maskShares[0][0].Share.Value = secp256k1.RandomFn()
+				err := helper.IsValid(maskShares, maskComs, k)
+				Expect(err).To(Equal(ErrInvalidShares))
+			})
+		})
+	})
+
+	Context("recovering", func() {
+		It("should recover the lost shares from k valid blinded shares", func() {
+			h := secp256k1.RandomPoint()
+			helperIndices, lostIndex, sharesBatch, coms, _, maskShares, maskComs := Setup(h)
+			recoverer := New(instance, lostIndex, helperIndices, coms, maskComs, h)
+
+			order := rand.Perm(n - 1)
+			for i, p := range order[:k] {
+				blinded := BlindShares(HelperShares(sharesBatch, p), maskShares[p])
+				for j := range blinded {
+					Expect(blinded[j].Share.IndexEq(&helperIndices[p])).To(BeTrue())
+					Expect(blinded[j].Eq(&sharesBatch[j][p+1])).To(BeFalse())
+				}
+
+				recovered, err := recoverer.HandleShareBatch(instance, blinded)
+				Expect(err).ToNot(HaveOccurred())
+				if i < k-1 {
+					Expect(recovered).To(BeNil())
+					continue
+				}
+
+				Expect(len(recovered)).To(Equal(b))
+				for j := range recovered {
+					Expect(recovered[j].Eq(&sharesBatch[j][0])).To(BeTrue())
+					Expect(shamir.IsValid(h, &coms[j], &recovered[j])).To(BeTrue())
+				}
+			}
+		})
+
+		It("should detect helpers that send incorrect blinded shares", func() {
+			h := secp256k1.RandomPoint()
+			helperIndices, lostIndex, sharesBatch, coms, _, maskShares, maskComs := Setup(h)
+			recoverer := New(instance, lostIndex, helperIndices, coms, maskComs, h)
+
+			// A helper that sends shares for a different instance.
+			var otherInstance params.InstanceID
+			rand.Read(otherInstance[:])
+			blinded := BlindShares(HelperShares(sharesBatch, 0), maskShares[0])
+			_, err := recoverer.HandleShareBatch(otherInstance, blinded)
+			Expect(err).To(Equal(open.ErrIncorrectInstance))
+
+			// A helper that does not blind its shares.
+			unblinded := HelperShares(sharesBatch, 0)
+			_, err = recoverer.HandleShareBatch(instance, unblinded)
+			Expect(err).To(Equal(open.ErrInvalidShares))
+
+			// A helper that modifies its blinded shares.
+			blinded = BlindShares(HelperShares(sharesBatch, 1), maskShares[1])
+			blinded[rand.Intn(b)].Share.Value = secp256k1.RandomFn()
+			_, err = recoverer.HandleShareBatch(instance, blinded)
+			Expect(err).To(Equal(open.ErrInvalidShares))
+
+			// A helper that sends a share for an index that is not a helper.
+			blinded = BlindShares(HelperShares(sharesBatch, 2), maskShares[2])
+			for i := range blinded {
+				blinded[i].Share.Index = lostIndex
+			}
+			_, err = recoverer.HandleShareBatch(instance, blinded)
+			Expect(err).To(Equal(open.ErrIndexOutOfRange))
+
+			// The honest helpers should still allow recovery.
+			var recovered shamir.VerifiableShares
+			for p := 3; p < k+3; p++ {
+				recovered, err = recoverer.HandleShareBatch(instance, BlindShares(HelperShares(sharesBatch, p), maskShares[p]))
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(len(recovered)).To(Equal(b))
+			for j := range recovered {
+				Expect(recovered[j].Eq(&sharesBatch[j][0])).To(BeTrue())
+			}
+		})
+
+		It("should panic if the lost index is a helper index", func() {
+			h := secp256k1.RandomPoint()
+			helperIndices, lostIndex, _, coms, _, _, maskComs := Setup(h)
+			indices := append([]secp256k1.Fn{lostIndex}, helperIndices...)
+			Expect(func() { New(instance, lostIndex, indices, coms, maskComs, h) }).To(Panic())
+			Expect(func() { NewHelper(b, k, indices[1], lostIndex, indices, h) }).To(Panic())
+		})
+	})
+})
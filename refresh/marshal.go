@@ -0,0 +1,49 @@
+package refresh
+
+import (
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/mpc/rng"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// SizeHint implements the surge.SizeHinter interface.
+func (refresher Refresher) SizeHint() int {
+	return refresher.rzger.SizeHint() +
+		surge.SizeHint(refresher.shareBatch)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (refresher Refresher) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := refresher.rzger.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(refresher.shareBatch, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (refresher *Refresher) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := refresher.rzger.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&refresher.shareBatch, buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (refresher Refresher) Generate(rand *rand.Rand, size int) reflect.Value {
+	rzger := rng.RNGer{}.Generate(rand, size).Interface().(rng.RNGer)
+	b := rand.Intn(size/32+1) + 1
+	shareBatch := make(shamir.VerifiableShares, b)
+	for i := range shareBatch {
+		shareBatch[i] = shamir.NewVerifiableShare(
+			shamir.NewShare(secp256k1.RandomFn(), secp256k1.RandomFn()),
+			secp256k1.RandomFn(),
+		)
+	}
+	return reflect.ValueOf(Refresher{rzger, shareBatch})
+}
@@ -0,0 +1,166 @@
+package refresh
+
+import (
+	"fmt"
+
+	"github.com/renproject/mpc/brng"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rng"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// A Refresher is a state machine that implements proactive refreshing of the
+// shares of a key for a fixed set of players. After the refresh, each player
+// holds a new share of the same secret, and shares from before the refresh
+// can not be combined with shares from after it.
+//
+// The refresh consists of an instance of RZG, which produces a random sharing
+// of zero, followed by each player adding its share of zero to its share of
+// the key. The commitment for the sharing of zero has the point at infinity as
+// its first element, and so the first element of the updated commitment is the
+// same as that of the original commitment. This means that the updated
+// commitment is still a commitment to the original key; see IsValidCommitment.
+//
+// The state machine starts with the output of the consensus step of BRNG,
+// which needs to have a batch size given by BRNGBatchSize. The state machine
+// supports batching, in which case all of the keys in the batch need to have
+// the same reconstruction threshold.
+type Refresher struct {
+	rzger      rng.RNGer
+	shareBatch shamir.VerifiableShares
+}
+
+// BRNGBatchSize returns the batch size that the BRNG instance, whose output is
+// used to construct a Refresher, needs to have in order to refresh a batch of
+// b keys with reconstruction threshold k.
+func BRNGBatchSize(b, k int) int {
+	return b * (k - 1)
+}
+
+// New returns a new Refresher state machine for the given instance ID along
+// with the directed openings for the RZG instance that are to be sent to the
+// other players, indexed by the index of the player that the openings are
+// destined for, and the updated commitments. The share and commitment batches
+// are the current shares and commitments for the keys that are being
+// refreshed. The arguments for the BRNG output are the same as for
+// brng.HandleConsensusOutput; the shares are expected to have been checked
+// using brng.BRNGer.IsValid and are nil if they were not valid. In this case
+// the returned map of openings will also be nil.
+//
+// Panics: This function will panic if any of the following conditions are
+// met.
+//	- The Pedersen parameter is insecure.
+//	- The batch size is less than 1.
+//	- The share and commitment batches have different batch sizes.
+//	- The reconstruction threshold (k) is less than 2.
+//	- Not all of the commitments have the same reconstruction threshold (k).
+//	- The BRNG batch size is not equal to BRNGBatchSize(b, k).
+//	- Any of the conditions for which rng.New would panic.
+func New(
+	instance params.InstanceID,
+	ownIndex secp256k1.Fn,
+	indices []secp256k1.Fn,
+	h secp256k1.Point,
+	shareBatch shamir.VerifiableShares,
+	commitmentBatch []shamir.Commitment,
+	brngSharesBatch []shamir.VerifiableShares,
+	brngCommitmentsBatch [][]shamir.Commitment,
+) (Refresher, map[secp256k1.Fn]shamir.VerifiableShares, []shamir.Commitment) {
+	if !params.ValidPedersenParameter(h) {
+		panic("insecure choice of pedersen parameter")
+	}
+	b := len(commitmentBatch)
+	if b < 1 {
+		panic(fmt.Sprintf("batch size must be at least 1: got %v", b))
+	}
+	if len(shareBatch) != b {
+		panic("inconsistent batch size")
+	}
+	k := commitmentBatch[0].Len()
+	if k < 2 {
+		panic(fmt.Sprintf("k must be at least 2: got %v", k))
+	}
+	for _, commitment := range commitmentBatch {
+		if commitment.Len() != k {
+			panic("inconsistent threshold (k) for commitments")
+		}
+	}
+	if len(brngCommitmentsBatch) != BRNGBatchSize(b, k) {
+		panic(fmt.Sprintf(
+			"invalid brng batch size: expected %v, got %v",
+			BRNGBatchSize(b, k), len(brngCommitmentsBatch),
+		))
+	}
+
+	shareSums, commitmentSums := brng.HandleConsensusOutput(brngSharesBatch, brngCommitmentsBatch)
+
+	rzgCommitmentBatch := make([][]shamir.Commitment, b)
+	for i := 0; i < b; i++ {
+		rzgCommitmentBatch[i] = commitmentSums[i*(k-1) : (i+1)*(k-1)]
+	}
+	var rzgShareBatch []shamir.VerifiableShares
+	if shareSums != nil {
+		rzgShareBatch = make([]shamir.VerifiableShares, b)
+		for i := 0; i < b; i++ {
+			rzgShareBatch[i] = shareSums[i*(k-1) : (i+1)*(k-1)]
+		}
+	}
+
+	rzger, rzgOpenings, rzgCommitments := rng.New(instance, ownIndex, indices, h, rzgShareBatch, rzgCommitmentBatch, true)
+
+	newCommitmentBatch := make([]shamir.Commitment, b)
+	for i := range newCommitmentBatch {
+		newCommitmentBatch[i].Set(commitmentBatch[i])
+		newCommitmentBatch[i].Add(newCommitmentBatch[i], rzgCommitments[i])
+	}
+
+	shareBatchCopy := make(shamir.VerifiableShares, b)
+	copy(shareBatchCopy, shareBatch)
+	refresher := Refresher{
+		rzger:      rzger,
+		shareBatch: shareBatchCopy,
+	}
+	return refresher, rzgOpenings, newCommitmentBatch
+}
+
+// Instance returns the instance ID of the refresher.
+func (refresher Refresher) Instance() params.InstanceID {
+	return refresher.rzger.Instance()
+}
+
+// HandleShareBatch applies a state transition upon receiving the directed
+// openings for the RZG instance from another player. If the share batch is
+// invalid, an error is returned. If the given share batch was the kth valid
+// batch to be received, the RZG instance completes and the refreshed shares
+// are returned, otherwise the return value is nil. The refreshed shares are
+// valid with respect to the updated commitments that were returned by New.
+func (refresher *Refresher) HandleShareBatch(
+	instance params.InstanceID,
+	shareBatch shamir.VerifiableShares,
+) (shamir.VerifiableShares, error) {
+	zeroShares, err := refresher.rzger.HandleShareBatch(instance, shareBatch)
+	if err != nil {
+		return nil, err
+	}
+	if zeroShares == nil {
+		return nil, nil
+	}
+	output := make(shamir.VerifiableShares, len(zeroShares))
+	for i := range output {
+		output[i].Add(&refresher.shareBatch[i], &zeroShares[i])
+	}
+	return output, nil
+}
+
+// IsValidCommitment returns true if the updated commitment is a commitment to
+// the same secret as the original commitment, that is, if they have the same
+// reconstruction threshold and the same first element. Players that know the
+// public key that corresponds to the original commitment can use this to check
+// that the updated commitment is still for the same key.
+func IsValidCommitment(commitment, updatedCommitment shamir.Commitment) bool {
+	if commitment.Len() != updatedCommitment.Len() || commitment.Len() == 0 {
+		return false
+	}
+	return commitment[0].Eq(&updatedCommitment[0])
+}
@@ -0,0 +1,13 @@
+package refresh_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRefresh(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Refresh Suite")
+}
@@ -0,0 +1,192 @@
+package refresh_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/refresh"
+	"github.com/renproject/mpc/refresh/refreshutil"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+)
+
+var _ = Describe("Refresh", func() {
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
+	Context("state transitions", func() {
+		n := 10
+		k := 3
+		b := 2
+
+		Specify("the updated commitments should commit to the same keys", func() {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			_, shares, coms := refreshutil.Keys(indices, k, b, h)
+			brngShares, brngComs := refreshutil.BRNGOutput(indices, k, b, h)
+			_, _, newComs := refresh.New(instance, indices[0], indices, h, shares[0], coms, brngShares[0], brngComs)
+			Expect(len(newComs)).To(Equal(b))
+			for i := range newComs {
+				Expect(newComs[i].Eq(coms[i])).To(BeFalse())
+				Expect(refresh.IsValidCommitment(coms[i], newComs[i])).To(BeTrue())
+			}
+		})
+
+		Specify("invalid commitments should be detected", func() {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			_, _, coms := refreshutil.Keys(indices, k, 1, h)
+			_, _, otherComs := refreshutil.Keys(indices, k, 1, h)
+			Expect(refresh.IsValidCommitment(coms[0], otherComs[0])).To(BeFalse())
+			Expect(refresh.IsValidCommitment(coms[0], coms[0][:k-1])).To(BeFalse())
+		})
+
+		Specify("invalid share batches should be rejected", func() {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			_, shares, coms := refreshutil.Keys(indices, k, b, h)
+			brngShares, brngComs := refreshutil.BRNGOutput(indices, k, b, h)
+			refresher, _, _ := refresh.New(instance, indices[0], indices, h, shares[0], coms, brngShares[0], brngComs)
+			_, openings, _ := refresh.New(instance, indices[1], indices, h, shares[1], coms, brngShares[1], brngComs)
+
+			var otherInstance params.InstanceID
+			rand.Read(otherInstance[:])
+			output, err := refresher.HandleShareBatch(otherInstance, openings[indices[0]])
+			Expect(output).To(BeNil())
+			Expect(err).To(Equal(open.ErrIncorrectInstance))
+
+			output, err = refresher.HandleShareBatch(instance, openings[indices[0]][1:])
+			Expect(output).To(BeNil())
+			Expect(err).To(Equal(open.ErrIncorrectBatchSize))
+
+			openings[indices[0]][0].Share.Value = secp256k1.RandomFn()
+			output, err = refresher.HandleShareBatch(instance, openings[indices[0]])
+			Expect(output).To(BeNil())
+			Expect(err).To(Equal(open.ErrInvalidShares))
+		})
+
+		Specify("players with invalid brng shares should not send openings", func() {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			_, shares, coms := refreshutil.Keys(indices, k, b, h)
+			_, brngComs := refreshutil.BRNGOutput(indices, k, b, h)
+			_, openings, _ := refresh.New(instance, indices[0], indices, h, shares[0], coms, nil, brngComs)
+			Expect(openings).To(BeNil())
+		})
+
+		Specify("brng output with an invalid batch size should panic", func() {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			_, shares, coms := refreshutil.Keys(indices, k, b, h)
+			brngShares, brngComs := refreshutil.BRNGOutput(indices, k, b, h)
+			Expect(func() {
+				refresh.New(instance, indices[0], indices, h, shares[0], coms, brngShares[0][1:], brngComs[1:])
+			}).To(Panic())
+		})
+	})
+
+	Context("network", func() {
+		n := 15
+		k := 4
+		b := 3
+		t := k - 1
+
+		tys := []refreshutil.MachineType{
+			refreshutil.Offline,
+			refreshutil.Malicious,
+		}
+		for _, ty := range tys {
+			ty := ty
+
+			Specify("all honest nodes should refresh their shares of the same keys", func() {
+				indices := shamirutil.RandomIndices(n)
+				h := secp256k1.RandomPoint()
+				machines := make([]mpcutil.Machine, n)
+
+				keys, shares, coms := refreshutil.Keys(indices, k, b, h)
+				brngShares, brngComs := refreshutil.BRNGOutput(indices, k, b, h)
+
+				ids := make([]mpcutil.ID, n)
+				for i := range ids {
+					ids[i] = mpcutil.ID(i + 1)
+				}
+				dishonestIDs := make(map[mpcutil.ID]struct{}, t)
+				{
+					tmp := make([]mpcutil.ID, n)
+					copy(tmp, ids)
+					rand.Shuffle(len(tmp), func(i, j int) {
+						tmp[i], tmp[j] = tmp[j], tmp[i]
+					})
+					for _, id := range tmp[:t] {
+						dishonestIDs[id] = struct{}{}
+					}
+				}
+				machineType := make(map[mpcutil.ID]refreshutil.MachineType, n)
+				for _, id := range ids {
+					if _, ok := dishonestIDs[id]; ok {
+						machineType[id] = ty
+					} else {
+						machineType[id] = refreshutil.Honest
+					}
+				}
+
+				honestMachines := make([]*refreshutil.Machine, 0, n-t)
+				for i, id := range ids {
+					var machine mpcutil.Machine
+					switch machineType[id] {
+					case refreshutil.Offline:
+						m := mpcutil.OfflineMachine(ids[i])
+						machine = &m
+					case refreshutil.Malicious:
+						m := refreshutil.NewMaliciousMachine(ids, id, instance, indices, b)
+						machine = &m
+					case refreshutil.Honest:
+						m := refreshutil.NewMachine(instance, shares[i], coms, brngShares[i], brngComs, ids, id, indices, h)
+						honestMachines = append(honestMachines, &m)
+						machine = &m
+					default:
+						panic("unexpected machine type")
+					}
+					machines[i] = machine
+				}
+
+				shuffleMsgs, _ := mpcutil.MessageShufflerDropper(ids, 0)
+				network := mpcutil.NewNetwork(machines, shuffleMsgs)
+				network.SetCaptureHist(true)
+				err := network.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				for i := 0; i < b; i++ {
+					com := honestMachines[0].Commitments[i]
+					Expect(refresh.IsValidCommitment(coms[i], com)).To(BeTrue())
+
+					newShares := make(shamir.Shares, 0, n)
+					vshares := make(shamir.VerifiableShares, 0, n)
+					for _, machine := range honestMachines {
+						Expect(machine.Output).ToNot(BeNil())
+						Expect(machine.Commitments[i].Eq(com)).To(BeTrue())
+						vshares = append(vshares, machine.Output[i])
+						newShares = append(newShares, machine.Output[i].Share)
+					}
+
+					Expect(shamirutil.VsharesAreConsistent(vshares, k-1)).To(BeFalse())
+					Expect(shamirutil.VsharesAreConsistent(vshares, k)).To(BeTrue())
+					for _, vshare := range vshares {
+						Expect(shamir.IsValid(h, &com, &vshare)).To(BeTrue())
+					}
+
+					// The refreshed shares should reconstruct to the original
+					// key.
+					secret := shamir.Open(newShares)
+					Expect(secret.Eq(&keys[i])).To(BeTrue())
+				}
+			})
+		}
+	})
+})
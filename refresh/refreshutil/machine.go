@@ -0,0 +1,135 @@
+package refreshutil
+
+import (
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/refresh"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// Machine represents a player that honestly carries out the refresh protocol.
+type Machine struct {
+	OwnID mpcutil.ID
+	refresh.Refresher
+	InitMsgs    []Message
+	Commitments []shamir.Commitment
+	Output      shamir.VerifiableShares
+}
+
+// NewMachine constructs a new honest machine for a refresh network test. It
+// will have the given instance ID, key shares, commitments, BRNG output and
+// ID. The player with ID ids[i] is assumed to have index indices[i].
+func NewMachine(
+	instance params.InstanceID,
+	shareBatch shamir.VerifiableShares, commitmentBatch []shamir.Commitment,
+	brngSharesBatch []shamir.VerifiableShares, brngCommitmentsBatch [][]shamir.Commitment,
+	ids []mpcutil.ID, ownID mpcutil.ID, indices []secp256k1.Fn, h secp256k1.Point,
+) Machine {
+	var ownIndex secp256k1.Fn
+	for i, id := range ids {
+		if id == ownID {
+			ownIndex = indices[i]
+		}
+	}
+	refresher, openings, commitments := refresh.New(
+		instance, ownIndex, indices, h,
+		shareBatch, commitmentBatch,
+		brngSharesBatch, brngCommitmentsBatch,
+	)
+	var initialMessages []Message
+	if openings != nil {
+		initialMessages = make([]Message, 0, len(ids)-1)
+		for i, id := range ids {
+			if id == ownID {
+				continue
+			}
+			initialMessages = append(initialMessages, Message{
+				FromID:   ownID,
+				ToID:     id,
+				Instance: instance,
+				Shares:   openings[indices[i]],
+			})
+		}
+	}
+	return Machine{
+		OwnID:       ownID,
+		Refresher:   refresher,
+		InitMsgs:    initialMessages,
+		Commitments: commitments,
+	}
+}
+
+// ID implements the Machine interface.
+func (m Machine) ID() mpcutil.ID { return m.OwnID }
+
+// InitialMessages implements the Machine interface.
+func (m Machine) InitialMessages() []mpcutil.Message {
+	msgs := make([]mpcutil.Message, len(m.InitMsgs))
+	for i := range m.InitMsgs {
+		msgs[i] = &m.InitMsgs[i]
+	}
+	return msgs
+}
+
+// Handle implements the Machine interface.
+func (m *Machine) Handle(msg mpcutil.Message) []mpcutil.Message {
+	message := msg.(*Message)
+	output, _ := m.Refresher.HandleShareBatch(message.Instance, message.Shares)
+	if output != nil {
+		m.Output = output
+	}
+	return nil
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (m Machine) SizeHint() int {
+	return m.OwnID.SizeHint() +
+		m.Refresher.SizeHint() +
+		surge.SizeHint(m.InitMsgs) +
+		surge.SizeHint(m.Commitments) +
+		surge.SizeHint(m.Output)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (m Machine) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = m.Refresher.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.InitMsgs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.Commitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(m.Output, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (m *Machine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = m.Refresher.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.InitMsgs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.Commitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&m.Output, buf, rem)
+}
@@ -0,0 +1,73 @@
+package refreshutil
+
+import (
+	"github.com/renproject/mpc/brng"
+	"github.com/renproject/mpc/refresh"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// Keys returns a batch of b random keys along with verifiable sharings of the
+// keys with reconstruction threshold k. In the returned shares, shares[i] is
+// the share batch for the player with index indices[i].
+func Keys(
+	indices []secp256k1.Fn,
+	k, b int,
+	h secp256k1.Point,
+) ([]secp256k1.Fn, []shamir.VerifiableShares, []shamir.Commitment) {
+	n := len(indices)
+	keys := make([]secp256k1.Fn, b)
+	sharesBatch := make([]shamir.VerifiableShares, n)
+	for p := range sharesBatch {
+		sharesBatch[p] = make(shamir.VerifiableShares, b)
+	}
+	commitmentBatch := make([]shamir.Commitment, b)
+	shares := make(shamir.VerifiableShares, n)
+	for i := range keys {
+		keys[i] = secp256k1.RandomFn()
+		commitmentBatch[i] = shamir.NewCommitmentWithCapacity(k)
+		shamir.VShareSecret(&shares, &commitmentBatch[i], indices, h, keys[i], k)
+		for p := range sharesBatch {
+			sharesBatch[p][i] = shares[p]
+		}
+	}
+	return keys, sharesBatch, commitmentBatch
+}
+
+// BRNGOutput returns a random valid output of the consensus step of an
+// instance of BRNG that can be used to refresh a batch of b keys with
+// reconstruction threshold k. The consensus table is formed from the rows of k
+// players. In the returned shares, shares[i] is the input for player i to
+// brng.HandleConsensusOutput, and the commitments are the same for all
+// players.
+func BRNGOutput(
+	indices []secp256k1.Fn,
+	k, b int,
+	h secp256k1.Point,
+) ([][]shamir.VerifiableShares, [][]shamir.Commitment) {
+	n := len(indices)
+	batchSize := refresh.BRNGBatchSize(b, k)
+	table := make([][]brng.Sharing, k)
+	for i := range table {
+		_, table[i] = brng.New(uint32(batchSize), uint32(k), indices, indices[i], h)
+	}
+
+	commitmentsBatch := make([][]shamir.Commitment, batchSize)
+	for i := range commitmentsBatch {
+		commitmentsBatch[i] = make([]shamir.Commitment, k)
+		for j := range commitmentsBatch[i] {
+			commitmentsBatch[i][j] = table[j][i].Commitment
+		}
+	}
+	sharesBatches := make([][]shamir.VerifiableShares, n)
+	for p := range sharesBatches {
+		sharesBatches[p] = make([]shamir.VerifiableShares, batchSize)
+		for i := range sharesBatches[p] {
+			sharesBatches[p][i] = make(shamir.VerifiableShares, k)
+			for j := range sharesBatches[p][i] {
+				sharesBatches[p][i][j] = table[j][i].Shares[p]
+			}
+		}
+	}
+	return sharesBatches, commitmentsBatch
+}
@@ -0,0 +1,45 @@
+package reshare
+
+import "errors"
+
+var (
+	// ErrIncorrectCommitmentsBatchSize is returned when the batch size of the
+	// given commitments is not equal to the batch size of the Resharer.
+	ErrIncorrectCommitmentsBatchSize = errors.New("incorrect commitments batch size")
+
+	// ErrIncorrectSharesBatchSize is returned when the batch size of the given
+	// shares is not equal to the batch size of the Resharer.
+	ErrIncorrectSharesBatchSize = errors.New("incorrect shares batch size")
+
+	// ErrInvalidCommitmentDimensions is returned when the batch of commitments
+	// has inconsistent dimensions. This can occur when not all slices in the
+	// batch have the same length as the number of dealers, or when not all
+	// commitments have the new threshold.
+	ErrInvalidCommitmentDimensions = errors.New("invalid commitment dimensions")
+
+	// ErrInvalidShareDimensions is returned when not all slices in the batch
+	// of shares have the same length as the number of dealers.
+	ErrInvalidShareDimensions = errors.New("invalid share dimensions")
+
+	// ErrInvalidDealerIndex is returned when a dealer index is not in the set
+	// of old indices, or when the same dealer index appears more than once.
+	ErrInvalidDealerIndex = errors.New("invalid dealer index")
+
+	// ErrInvalidCommitment is returned when the commitment for a subsharing
+	// does not commit to the share of the dealer, that is, when its first
+	// element is not equal to the old commitment evaluated at the index of the
+	// dealer.
+	ErrInvalidCommitment = errors.New("invalid commitment")
+
+	// ErrInvalidShares is returned when not all of the given shares are valid
+	// with respect to their corresponding commitments.
+	ErrInvalidShares = errors.New("invalid shares")
+
+	// ErrIncorrectIndex is returned when not all of the shares have index
+	// equal to the new index for the Resharer.
+	ErrIncorrectIndex = errors.New("incorrect index")
+
+	// ErrNotEnoughContributions is returned when the number of dealers is
+	// smaller than the reconstruction threshold of the old sharing.
+	ErrNotEnoughContributions = errors.New("not enough contributions")
+)
@@ -0,0 +1,92 @@
+package reshare
+
+import (
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// Generate implements the quick.Generator interface.
+func (resharer Resharer) Generate(rand *rand.Rand, size int) reflect.Value {
+	size /= 2
+	oldIndices := make([]secp256k1.Fn, rand.Intn(size/4+1))
+	for i := range oldIndices {
+		oldIndices[i] = secp256k1.RandomFn()
+	}
+	oldCommitmentBatch := make([]shamir.Commitment, rand.Intn(size/4+1)+1)
+	for i := range oldCommitmentBatch {
+		oldCommitmentBatch[i] = shamir.Commitment{}.Generate(rand, size/4).Interface().(shamir.Commitment)
+	}
+	r := Resharer{
+		batchSize:          rand.Uint32(),
+		newK:               rand.Uint32(),
+		newIndex:           secp256k1.RandomFn(),
+		oldIndices:         oldIndices,
+		oldCommitmentBatch: oldCommitmentBatch,
+		h:                  secp256k1.RandomPoint(),
+	}
+	return reflect.ValueOf(r)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (resharer Resharer) SizeHint() int {
+	return surge.SizeHint(resharer.batchSize) +
+		surge.SizeHint(resharer.newK) +
+		resharer.newIndex.SizeHint() +
+		surge.SizeHint(resharer.oldIndices) +
+		surge.SizeHint(resharer.oldCommitmentBatch) +
+		resharer.h.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (resharer Resharer) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.MarshalU32(resharer.batchSize, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.MarshalU32(resharer.newK, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = resharer.newIndex.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(resharer.oldIndices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(resharer.oldCommitmentBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return resharer.h.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (resharer *Resharer) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.UnmarshalU32(&resharer.batchSize, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.UnmarshalU32(&resharer.newK, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = resharer.newIndex.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&resharer.oldIndices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&resharer.oldCommitmentBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return resharer.h.Unmarshal(buf, rem)
+}
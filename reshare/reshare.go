@@ -0,0 +1,274 @@
+package reshare
+
+import (
+	"fmt"
+
+	"github.com/renproject/mpc/brng"
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// A Resharer is a state machine for a player in the new committee of the
+// resharing protocol. The resharing protocol transfers a verifiable sharing
+// from an old set of indices and reconstruction threshold to a new set of
+// indices and threshold, without changing the secret or the first element of
+// the commitment (and hence the public key in the case that the secret is a
+// private key).
+//
+// The protocol proceeds as follows.
+//	1. Each player in the old committee creates a verifiable sharing of its
+//	share for the new committee using Subshare. The subsharing uses the
+//	decommitment of the share as the constant term of the decommitment
+//	polynomial, so that the first element of the commitment of the subsharing
+//	is equal to the old commitment evaluated at the index of the dealer.
+//	2. The old players submit their subsharings to a consensus algorithm,
+//	which decides on at least k (the old threshold) subsharings that are
+//	valid for enough players in the new committee. Players in the new
+//	committee use IsValid to check the subsharings during consensus.
+//	3. Each player in the new committee computes its new share and the new
+//	commitment using HandleConsensusOutput, which combines the subsharings
+//	using Lagrange interpolation at zero over the indices of the dealers.
+//
+// A player can be a member of both committees, in which case it acts as both
+// a dealer and a Resharer.
+//
+// The state machine supports batching, in which case all sharings in the batch
+// must have the same old indices and threshold.
+type Resharer struct {
+	batchSize          uint32
+	newK               uint32
+	newIndex           secp256k1.Fn
+	oldIndices         []secp256k1.Fn
+	oldCommitmentBatch []shamir.Commitment
+	h                  secp256k1.Point
+}
+
+// New creates a new Resharer state machine for the player in the new
+// committee with the given index. The old indices and commitments are those
+// of the sharings being reshared, and the new threshold is the reconstruction
+// threshold of the new sharings. The batch size is given by the number of old
+// commitments.
+//
+// Panics: This function will panic if the batch size or the new threshold is
+// less than 1, if the old commitments do not all have the same threshold, or
+// if the Pedersen parameter is insecure.
+func New(
+	oldIndices []secp256k1.Fn,
+	oldCommitmentBatch []shamir.Commitment,
+	newIndex secp256k1.Fn,
+	newK int,
+	h secp256k1.Point,
+) Resharer {
+	if !params.ValidPedersenParameter(h) {
+		panic("insecure choice of pedersen parameter")
+	}
+	b := len(oldCommitmentBatch)
+	if b < 1 {
+		panic(fmt.Sprintf("batch size must be at least 1: got %v", b))
+	}
+	if newK < 1 {
+		panic(fmt.Sprintf("k must be at least 1: got %v", newK))
+	}
+	oldK := oldCommitmentBatch[0].Len()
+	for _, com := range oldCommitmentBatch {
+		if com.Len() != oldK {
+			panic("inconsistent threshold (k) for old commitments")
+		}
+	}
+
+	oldIndicesCopy := make([]secp256k1.Fn, len(oldIndices))
+	copy(oldIndicesCopy, oldIndices)
+	oldCommitmentBatchCopy := make([]shamir.Commitment, b)
+	for i := range oldCommitmentBatchCopy {
+		oldCommitmentBatchCopy[i].Set(oldCommitmentBatch[i])
+	}
+	return Resharer{
+		batchSize:          uint32(b),
+		newK:               uint32(newK),
+		newIndex:           newIndex,
+		oldIndices:         oldIndicesCopy,
+		oldCommitmentBatch: oldCommitmentBatchCopy,
+		h:                  h,
+	}
+}
+
+// Subshare creates, for each share in the given batch, a verifiable sharing
+// of that share for the given new indices and new reconstruction threshold.
+// The decommitment of the share is used as the constant term of the
+// decommitment polynomial, so that the first element of the commitment for the
+// subsharing is a commitment to the share.
+//
+// Panics: This function will panic if the new threshold is less than 1 or
+// greater than the number of new indices, or if the Pedersen parameter is
+// insecure.
+func Subshare(
+	shareBatch shamir.VerifiableShares,
+	newIndices []secp256k1.Fn,
+	newK int,
+	h secp256k1.Point,
+) []brng.Sharing {
+	if !params.ValidPedersenParameter(h) {
+		panic("insecure choice of pedersen parameter")
+	}
+	if newK < 1 || newK > len(newIndices) {
+		panic(fmt.Sprintf("invalid k: expected 1 <= k <= %v, got %v", len(newIndices), newK))
+	}
+
+	coeffs := make([]secp256k1.Fn, newK)
+	decomCoeffs := make([]secp256k1.Fn, newK)
+	sharings := make([]brng.Sharing, len(shareBatch))
+	for i, share := range shareBatch {
+		coeffs[0] = share.Share.Value
+		decomCoeffs[0] = share.Decommitment
+		for j := 1; j < newK; j++ {
+			coeffs[j] = secp256k1.RandomFn()
+			decomCoeffs[j] = secp256k1.RandomFn()
+		}
+
+		sharings[i].Commitment = shamir.NewCommitmentWithCapacity(newK)
+		for j := 0; j < newK; j++ {
+			var point, hPow secp256k1.Point
+			point.BaseExp(&coeffs[j])
+			hPow.Scale(&h, &decomCoeffs[j])
+			point.Add(&point, &hPow)
+			sharings[i].Commitment.Append(point)
+		}
+
+		sharings[i].Shares = make(shamir.VerifiableShares, len(newIndices))
+		for j, index := range newIndices {
+			value := msm.EvalPoly(coeffs, &index)
+			decom := msm.EvalPoly(decomCoeffs, &index)
+			sharings[i].Shares[j] = shamir.NewVerifiableShare(shamir.NewShare(index, value), decom)
+		}
+	}
+	return sharings
+}
+
+// IsValid checks the validity of the given potential consensus output. The
+// dealer indices are the old indices of the dealers of the subsharings, and
+// sharesBatch[i][j] and commitmentsBatch[i][j] are the share for this player
+// and the commitment for the subsharing of the ith sharing in the batch by
+// the jth dealer. A return value of nil means that the consensus output can be
+// used to construct the new share and commitment. Otherwise, an error is
+// returned that describes how the output is invalid. If the shares are nil,
+// only the commitments are checked; this can be used by players that are not
+// in the new committee.
+func (resharer *Resharer) IsValid(
+	dealerIndices []secp256k1.Fn,
+	sharesBatch []shamir.VerifiableShares,
+	commitmentsBatch [][]shamir.Commitment,
+) error {
+	// Dealer validity.
+	numDealers := len(dealerIndices)
+	if numDealers < resharer.oldCommitmentBatch[0].Len() {
+		return ErrNotEnoughContributions
+	}
+	for i := range dealerIndices {
+		exists := false
+		for j := range resharer.oldIndices {
+			if dealerIndices[i].Eq(&resharer.oldIndices[j]) {
+				exists = true
+			}
+		}
+		if !exists {
+			return ErrInvalidDealerIndex
+		}
+		for j := 0; j < i; j++ {
+			if dealerIndices[i].Eq(&dealerIndices[j]) {
+				return ErrInvalidDealerIndex
+			}
+		}
+	}
+
+	// Commitments validity.
+	if uint32(len(commitmentsBatch)) != resharer.batchSize {
+		return ErrIncorrectCommitmentsBatchSize
+	}
+	for _, commitments := range commitmentsBatch {
+		if len(commitments) != numDealers {
+			return ErrInvalidCommitmentDimensions
+		}
+		for _, commitment := range commitments {
+			if uint32(commitment.Len()) != resharer.newK {
+				return ErrInvalidCommitmentDimensions
+			}
+		}
+	}
+	for i, commitments := range commitmentsBatch {
+		oldShareCommitments := msm.EvalCommitmentAll(resharer.oldCommitmentBatch[i], dealerIndices)
+		for j, commitment := range commitments {
+			if !commitment[0].Eq(&oldShareCommitments[j]) {
+				return ErrInvalidCommitment
+			}
+		}
+	}
+
+	if sharesBatch == nil {
+		return nil
+	}
+
+	// Shares validity.
+	if uint32(len(sharesBatch)) != resharer.batchSize {
+		return ErrIncorrectSharesBatchSize
+	}
+	for i, shares := range sharesBatch {
+		if len(shares) != numDealers {
+			return ErrInvalidShareDimensions
+		}
+		for j, share := range shares {
+			if !share.Share.IndexEq(&resharer.newIndex) {
+				return ErrIncorrectIndex
+			}
+			if !shamir.IsValid(resharer.h, &commitmentsBatch[i][j], &share) {
+				return ErrInvalidShares
+			}
+		}
+	}
+
+	return nil
+}
+
+// HandleConsensusOutput computes the new shares and commitments from the
+// output of the consensus algorithm. The arguments have the same form as for
+// IsValid, and it is assumed that they have been checked using IsValid. If the
+// shares in the consensus output were not valid for this player, the shares
+// argument should be nil, in which case the returned shares will also be nil.
+func HandleConsensusOutput(
+	dealerIndices []secp256k1.Fn,
+	sharesBatch []shamir.VerifiableShares,
+	commitmentsBatch [][]shamir.Commitment,
+) (shamir.VerifiableShares, []shamir.Commitment) {
+	lambdas := msm.LagrangeCoefficients(dealerIndices)
+
+	commitmentBatch := make([]shamir.Commitment, len(commitmentsBatch))
+	for i, commitments := range commitmentsBatch {
+		k := commitments[0].Len()
+		commitmentBatch[i] = shamir.NewCommitmentWithCapacity(k)
+		for l := 0; l < k; l++ {
+			acc := secp256k1.NewPointInfinity()
+			for j, commitment := range commitments {
+				var term secp256k1.Point
+				term.Scale(&commitment[l], &lambdas[j])
+				acc.Add(&acc, &term)
+			}
+			commitmentBatch[i].Append(acc)
+		}
+	}
+
+	if sharesBatch == nil {
+		return nil, commitmentBatch
+	}
+
+	shareBatch := make(shamir.VerifiableShares, len(sharesBatch))
+	for i, shares := range sharesBatch {
+		shareBatch[i].Scale(&shares[0], &lambdas[0])
+		for j := 1; j < len(shares); j++ {
+			var term shamir.VerifiableShare
+			term.Scale(&shares[j], &lambdas[j])
+			shareBatch[i].Add(&shareBatch[i], &term)
+		}
+	}
+	return shareBatch, commitmentBatch
+}
@@ -0,0 +1,278 @@
+package reshare_test
+
+import (
+	"math/rand"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/mpc/reshare"
+
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+)
+
+var _ = Describe("Reshare", func() {
+	rand.Seed(int64(time.Now().Nanosecond()))
+
+	// The new committee shares some of its indices with the old committee, and
+	// has a different size and threshold.
+	RandomTestParameters := func() (int, int, int, []secp256k1.Fn, []secp256k1.Fn, secp256k1.Point) {
+		oldN := shamirutil.RandRange(5, 15)
+		oldK := shamirutil.RandRange(1, oldN)
+		newN := shamirutil.RandRange(5, 15)
+		newK := shamirutil.RandRange(1, newN)
+		b := shamirutil.RandRange(1, 5)
+		oldIndices := shamirutil.RandomIndices(oldN)
+		newIndices := shamirutil.RandomIndices(newN)
+		numShared := rand.Intn(oldN/2) + 1
+		copy(newIndices, oldIndices[:numShared])
+		h := secp256k1.RandomPoint()
+		return oldK, newK, b, oldIndices, newIndices, h
+	}
+
+	OldSharings := func(oldK, b int, oldIndices []secp256k1.Fn, h secp256k1.Point) (
+		[]secp256k1.Fn, []shamir.VerifiableShares, []shamir.Commitment,
+	) {
+		secrets := make([]secp256k1.Fn, b)
+		sharesBatch := make([]shamir.VerifiableShares, b)
+		commitmentBatch := make([]shamir.Commitment, b)
+		for i := 0; i < b; i++ {
+			secrets[i] = secp256k1.RandomFn()
+			sharesBatch[i] = make(shamir.VerifiableShares, len(oldIndices))
+			commitmentBatch[i] = shamir.NewCommitmentWithCapacity(oldK)
+			shamir.VShareSecret(&sharesBatch[i], &commitmentBatch[i], oldIndices, h, secrets[i], oldK)
+		}
+		return secrets, sharesBatch, commitmentBatch
+	}
+
+	// ConsensusOutput returns the dealer indices, and for each new player the
+	// shares and commitments that would be the result of consensus when the
+	// given number of old players act as dealers.
+	ConsensusOutput := func(
+		t, newK int, oldIndices, newIndices []secp256k1.Fn,
+		oldSharesBatch []shamir.VerifiableShares, h secp256k1.Point,
+	) ([]secp256k1.Fn, [][]shamir.VerifiableShares, [][]shamir.Commitment) {
+		b := len(oldSharesBatch)
+		dealers := rand.Perm(len(oldIndices))[:t]
+		dealerIndices := make([]secp256k1.Fn, t)
+		sharesBatches := make([][]shamir.VerifiableShares, len(newIndices))
+		for i := range sharesBatches {
+			sharesBatches[i] = make([]shamir.VerifiableShares, b)
+			for j := range sharesBatches[i] {
+				sharesBatches[i][j] = make(shamir.VerifiableShares, t)
+			}
+		}
+		commitmentsBatch := make([][]shamir.Commitment, b)
+		for i := range commitmentsBatch {
+			commitmentsBatch[i] = make([]shamir.Commitment, t)
+		}
+		for d, dealer := range dealers {
+			dealerIndices[d] = oldIndices[dealer]
+			shareBatch := make(shamir.VerifiableShares, b)
+			for i := range shareBatch {
+				shareBatch[i] = oldSharesBatch[i][dealer]
+			}
+			sharings := Subshare(shareBatch, newIndices, newK, h)
+			for i, sharing := range sharings {
+				commitmentsBatch[i][d] = sharing.Commitment
+				for j := range newIndices {
+					sharesBatches[j][i][d] = sharing.Shares[j]
+				}
+			}
+		}
+		return dealerIndices, sharesBatches, commitmentsBatch
+	}
+
+	Context("creating subsharings", func() {
+		Specify("the subsharings should be valid and commit to the dealer's share", func() {
+			oldK, newK, b, oldIndices, newIndices, h := RandomTestParameters()
+			_, oldSharesBatch, _ := OldSharings(oldK, b, oldIndices, h)
+			dealer := rand.Intn(len(oldIndices))
+			shareBatch := make(shamir.VerifiableShares, b)
+			for i := range shareBatch {
+				shareBatch[i] = oldSharesBatch[i][dealer]
+			}
+
+			sharings := Subshare(shareBatch, newIndices, newK, h)
+			Expect(len(sharings)).To(Equal(b))
+			for i, sharing := range sharings {
+				Expect(shamirutil.VsharesAreConsistent(sharing.Shares, newK)).To(BeTrue())
+				for _, share := range sharing.Shares {
+					Expect(shamir.IsValid(h, &sharing.Commitment, &share)).To(BeTrue())
+				}
+
+				// The first element of the commitment should be a commitment
+				// to the dealer's share, and hence valid for it.
+				oldShare := oldSharesBatch[i][dealer]
+				oldShare.Share.Index = secp256k1.NewFnFromU16(0)
+				Expect(shamir.IsValid(h, &sharing.Commitment, &oldShare)).To(BeTrue())
+			}
+		})
+	})
+
+	Context("checking if consensus outputs are valid", func() {
+		Specify("valid share and commitment batches", func() {
+			oldK, newK, b, oldIndices, newIndices, h := RandomTestParameters()
+			_, oldSharesBatch, oldCommitmentBatch := OldSharings(oldK, b, oldIndices, h)
+			t := shamirutil.RandRange(oldK, len(oldIndices))
+			dealerIndices, sharesBatches, commitmentsBatch := ConsensusOutput(
+				t, newK, oldIndices, newIndices, oldSharesBatch, h,
+			)
+			for i, index := range newIndices {
+				resharer := New(oldIndices, oldCommitmentBatch, index, newK, h)
+				Expect(resharer.IsValid(dealerIndices, sharesBatches[i], commitmentsBatch)).To(Succeed())
+				Expect(resharer.IsValid(dealerIndices, nil, commitmentsBatch)).To(Succeed())
+			}
+		})
+
+		Context("error cases", func() {
+			var oldK, newK, b, t int
+			var oldIndices, newIndices, dealerIndices []secp256k1.Fn
+			var h secp256k1.Point
+			var oldSharesBatch, sharesBatch []shamir.VerifiableShares
+			var oldCommitmentBatch []shamir.Commitment
+			var commitmentsBatch [][]shamir.Commitment
+			var resharer Resharer
+
+			BeforeEach(func() {
+				var sharesBatches [][]shamir.VerifiableShares
+				oldK, newK, b, oldIndices, newIndices, h = RandomTestParameters()
+				_, oldSharesBatch, oldCommitmentBatch = OldSharings(oldK, b, oldIndices, h)
+				t = shamirutil.RandRange(oldK, len(oldIndices))
+				dealerIndices, sharesBatches, commitmentsBatch = ConsensusOutput(
+					t, newK, oldIndices, newIndices, oldSharesBatch, h,
+				)
+				i := rand.Intn(len(newIndices))
+				sharesBatch = sharesBatches[i]
+				resharer = New(oldIndices, oldCommitmentBatch, newIndices[i], newK, h)
+			})
+
+			Specify("incorrect batch size", func() {
+				err := resharer.IsValid(dealerIndices, sharesBatch[1:], commitmentsBatch)
+				Expect(err).To(Equal(ErrIncorrectSharesBatchSize))
+
+				err = resharer.IsValid(dealerIndices, sharesBatch, commitmentsBatch[1:])
+				Expect(err).To(Equal(ErrIncorrectCommitmentsBatchSize))
+			})
+
+			Specify("not enough contributions", func() {
+				dealerIndices = dealerIndices[:oldK-1]
+				err := resharer.IsValid(dealerIndices, sharesBatch, commitmentsBatch)
+				Expect(err).To(Equal(ErrNotEnoughContributions))
+			})
+
+			Specify("dealer index not in the old indices", func() {
+				dealerIndices[rand.Intn(t)] = secp256k1.RandomFn()
+				err := resharer.IsValid(dealerIndices, sharesBatch, commitmentsBatch)
+				Expect(err).To(Equal(ErrInvalidDealerIndex))
+			})
+
+			Specify("duplicate dealer index", func() {
+				// This test only makes sense if there is more than one dealer.
+				if t == 1 {
+					return
+				}
+				dealerIndices[1] = dealerIndices[0]
+				err := resharer.IsValid(dealerIndices, sharesBatch, commitmentsBatch)
+				Expect(err).To(Equal(ErrInvalidDealerIndex))
+			})
+
+			Specify("commitment contributions length", func() {
+				commitmentsBatch[b-1] = commitmentsBatch[b-1][1:]
+				err := resharer.IsValid(dealerIndices, sharesBatch, commitmentsBatch)
+				Expect(err).To(Equal(ErrInvalidCommitmentDimensions))
+			})
+
+			Specify("commitment threshold", func() {
+				commitmentsBatch[0][0] = shamir.NewCommitmentWithCapacity(newK + 1)
+				err := resharer.IsValid(dealerIndices, sharesBatch, commitmentsBatch)
+				Expect(err).To(Equal(ErrInvalidCommitmentDimensions))
+			})
+
+			Specify("commitment to the wrong share", func() {
+				commitmentsBatch[0][0][0] = secp256k1.RandomPoint()
+				err := resharer.IsValid(dealerIndices, sharesBatch, commitmentsBatch)
+				Expect(err).To(Equal(ErrInvalidCommitment))
+			})
+
+			Specify("share contributions length", func() {
+				sharesBatch[b-1] = sharesBatch[b-1][1:]
+				err := resharer.IsValid(dealerIndices, sharesBatch, commitmentsBatch)
+				Expect(err).To(Equal(ErrInvalidShareDimensions))
+			})
+
+			Specify("incorrect share index", func() {
+				sharesBatch[0][0].Share.Index = secp256k1.RandomFn()
+				err := resharer.IsValid(dealerIndices, sharesBatch, commitmentsBatch)
+				Expect(err).To(Equal(ErrIncorrectIndex))
+			})
+
+			Specify("invalid shares", func() {
+				sharesBatch[0][0].Share.Value = secp256k1.RandomFn()
+				err := resharer.IsValid(dealerIndices, sharesBatch, commitmentsBatch)
+				Expect(err).To(Equal(ErrInvalidShares))
+			})
+		})
+	})
+
+	Context("constructing output shares and commitments", func() {
+		It("should return nil shares when the corresponding argument is nil", func() {
+			oldK, newK, b, oldIndices, newIndices, h := RandomTestParameters()
+			_, oldSharesBatch, _ := OldSharings(oldK, b, oldIndices, h)
+			dealerIndices, _, commitmentsBatch := ConsensusOutput(
+				oldK, newK, oldIndices, newIndices, oldSharesBatch, h,
+			)
+			shares, commitments := HandleConsensusOutput(dealerIndices, nil, commitmentsBatch)
+			Expect(shares).To(BeNil())
+			Expect(len(commitments)).To(Equal(b))
+		})
+
+		It("should reshare the same secrets to the new committee", func() {
+			oldK, newK, b, oldIndices, newIndices, h := RandomTestParameters()
+			secrets, oldSharesBatch, oldCommitmentBatch := OldSharings(oldK, b, oldIndices, h)
+			t := shamirutil.RandRange(oldK, len(oldIndices))
+			dealerIndices, sharesBatches, commitmentsBatch := ConsensusOutput(
+				t, newK, oldIndices, newIndices, oldSharesBatch, h,
+			)
+
+			newSharesBatch := make([]shamir.VerifiableShares, b)
+			for i := range newSharesBatch {
+				newSharesBatch[i] = make(shamir.VerifiableShares, len(newIndices))
+			}
+			var newCommitmentBatch []shamir.Commitment
+			for j := range newIndices {
+				shareBatch, commitmentBatch := HandleConsensusOutput(dealerIndices, sharesBatches[j], commitmentsBatch)
+				Expect(len(shareBatch)).To(Equal(b))
+				Expect(len(commitmentBatch)).To(Equal(b))
+				for i := range shareBatch {
+					Expect(shareBatch[i].Share.IndexEq(&newIndices[j])).To(BeTrue())
+					newSharesBatch[i][j] = shareBatch[i]
+				}
+				if newCommitmentBatch != nil {
+					for i := range commitmentBatch {
+						Expect(commitmentBatch[i].Eq(newCommitmentBatch[i])).To(BeTrue())
+					}
+				}
+				newCommitmentBatch = commitmentBatch
+			}
+
+			for i := 0; i < b; i++ {
+				Expect(newCommitmentBatch[i].Len()).To(Equal(newK))
+				Expect(newCommitmentBatch[i][0].Eq(&oldCommitmentBatch[i][0])).To(BeTrue())
+				Expect(shamirutil.VsharesAreConsistent(newSharesBatch[i], newK)).To(BeTrue())
+				for _, share := range newSharesBatch[i] {
+					Expect(shamir.IsValid(h, &newCommitmentBatch[i], &share)).To(BeTrue())
+				}
+
+				shares := make(shamir.Shares, newK)
+				for j, l := range rand.Perm(len(newIndices))[:newK] {
+					shares[j] = newSharesBatch[i][l].Share
+				}
+				secret := shamir.Open(shares)
+				Expect(secret.Eq(&secrets[i])).To(BeTrue())
+			}
+		})
+	})
+})
@@ -3,6 +3,10 @@ package rkpg
 import "errors"
 
 var (
+	// ErrIncorrectInstance is returned when the given shares are tagged with
+	// an instance ID that is different to that of the RKPG instance.
+	ErrIncorrectInstance = errors.New("incorrect instance")
+
 	// ErrWrongBatchSize is returned when the batch size of the given shares is
 	// not equal to the batch size for the RKPG instance.
 	ErrWrongBatchSize = errors.New("wrong batch size")
@@ -19,6 +23,10 @@ var (
 	// the same index.
 	ErrInconsistentShares = errors.New("inconsistent shares")
 
+	// ErrInvalidZKP is returned when not all of the ZKPs in the given
+	// contributions are valid.
+	ErrInvalidZKP = errors.New("invalid zkp")
+
 	// ErrTooManyErrors is returned when during a reconstruction attempt using
 	// RS decoding, there were too many errant shares to obtain a result.
 	ErrTooManyErrors = errors.New("too many errors")
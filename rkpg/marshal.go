@@ -4,7 +4,12 @@ import (
 	"math/rand"
 	"reflect"
 
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg/rkpgzkp"
+	"github.com/renproject/mpc/rng/rngutil"
 	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
 	"github.com/renproject/shamir/rs"
 	"github.com/renproject/surge"
 )
@@ -22,13 +27,16 @@ func (rkpger RKPGer) Generate(rand *rand.Rand, size int) reflect.Value {
 	for i := range indices {
 		indices[i] = secp256k1.RandomFn()
 	}
+	h := secp256k1.RandomPoint()
 	r := RKPGer{
-		state:   state,
-		k:       rand.Int31(),
-		points:  points,
-		decoder: decoder,
-		indices: indices,
-		h:       secp256k1.RandomPoint(),
+		state:    state,
+		instance: params.InstanceID{}.Generate(rand, size).Interface().(params.InstanceID),
+		k:        rand.Int31(),
+		points:   points,
+		decoder:  decoder,
+		indices:  indices,
+		h:        h,
+		hTable:   msm.NewFixedBase(h),
 	}
 	return reflect.ValueOf(r)
 }
@@ -36,6 +44,7 @@ func (rkpger RKPGer) Generate(rand *rand.Rand, size int) reflect.Value {
 // SizeHint implements the surge.SizeHinter interface.
 func (rkpger RKPGer) SizeHint() int {
 	return rkpger.state.SizeHint() +
+		rkpger.instance.SizeHint() +
 		surge.SizeHint(rkpger.k) +
 		surge.SizeHint(rkpger.points) +
 		rkpger.decoder.SizeHint() +
@@ -49,6 +58,10 @@ func (rkpger RKPGer) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, err
 	}
+	buf, rem, err = rkpger.instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
 	buf, rem, err = surge.MarshalI32(rkpger.k, buf, rem)
 	if err != nil {
 		return buf, rem, err
@@ -74,6 +87,10 @@ func (rkpger *RKPGer) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, err
 	}
+	buf, rem, err = rkpger.instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
 	buf, rem, err = surge.UnmarshalI32(&rkpger.k, buf, rem)
 	if err != nil {
 		return buf, rem, err
@@ -90,5 +107,162 @@ func (rkpger *RKPGer) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, err
 	}
-	return rkpger.h.Unmarshal(buf, rem)
+	buf, rem, err = rkpger.h.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	rkpger.hTable = msm.NewFixedBase(rkpger.h)
+	return buf, rem, nil
+}
+
+// Generate implements the quick.Generator interface.
+func (c Contribution) Generate(rand *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(Contribution{
+		Index: secp256k1.RandomFn(),
+		Point: secp256k1.RandomPoint(),
+		Proof: rkpgzkp.Proof{}.Generate(rand, size).Interface().(rkpgzkp.Proof),
+	})
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (c Contribution) SizeHint() int {
+	return c.Index.SizeHint() + c.Point.SizeHint() + c.Proof.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (c Contribution) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := c.Index.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = c.Point.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return c.Proof.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (c *Contribution) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := c.Index.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = c.Point.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return c.Proof.Unmarshal(buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (rkpger VerifiedRKPGer) Generate(rand *rand.Rand, size int) reflect.Value {
+	size /= 4
+	b := rand.Intn(size + 1)
+	n := size / rngutil.Max(b, 1)
+	pointBufs := make([][]secp256k1.Point, b)
+	for i := range pointBufs {
+		pointBufs[i] = make([]secp256k1.Point, rand.Intn(n+1))
+		for j := range pointBufs[i] {
+			pointBufs[i][j] = secp256k1.RandomPoint()
+		}
+	}
+	received := make([]secp256k1.Fn, rand.Intn(n+1))
+	for i := range received {
+		received[i] = secp256k1.RandomFn()
+	}
+	commitments := make([]shamir.Commitment, b)
+	for i := range commitments {
+		commitments[i] = shamir.Commitment{}.Generate(rand, n).Interface().(shamir.Commitment)
+	}
+	indices := make([]secp256k1.Fn, n)
+	for i := range indices {
+		indices[i] = secp256k1.RandomFn()
+	}
+	h := secp256k1.RandomPoint()
+	r := VerifiedRKPGer{
+		pointBufs:   pointBufs,
+		received:    received,
+		instance:    params.InstanceID{}.Generate(rand, size).Interface().(params.InstanceID),
+		k:           rand.Int31(),
+		commitments: commitments,
+		indices:     indices,
+		h:           h,
+		hTable:      msm.NewFixedBase(h),
+	}
+	return reflect.ValueOf(r)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (rkpger VerifiedRKPGer) SizeHint() int {
+	return surge.SizeHint(rkpger.pointBufs) +
+		surge.SizeHint(rkpger.received) +
+		rkpger.instance.SizeHint() +
+		surge.SizeHint(rkpger.k) +
+		surge.SizeHint(rkpger.commitments) +
+		surge.SizeHint(rkpger.indices) +
+		rkpger.h.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (rkpger VerifiedRKPGer) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.Marshal(rkpger.pointBufs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(rkpger.received, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = rkpger.instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.MarshalI32(rkpger.k, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(rkpger.commitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(rkpger.indices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return rkpger.h.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (rkpger *VerifiedRKPGer) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.Unmarshal(&rkpger.pointBufs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&rkpger.received, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = rkpger.instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.UnmarshalI32(&rkpger.k, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&rkpger.commitments, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&rkpger.indices, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = rkpger.h.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	rkpger.hTable = msm.NewFixedBase(rkpger.h)
+	return buf, rem, nil
 }
@@ -3,9 +3,12 @@ package rkpg
 import (
 	"fmt"
 
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/parallel"
 	"github.com/renproject/mpc/params"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/poly"
 	"github.com/renproject/shamir/rs"
 )
 
@@ -14,19 +17,26 @@ type RKPGer struct {
 	state State
 
 	// Instance parameters
-	k       int32
-	points  []secp256k1.Point
-	decoder rs.Decoder
+	instance params.InstanceID
+	k        int32
+	points   []secp256k1.Point
+	decoder  rs.Decoder
 
 	// Global parameters
 	indices []secp256k1.Fn
 	h       secp256k1.Point
+
+	// Precomputed fixed-base table for h. This is not marshaled, and is
+	// computed again when unmarshaling.
+	hTable *msm.FixedBase
 }
 
-// New returns a new RKPG state machine along with the initial message that is
-// to be broadcast to the other parties. The state machine will handle this
-// message before being returned.
+// New returns a new RKPG state machine for the given instance ID along with the
+// initial message that is to be broadcast to the other parties, tagged with
+// the instance ID. The state machine will handle this message before being
+// returned.
 func New(
+	instance params.InstanceID,
 	indices []secp256k1.Fn,
 	h secp256k1.Point,
 	rngShares, rzgShares shamir.VerifiableShares,
@@ -66,16 +76,18 @@ func New(
 	indicesCopy := make([]secp256k1.Fn, n)
 	copy(indicesCopy, indices)
 	rkpger := RKPGer{
-		state:   state,
-		k:       int32(k),
-		points:  points,
-		decoder: rs.NewDecoder(indices, k),
-		indices: indicesCopy,
-		h:       h,
+		state:    state,
+		instance: instance,
+		k:        int32(k),
+		points:   points,
+		decoder:  rs.NewDecoder(indices, k),
+		indices:  indicesCopy,
+		h:        h,
+		hTable:   msm.NewFixedBase(h),
 	}
 
 	// Proccess own share.
-	_, err := rkpger.HandleShareBatch(shares)
+	_, _, err := rkpger.HandleShareBatch(instance, shares)
 	if err != nil {
 		panic("error handling own share")
 	}
@@ -83,18 +95,35 @@ func New(
 	return rkpger, shares
 }
 
+// Instance returns the instance ID of the state machine.
+func (rkpger RKPGer) Instance() params.InstanceID {
+	return rkpger.instance
+}
+
 // HandleShareBatch applies a state transition to the given state upon
-// receiveing the given shares from another party during the open in the RKPG
-// protocol. Once enough shares have been received to reconstruct, the output
-// public key batch is computed and returned. If not enough shares have been
-// received, the return value will be nil.
-func (rkpger *RKPGer) HandleShareBatch(shares shamir.Shares) (
-	[]secp256k1.Point, error,
+// receiveing the given shares, tagged with the given instance ID, from another
+// party during the open in the RKPG protocol. Once enough shares have been
+// received to reconstruct, the output public key batch is computed and
+// returned. If not enough shares have been received, the return value will be
+// nil. If the shares are tagged for a different instance, ErrIncorrectInstance
+// is returned.
+//
+// Whenever reconstruction is attempted, the second return value contains, for
+// each element of the batch, the indices of the players whose shares are not
+// consistent with the decoded polynomial, so that faulty or malicious players
+// can be identified. If there were too many incorrect shares to decode some of
+// the batch elements, ErrTooManyErrors is returned, and the entries for those
+// elements will be nil, as the incorrect shares can not be located.
+func (rkpger *RKPGer) HandleShareBatch(instance params.InstanceID, shares shamir.Shares) (
+	[]secp256k1.Point, [][]secp256k1.Fn, error,
 ) {
+	if instance != rkpger.instance {
+		return nil, nil, ErrIncorrectInstance
+	}
 	n := len(rkpger.indices)
 	b := len(rkpger.points)
 	if len(shares) != int(b) {
-		return nil, ErrWrongBatchSize
+		return nil, nil, ErrWrongBatchSize
 	}
 	// Check that the index of the first share is in the list of indices.
 	ind := -1
@@ -105,16 +134,16 @@ func (rkpger *RKPGer) HandleShareBatch(shares shamir.Shares) (
 		}
 	}
 	if ind < 0 {
-		return nil, ErrInvalidIndex
+		return nil, nil, ErrInvalidIndex
 	}
 
 	if rkpger.state.shareReceived[ind] {
-		return nil, ErrDuplicateIndex
+		return nil, nil, ErrDuplicateIndex
 	}
 	// Check that all indices in the share batch are the same.
 	for i := 1; i < len(shares); i++ {
 		if !shares[i].IndexEq(&index) {
-			return nil, ErrInconsistentShares
+			return nil, nil, ErrInconsistentShares
 		}
 	}
 
@@ -127,25 +156,64 @@ func (rkpger *RKPGer) HandleShareBatch(shares shamir.Shares) (
 
 	if int(rkpger.state.count) < n-int(rkpger.k)+1 {
 		// Not enough shares have been received for reconstruction.
-		return nil, nil
+		return nil, nil, nil
 	}
+	// The batch elements are decoded using the worker pool from the parallel
+	// package. A decoder holds scratch space that is used during decoding, so
+	// when there is more than one worker each element uses its own decoder.
+	// Every element is decoded, even if decoding fails for one of them, so
+	// that the incorrect shares are located for as many elements as possible.
 	secrets := make([]secp256k1.Fn, b)
-	for i, buf := range rkpger.state.buffers {
-		poly, ok := rkpger.decoder.Decode(buf)
+	faults := make([][]secp256k1.Fn, b)
+	decoded := make([]bool, b)
+	sequential := parallel.Workers() == 1
+	parallel.ForEach(b, func(i int) {
+		decoder := &rkpger.decoder
+		if !sequential {
+			d := rs.NewDecoder(rkpger.indices, int(rkpger.k))
+			decoder = &d
+		}
+		p, ok := decoder.Decode(rkpger.state.buffers[i])
 		if !ok {
+			return
+		}
+		secrets[i] = *p.Coefficient(0)
+		faults[i] = rkpger.inconsistentIndices(p, rkpger.state.buffers[i])
+		decoded[i] = true
+	})
+	for i := range decoded {
+		if !decoded[i] {
 			// The RS decoder was not able to reconstruct the polynomial
 			// because there are too many incorrect shares.
-			return nil, ErrTooManyErrors
+			return nil, faults, ErrTooManyErrors
 		}
-		secrets[i] = *poly.Coefficient(0)
 	}
 
 	pubKeys := make([]secp256k1.Point, b)
 	for i, secret := range secrets {
 		// Compute xG = (xG + sH) + (-s)H
 		secret.Negate(&secret)
-		pubKeys[i].Scale(&rkpger.h, &secret)
+		pubKeys[i] = rkpger.hTable.Exp(&secret)
 		pubKeys[i].Add(&pubKeys[i], &rkpger.points[i])
 	}
-	return pubKeys, nil
+	return pubKeys, faults, nil
+}
+
+// inconsistentIndices returns the indices of the players that have sent a
+// share that does not lie on the given decoded polynomial, where the values of
+// the shares are given in the same order as the indices. Players from which no
+// share has been received are not included. The returned slice is empty, but
+// not nil, if all of the received shares are consistent.
+func (rkpger *RKPGer) inconsistentIndices(p *poly.Poly, values []secp256k1.Fn) []secp256k1.Fn {
+	faults := []secp256k1.Fn{}
+	for j := range rkpger.indices {
+		if !rkpger.state.shareReceived[j] {
+			continue
+		}
+		expected := p.Evaluate(rkpger.indices[j])
+		if !expected.Eq(&values[j]) {
+			faults = append(faults, rkpger.indices[j])
+		}
+	}
+	return faults
 }
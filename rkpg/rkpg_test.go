@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/parallel"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/mpc/rkpg/rkpgutil"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
@@ -20,6 +22,9 @@ var _ = Describe("RKPG", func() {
 	rand.Seed(int64(time.Now().Nanosecond()))
 	trials := 10
 
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
 	RandomTestParams := func() (int, int, int, int, secp256k1.Point, []secp256k1.Fn) {
 		k := shamirutil.RandRange(4, 15)
 		n := 3 * k
@@ -54,24 +59,43 @@ var _ = Describe("RKPG", func() {
 			for i := 0; i < trials; i++ {
 				_, k, _, b, h, indices := RandomTestParams()
 				rngShares, rzgShares, rngComs, _ := RXGOutputs(k, b, indices, h)
-				rkpger, _ := New(indices, h, rngShares[1], rzgShares[1], rngComs)
+				rkpger, _ := New(instance, indices, h, rngShares[1], rzgShares[1], rngComs)
 
-				res, err := rkpger.HandleShareBatch(make(shamir.Shares, b-1))
+				res, _, err := rkpger.HandleShareBatch(instance, make(shamir.Shares, b-1))
 				Expect(res).To(BeNil())
 				Expect(err).To(Equal(ErrWrongBatchSize))
 			}
 		})
 
+		Specify("shares for a different instance", func() {
+			for i := 0; i < trials; i++ {
+				_, k, _, b, h, indices := RandomTestParams()
+				rngShares, rzgShares, rngComs, _ := RXGOutputs(k, b, indices, h)
+				rkpger, _ := New(instance, indices, h, rngShares[0], rzgShares[0], rngComs)
+				_, shares := New(instance, indices, h, rngShares[1], rzgShares[1], rngComs)
+
+				otherInstance := instance
+				otherInstance[rand.Intn(len(otherInstance))]++
+				res, _, err := rkpger.HandleShareBatch(otherInstance, shares)
+				Expect(res).To(BeNil())
+				Expect(err).To(Equal(ErrIncorrectInstance))
+
+				// The shares should still be accepted for the right instance.
+				_, _, err = rkpger.HandleShareBatch(instance, shares)
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+
 		Specify("shares with invalid index", func() {
 			for i := 0; i < trials; i++ {
 				_, k, _, b, h, indices := RandomTestParams()
 				rngShares, rzgShares, rngComs, _ := RXGOutputs(k, b, indices, h)
-				rkpger, _ := New(indices, h, rngShares[1], rzgShares[1], rngComs)
+				rkpger, _ := New(instance, indices, h, rngShares[1], rzgShares[1], rngComs)
 
 				// As it is an uninitialised slice, all of the shares in
 				// `shares` should have index zero, which should not be in the
 				// set `indices` with overwhelming probability.
-				res, err := rkpger.HandleShareBatch(make(shamir.Shares, b))
+				res, _, err := rkpger.HandleShareBatch(instance, make(shamir.Shares, b))
 				Expect(res).To(BeNil())
 				Expect(err).To(Equal(ErrInvalidIndex))
 			}
@@ -81,11 +105,11 @@ var _ = Describe("RKPG", func() {
 			for i := 0; i < trials; i++ {
 				_, k, _, b, h, indices := RandomTestParams()
 				rngShares, rzgShares, rngComs, _ := RXGOutputs(k, b, indices, h)
-				rkpger, shares := New(indices, h, rngShares[0], rzgShares[0], rngComs)
+				rkpger, shares := New(instance, indices, h, rngShares[0], rzgShares[0], rngComs)
 
 				// The RKPGer has already handled its own shares, so this
 				// should trigger a duplciate index error.
-				res, err := rkpger.HandleShareBatch(shares)
+				res, _, err := rkpger.HandleShareBatch(instance, shares)
 				Expect(res).To(BeNil())
 				Expect(err).To(Equal(ErrDuplicateIndex))
 			}
@@ -95,7 +119,7 @@ var _ = Describe("RKPG", func() {
 			for i := 0; i < trials; i++ {
 				_, k, _, b, h, indices := RandomTestParams()
 				rngShares, rzgShares, rngComs, _ := RXGOutputs(k, b, indices, h)
-				rkpger, _ := New(indices, h, rngShares[0], rzgShares[0], rngComs)
+				rkpger, _ := New(instance, indices, h, rngShares[0], rzgShares[0], rngComs)
 
 				shares := make(shamir.Shares, b)
 				shares[0] = shamir.NewShare(indices[1], secp256k1.Fn{})
@@ -103,7 +127,7 @@ var _ = Describe("RKPG", func() {
 					shares[j] = shamir.NewShare(indices[2], secp256k1.Fn{})
 				}
 
-				res, err := rkpger.HandleShareBatch(shares)
+				res, _, err := rkpger.HandleShareBatch(instance, shares)
 				Expect(res).To(BeNil())
 				Expect(err).To(Equal(ErrInconsistentShares))
 			}
@@ -113,21 +137,21 @@ var _ = Describe("RKPG", func() {
 			for i := 0; i < 1; i++ {
 				n, k, _, b, h, indices := RandomTestParams()
 				rngShares, rzgShares, rngComs, secrets := RXGOutputs(k, b, indices, h)
-				rkpger, _ := New(indices, h, rngShares[0], rzgShares[0], rngComs)
+				rkpger, _ := New(instance, indices, h, rngShares[0], rzgShares[0], rngComs)
 
 				var err error
 				shares := make([]shamir.Shares, n-1)
 				for j := range shares {
-					_, shares[j] = New(indices, h, rngShares[j+1], rzgShares[j+1], rngComs)
+					_, shares[j] = New(instance, indices, h, rngShares[j+1], rzgShares[j+1], rngComs)
 				}
 
 				threshold := n - k + 1
 				for j := 0; j < threshold-2; j++ {
-					res, err := rkpger.HandleShareBatch(shares[j])
+					res, _, err := rkpger.HandleShareBatch(instance, shares[j])
 					Expect(err).ToNot(HaveOccurred())
 					Expect(res).To(BeNil())
 				}
-				pubkeys, err := rkpger.HandleShareBatch(shares[threshold-1])
+				pubkeys, _, err := rkpger.HandleShareBatch(instance, shares[threshold-1])
 				Expect(err).ToNot(HaveOccurred())
 				for j := range pubkeys {
 					var expected secp256k1.Point
@@ -137,11 +161,35 @@ var _ = Describe("RKPG", func() {
 			}
 		})
 
+		Specify("valid shares with a worker pool", func() {
+			parallel.SetWorkers(4)
+			defer parallel.SetWorkers(1)
+
+			n, k, _, b, h, indices := RandomTestParams()
+			rngShares, rzgShares, rngComs, secrets := RXGOutputs(k, b, indices, h)
+			rkpger, _ := New(instance, indices, h, rngShares[0], rzgShares[0], rngComs)
+
+			threshold := n - k + 1
+			var pubkeys []secp256k1.Point
+			for j := 1; j < threshold; j++ {
+				_, shares := New(instance, indices, h, rngShares[j], rzgShares[j], rngComs)
+				var err error
+				pubkeys, _, err = rkpger.HandleShareBatch(instance, shares)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(len(pubkeys)).To(Equal(b))
+			for j := range pubkeys {
+				var expected secp256k1.Point
+				expected.BaseExpUnsafe(&secrets[j])
+				Expect(expected.Eq(&pubkeys[j])).To(BeTrue())
+			}
+		})
+
 		Specify("invalid shares", func() {
 			for i := 0; i < trials; i++ {
 				n, k, t, b, h, indices := RandomTestParams()
 				rngShares, rzgShares, rngComs, _ := RXGOutputs(k, b, indices, h)
-				rkpger, _ := New(indices, h, rngShares[0], rzgShares[0], rngComs)
+				rkpger, _ := New(instance, indices, h, rngShares[0], rzgShares[0], rngComs)
 
 				// Create invalid shares.
 				shares := make([]shamir.Shares, n-1)
@@ -159,18 +207,98 @@ var _ = Describe("RKPG", func() {
 				threshold := n - k + 1
 				errThreshold := n - 2
 				for i := 0; i < threshold-2; i++ {
-					res, err := rkpger.HandleShareBatch(shares[i])
+					res, _, err := rkpger.HandleShareBatch(instance, shares[i])
 					Expect(err).ToNot(HaveOccurred())
 					Expect(res).To(BeNil())
 				}
 				for i := threshold - 2; i < errThreshold-2; i++ {
-					res, err := rkpger.HandleShareBatch(shares[i])
+					res, faults, err := rkpger.HandleShareBatch(instance, shares[i])
 					Expect(err).To(Equal(ErrTooManyErrors))
 					Expect(res).To(BeNil())
+
+					// The incorrect shares can not be located for the batch
+					// element that could not be decoded, but they should be
+					// for the other elements.
+					Expect(len(faults)).To(Equal(b))
+					for j := range faults {
+						if j == badBuf {
+							Expect(faults[j]).To(BeNil())
+						} else {
+							Expect(faults[j]).ToNot(BeNil())
+							Expect(faults[j]).To(BeEmpty())
+						}
+					}
 				}
-				res, err := rkpger.HandleShareBatch(shares[errThreshold-1])
+				res, faults, err := rkpger.HandleShareBatch(instance, shares[errThreshold-1])
 				Expect(res).ToNot(BeNil())
 				Expect(err).ToNot(HaveOccurred())
+
+				// The players that sent incorrect shares should be identified.
+				Expect(len(faults)).To(Equal(b))
+				for j := range faults {
+					if j != badBuf {
+						Expect(faults[j]).To(BeEmpty())
+						continue
+					}
+					Expect(len(faults[j])).To(Equal(t))
+					for l := 0; l < t; l++ {
+						Expect(faults[j][l].Eq(&shares[l][j].Index)).To(BeTrue())
+					}
+				}
+			}
+		})
+
+		Specify("locating incorrect shares", func() {
+			for i := 0; i < trials; i++ {
+				n, k, t, b, h, indices := RandomTestParams()
+				rngShares, rzgShares, rngComs, _ := RXGOutputs(k, b, indices, h)
+				rkpger, _ := New(instance, indices, h, rngShares[0], rzgShares[0], rngComs)
+
+				// A random subset of the other players send incorrect shares
+				// for a random subset of the batch elements.
+				bad := make(map[int]map[int]bool, b)
+				for j := 0; j < b; j++ {
+					bad[j] = make(map[int]bool, t)
+					if rand.Intn(2) == 0 {
+						continue
+					}
+					for _, l := range rand.Perm(n - 1)[:rand.Intn(t+1)] {
+						bad[j][l+1] = true
+					}
+				}
+
+				// Decoding may fail until enough of the correct shares have
+				// been received, but once all of the shares have been
+				// received, there are few enough incorrect shares that every
+				// batch element can be decoded.
+				var faults [][]secp256k1.Fn
+				var err error
+				for l := 1; l < n; l++ {
+					shares := make(shamir.Shares, b)
+					for j := range shares {
+						shares[j] = RKPGShare(rngShares[l][j], rzgShares[l][j])
+						if bad[j][l] {
+							shares[j] = shamir.NewShare(shares[j].Index, secp256k1.RandomFn())
+						}
+					}
+					_, faults, err = rkpger.HandleShareBatch(instance, shares)
+				}
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(len(faults)).To(Equal(b))
+				for j := range faults {
+					Expect(len(faults[j])).To(Equal(len(bad[j])))
+					for _, index := range faults[j] {
+						found := false
+						for l := range bad[j] {
+							if index.Eq(&indices[l]) {
+								found = true
+								break
+							}
+						}
+						Expect(found).To(BeTrue())
+					}
+				}
 			}
 		})
 	})
@@ -183,7 +311,7 @@ var _ = Describe("RKPG", func() {
 			rzgShares := make(shamir.VerifiableShares, b)
 			rngComs := make([]shamir.Commitment, b)
 
-			Expect(func() { New(indices, inf, rngShares, rzgShares, rngComs) }).To(Panic())
+			Expect(func() { New(instance, indices, inf, rngShares, rzgShares, rngComs) }).To(Panic())
 		})
 
 		Specify("shares with the wrong batch size", func() {
@@ -193,9 +321,9 @@ var _ = Describe("RKPG", func() {
 				rzgShares := make(shamir.VerifiableShares, b)
 				rngComs := make([]shamir.Commitment, b)
 
-				Expect(func() { New(indices, h, rngShares[:b-1], rzgShares, rngComs) }).To(Panic())
-				Expect(func() { New(indices, h, rngShares, rzgShares[:b-1], rngComs) }).To(Panic())
-				Expect(func() { New(indices, h, rngShares, rzgShares, rngComs[:b-1]) }).To(Panic())
+				Expect(func() { New(instance, indices, h, rngShares[:b-1], rzgShares, rngComs) }).To(Panic())
+				Expect(func() { New(instance, indices, h, rngShares, rzgShares[:b-1], rngComs) }).To(Panic())
+				Expect(func() { New(instance, indices, h, rngShares, rzgShares, rngComs[:b-1]) }).To(Panic())
 			}
 		})
 
@@ -210,7 +338,7 @@ var _ = Describe("RKPG", func() {
 					shamir.NewShare(secp256k1.RandomFn(), secp256k1.Fn{}),
 					secp256k1.Fn{},
 				)
-				Expect(func() { New(indices, h, rngShares, rzgShares, rngComs) }).To(Panic())
+				Expect(func() { New(instance, indices, h, rngShares, rzgShares, rngComs) }).To(Panic())
 			}
 		})
 	})
@@ -259,15 +387,16 @@ var _ = Describe("RKPG", func() {
 							m := mpcutil.OfflineMachine(ids[i])
 							machine = &m
 						case rkpgutil.Malicious:
-							m := rkpgutil.NewMaliciousMachine(ids[i], ids, int32(b), indices, false)
+							m := rkpgutil.NewMaliciousMachine(ids[i], ids, instance, int32(b), indices, false)
 							machine = &m
 						case rkpgutil.MaliciousZero:
-							m := rkpgutil.NewMaliciousMachine(ids[i], ids, int32(b), indices, true)
+							m := rkpgutil.NewMaliciousMachine(ids[i], ids, instance, int32(b), indices, true)
 							machine = &m
 						case rkpgutil.Honest:
 							m := rkpgutil.NewHonestMachine(
 								ids[i],
 								ids,
+								instance,
 								indices,
 								h,
 								rngComs,
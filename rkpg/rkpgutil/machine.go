@@ -2,6 +2,7 @@ package rkpgutil
 
 import (
 	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/mpc/rkpg"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
@@ -58,17 +59,19 @@ type HonestMachine struct {
 func NewHonestMachine(
 	ownID mpcutil.ID,
 	ids []mpcutil.ID,
+	instance params.InstanceID,
 	indices []secp256k1.Fn,
 	h secp256k1.Point,
 	coms []shamir.Commitment,
 	rngShares, rzgShares shamir.VerifiableShares,
 ) HonestMachine {
-	rkpger, shares := rkpg.New(indices, h, rngShares, rzgShares, coms)
+	rkpger, shares := rkpg.New(instance, indices, h, rngShares, rzgShares, coms)
 	messages := make([]mpcutil.Message, len(ids))
 	for i, to := range ids {
 		msgShares := make(shamir.Shares, len(shares))
 		copy(msgShares, shares)
 		messages[i] = &Message{
+			Instance:   instance,
 			ToID:       to,
 			FromID:     ownID,
 			ShareBatch: msgShares,
@@ -97,7 +100,7 @@ func (m HonestMachine) InitialMessages() []mpcutil.Message {
 // Handle implements the mpcutil.Machine interface.
 func (m *HonestMachine) Handle(msg mpcutil.Message) []mpcutil.Message {
 	message := msg.(*Message)
-	points, _ := m.RKPGer.HandleShareBatch(message.ShareBatch)
+	points, _, _ := m.RKPGer.HandleShareBatch(message.Instance, message.ShareBatch)
 	if points != nil {
 		m.Points = points
 	}
@@ -107,10 +110,11 @@ func (m *HonestMachine) Handle(msg mpcutil.Message) []mpcutil.Message {
 // A MaliciousMachine represents a player that acts maliciously by sending
 // shares with incorrect values.
 type MaliciousMachine struct {
-	OwnID   mpcutil.ID
-	IDs     []mpcutil.ID
-	B       int32
-	Indices []secp256k1.Fn
+	OwnID    mpcutil.ID
+	IDs      []mpcutil.ID
+	Instance params.InstanceID
+	B        int32
+	Indices  []secp256k1.Fn
 
 	// If set, the player will send shares that have values equal to zero.
 	// Otherwise, these values will be random.
@@ -121,16 +125,18 @@ type MaliciousMachine struct {
 func NewMaliciousMachine(
 	ownID mpcutil.ID,
 	ids []mpcutil.ID,
+	instance params.InstanceID,
 	b int32,
 	indices []secp256k1.Fn,
 	zero bool,
 ) MaliciousMachine {
 	return MaliciousMachine{
-		OwnID:   ownID,
-		IDs:     ids,
-		B:       b,
-		Indices: indices,
-		Zero:    zero,
+		OwnID:    ownID,
+		IDs:      ids,
+		Instance: instance,
+		B:        b,
+		Indices:  indices,
+		Zero:     zero,
 	}
 }
 
@@ -152,6 +158,7 @@ func (m MaliciousMachine) InitialMessages() []mpcutil.Message {
 			msgShares[j] = shamir.NewShare(m.Indices[i], val)
 		}
 		messages[i] = &Message{
+			Instance:   m.Instance,
 			ToID:       to,
 			FromID:     m.OwnID,
 			ShareBatch: msgShares,
@@ -238,6 +245,7 @@ func (m *HonestMachine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 func (m MaliciousMachine) SizeHint() int {
 	return m.OwnID.SizeHint() +
 		surge.SizeHint(m.IDs) +
+		m.Instance.SizeHint() +
 		surge.SizeHint(m.B) +
 		surge.SizeHint(m.Indices) +
 		surge.SizeHint(m.Zero)
@@ -253,6 +261,10 @@ func (m MaliciousMachine) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, err
 	}
+	buf, rem, err = m.Instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
 	buf, rem, err = surge.MarshalI32(m.B, buf, rem)
 	if err != nil {
 		return buf, rem, err
@@ -274,6 +286,10 @@ func (m *MaliciousMachine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	if err != nil {
 		return buf, rem, err
 	}
+	buf, rem, err = m.Instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
 	buf, rem, err = surge.UnmarshalI32(&m.B, buf, rem)
 	if err != nil {
 		return buf, rem, err
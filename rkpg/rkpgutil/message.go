@@ -2,11 +2,15 @@ package rkpgutil
 
 import (
 	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg"
 	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
 )
 
 // A Message is sent between machines during a RKPG simulation.
 type Message struct {
+	Instance     params.InstanceID
 	ToID, FromID mpcutil.ID
 	ShareBatch   shamir.Shares
 }
@@ -19,14 +23,19 @@ func (msg Message) From() mpcutil.ID { return msg.FromID }
 
 // SizeHint implements the surge.SizeHinter interface.
 func (msg Message) SizeHint() int {
-	return msg.ToID.SizeHint() +
+	return msg.Instance.SizeHint() +
+		msg.ToID.SizeHint() +
 		msg.FromID.SizeHint() +
 		msg.ShareBatch.SizeHint()
 }
 
 // Marshal implements the surge.Marshaler interface.
 func (msg Message) Marshal(buf []byte, rem int) ([]byte, int, error) {
-	buf, rem, err := msg.ToID.Marshal(buf, rem)
+	buf, rem, err := msg.Instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.ToID.Marshal(buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
@@ -39,7 +48,11 @@ func (msg Message) Marshal(buf []byte, rem int) ([]byte, int, error) {
 
 // Unmarshal implements the surge.Unmarshaler interface.
 func (msg *Message) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
-	buf, rem, err := msg.ToID.Unmarshal(buf, rem)
+	buf, rem, err := msg.Instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.ToID.Unmarshal(buf, rem)
 	if err != nil {
 		return buf, rem, err
 	}
@@ -49,3 +62,59 @@ func (msg *Message) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	}
 	return msg.ShareBatch.Unmarshal(buf, rem)
 }
+
+// A ContributionMessage is sent between machines during a verified RKPG
+// simulation.
+type ContributionMessage struct {
+	Instance      params.InstanceID
+	ToID, FromID  mpcutil.ID
+	Contributions []rkpg.Contribution
+}
+
+// To implements the mpcutil.Message interface.
+func (msg ContributionMessage) To() mpcutil.ID { return msg.ToID }
+
+// From implements the mpcutil.Message interface.
+func (msg ContributionMessage) From() mpcutil.ID { return msg.FromID }
+
+// SizeHint implements the surge.SizeHinter interface.
+func (msg ContributionMessage) SizeHint() int {
+	return msg.Instance.SizeHint() +
+		msg.ToID.SizeHint() +
+		msg.FromID.SizeHint() +
+		surge.SizeHint(msg.Contributions)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (msg ContributionMessage) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := msg.Instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.ToID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.FromID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(msg.Contributions, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (msg *ContributionMessage) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := msg.Instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.ToID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = msg.FromID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&msg.Contributions, buf, rem)
+}
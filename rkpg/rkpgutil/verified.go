@@ -0,0 +1,209 @@
+package rkpgutil
+
+import (
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// VerifiedHonestMachine is a machine that follows the verified RKPG protocol
+// as specified.
+type VerifiedHonestMachine struct {
+	OwnID mpcutil.ID
+	IDs   []mpcutil.ID
+
+	RKPGer   rkpg.VerifiedRKPGer
+	Messages []ContributionMessage
+	Points   []secp256k1.Point
+}
+
+// NewVerifiedHonestMachine constructs and returns a new honest machine for the
+// verified RKPG protocol.
+func NewVerifiedHonestMachine(
+	ownID mpcutil.ID,
+	ids []mpcutil.ID,
+	instance params.InstanceID,
+	indices []secp256k1.Fn,
+	h secp256k1.Point,
+	coms []shamir.Commitment,
+	rngShares shamir.VerifiableShares,
+) VerifiedHonestMachine {
+	rkpger, contributions := rkpg.NewVerified(instance, indices, h, rngShares, coms)
+	messages := make([]ContributionMessage, 0, len(ids)-1)
+	for _, to := range ids {
+		if to == ownID {
+			continue
+		}
+		messages = append(messages, ContributionMessage{
+			Instance:      instance,
+			ToID:          to,
+			FromID:        ownID,
+			Contributions: contributions,
+		})
+	}
+	return VerifiedHonestMachine{
+		OwnID: ownID,
+		IDs:   ids,
+
+		RKPGer:   rkpger,
+		Messages: messages,
+		Points:   []secp256k1.Point{},
+	}
+}
+
+// ID implements the mpcutil.Machine interface.
+func (m VerifiedHonestMachine) ID() mpcutil.ID { return m.OwnID }
+
+// InitialMessages implements the mpcutil.Machine interface.
+func (m VerifiedHonestMachine) InitialMessages() []mpcutil.Message {
+	messages := make([]mpcutil.Message, len(m.Messages))
+	for i := range m.Messages {
+		messages[i] = &m.Messages[i]
+	}
+	return messages
+}
+
+// Handle implements the mpcutil.Machine interface.
+func (m *VerifiedHonestMachine) Handle(msg mpcutil.Message) []mpcutil.Message {
+	message := msg.(*ContributionMessage)
+	points, _ := m.RKPGer.HandleContributionBatch(message.Instance, message.Contributions)
+	if points != nil {
+		m.Points = points
+	}
+	return nil
+}
+
+// A VerifiedMaliciousMachine represents a player that acts maliciously in the
+// verified RKPG protocol by sending incorrect shares of the public keys along
+// with the proofs for its correct shares.
+type VerifiedMaliciousMachine struct {
+	OwnID    mpcutil.ID
+	Messages []ContributionMessage
+}
+
+// NewVerifiedMaliciousMachine constructs and returns a new malicious machine
+// for the verified RKPG protocol.
+func NewVerifiedMaliciousMachine(
+	ownID mpcutil.ID,
+	ids []mpcutil.ID,
+	instance params.InstanceID,
+	indices []secp256k1.Fn,
+	h secp256k1.Point,
+	coms []shamir.Commitment,
+	rngShares shamir.VerifiableShares,
+) VerifiedMaliciousMachine {
+	_, contributions := rkpg.NewVerified(instance, indices, h, rngShares, coms)
+	for i := range contributions {
+		contributions[i].Point = secp256k1.RandomPoint()
+	}
+	messages := make([]ContributionMessage, 0, len(ids)-1)
+	for _, to := range ids {
+		if to == ownID {
+			continue
+		}
+		messages = append(messages, ContributionMessage{
+			Instance:      instance,
+			ToID:          to,
+			FromID:        ownID,
+			Contributions: contributions,
+		})
+	}
+	return VerifiedMaliciousMachine{
+		OwnID:    ownID,
+		Messages: messages,
+	}
+}
+
+// ID implements the mpcutil.Machine interface.
+func (m VerifiedMaliciousMachine) ID() mpcutil.ID { return m.OwnID }
+
+// InitialMessages implements the mpcutil.Machine interface.
+func (m VerifiedMaliciousMachine) InitialMessages() []mpcutil.Message {
+	messages := make([]mpcutil.Message, len(m.Messages))
+	for i := range m.Messages {
+		messages[i] = &m.Messages[i]
+	}
+	return messages
+}
+
+// Handle implements the mpcutil.Machine interface.
+func (m *VerifiedMaliciousMachine) Handle(msg mpcutil.Message) []mpcutil.Message {
+	return nil
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (m VerifiedHonestMachine) SizeHint() int {
+	return m.OwnID.SizeHint() +
+		surge.SizeHint(m.IDs) +
+		m.RKPGer.SizeHint() +
+		surge.SizeHint(m.Messages) +
+		surge.SizeHint(m.Points)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (m VerifiedHonestMachine) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.IDs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = m.RKPGer.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(m.Messages, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(m.Points, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (m *VerifiedHonestMachine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.IDs, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = m.RKPGer.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&m.Messages, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&m.Points, buf, rem)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (m VerifiedMaliciousMachine) SizeHint() int {
+	return m.OwnID.SizeHint() + surge.SizeHint(m.Messages)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (m VerifiedMaliciousMachine) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(m.Messages, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (m *VerifiedMaliciousMachine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&m.Messages, buf, rem)
+}
@@ -0,0 +1,65 @@
+package rkpgzkp
+
+import (
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/secp256k1"
+)
+
+// A Proof for the ZKP. The commitments are the first messages of the sigma
+// protocols for the G and H components respectively, and the responses are
+// for the value and the decommitment respectively.
+type Proof struct {
+	m1, m2 secp256k1.Point
+	z1, z2 secp256k1.Fn
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (p Proof) SizeHint() int {
+	return p.m1.SizeHint() + p.m2.SizeHint() + p.z1.SizeHint() + p.z2.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (p Proof) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := p.m1.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = p.m2.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = p.z1.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return p.z2.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (p *Proof) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := p.m1.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = p.m2.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = p.z1.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return p.z2.Unmarshal(buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (p Proof) Generate(_ *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(Proof{
+		m1: secp256k1.RandomPoint(),
+		m2: secp256k1.RandomPoint(),
+		z1: secp256k1.RandomFn(),
+		z2: secp256k1.RandomFn(),
+	})
+}
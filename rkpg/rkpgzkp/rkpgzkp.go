@@ -0,0 +1,158 @@
+// Package rkpgzkp provides an implementation of a ZKP that a point is the G
+// component of a Pedersen commitment, made non interactive by using the Fiat
+// Shamir transform. That is, for a commitment
+// 		c = (x)G + (s)H,
+// a prover can show that a point p is equal to (x)G, without revealing
+// anything about s. This allows a player in the RKPG protocol to publish its
+// share of the public key, and have it checked against the commitment to its
+// share of the private key.
+//
+// The ZKP is a Chaum-Pedersen style composition of two Schnorr proofs that
+// share a challenge, showing that the prover knows x and s such that
+// 		p = (x)G, and
+// 		c - p = (s)H.
+// Since the Pedersen commitment is binding, x must then be the value that is
+// committed to by c. The prover sends m1 = (a)G and m2 = (b)H for random a
+// and b, receives a challenge e, and responds with z1 = a + e*x and
+// z2 = b + e*s. The verifier accepts if
+// 		(z1)G = m1 + (e)p, and
+// 		(z2)H = m2 + (e)(c - p).
+package rkpgzkp
+
+import (
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/parallel"
+	"github.com/renproject/mpc/transcript"
+	"github.com/renproject/secp256k1"
+)
+
+// domain is the domain separator that is appended to the transcript for each
+// proof.
+const domain = "renproject/mpc/rkpgzkp"
+
+// CreateProof constructs a new ZKP that attests to the fact that
+// 		p = (x)G,
+// where
+// 		c = (x)G + (s)H.
+// The Fiat Shamir challenge is derived from the given transcript, which should
+// contain the context for the proof, such as the instance ID, the index of the
+// prover and the position of the proof in a batch. The proof will only be
+// valid for a transcript with the same context.
+func CreateProof(t transcript.Transcript, h, c, p *secp256k1.Point, x, s secp256k1.Fn) Proof {
+	a, b := secp256k1.RandomFn(), secp256k1.RandomFn()
+
+	var proof Proof
+	proof.m1.BaseExp(&a)
+	proof.m2.Scale(h, &b)
+
+	e := computeChallenge(t, h, c, p, &proof)
+	proof.z1.Mul(&e, &x)
+	proof.z1.Add(&proof.z1, &a)
+	proof.z2.Mul(&e, &s)
+	proof.z2.Add(&proof.z2, &b)
+
+	return proof
+}
+
+// Verify the given proof. The return value will be true if
+// 		p = (x)G,
+// where
+// 		c = (x)G + (s)H
+// for some x and s, and the proof was created with a transcript with the same
+// context as the given transcript. Otherwise, the return value will be false.
+func Verify(t transcript.Transcript, h, c, p *secp256k1.Point, proof *Proof) bool {
+	e := computeChallenge(t, h, c, p, proof)
+	return verify(h, c, p, proof, &e)
+}
+
+// VerifyBatch verifies the given batch of proofs, where the ith proof is for
+// the ith elements of the given slices of transcripts, commitments and points,
+// and h is the fixed-base table for the Pedersen parameter. The proofs are
+// checked together using msm.VerifyBatch, and the return values are the same.
+//
+// Panics: This function will panic if the slices do not all have the same
+// length.
+func VerifyBatch(
+	ts []transcript.Transcript,
+	h *msm.FixedBase,
+	cs, ps []secp256k1.Point,
+	proofs []Proof,
+) (int, bool) {
+	n := len(proofs)
+	if len(ts) != n || len(cs) != n || len(ps) != n {
+		panic("inconsistent batch size")
+	}
+	hPoint := h.Point()
+	es := make([]secp256k1.Fn, n)
+	parallel.ForEach(n, func(i int) {
+		es[i] = computeChallenge(ts[i], &hPoint, &cs[i], &ps[i], &proofs[i])
+	})
+	return msm.VerifyBatch(h, n,
+		func(i int) []msm.Equation {
+			return equations(&cs[i], &ps[i], &proofs[i], &es[i])
+		},
+		func(i int) bool {
+			return verify(&hPoint, &cs[i], &ps[i], &proofs[i], &es[i])
+		},
+	)
+}
+
+// equations returns the verification equations for the given proof,
+// commitment, point and challenge in the form used by msm.VerifyBatch, that
+// is,
+//	(z1)G = m1 + (e)p, and
+//	(z2)H = m2 + (e)c - (e)p.
+func equations(c, p *secp256k1.Point, proof *Proof, e *secp256k1.Fn) []msm.Equation {
+	var zero, negE secp256k1.Fn
+	one := secp256k1.NewFnFromU16(1)
+	negE.Negate(e)
+	return []msm.Equation{
+		{
+			G:       proof.z1,
+			H:       zero,
+			Points:  []secp256k1.Point{proof.m1, *p},
+			Scalars: []secp256k1.Fn{one, *e},
+		},
+		{
+			G:       zero,
+			H:       proof.z2,
+			Points:  []secp256k1.Point{proof.m2, *c, *p},
+			Scalars: []secp256k1.Fn{one, *e, negE},
+		},
+	}
+}
+
+// verify returns true if the given proof is valid for the given commitment,
+// point and challenge, and false otherwise.
+func verify(h, c, p *secp256k1.Point, proof *Proof, e *secp256k1.Fn) bool {
+	var actual, expected, ePow secp256k1.Point
+	var negE secp256k1.Fn
+
+	expected.BaseExp(&proof.z1)
+	ePow.Scale(p, e)
+	actual.Add(&ePow, &proof.m1)
+	if !actual.Eq(&expected) {
+		return false
+	}
+
+	expected.Scale(h, &proof.z2)
+	negE.Negate(e)
+	ePow.Scale(p, &negE)
+	actual.Scale(c, e)
+	actual.Add(&actual, &ePow)
+	actual.Add(&actual, &proof.m2)
+	return actual.Eq(&expected)
+}
+
+// computeChallenge appends the statement and the first messages of the proof
+// to the given transcript and derives the challenge from it. The transcript is
+// passed by value, and so the transcript of the caller is not modified.
+func computeChallenge(t transcript.Transcript, h, c, p *secp256k1.Point, proof *Proof) secp256k1.Fn {
+	t.AppendBytes("dom-sep", []byte(domain))
+	t.AppendPoint("h", h)
+	t.AppendPoint("c", c)
+	t.AppendPoint("p", p)
+	t.AppendPoint("m1", &proof.m1)
+	t.AppendPoint("m2", &proof.m2)
+	return t.Challenge("e")
+}
@@ -0,0 +1,13 @@
+package rkpgzkp_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRkpgZkp(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RkpgZkp Suite")
+}
@@ -0,0 +1,157 @@
+package rkpgzkp_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/mpc/rkpg/rkpgzkp"
+
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/transcript"
+	"github.com/renproject/secp256k1"
+)
+
+var _ = Describe("NIZK", func() {
+	trials := 100
+
+	// RandomTranscript returns a transcript with a random context.
+	RandomTranscript := func() transcript.Transcript {
+		t := transcript.New("rkpgzkp test")
+		var context [32]byte
+		rand.Read(context[:])
+		t.AppendBytes("context", context[:])
+		return t
+	}
+
+	// RandomTestParams returns a random value and decommitment along with the
+	// Pedersen commitment to them for the given parameter, and the G component
+	// of the commitment.
+	RandomTestParams := func(h secp256k1.Point) (
+		secp256k1.Fn, secp256k1.Fn, secp256k1.Point, secp256k1.Point,
+	) {
+		x := secp256k1.RandomFn()
+		s := secp256k1.RandomFn()
+		var c, p, hPow secp256k1.Point
+		hPow.Scale(&h, &s)
+		p.BaseExp(&x)
+		c.Add(&p, &hPow)
+		return x, s, c, p
+	}
+
+	Context("verifying proofs", func() {
+		It("should accept correct proofs", func() {
+			for i := 0; i < trials; i++ {
+				h := secp256k1.RandomPoint()
+				x, s, c, p := RandomTestParams(h)
+
+				t := RandomTranscript()
+				proof := CreateProof(t, &h, &c, &p, x, s)
+				Expect(Verify(t, &h, &c, &p, &proof)).To(BeTrue())
+			}
+		})
+
+		It("should reject proofs for the wrong point", func() {
+			for i := 0; i < trials; i++ {
+				h := secp256k1.RandomPoint()
+				x, s, c, _ := RandomTestParams(h)
+
+				// Shifting the point by a multiple of H keeps the sum of the
+				// two components equal to the commitment.
+				var p, hPow secp256k1.Point
+				delta := secp256k1.RandomFn()
+				hPow.Scale(&h, &delta)
+				p.BaseExp(&x)
+				p.Add(&p, &hPow)
+				delta.Negate(&delta)
+				s.Add(&s, &delta)
+
+				t := RandomTranscript()
+				proof := CreateProof(t, &h, &c, &p, x, s)
+				Expect(Verify(t, &h, &c, &p, &proof)).To(BeFalse())
+			}
+		})
+
+		It("should reject proofs for a different commitment", func() {
+			for i := 0; i < trials; i++ {
+				h := secp256k1.RandomPoint()
+				x, s, c, p := RandomTestParams(h)
+
+				t := RandomTranscript()
+				proof := CreateProof(t, &h, &c, &p, x, s)
+				c = secp256k1.RandomPoint()
+				Expect(Verify(t, &h, &c, &p, &proof)).To(BeFalse())
+			}
+		})
+
+		It("should reject proofs for a different transcript", func() {
+			for i := 0; i < trials; i++ {
+				h := secp256k1.RandomPoint()
+				x, s, c, p := RandomTestParams(h)
+
+				t := RandomTranscript()
+				proof := CreateProof(t, &h, &c, &p, x, s)
+				Expect(Verify(RandomTranscript(), &h, &c, &p, &proof)).To(BeFalse())
+
+				// Appending further context should also invalidate the proof.
+				other := t
+				other.AppendU32("position", uint32(i))
+				Expect(Verify(other, &h, &c, &p, &proof)).To(BeFalse())
+			}
+		})
+	})
+
+	Context("verifying batches of proofs", func() {
+		batchSize := 10
+
+		// RandomBatch returns the transcripts, commitments, points and proofs
+		// for a batch of correct proofs that use the same Pedersen parameter.
+		RandomBatch := func(h secp256k1.Point) (
+			[]transcript.Transcript, []secp256k1.Point, []secp256k1.Point, []Proof,
+		) {
+			ts := make([]transcript.Transcript, batchSize)
+			cs := make([]secp256k1.Point, batchSize)
+			ps := make([]secp256k1.Point, batchSize)
+			proofs := make([]Proof, batchSize)
+			for i := 0; i < batchSize; i++ {
+				var x, s secp256k1.Fn
+				x, s, cs[i], ps[i] = RandomTestParams(h)
+				ts[i] = RandomTranscript()
+				proofs[i] = CreateProof(ts[i], &h, &cs[i], &ps[i], x, s)
+			}
+			return ts, cs, ps, proofs
+		}
+
+		It("should accept batches of correct proofs", func() {
+			for i := 0; i < trials/10; i++ {
+				h := secp256k1.RandomPoint()
+				ts, cs, ps, proofs := RandomBatch(h)
+				position, ok := VerifyBatch(ts, msm.NewFixedBase(h), cs, ps, proofs)
+				Expect(ok).To(BeTrue())
+				Expect(position).To(Equal(-1))
+			}
+		})
+
+		It("should return the position of the first incorrect proof", func() {
+			for i := 0; i < trials/10; i++ {
+				h := secp256k1.RandomPoint()
+				ts, cs, ps, proofs := RandomBatch(h)
+				first := i % (batchSize - 1)
+				ps[first] = secp256k1.RandomPoint()
+				ps[batchSize-1] = secp256k1.RandomPoint()
+				position, ok := VerifyBatch(ts, msm.NewFixedBase(h), cs, ps, proofs)
+				Expect(ok).To(BeFalse())
+				Expect(position).To(Equal(first))
+			}
+		})
+
+		It("should reject proofs for different transcripts", func() {
+			h := secp256k1.RandomPoint()
+			ts, cs, ps, proofs := RandomBatch(h)
+			ts[1] = RandomTranscript()
+			position, ok := VerifyBatch(ts, msm.NewFixedBase(h), cs, ps, proofs)
+			Expect(ok).To(BeFalse())
+			Expect(position).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,227 @@
+package rkpg
+
+import (
+	"fmt"
+
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/parallel"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg/rkpgzkp"
+	"github.com/renproject/mpc/transcript"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// A Contribution is sent by a player during the verified variant of the RKPG
+// protocol for an element of the batch. The point is the share of the public
+// key of the player with the given index, that is, xG where x is its RNG
+// share, and the proof shows that the point is consistent with the commitment
+// to the RNG share.
+type Contribution struct {
+	Index secp256k1.Fn
+	Point secp256k1.Point
+	Proof rkpgzkp.Proof
+}
+
+// A VerifiedRKPGer is a state machine that implements a variant of the RKPG
+// protocol in which each contribution can be checked individually. Instead of
+// opening a masked share of the decommitment, each player sends its share of
+// the public key along with a ZKP that it is consistent with the commitments
+// from the RNG protocol (see rkpgzkp). Invalid contributions are rejected on
+// arrival, and so the public keys can be computed from any k valid
+// contributions, rather than the n-k+1 that are needed for the RS decoding in
+// RKPGer. This also means that the RZG shares are not needed.
+type VerifiedRKPGer struct {
+	pointBufs [][]secp256k1.Point
+	received  []secp256k1.Fn
+
+	// Instance parameters
+	instance    params.InstanceID
+	k           int32
+	commitments []shamir.Commitment
+
+	// Global parameters
+	indices []secp256k1.Fn
+	h       secp256k1.Point
+
+	// Precomputed fixed-base table for h. This is not marshaled, and is
+	// computed again when unmarshaling.
+	hTable *msm.FixedBase
+}
+
+// NewVerified returns a new verified RKPG state machine for the given instance
+// ID along with the initial contributions that are to be broadcast to the
+// other parties, tagged with the instance ID. The state machine will handle
+// these contributions before being returned. The instance ID, the index of the
+// player and the position in the batch are bound into the ZKPs (see
+// ContributionTranscript), so that they can not be used in a different
+// context.
+//
+// Panics: This function will panic if the Pedersen parameter is insecure, if
+// the batch size is less than 1, if the number of commitments is not equal to
+// the batch size, or if the RNG shares do not all have the same index.
+func NewVerified(
+	instance params.InstanceID,
+	indices []secp256k1.Fn,
+	h secp256k1.Point,
+	rngShares shamir.VerifiableShares,
+	rngComs []shamir.Commitment,
+) (VerifiedRKPGer, []Contribution) {
+	if !params.ValidPedersenParameter(h) {
+		panic("insecure choice of pedersen parameter")
+	}
+	b := len(rngShares)
+	if b < 1 {
+		panic(fmt.Sprintf("batch size should be at least 1: got %v", b))
+	}
+	if len(rngComs) != b {
+		panic(fmt.Sprintf(
+			"invalid commitment batch size: expected %v (rngShares), got %v",
+			b, len(rngComs),
+		))
+	}
+	index := rngShares[0].Share.Index
+	for i := range rngShares {
+		if !rngShares[i].Share.IndexEq(&index) {
+			panic("rng shares have inconsistent indices")
+		}
+	}
+	k := rngComs[0].Len()
+
+	pointBufs := make([][]secp256k1.Point, b)
+	for i := range pointBufs {
+		pointBufs[i] = make([]secp256k1.Point, 0, k)
+	}
+	indicesCopy := make([]secp256k1.Fn, len(indices))
+	copy(indicesCopy, indices)
+	comsCopy := make([]shamir.Commitment, b)
+	for i := range comsCopy {
+		comsCopy[i].Set(rngComs[i])
+	}
+	rkpger := VerifiedRKPGer{
+		pointBufs:   pointBufs,
+		received:    make([]secp256k1.Fn, 0, k),
+		instance:    instance,
+		k:           int32(k),
+		commitments: comsCopy,
+		indices:     indicesCopy,
+		h:           h,
+		hTable:      msm.NewFixedBase(h),
+	}
+
+	contributions := make([]Contribution, b)
+	for i := range contributions {
+		share := &rngShares[i]
+		contributions[i].Index = index
+		contributions[i].Point.BaseExp(&share.Share.Value)
+		// The commitment to the share is obtained from the public
+		// commitment to the sharing, so that the secret share is not used in
+		// a variable time computation.
+		c := msm.EvalCommitment(rngComs[i], &index)
+		contributions[i].Proof = rkpgzkp.CreateProof(
+			ContributionTranscript(instance, &index, i),
+			&h, &c, &contributions[i].Point,
+			share.Share.Value, share.Decommitment,
+		)
+	}
+
+	// Process own contributions.
+	if _, err := rkpger.HandleContributionBatch(instance, contributions); err != nil {
+		panic(fmt.Sprintf("error handling own contributions: %v", err))
+	}
+
+	return rkpger, contributions
+}
+
+// Instance returns the instance ID of the state machine.
+func (rkpger VerifiedRKPGer) Instance() params.InstanceID {
+	return rkpger.instance
+}
+
+// HandleContributionBatch applies a state transition upon receiving the given
+// contributions, tagged with the given instance ID, from another party. The
+// contributions are checked as soon as they are received, and if they are
+// invalid in any way, an error is returned and the state is not changed. In
+// particular, if the contributions are tagged for a different instance,
+// ErrIncorrectInstance is returned, and if any of the ZKPs are not valid,
+// ErrInvalidZKP is returned. Once k valid contributions have been received,
+// the output public key batch is computed and returned. Otherwise, the return
+// value will be nil.
+func (rkpger *VerifiedRKPGer) HandleContributionBatch(instance params.InstanceID, contributions []Contribution) (
+	[]secp256k1.Point, error,
+) {
+	if instance != rkpger.instance {
+		return nil, ErrIncorrectInstance
+	}
+	b := len(rkpger.pointBufs)
+	if len(contributions) != b {
+		return nil, ErrWrongBatchSize
+	}
+	index := contributions[0].Index
+	if position(rkpger.indices, &index) < 0 {
+		return nil, ErrInvalidIndex
+	}
+	for i := range contributions {
+		if !contributions[i].Index.Eq(&index) {
+			return nil, ErrInconsistentShares
+		}
+	}
+	if position(rkpger.received, &index) >= 0 {
+		return nil, ErrDuplicateIndex
+	}
+
+	transcripts := make([]transcript.Transcript, b)
+	shareCommitments := make([]secp256k1.Point, b)
+	points := make([]secp256k1.Point, b)
+	proofs := make([]rkpgzkp.Proof, b)
+	parallel.ForEach(b, func(i int) {
+		transcripts[i] = ContributionTranscript(rkpger.instance, &index, i)
+		shareCommitments[i] = msm.EvalCommitment(rkpger.commitments[i], &index)
+		points[i] = contributions[i].Point
+		proofs[i] = contributions[i].Proof
+	})
+	if _, ok := rkpgzkp.VerifyBatch(transcripts, rkpger.hTable, shareCommitments, points, proofs); !ok {
+		return nil, ErrInvalidZKP
+	}
+
+	// The contributions are valid so we add them to the buffers.
+	for i := range rkpger.pointBufs {
+		rkpger.pointBufs[i] = append(rkpger.pointBufs[i], points[i])
+	}
+	rkpger.received = append(rkpger.received, index)
+
+	if len(rkpger.received) != int(rkpger.k) {
+		return nil, nil
+	}
+
+	// Interpolate the public keys in the exponent from the shares of the
+	// public keys.
+	lambdas := msm.LagrangeCoefficients(rkpger.received)
+	pubKeys := make([]secp256k1.Point, b)
+	parallel.ForEach(b, func(i int) {
+		pubKeys[i] = msm.MultiExp(rkpger.pointBufs[i], lambdas)
+	})
+	return pubKeys, nil
+}
+
+// ContributionTranscript returns the transcript for the ZKP that is created by
+// the player with the given index for the ith element of the batch in the
+// given instance.
+func ContributionTranscript(instance params.InstanceID, index *secp256k1.Fn, i int) transcript.Transcript {
+	t := transcript.New("renproject/mpc/rkpg")
+	t.AppendBytes("instance", instance[:])
+	t.AppendScalar("index", index)
+	t.AppendU32("position", uint32(i))
+	return t
+}
+
+// position returns the position of the given index in the given slice, or -1
+// if it is not in the slice.
+func position(indices []secp256k1.Fn, index *secp256k1.Fn) int {
+	for i := range indices {
+		if indices[i].Eq(index) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,293 @@
+package rkpg_test
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg/rkpgutil"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/mpc/rkpg"
+)
+
+var _ = Describe("Verified RKPG", func() {
+	trials := 10
+
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
+	RandomTestParams := func() (int, int, int, int, secp256k1.Point, []secp256k1.Fn) {
+		k := shamirutil.RandRange(4, 15)
+		n := 3 * k
+		t := k - 2
+		b := shamirutil.RandRange(2, 10)
+		h := secp256k1.RandomPoint()
+		indices := shamirutil.RandomIndices(n)
+		return n, k, t, b, h, indices
+	}
+
+	ExpectCorrectPubKeys := func(pubKeys []secp256k1.Point, secrets []secp256k1.Fn) {
+		Expect(len(pubKeys)).To(Equal(len(secrets)))
+		for j := range pubKeys {
+			var expected secp256k1.Point
+			expected.BaseExpUnsafe(&secrets[j])
+			Expect(expected.Eq(&pubKeys[j])).To(BeTrue())
+		}
+	}
+
+	Context("state transitions", func() {
+		Specify("contributions for a different instance", func() {
+			for i := 0; i < trials; i++ {
+				_, k, _, b, h, indices := RandomTestParams()
+				rngShares, rngComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				rkpger, _ := NewVerified(instance, indices, h, rngShares[0], rngComs)
+				_, contributions := NewVerified(instance, indices, h, rngShares[1], rngComs)
+
+				otherInstance := instance
+				otherInstance[rand.Intn(len(otherInstance))]++
+				res, err := rkpger.HandleContributionBatch(otherInstance, contributions)
+				Expect(res).To(BeNil())
+				Expect(err).To(Equal(ErrIncorrectInstance))
+
+				// The contributions should still be accepted for the right
+				// instance.
+				_, err = rkpger.HandleContributionBatch(instance, contributions)
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+
+		Specify("contributions with invalid batch size", func() {
+			for i := 0; i < trials; i++ {
+				_, k, _, b, h, indices := RandomTestParams()
+				rngShares, rngComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				rkpger, _ := NewVerified(instance, indices, h, rngShares[0], rngComs)
+				_, contributions := NewVerified(instance, indices, h, rngShares[1], rngComs)
+
+				res, err := rkpger.HandleContributionBatch(instance, contributions[1:])
+				Expect(res).To(BeNil())
+				Expect(err).To(Equal(ErrWrongBatchSize))
+			}
+		})
+
+		Specify("contributions with invalid index", func() {
+			for i := 0; i < trials; i++ {
+				_, k, _, b, h, indices := RandomTestParams()
+				rngShares, rngComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				rkpger, _ := NewVerified(instance, indices, h, rngShares[0], rngComs)
+				_, contributions := NewVerified(instance, indices, h, rngShares[1], rngComs)
+
+				for j := range contributions {
+					contributions[j].Index = secp256k1.RandomFn()
+				}
+				res, err := rkpger.HandleContributionBatch(instance, contributions)
+				Expect(res).To(BeNil())
+				Expect(err).To(Equal(ErrInvalidIndex))
+			}
+		})
+
+		Specify("contributions with inconsistent indices", func() {
+			for i := 0; i < trials; i++ {
+				_, k, _, b, h, indices := RandomTestParams()
+				rngShares, rngComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				rkpger, _ := NewVerified(instance, indices, h, rngShares[0], rngComs)
+				_, contributions := NewVerified(instance, indices, h, rngShares[1], rngComs)
+
+				contributions[b-1].Index = indices[2]
+				res, err := rkpger.HandleContributionBatch(instance, contributions)
+				Expect(res).To(BeNil())
+				Expect(err).To(Equal(ErrInconsistentShares))
+			}
+		})
+
+		Specify("contributions with duplicate indices", func() {
+			for i := 0; i < trials; i++ {
+				_, k, _, b, h, indices := RandomTestParams()
+				rngShares, rngComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				rkpger, contributions := NewVerified(instance, indices, h, rngShares[0], rngComs)
+
+				// The RKPGer has already handled its own contributions, so
+				// this should trigger a duplicate index error.
+				res, err := rkpger.HandleContributionBatch(instance, contributions)
+				Expect(res).To(BeNil())
+				Expect(err).To(Equal(ErrDuplicateIndex))
+			}
+		})
+
+		Specify("contributions with invalid points", func() {
+			for i := 0; i < trials; i++ {
+				_, k, _, b, h, indices := RandomTestParams()
+				rngShares, rngComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				rkpger, _ := NewVerified(instance, indices, h, rngShares[0], rngComs)
+				_, contributions := NewVerified(instance, indices, h, rngShares[1], rngComs)
+
+				contributions[rand.Intn(b)].Point = secp256k1.RandomPoint()
+				res, err := rkpger.HandleContributionBatch(instance, contributions)
+				Expect(res).To(BeNil())
+				Expect(err).To(Equal(ErrInvalidZKP))
+			}
+		})
+
+		Specify("contributions with proofs for a different player", func() {
+			for i := 0; i < trials; i++ {
+				_, k, _, b, h, indices := RandomTestParams()
+				rngShares, rngComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				rkpger, _ := NewVerified(instance, indices, h, rngShares[0], rngComs)
+				_, contributions := NewVerified(instance, indices, h, rngShares[1], rngComs)
+
+				// Claiming the contributions of another player should not be
+				// possible, as the index is bound into the proofs.
+				for j := range contributions {
+					contributions[j].Index = indices[2]
+				}
+				res, err := rkpger.HandleContributionBatch(instance, contributions)
+				Expect(res).To(BeNil())
+				Expect(err).To(Equal(ErrInvalidZKP))
+			}
+		})
+
+		Specify("valid contributions", func() {
+			n, k, _, b, h, indices := RandomTestParams()
+			rngShares, rngComs, secrets := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			rkpger, _ := NewVerified(instance, indices, h, rngShares[0], rngComs)
+
+			// Only k contributions, including its own, should be needed,
+			// and they can come from any of the players.
+			perm := rand.Perm(n - 1)
+			for j := 0; j < k-2; j++ {
+				_, contributions := NewVerified(instance, indices, h, rngShares[perm[j]+1], rngComs)
+				res, err := rkpger.HandleContributionBatch(instance, contributions)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res).To(BeNil())
+			}
+			_, contributions := NewVerified(instance, indices, h, rngShares[perm[k-2]+1], rngComs)
+			pubKeys, err := rkpger.HandleContributionBatch(instance, contributions)
+			Expect(err).ToNot(HaveOccurred())
+			ExpectCorrectPubKeys(pubKeys, secrets)
+		})
+
+		Specify("invalid contributions should not count towards the threshold", func() {
+			_, k, _, b, h, indices := RandomTestParams()
+			rngShares, rngComs, secrets := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			rkpger, _ := NewVerified(instance, indices, h, rngShares[0], rngComs)
+
+			for j := 1; j < k; j++ {
+				_, contributions := NewVerified(instance, indices, h, rngShares[j], rngComs)
+				contributions[0].Point = secp256k1.RandomPoint()
+				_, err := rkpger.HandleContributionBatch(instance, contributions)
+				Expect(err).To(Equal(ErrInvalidZKP))
+			}
+			var pubKeys []secp256k1.Point
+			for j := k; j < 2*k-1; j++ {
+				_, contributions := NewVerified(instance, indices, h, rngShares[j], rngComs)
+				var err error
+				pubKeys, err = rkpger.HandleContributionBatch(instance, contributions)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			ExpectCorrectPubKeys(pubKeys, secrets)
+		})
+	})
+
+	Context("initial messages", func() {
+		Specify("insecure pedersen parameter", func() {
+			_, k, _, b, h, indices := RandomTestParams()
+			rngShares, rngComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			inf := secp256k1.NewPointInfinity()
+
+			Expect(func() { NewVerified(instance, indices, inf, rngShares[0], rngComs) }).To(Panic())
+		})
+
+		Specify("shares with the wrong batch size", func() {
+			for i := 0; i < trials; i++ {
+				_, k, _, b, h, indices := RandomTestParams()
+				rngShares, rngComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+
+				Expect(func() { NewVerified(instance, indices, h, rngShares[0][:b-1], rngComs) }).To(Panic())
+				Expect(func() { NewVerified(instance, indices, h, rngShares[0], rngComs[:b-1]) }).To(Panic())
+				Expect(func() { NewVerified(instance, indices, h, nil, nil) }).To(Panic())
+			}
+		})
+
+		Specify("inconsistent share indices", func() {
+			for i := 0; i < trials; i++ {
+				_, k, _, b, h, indices := RandomTestParams()
+				rngShares, rngComs, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+
+				shares := make(shamir.VerifiableShares, b)
+				copy(shares, rngShares[0])
+				shares[b-1] = rngShares[1][b-1]
+				Expect(func() { NewVerified(instance, indices, h, shares, rngComs) }).To(Panic())
+			}
+		})
+	})
+
+	Context("network simulation", func() {
+		tys := []rkpgutil.MachineType{
+			rkpgutil.Offline,
+			rkpgutil.Malicious,
+		}
+
+		for _, ty := range tys {
+			ty := ty
+			Context(fmt.Sprintf("dishonest machine type %v", ty), func() {
+				Specify("players should end up with the same correct public key", func() {
+					// With verified contributions, the public keys can be
+					// computed as long as there are k honest players.
+					k := shamirutil.RandRange(4, 15)
+					n := 2*k - 1
+					t := k - 1
+					b := shamirutil.RandRange(2, 10)
+					h := secp256k1.RandomPoint()
+					indices := shamirutil.RandomIndices(n)
+					rngShares, rngComs, secrets := rkpgutil.RNGOutputBatch(indices, k, b, h)
+
+					ids := make([]mpcutil.ID, n)
+					for i := range ids {
+						ids[i] = mpcutil.ID(i + 1)
+					}
+					dishonest := rand.Perm(n)[:t]
+					machineType := make(map[mpcutil.ID]rkpgutil.MachineType, n)
+					for _, id := range ids {
+						machineType[id] = rkpgutil.Honest
+					}
+					for _, i := range dishonest {
+						machineType[ids[i]] = ty
+					}
+
+					machines := make([]mpcutil.Machine, n)
+					for i, id := range ids {
+						switch machineType[id] {
+						case rkpgutil.Offline:
+							m := mpcutil.OfflineMachine(id)
+							machines[i] = &m
+						case rkpgutil.Malicious:
+							m := rkpgutil.NewVerifiedMaliciousMachine(id, ids, instance, indices, h, rngComs, rngShares[i])
+							machines[i] = &m
+						case rkpgutil.Honest:
+							m := rkpgutil.NewVerifiedHonestMachine(id, ids, instance, indices, h, rngComs, rngShares[i])
+							machines[i] = &m
+						}
+					}
+					shuffleMsgs, _ := mpcutil.MessageShufflerDropper(ids, 0)
+					network := mpcutil.NewNetwork(machines, shuffleMsgs)
+					network.SetCaptureHist(true)
+					err := network.Run()
+					Expect(err).ToNot(HaveOccurred())
+
+					// All honest players should have the correct public keys.
+					for i := range machines {
+						if machineType[machines[i].ID()] != rkpgutil.Honest {
+							continue
+						}
+						ExpectCorrectPubKeys(machines[i].(*rkpgutil.VerifiedHonestMachine).Points, secrets)
+					}
+				})
+			})
+		}
+	})
+})
@@ -1,6 +1,7 @@
 package compute
 
 import (
+	"github.com/renproject/mpc/msm"
 	"github.com/renproject/secp256k1"
 	"github.com/renproject/shamir"
 )
@@ -8,17 +9,21 @@ import (
 // ShareCommitment accepts the set of commitments and computes a weighted
 // linear combination of those commitments. This accumulated value represents
 // the commitment for the share of the final unbiased random number for the
-// given index.
+// given index. Each element of the output is computed using a single
+// multi-scalar multiplication.
 //
 // Panics: This function panics if the length of the slice of commitments is
 // less than 1.
 func ShareCommitment(index secp256k1.Fn, coms []shamir.Commitment) shamir.Commitment {
-	var acc shamir.Commitment
-
-	acc.Set(coms[len(coms)-1])
-	for l := len(coms) - 2; l >= 0; l-- {
-		acc.Scale(acc, &index)
-		acc.Add(acc, coms[l])
+	powers := msm.Powers(&index, len(coms))
+	k := coms[len(coms)-1].Len()
+	acc := shamir.NewCommitmentWithCapacity(k)
+	points := make([]secp256k1.Point, len(coms))
+	for j := 0; j < k; j++ {
+		for l := range coms {
+			points[l] = coms[l][j]
+		}
+		acc.Append(msm.MultiExp(points, powers))
 	}
 
 	return acc
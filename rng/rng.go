@@ -19,7 +19,9 @@ type RNGer struct {
 
 // New creates a new intance of a state machine that carries out either the RNG
 // or RZG protocol. Which one of these two cases is instantiated is determined
-// by the isZero argument. The share and commitment batch arguments are outputs
+// by the isZero argument. The instance ID identifies the invocation of the
+// protocol, and the initial messages should be tagged with it when they are
+// sent. The share and commitment batch arguments are outputs
 // from the BRNG protocol; they are expected to be valid, and if the given
 // shares are nil then they will be ignored. Along with the state machine, the
 // initial messages to be sent to the other parties are returned, which is a
@@ -36,6 +38,7 @@ type RNGer struct {
 //	- Not all commitments have the correct threshold (k).
 //	- The shares and commitments have a different batch size.
 func New(
+	instance params.InstanceID,
 	ownIndex secp256k1.Fn,
 	indices []secp256k1.Fn,
 	h secp256k1.Point,
@@ -124,7 +127,7 @@ func New(
 
 		ownCommitments[i].Set(accCommitment)
 	}
-	opener := open.New(ownCommitments, indices, h)
+	opener := open.New(instance, ownCommitments, indices, h)
 
 	// If the sets of shares are valid, construct the directed openings to
 	// other players in the network.
@@ -142,7 +145,7 @@ func New(
 		}
 
 		// Handle own share.
-		secrets, decommitments, err := opener.HandleShareBatch(directedOpenings[ownIndex])
+		secrets, decommitments, err := opener.HandleShareBatch(instance, directedOpenings[ownIndex])
 		if err != nil {
 			panic(fmt.Sprintf("unexpected error: %v", err))
 		}
@@ -159,13 +162,23 @@ func New(
 	return rnger, directedOpenings, outputCommitments
 }
 
-// HandleShareBatch handles a batch of shares received from another player. If
-// the share batch was invalid in any way, an error will be returned. If the
+// Instance returns the instance ID of the state machine.
+func (rnger RNGer) Instance() params.InstanceID {
+	return rnger.opener.Instance()
+}
+
+// HandleShareBatch handles a batch of shares received from another player that
+// is tagged with the given instance ID. If the share batch was invalid in any
+// way, an error will be returned; in particular, if it is tagged for a
+// different instance, open.ErrIncorrectInstance will be returned. If the
 // given share batch was the kth valid batch to be received, reconstruction is
 // possible and the return value will be the reconstructed secrets. Otherwise,
 // the return value will be nil.
-func (rnger *RNGer) HandleShareBatch(shareBatch shamir.VerifiableShares) (shamir.VerifiableShares, error) {
-	secrets, decommitments, err := rnger.opener.HandleShareBatch(shareBatch)
+func (rnger *RNGer) HandleShareBatch(
+	instance params.InstanceID,
+	shareBatch shamir.VerifiableShares,
+) (shamir.VerifiableShares, error) {
+	secrets, decommitments, err := rnger.opener.HandleShareBatch(instance, shareBatch)
 	if err != nil {
 		return nil, err
 	}
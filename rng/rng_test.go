@@ -12,12 +12,16 @@ import (
 	"github.com/renproject/shamir/shamirutil"
 
 	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/mpc/rng/rngutil"
 )
 
 var _ = Describe("RNG", func() {
 	rand.Seed(int64(time.Now().Nanosecond()))
 
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
 	Describe("Network Simulation", func() {
 		var n, b, k, nOffline int
 		var indices []secp256k1.Fn
@@ -116,7 +120,7 @@ var _ = Describe("RNG", func() {
 			machines = make([]mpcutil.Machine, n)
 			for i, index := range indices {
 				rngMachine := rngutil.NewRngMachine(
-					mpcutil.ID(i), index, indices, b, k, h, isZero,
+					mpcutil.ID(i), instance, index, indices, b, k, h, isZero,
 					setsOfSharesByPlayer[index],
 					setsOfCommitmentsByPlayer,
 				)
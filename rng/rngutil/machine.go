@@ -8,6 +8,7 @@ import (
 	"github.com/renproject/surge"
 
 	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
 
 	"github.com/renproject/mpc/rng"
 )
@@ -29,6 +30,7 @@ type RngMachine struct {
 // and transitions it to the WaitingOpen state by supplying its own shares
 func NewRngMachine(
 	id mpcutil.ID,
+	instance params.InstanceID,
 	index secp256k1.Fn,
 	indices []secp256k1.Fn,
 	b, k int,
@@ -38,7 +40,7 @@ func NewRngMachine(
 	ownSetsOfCommitments [][]shamir.Commitment,
 ) RngMachine {
 	rnger, directedOpenings, commitments :=
-		rng.New(index, indices, h, ownSetsOfShares, ownSetsOfCommitments, isZero)
+		rng.New(instance, index, indices, h, ownSetsOfShares, ownSetsOfCommitments, isZero)
 
 	return RngMachine{
 		id:      id,
@@ -85,6 +87,7 @@ func (machine RngMachine) InitialMessages() []mpcutil.Message {
 
 		openings := machine.directedOpenings[to]
 		messages = append(messages, &RngMessage{
+			instance:  machine.rnger.Instance(),
 			from:      machine.id,
 			to:        mpcutil.ID(i),
 			fromIndex: machine.index,
@@ -102,7 +105,7 @@ func (machine RngMachine) InitialMessages() []mpcutil.Message {
 func (machine *RngMachine) Handle(msg mpcutil.Message) []mpcutil.Message {
 	switch msg := msg.(type) {
 	case *RngMessage:
-		shares, _ := machine.rnger.HandleShareBatch(msg.openings)
+		shares, _ := machine.rnger.HandleShareBatch(msg.instance, msg.openings)
 		if shares != nil {
 			machine.outputShares = shares
 		}
@@ -7,10 +7,12 @@ import (
 	"github.com/renproject/shamir"
 
 	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
 )
 
 // RngMessage type represents the message structure in the RNG protocol
 type RngMessage struct {
+	instance  params.InstanceID
 	from, to  mpcutil.ID
 	fromIndex secp256k1.Fn
 	openings  shamir.VerifiableShares
@@ -28,7 +30,8 @@ func (msg RngMessage) To() mpcutil.ID {
 
 // SizeHint implements surge SizeHinter
 func (msg RngMessage) SizeHint() int {
-	return msg.from.SizeHint() +
+	return msg.instance.SizeHint() +
+		msg.from.SizeHint() +
 		msg.to.SizeHint() +
 		msg.fromIndex.SizeHint() +
 		msg.openings.SizeHint()
@@ -36,7 +39,11 @@ func (msg RngMessage) SizeHint() int {
 
 // Marshal implements surge Marshaler
 func (msg RngMessage) Marshal(buf []byte, rem int) ([]byte, int, error) {
-	buf, rem, err := msg.from.Marshal(buf, rem)
+	buf, rem, err := msg.instance.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("marshaling instance: %v", err)
+	}
+	buf, rem, err = msg.from.Marshal(buf, rem)
 	if err != nil {
 		return buf, rem, fmt.Errorf("marshaling from: %v", err)
 	}
@@ -58,7 +65,11 @@ func (msg RngMessage) Marshal(buf []byte, rem int) ([]byte, int, error) {
 
 // Unmarshal implements surge Unmarshaler
 func (msg *RngMessage) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
-	buf, rem, err := msg.from.Unmarshal(buf, rem)
+	buf, rem, err := msg.instance.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, fmt.Errorf("unmarshaling instance: %v", err)
+	}
+	buf, rem, err = msg.from.Unmarshal(buf, rem)
 	if err != nil {
 		return buf, rem, fmt.Errorf("unmarshaling from: %v", err)
 	}
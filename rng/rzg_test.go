@@ -12,12 +12,16 @@ import (
 	"github.com/renproject/shamir/shamirutil"
 
 	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/mpc/rng/rngutil"
 )
 
 var _ = Describe("RZG", func() {
 	rand.Seed(int64(time.Now().Nanosecond()))
 
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
 	Describe("Network Simulation", func() {
 		var ids []mpcutil.ID
 		var machines []mpcutil.Machine
@@ -49,7 +53,7 @@ var _ = Describe("RZG", func() {
 			for i, index := range indices {
 				id := mpcutil.ID(i)
 				rngMachine := rngutil.NewRngMachine(
-					id, index, indices, b, k, h, isZero,
+					id, instance, index, indices, b, k, h, isZero,
 					setsOfSharesByPlayer[index],
 					setsOfCommitmentsByPlayer,
 				)
@@ -11,6 +11,8 @@ import (
 	"github.com/renproject/shamir"
 	"github.com/renproject/shamir/shamirutil"
 
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/mpc/params"
 	"github.com/renproject/mpc/rng"
 	"github.com/renproject/mpc/rng/rngutil"
 )
@@ -18,6 +20,9 @@ import (
 var _ = Describe("RNG/RZG state transitions", func() {
 	rand.Seed(int64(time.Now().Nanosecond()))
 
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
 	RandomTestParameters := func(isZero bool) (
 		int,
 		[]secp256k1.Fn,
@@ -65,7 +70,7 @@ var _ = Describe("RNG/RZG state transitions", func() {
 			Specify("when given nil shares, no initial messages should be supplied", func() {
 				_, indices, index, b, c, k, h := RandomTestParameters(isZero)
 				_, brngCommitmentBatch := rngutil.BRNGOutputBatch(index, b, c, k, h)
-				_, directedOpenings, _ := rng.New(index, indices, h, nil, brngCommitmentBatch, isZero)
+				_, directedOpenings, _ := rng.New(instance, index, indices, h, nil, brngCommitmentBatch, isZero)
 				Expect(directedOpenings).To(BeNil())
 			})
 
@@ -73,7 +78,7 @@ var _ = Describe("RNG/RZG state transitions", func() {
 				_, indices, index, b, c, k, h := RandomTestParameters(isZero)
 				brngShareBatch, brngCommitmentBatch := rngutil.BRNGOutputBatch(index, b, c, k, h)
 				_, directedOpenings, _ := rng.New(
-					index, indices, h, brngShareBatch, brngCommitmentBatch, isZero,
+					instance, index, indices, h, brngShareBatch, brngCommitmentBatch, isZero,
 				)
 				Expect(directedOpenings).ToNot(BeNil())
 			})
@@ -83,7 +88,7 @@ var _ = Describe("RNG/RZG state transitions", func() {
 				ownSetsOfShares, ownSetsOfCommitments, openingsByPlayer, _ :=
 					rngutil.RNGSharesBatch(indices, index, b, k, h, isZero)
 				_, directedOpenings, _ := rng.New(
-					index, indices, h, ownSetsOfShares, ownSetsOfCommitments, isZero,
+					instance, index, indices, h, ownSetsOfShares, ownSetsOfCommitments, isZero,
 				)
 
 				selfOpenings := directedOpenings[index]
@@ -98,7 +103,7 @@ var _ = Describe("RNG/RZG state transitions", func() {
 				_, indices, index, b, _, k, h := RandomTestParameters(isZero)
 				ownSetsOfShares, ownSetsOfCommitments, openingsByPlayer, _ :=
 					rngutil.RNGSharesBatch(indices, index, b, k, h, isZero)
-				rnger, _, _ := rng.New(index, indices, h, ownSetsOfShares, ownSetsOfCommitments, isZero)
+				rnger, _, _ := rng.New(instance, index, indices, h, ownSetsOfShares, ownSetsOfCommitments, isZero)
 
 				// Pick an index other than our own.
 				from := indices[rand.Intn(len(indices))]
@@ -107,12 +112,18 @@ var _ = Describe("RNG/RZG state transitions", func() {
 				}
 
 				// Incorrect shares batch length.
-				_, err := rnger.HandleShareBatch(openingsByPlayer[from][1:])
+				_, err := rnger.HandleShareBatch(instance, openingsByPlayer[from][1:])
 				Expect(err).To(HaveOccurred())
 
+				// Share batch for a different instance.
+				otherInstance := instance
+				otherInstance[rand.Intn(len(otherInstance))]++
+				_, err = rnger.HandleShareBatch(otherInstance, openingsByPlayer[from])
+				Expect(err).To(Equal(open.ErrIncorrectInstance))
+
 				// Invalid share (random value).
 				openingsByPlayer[from][rand.Intn(b)].Share.Value = secp256k1.RandomFn()
-				_, err = rnger.HandleShareBatch(openingsByPlayer[from])
+				_, err = rnger.HandleShareBatch(instance, openingsByPlayer[from])
 				Expect(err).To(HaveOccurred())
 			})
 
@@ -120,7 +131,7 @@ var _ = Describe("RNG/RZG state transitions", func() {
 				_, indices, index, b, _, k, h := RandomTestParameters(isZero)
 				ownSetsOfShares, ownSetsOfCommitments, openingsByPlayer, _ :=
 					rngutil.RNGSharesBatch(indices, index, b, k, h, isZero)
-				rnger, _, _ := rng.New(index, indices, h, ownSetsOfShares, ownSetsOfCommitments, isZero)
+				rnger, _, _ := rng.New(instance, index, indices, h, ownSetsOfShares, ownSetsOfCommitments, isZero)
 
 				// The own player's openings have already been processed.
 				count := 1
@@ -129,7 +140,7 @@ var _ = Describe("RNG/RZG state transitions", func() {
 						continue
 					}
 
-					outputShares, err := rnger.HandleShareBatch(openingsByPlayer[from])
+					outputShares, err := rnger.HandleShareBatch(instance, openingsByPlayer[from])
 					Expect(err).ToNot(HaveOccurred())
 					count++
 
@@ -147,12 +158,12 @@ var _ = Describe("RNG/RZG state transitions", func() {
 				ownSetsOfShares, ownSetsOfCommitments, openingsByPlayer, _ :=
 					rngutil.RNGSharesBatch(indices, index, b, k, h, isZero)
 				rnger, _, commitments := rng.New(
-					index, indices, h, ownSetsOfShares, ownSetsOfCommitments, isZero,
+					instance, index, indices, h, ownSetsOfShares, ownSetsOfCommitments, isZero,
 				)
 
 				var shares shamir.VerifiableShares
 				for _, from := range indices {
-					shares, _ = rnger.HandleShareBatch(openingsByPlayer[from])
+					shares, _ = rnger.HandleShareBatch(instance, openingsByPlayer[from])
 					if shares != nil {
 						break
 					}
@@ -173,7 +184,7 @@ var _ = Describe("RNG/RZG state transitions", func() {
 				inf := secp256k1.NewPointInfinity()
 				brngShareBatch, brngCommitmentBatch := rngutil.BRNGOutputBatch(index, b, c, k, h)
 				Expect(func() {
-					rng.New(index, indices, inf, brngShareBatch, brngCommitmentBatch, isZero)
+					rng.New(instance, index, indices, inf, brngShareBatch, brngCommitmentBatch, isZero)
 				}).To(Panic())
 			})
 
@@ -181,7 +192,7 @@ var _ = Describe("RNG/RZG state transitions", func() {
 				_, indices, index, b, c, k, h := RandomTestParameters(isZero)
 				brngShareBatch, _ := rngutil.BRNGOutputBatch(index, b, c, k, h)
 				Expect(func() {
-					rng.New(index, indices, h, brngShareBatch, [][]shamir.Commitment{}, isZero)
+					rng.New(instance, index, indices, h, brngShareBatch, [][]shamir.Commitment{}, isZero)
 				}).To(Panic())
 			})
 
@@ -197,7 +208,7 @@ var _ = Describe("RNG/RZG state transitions", func() {
 					brngCommitmentBatch[0] = brngCommitmentBatch[0][:0]
 				}
 				Expect(func() {
-					rng.New(index, indices, h, brngShareBatch, brngCommitmentBatch, isZero)
+					rng.New(instance, index, indices, h, brngShareBatch, brngCommitmentBatch, isZero)
 				}).To(Panic())
 			})
 
@@ -206,7 +217,7 @@ var _ = Describe("RNG/RZG state transitions", func() {
 				brngShareBatch, brngCommitmentBatch := rngutil.BRNGOutputBatch(index, b, c, k, h)
 				brngShareBatch = brngShareBatch[1:]
 				Expect(func() {
-					rng.New(index, indices, h, brngShareBatch, brngCommitmentBatch, isZero)
+					rng.New(instance, index, indices, h, brngShareBatch, brngCommitmentBatch, isZero)
 				}).To(Panic())
 			})
 
@@ -219,7 +230,7 @@ var _ = Describe("RNG/RZG state transitions", func() {
 				brngShareBatch, brngCommitmentBatch := rngutil.BRNGOutputBatch(index, b, c, k, h)
 				brngCommitmentBatch[1] = brngCommitmentBatch[1][1:]
 				Expect(func() {
-					rng.New(index, indices, h, brngShareBatch, brngCommitmentBatch, isZero)
+					rng.New(instance, index, indices, h, brngShareBatch, brngCommitmentBatch, isZero)
 				}).To(Panic())
 			})
 
@@ -228,7 +239,7 @@ var _ = Describe("RNG/RZG state transitions", func() {
 				brngShareBatch, brngCommitmentBatch := rngutil.BRNGOutputBatch(index, b, c, k, h)
 				brngCommitmentBatch[0][0] = shamir.Commitment{}
 				Expect(func() {
-					rng.New(index, indices, h, brngShareBatch, brngCommitmentBatch, isZero)
+					rng.New(instance, index, indices, h, brngShareBatch, brngCommitmentBatch, isZero)
 				}).To(Panic())
 			})
 
@@ -237,7 +248,7 @@ var _ = Describe("RNG/RZG state transitions", func() {
 				brngShareBatch, brngCommitmentBatch := rngutil.BRNGOutputBatch(index, b, c, k, h)
 				brngShareBatch[0] = brngShareBatch[0][1:]
 				Expect(func() {
-					rng.New(index, indices, h, brngShareBatch, brngCommitmentBatch, isZero)
+					rng.New(instance, index, indices, h, brngShareBatch, brngCommitmentBatch, isZero)
 				}).To(Panic())
 			})
 		})
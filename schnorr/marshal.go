@@ -0,0 +1,81 @@
+package schnorr
+
+import (
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/surge"
+)
+
+// SizeHint implements the surge.SizeHinter interface.
+func (signer Signer) SizeHint() int {
+	return signer.opener.SizeHint() +
+		surge.SizeHint(signer.nonceBatch)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (signer Signer) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := signer.opener.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(signer.nonceBatch, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (signer *Signer) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := signer.opener.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&signer.nonceBatch, buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (signer Signer) Generate(rand *rand.Rand, size int) reflect.Value {
+	size /= 2
+	opener := open.Opener{}.Generate(rand, size).Interface().(open.Opener)
+	nonceBatch := make([]secp256k1.Point, rand.Intn(size/4+1)+1)
+	for i := range nonceBatch {
+		nonceBatch[i] = secp256k1.RandomPoint()
+	}
+	return reflect.ValueOf(Signer{opener, nonceBatch})
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (sig Signature) SizeHint() int {
+	return len(sig)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (sig Signature) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	var err error
+	for i := range sig {
+		buf, rem, err = surge.MarshalU8(sig[i], buf, rem)
+		if err != nil {
+			return buf, rem, err
+		}
+	}
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (sig *Signature) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	var err error
+	for i := range sig {
+		buf, rem, err = surge.UnmarshalU8(&sig[i], buf, rem)
+		if err != nil {
+			return buf, rem, err
+		}
+	}
+	return buf, rem, nil
+}
+
+// Generate implements the quick.Generator interface.
+func (sig Signature) Generate(rand *rand.Rand, _ int) reflect.Value {
+	var generated Signature
+	rand.Read(generated[:])
+	return reflect.ValueOf(generated)
+}
@@ -0,0 +1,282 @@
+package schnorr
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// fieldPrime is the order of the field over which the secp256k1 curve is
+// defined.
+var fieldPrime, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+
+// challengeTag is the tag used for the hash that computes the challenge in
+// BIP-340.
+const challengeTag = "BIP0340/challenge"
+
+// A Signer is a state machine that implements threshold Schnorr signing as
+// specified in BIP-340.
+//
+// The inputs to the signing protocol are a sharing of the private key x along
+// with the public key P = xG, and for each signature a sharing of a random
+// nonce k along with the nonce point R = kG (the output of RKPG). BIP-340 uses
+// x-only public keys and nonce points, which implicitly have an even y
+// coordinate, and so if P (respectively R) has an odd y coordinate, the
+// sharing of x (respectively k) is negated. For a message m, the signature is
+// (r, s) where r is the x coordinate of R and
+//	s = k + e*x,
+// where e is the challenge, computed as the tagged hash of r, the x coordinate
+// of P and m. Since e is public, a sharing of s can be computed locally, and
+// so the signing protocol consists of a single open.
+//
+// The state machine supports batching. Each element in the batch can use a
+// different private key, but all sharings must have been created with the same
+// indices, reconstruction threshold (k) and Pedersen parameter (h). Each nonce
+// must only ever be used to sign a single message, as otherwise the private
+// key can be recovered from the signatures.
+type Signer struct {
+	opener     open.Opener
+	nonceBatch []secp256k1.Point
+}
+
+// New returns a new Signer state machine along with the share batch that is
+// to be broadcast to the other parties. The state machine will handle this
+// share batch before being returned.
+//
+// Panics: This function will panic if any of the following conditions are
+// met.
+//	- The batch size is less than 1.
+//	- The messages, key sharings, public keys, nonce sharings or nonce points
+//		have different batch sizes.
+//	- Any of the public keys or nonce points is the point at infinity.
+//	- Any of the conditions for which open.NewPublic would panic.
+func New(
+	instance params.InstanceID,
+	msgBatch [][32]byte,
+	keyShareBatch shamir.VerifiableShares, keyCommitmentBatch []shamir.Commitment,
+	pubKeyBatch []secp256k1.Point,
+	nonceShareBatch shamir.VerifiableShares, nonceCommitmentBatch []shamir.Commitment,
+	nonceBatch []secp256k1.Point,
+	indices []secp256k1.Fn, h secp256k1.Point,
+) (Signer, shamir.VerifiableShares) {
+	if !params.ValidPedersenParameter(h) {
+		panic("insecure choice of pedersen parameter")
+	}
+	b := len(msgBatch)
+	if b < 1 {
+		panic(fmt.Sprintf("batch size should be at least 1: got %v", b))
+	}
+	if len(keyShareBatch) != b ||
+		len(keyCommitmentBatch) != b ||
+		len(pubKeyBatch) != b ||
+		len(nonceShareBatch) != b ||
+		len(nonceCommitmentBatch) != b ||
+		len(nonceBatch) != b {
+		panic("inconsistent batch size")
+	}
+
+	one := secp256k1.NewFnFromU16(1)
+	var minusOne secp256k1.Fn
+	minusOne.Negate(&one)
+
+	shares := make(shamir.VerifiableShares, b)
+	commitments := make([]shamir.Commitment, b)
+	nonceBatchCopy := make([]secp256k1.Point, b)
+	for i := 0; i < b; i++ {
+		pubKey := &pubKeyBatch[i]
+		nonce := &nonceBatch[i]
+		if pubKey.IsInfinity() || nonce.IsInfinity() {
+			panic("public key or nonce point is the point at infinity")
+		}
+		nonceBatchCopy[i] = *nonce
+
+		// The coefficients for the nonce and key sharings, taking into
+		// account the negation for points with odd y coordinates.
+		nonceCoeff := one
+		if !hasEvenY(nonce) {
+			nonceCoeff = minusOne
+		}
+		keyCoeff := challenge(XOnly(nonce), XOnly(pubKey), msgBatch[i])
+		if !hasEvenY(pubKey) {
+			keyCoeff.Negate(&keyCoeff)
+		}
+
+		// Compute a share of k + e*x and the corresponding commitment.
+		var keyShare shamir.VerifiableShare
+		shares[i].Scale(&nonceShareBatch[i], &nonceCoeff)
+		keyShare.Scale(&keyShareBatch[i], &keyCoeff)
+		shares[i].Add(&shares[i], &keyShare)
+
+		keyCommitment := shamir.NewCommitmentWithCapacity(keyCommitmentBatch[i].Len())
+		commitments[i] = shamir.NewCommitmentWithCapacity(nonceCommitmentBatch[i].Len())
+		commitments[i].Scale(nonceCommitmentBatch[i], &nonceCoeff)
+		keyCommitment.Scale(keyCommitmentBatch[i], &keyCoeff)
+		commitments[i].Add(commitments[i], keyCommitment)
+	}
+
+	opener := open.NewPublic(instance, commitments, indices, h)
+	secrets, _, err := opener.HandleShareBatch(instance, shares)
+	if err != nil {
+		panic(fmt.Sprintf("unexpected error handling own share: %v", err))
+	}
+	if secrets != nil {
+		panic("opener should not have reconstructed after one share")
+	}
+
+	signer := Signer{
+		opener:     opener,
+		nonceBatch: nonceBatchCopy,
+	}
+	return signer, shares
+}
+
+// Instance returns the instance identifier of the signing protocol.
+func (signer Signer) Instance() params.InstanceID {
+	return signer.opener.Instance()
+}
+
+// HandleShareBatch applies a state transition upon receiving the given shares
+// from another party during the open. Once enough valid shares have been
+// received to reconstruct, the output signatures are computed and returned. If
+// not enough shares have been received, the return value will be nil. If the
+// share batch is invalid in any way, an error will be returned along with a
+// nil value.
+func (signer *Signer) HandleShareBatch(
+	instance params.InstanceID,
+	shareBatch shamir.VerifiableShares,
+) ([]Signature, error) {
+	secrets, _, err := signer.opener.HandleShareBatch(instance, shareBatch)
+	if err != nil {
+		return nil, err
+	}
+	if secrets == nil {
+		return nil, nil
+	}
+	sigs := make([]Signature, len(secrets))
+	for i := range secrets {
+		sigs[i] = NewSignature(XOnly(&signer.nonceBatch[i]), secrets[i])
+	}
+	return sigs, nil
+}
+
+// Verify returns true if the given signature is a valid BIP-340 signature for
+// the given message and x-only public key, and false otherwise.
+func Verify(pubKey [32]byte, msg [32]byte, sig Signature) bool {
+	p, ok := liftX(pubKey)
+	if !ok {
+		return false
+	}
+	r := sig.R()
+	if new(big.Int).SetBytes(r[:]).Cmp(fieldPrime) >= 0 {
+		return false
+	}
+	var s secp256k1.Fn
+	sBytes := sig.S()
+	if overflow := s.SetB32(sBytes[:]); overflow {
+		return false
+	}
+
+	// Compute R = sG - eP.
+	e := challenge(r, pubKey, msg)
+	e.Negate(&e)
+	var nonce, eP secp256k1.Point
+	nonce.BaseExp(&s)
+	eP.Scale(&p, &e)
+	nonce.Add(&nonce, &eP)
+	if nonce.IsInfinity() || !hasEvenY(&nonce) {
+		return false
+	}
+	return XOnly(&nonce) == r
+}
+
+// XOnly returns the 32 byte x-only representation of the given point as
+// specified in BIP-340, that is, the big endian encoding of its x coordinate.
+//
+// Panics: This function will panic if the point is the point at infinity.
+func XOnly(p *secp256k1.Point) [32]byte {
+	if p.IsInfinity() {
+		panic("point at infinity has no x-only representation")
+	}
+	x, _ := msm.XY(p)
+	var xBytes [32]byte
+	x.PutB32(xBytes[:])
+	return xBytes
+}
+
+// hasEvenY returns true if the y coordinate of the given point is even.
+//
+// Panics: This function will panic if the point is the point at infinity.
+func hasEvenY(p *secp256k1.Point) bool {
+	if p.IsInfinity() {
+		panic("point at infinity has no y coordinate")
+	}
+	_, y := msm.XY(p)
+	var yBytes [32]byte
+	y.PutB32(yBytes[:])
+	return yBytes[31]&1 == 0
+}
+
+// liftX returns the point with the given x coordinate and an even y
+// coordinate. If no such point exists, the second return value will be false.
+func liftX(xBytes [32]byte) (secp256k1.Point, bool) {
+	x := new(big.Int).SetBytes(xBytes[:])
+	if x.Cmp(fieldPrime) >= 0 {
+		return secp256k1.Point{}, false
+	}
+
+	// c = x^3 + 7 and y = c^((p+1)/4), which is a square root of c if c is a
+	// quadratic residue since p = 3 mod 4.
+	c := new(big.Int).Exp(x, big.NewInt(3), fieldPrime)
+	c.Add(c, big.NewInt(7))
+	c.Mod(c, fieldPrime)
+	e := new(big.Int).Add(fieldPrime, big.NewInt(1))
+	e.Rsh(e, 2)
+	y := new(big.Int).Exp(c, e, fieldPrime)
+	if new(big.Int).Exp(y, big.NewInt(2), fieldPrime).Cmp(c) != 0 {
+		return secp256k1.Point{}, false
+	}
+	if y.Bit(0) == 1 {
+		y.Sub(fieldPrime, y)
+	}
+
+	var yBytes [32]byte
+	yBytesTrimmed := y.Bytes()
+	copy(yBytes[32-len(yBytesTrimmed):], yBytesTrimmed)
+	var xFp, yFp secp256k1.Fp
+	_ = xFp.SetB32(xBytes[:])
+	_ = yFp.SetB32(yBytes[:])
+	var p secp256k1.Point
+	p.SetXY(&xFp, &yFp)
+	return p, true
+}
+
+// challenge computes the BIP-340 challenge for the given x-only nonce point,
+// x-only public key and message.
+func challenge(r, pubKey, msg [32]byte) secp256k1.Fn {
+	hash := taggedHash(challengeTag, r[:], pubKey[:], msg[:])
+	var e secp256k1.Fn
+	_ = e.SetB32(hash[:])
+	return e
+}
+
+// taggedHash computes the tagged hash of the concatenation of the given
+// messages as specified in BIP-340, that is,
+//	SHA256(SHA256(tag) || SHA256(tag) || msg).
+func taggedHash(tag string, msgs ...[]byte) [32]byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, msg := range msgs {
+		h.Write(msg)
+	}
+	var hash [32]byte
+	copy(hash[:], h.Sum(nil))
+	return hash
+}
@@ -0,0 +1,13 @@
+package schnorr_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSchnorr(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Schnorr Suite")
+}
@@ -0,0 +1,351 @@
+package schnorr_test
+
+import (
+	"encoding/hex"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg/rkpgutil"
+	"github.com/renproject/mpc/schnorr"
+	"github.com/renproject/mpc/schnorr/schnorrutil"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+)
+
+var _ = Describe("Schnorr", func() {
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
+	randomMsg := func() [32]byte {
+		var msg [32]byte
+		rand.Read(msg[:])
+		return msg
+	}
+
+	decode32 := func(s string) [32]byte {
+		var bs [32]byte
+		n, err := hex.Decode(bs[:], []byte(s))
+		if err != nil || n != 32 {
+			panic("invalid test vector")
+		}
+		return bs
+	}
+
+	decodeSig := func(s string) schnorr.Signature {
+		var sig schnorr.Signature
+		n, err := hex.Decode(sig[:], []byte(s))
+		if err != nil || n != 64 {
+			panic("invalid test vector")
+		}
+		return sig
+	}
+
+	pubKeys := func(keys []secp256k1.Fn) []secp256k1.Point {
+		points := make([]secp256k1.Point, len(keys))
+		for i := range keys {
+			points[i].BaseExp(&keys[i])
+		}
+		return points
+	}
+
+	Context("verification", func() {
+		It("should accept the BIP-340 test vectors", func() {
+			vectors := []struct {
+				pubKey, msg, sig string
+			}{
+				{
+					"F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+					"0000000000000000000000000000000000000000000000000000000000000000",
+					"E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA8215" +
+						"25F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0",
+				},
+				{
+					"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+					"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+					"6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE3341" +
+						"8906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+				},
+			}
+			for _, vector := range vectors {
+				pubKey := decode32(vector.pubKey)
+				msg := decode32(vector.msg)
+				sig := decodeSig(vector.sig)
+				Expect(schnorr.Verify(pubKey, msg, sig)).To(BeTrue())
+
+				// Modifying any part of the signature should make it invalid.
+				for _, i := range []int{0, 31, 32, 63} {
+					modified := sig
+					modified[i] ^= 1
+					Expect(schnorr.Verify(pubKey, msg, modified)).To(BeFalse())
+				}
+				msg[0] ^= 1
+				Expect(schnorr.Verify(pubKey, msg, sig)).To(BeFalse())
+			}
+		})
+
+		It("should reject public keys that are not on the curve", func() {
+			// This x coordinate does not correspond to a point on the curve.
+			pubKey := decode32("EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34")
+			msg := randomMsg()
+			var sig schnorr.Signature
+			rand.Read(sig[:])
+			Expect(schnorr.Verify(pubKey, msg, sig)).To(BeFalse())
+		})
+
+		It("should not have an x-only representation for the point at infinity", func() {
+			inf := secp256k1.NewPointInfinity()
+			Expect(func() { schnorr.XOnly(&inf) }).To(Panic())
+		})
+	})
+
+	Context("signing", func() {
+		n := 10
+		k := 3
+
+		// The keys and nonces are chosen so that every combination of even
+		// and odd y coordinates for the public key and nonce point occurs.
+		It("should produce valid signatures for keys and nonces with odd and even y coordinates", func() {
+			b := 4
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+
+			keys := make([]secp256k1.Fn, b)
+			nonces := make([]secp256k1.Fn, b)
+			for i := 0; i < b; i++ {
+				for {
+					keys[i] = secp256k1.RandomFn()
+					nonces[i] = secp256k1.RandomFn()
+					var p, r secp256k1.Point
+					p.BaseExp(&keys[i])
+					r.BaseExp(&nonces[i])
+					_, py := p.XY()
+					_, ry := r.XY()
+					var zero secp256k1.Fp
+					py.Add(&py, &zero)
+					ry.Add(&ry, &zero)
+					var pyBytes, ryBytes [32]byte
+					py.PutB32(pyBytes[:])
+					ry.PutB32(ryBytes[:])
+					if int(pyBytes[31]&1) == i%2 && int(ryBytes[31]&1) == i/2 {
+						break
+					}
+				}
+			}
+
+			keyShares := make([]shamir.VerifiableShares, n)
+			nonceShares := make([]shamir.VerifiableShares, n)
+			for j := range keyShares {
+				keyShares[j] = make(shamir.VerifiableShares, b)
+				nonceShares[j] = make(shamir.VerifiableShares, b)
+			}
+			keyComs := make([]shamir.Commitment, b)
+			nonceComs := make([]shamir.Commitment, b)
+			for i := 0; i < b; i++ {
+				var shares shamir.VerifiableShares
+				shares, keyComs[i] = rkpgutil.RXGOutput(indices, k, h, keys[i])
+				for j := range shares {
+					keyShares[j][i] = shares[j]
+				}
+				shares, nonceComs[i] = rkpgutil.RXGOutput(indices, k, h, nonces[i])
+				for j := range shares {
+					nonceShares[j][i] = shares[j]
+				}
+			}
+
+			msgs := make([][32]byte, b)
+			for i := range msgs {
+				msgs[i] = randomMsg()
+			}
+			pubKeyBatch := pubKeys(keys)
+			nonceBatch := pubKeys(nonces)
+
+			signer, _ := schnorr.New(
+				instance,
+				msgs,
+				keyShares[0], keyComs, pubKeyBatch,
+				nonceShares[0], nonceComs, nonceBatch,
+				indices, h,
+			)
+			var sigs []schnorr.Signature
+			for j := 1; j < k; j++ {
+				_, shares := schnorr.New(
+					instance,
+					msgs,
+					keyShares[j], keyComs, pubKeyBatch,
+					nonceShares[j], nonceComs, nonceBatch,
+					indices, h,
+				)
+				var err error
+				sigs, err = signer.HandleShareBatch(instance, shares)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(len(sigs)).To(Equal(b))
+			for i := 0; i < b; i++ {
+				Expect(schnorr.Verify(schnorr.XOnly(&pubKeyBatch[i]), msgs[i], sigs[i])).To(BeTrue())
+				Expect(sigs[i].R()).To(Equal(schnorr.XOnly(&nonceBatch[i])))
+
+				wrongMsg := randomMsg()
+				Expect(schnorr.Verify(schnorr.XOnly(&pubKeyBatch[i]), wrongMsg, sigs[i])).To(BeFalse())
+				wrongKey := secp256k1.RandomPoint()
+				Expect(schnorr.Verify(schnorr.XOnly(&wrongKey), msgs[i], sigs[i])).To(BeFalse())
+			}
+		})
+
+		It("should reject invalid shares", func() {
+			b := 2
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			keyShares, keyComs, keys := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			nonceShares, nonceComs, nonces := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			msgs := [][32]byte{randomMsg(), randomMsg()}
+
+			signer, _ := schnorr.New(
+				instance,
+				msgs,
+				keyShares[0], keyComs, pubKeys(keys),
+				nonceShares[0], nonceComs, pubKeys(nonces),
+				indices, h,
+			)
+			_, shares := schnorr.New(
+				instance,
+				msgs,
+				keyShares[1], keyComs, pubKeys(keys),
+				nonceShares[1], nonceComs, pubKeys(nonces),
+				indices, h,
+			)
+
+			var otherInstance params.InstanceID
+			rand.Read(otherInstance[:])
+			sigs, err := signer.HandleShareBatch(otherInstance, shares)
+			Expect(sigs).To(BeNil())
+			Expect(err).To(Equal(open.ErrIncorrectInstance))
+
+			sigs, err = signer.HandleShareBatch(instance, shares[1:])
+			Expect(sigs).To(BeNil())
+			Expect(err).To(Equal(open.ErrIncorrectBatchSize))
+
+			shares[0].Share.Value = secp256k1.RandomFn()
+			sigs, err = signer.HandleShareBatch(instance, shares)
+			Expect(sigs).To(BeNil())
+			Expect(err).To(Equal(open.ErrInvalidShares))
+		})
+
+		It("should panic if the nonce point is the point at infinity", func() {
+			indices := shamirutil.RandomIndices(n)
+			h := secp256k1.RandomPoint()
+			keyShares, keyComs, keys := rkpgutil.RNGOutputBatch(indices, k, 1, h)
+			nonceShares, nonceComs, _ := rkpgutil.RNGOutputBatch(indices, k, 1, h)
+			Expect(func() {
+				schnorr.New(
+					instance,
+					[][32]byte{randomMsg()},
+					keyShares[0], keyComs, pubKeys(keys),
+					nonceShares[0], nonceComs, []secp256k1.Point{secp256k1.NewPointInfinity()},
+					indices, h,
+				)
+			}).To(Panic())
+		})
+	})
+
+	Context("network", func() {
+		n := 15
+		k := 4
+		b := 3
+		t := k - 1
+
+		tys := []schnorrutil.MachineType{
+			schnorrutil.Offline,
+			schnorrutil.Malicious,
+		}
+		for _, ty := range tys {
+			ty := ty
+
+			Specify("all honest nodes should compute valid signatures", func() {
+				indices := shamirutil.RandomIndices(n)
+				h := secp256k1.RandomPoint()
+				machines := make([]mpcutil.Machine, n)
+
+				keyShares, keyCommitments, keys := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				nonceShares, nonceCommitments, nonces := rkpgutil.RNGOutputBatch(indices, k, b, h)
+				pubKeyBatch := pubKeys(keys)
+				nonceBatch := pubKeys(nonces)
+
+				msgs := make([][32]byte, b)
+				for i := range msgs {
+					msgs[i] = randomMsg()
+				}
+
+				ids := make([]mpcutil.ID, n)
+				for i := range ids {
+					ids[i] = mpcutil.ID(i + 1)
+				}
+				dishonestIDs := make(map[mpcutil.ID]struct{}, t)
+				{
+					tmp := make([]mpcutil.ID, n)
+					copy(tmp, ids)
+					rand.Shuffle(len(tmp), func(i, j int) {
+						tmp[i], tmp[j] = tmp[j], tmp[i]
+					})
+					for _, id := range tmp[:t] {
+						dishonestIDs[id] = struct{}{}
+					}
+				}
+				machineType := make(map[mpcutil.ID]schnorrutil.MachineType, n)
+				for _, id := range ids {
+					if _, ok := dishonestIDs[id]; ok {
+						machineType[id] = ty
+					} else {
+						machineType[id] = schnorrutil.Honest
+					}
+				}
+
+				honestMachines := make([]*schnorrutil.Machine, 0, n-t)
+				for i, id := range ids {
+					var machine mpcutil.Machine
+					switch machineType[id] {
+					case schnorrutil.Offline:
+						m := mpcutil.OfflineMachine(ids[i])
+						machine = &m
+					case schnorrutil.Malicious:
+						m := schnorrutil.NewMaliciousMachine(ids, id, instance, indices, b)
+						machine = &m
+					case schnorrutil.Honest:
+						m := schnorrutil.NewMachine(
+							instance,
+							msgs,
+							keyShares[i], keyCommitments, pubKeyBatch,
+							nonceShares[i], nonceCommitments, nonceBatch,
+							ids, id, indices, h,
+						)
+						honestMachines = append(honestMachines, &m)
+						machine = &m
+					default:
+						panic("unexpected machine type")
+					}
+					machines[i] = machine
+				}
+
+				shuffleMsgs, _ := mpcutil.MessageShufflerDropper(ids, 0)
+				network := mpcutil.NewNetwork(machines, shuffleMsgs)
+				network.SetCaptureHist(true)
+				err := network.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				for i := 0; i < b; i++ {
+					sig := honestMachines[0].Signatures[i]
+					Expect(schnorr.Verify(schnorr.XOnly(&pubKeyBatch[i]), msgs[i], sig)).To(BeTrue())
+					for _, machine := range honestMachines {
+						Expect(machine.Signatures[i]).To(Equal(sig))
+					}
+				}
+			})
+		}
+	})
+})
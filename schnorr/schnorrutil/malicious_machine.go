@@ -0,0 +1,96 @@
+package schnorrutil
+
+import (
+	"github.com/renproject/mpc/mpcutil"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// MaliciousMachine represents a player that deviates from the signing
+// protocol by sending shares with random values.
+type MaliciousMachine struct {
+	OwnID    mpcutil.ID
+	InitMsgs []Message
+}
+
+// NewMaliciousMachine constructs a new malicious machine for a signing
+// network test that will sign a batch of b messages in the given instance. The
+// player with ID ids[i] is assumed to have index indices[i].
+func NewMaliciousMachine(
+	ids []mpcutil.ID, ownID mpcutil.ID,
+	instance params.InstanceID,
+	indices []secp256k1.Fn, b int,
+) MaliciousMachine {
+	var ownIndex secp256k1.Fn
+	for i, id := range ids {
+		if id == ownID {
+			ownIndex = indices[i]
+		}
+	}
+	initialMessages := make([]Message, 0, len(ids)-1)
+	for _, id := range ids {
+		if id == ownID {
+			continue
+		}
+		shares := make(shamir.VerifiableShares, b)
+		for i := range shares {
+			shares[i] = shamir.NewVerifiableShare(
+				shamir.NewShare(ownIndex, secp256k1.RandomFn()),
+				secp256k1.RandomFn(),
+			)
+		}
+		initialMessages = append(initialMessages, Message{
+			FromID:   ownID,
+			ToID:     id,
+			Instance: instance,
+			Shares:   shares,
+		})
+	}
+	return MaliciousMachine{
+		OwnID:    ownID,
+		InitMsgs: initialMessages,
+	}
+}
+
+// ID implements the Machine interface.
+func (m MaliciousMachine) ID() mpcutil.ID { return m.OwnID }
+
+// InitialMessages implements the Machine interface.
+func (m MaliciousMachine) InitialMessages() []mpcutil.Message {
+	msgs := make([]mpcutil.Message, len(m.InitMsgs))
+	for i := range m.InitMsgs {
+		msgs[i] = &m.InitMsgs[i]
+	}
+	return msgs
+}
+
+// Handle implements the Machine interface.
+func (m *MaliciousMachine) Handle(msg mpcutil.Message) []mpcutil.Message {
+	return nil
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (m MaliciousMachine) SizeHint() int {
+	return m.OwnID.SizeHint() +
+		surge.SizeHint(m.InitMsgs)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (m MaliciousMachine) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(m.InitMsgs, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (m *MaliciousMachine) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := m.OwnID.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&m.InitMsgs, buf, rem)
+}
@@ -0,0 +1,17 @@
+package schnorrutil
+
+// MachineType represents a type of player in the network.
+type MachineType byte
+
+const (
+	// Honest represents a player that follows the signing protocol as
+	// specified.
+	Honest = MachineType(iota)
+
+	// Offline represents a player that is offline.
+	Offline
+
+	// Malicious represents a player that deviates from the signing protocol
+	// by sending shares with incorrect values.
+	Malicious
+)
@@ -0,0 +1,31 @@
+package schnorr
+
+import "github.com/renproject/secp256k1"
+
+// A Signature is a 64 byte BIP-340 Schnorr signature over the secp256k1
+// curve. It is the concatenation of the x-only nonce point r and the big
+// endian encoding of s.
+type Signature [64]byte
+
+// NewSignature constructs a new signature from the given x-only nonce point
+// and s value.
+func NewSignature(r [32]byte, s secp256k1.Fn) Signature {
+	var sig Signature
+	copy(sig[:32], r[:])
+	s.PutB32(sig[32:])
+	return sig
+}
+
+// R returns the x-only nonce point of the signature.
+func (sig Signature) R() [32]byte {
+	var r [32]byte
+	copy(r[:], sig[:32])
+	return r
+}
+
+// S returns the big endian encoding of the s value of the signature.
+func (sig Signature) S() [32]byte {
+	var s [32]byte
+	copy(s[:], sig[32:])
+	return s
+}
@@ -0,0 +1,128 @@
+// Package transcript implements a transcript for the Fiat-Shamir transform
+// that is used by the non-interactive proofs in this module. It is in the
+// style of Merlin, but built on a chained SHA-256 hash rather than STROBE.
+//
+// A transcript is created with a domain separator that identifies the proof
+// system, and the statement and context for the proof are then appended to it
+// as a sequence of labelled messages, before challenges are derived from it.
+// Each message is hashed together with its label, its length and the previous
+// state, so that two transcripts produce the same challenges only if the same
+// domain separator and the same sequence of labelled messages were used. In
+// particular, a proof for one statement or context can not be replayed for
+// another, as long as everything that distinguishes them is appended to the
+// transcript.
+//
+// The prover and verifier must append the same messages in the same order.
+// For example, a proof would typically be created and verified as follows.
+//	t := transcript.New("example proof")
+//	t.AppendBytes("instance", instance[:])
+//	t.AppendPoint("statement", &point)
+//	t.AppendPoint("commitment", &commitment)
+//	challenge := t.Challenge("challenge")
+package transcript
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/renproject/mpc/msm"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/surge"
+)
+
+// protocolLabel is absorbed into the state of every transcript before the
+// domain separator, so that the transcripts in this module can not collide
+// with other uses of the same construction.
+const protocolLabel = "renproject/mpc/transcript"
+
+const (
+	opDomain    = byte(0)
+	opAppend    = byte(1)
+	opChallenge = byte(2)
+)
+
+// A Transcript is the running state of a Fiat-Shamir transcript. Transcripts
+// are values, and so copying a transcript forks it: messages that are
+// appended to the copy do not affect the original. This can be used to derive
+// separate challenges for each element of a batch from a common prefix.
+type Transcript struct {
+	state [32]byte
+}
+
+// New returns a new transcript with the given domain separator, which should
+// be unique to the proof system that uses the transcript.
+func New(domain string) Transcript {
+	var t Transcript
+	t.absorb(opDomain, protocolLabel, []byte(domain))
+	return t
+}
+
+// AppendBytes appends the given labelled message to the transcript.
+func (t *Transcript) AppendBytes(label string, data []byte) {
+	t.absorb(opAppend, label, data)
+}
+
+// AppendU32 appends the given labelled integer to the transcript, encoded in
+// big endian.
+func (t *Transcript) AppendU32(label string, v uint32) {
+	var data [4]byte
+	binary.BigEndian.PutUint32(data[:], v)
+	t.absorb(opAppend, label, data[:])
+}
+
+// AppendScalar appends the given labelled scalar to the transcript, encoded
+// as 32 bytes in big endian.
+func (t *Transcript) AppendScalar(label string, s *secp256k1.Fn) {
+	var data [32]byte
+	s.PutB32(data[:])
+	t.absorb(opAppend, label, data[:])
+}
+
+// AppendPoint appends the given labelled point to the transcript, encoded
+// using msm.PutPoint.
+func (t *Transcript) AppendPoint(label string, p *secp256k1.Point) {
+	var data [secp256k1.PointSizeMarshalled]byte
+	msm.PutPoint(data[:], p)
+	t.absorb(opAppend, label, data[:])
+}
+
+// AppendValue appends the given labelled value to the transcript, encoded
+// using its surge marshaling.
+//
+// Panics: This function will panic if the value can not be marshaled.
+func (t *Transcript) AppendValue(label string, v surge.Marshaler) {
+	data, err := surge.ToBinary(v)
+	if err != nil {
+		panic(fmt.Sprintf("marshaling transcript value: %v", err))
+	}
+	t.absorb(opAppend, label, data)
+}
+
+// Challenge derives a labelled challenge scalar from the transcript. The
+// challenge is also absorbed into the transcript, so that further challenges
+// will be different even if no other messages are appended in between.
+func (t *Transcript) Challenge(label string) secp256k1.Fn {
+	t.absorb(opChallenge, label, nil)
+	var e secp256k1.Fn
+	_ = e.SetB32(t.state[:])
+	return e
+}
+
+// absorb updates the state of the transcript to be the hash of the previous
+// state and the given operation, label and data. The label and data are
+// prefixed by their lengths so that the encoding is unambiguous.
+func (t *Transcript) absorb(op byte, label string, data []byte) {
+	var lengths [8]byte
+	binary.BigEndian.PutUint32(lengths[:4], uint32(len(label)))
+	binary.BigEndian.PutUint32(lengths[4:], uint32(len(data)))
+
+	h := sha256.New()
+	h.Write(t.state[:])
+	h.Write([]byte{op})
+	h.Write(lengths[:4])
+	h.Write([]byte(label))
+	h.Write(lengths[4:])
+	h.Write(data)
+	copy(t.state[:], h.Sum(nil))
+}
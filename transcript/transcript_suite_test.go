@@ -0,0 +1,13 @@
+package transcript_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestTranscript(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Transcript Suite")
+}
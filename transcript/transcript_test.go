@@ -0,0 +1,170 @@
+package transcript_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/mpc/transcript"
+
+	"github.com/renproject/secp256k1"
+)
+
+var _ = Describe("Transcript", func() {
+	trials := 20
+
+	RandomBytes := func() []byte {
+		bs := make([]byte, rand.Intn(64))
+		rand.Read(bs)
+		return bs
+	}
+
+	// RandomTranscript returns a transcript with the given domain separator
+	// to which one of each kind of message has been appended.
+	RandomTranscript := func(domain string) (Transcript, []byte, uint32, secp256k1.Fn, secp256k1.Point) {
+		bs := RandomBytes()
+		v := rand.Uint32()
+		s := secp256k1.RandomFn()
+		p := secp256k1.RandomPoint()
+		t := New(domain)
+		t.AppendBytes("bytes", bs)
+		t.AppendU32("u32", v)
+		t.AppendScalar("scalar", &s)
+		t.AppendPoint("point", &p)
+		return t, bs, v, s, p
+	}
+
+	It("should derive the same challenges for the same messages", func() {
+		for i := 0; i < trials; i++ {
+			t1, bs, v, s, p := RandomTranscript("domain")
+			t2 := New("domain")
+			t2.AppendBytes("bytes", bs)
+			t2.AppendU32("u32", v)
+			t2.AppendScalar("scalar", &s)
+			t2.AppendPoint("point", &p)
+
+			c1 := t1.Challenge("c")
+			c2 := t2.Challenge("c")
+			Expect(c1.Eq(&c2)).To(BeTrue())
+		}
+	})
+
+	It("should encode points independently of their representation", func() {
+		for i := 0; i < trials; i++ {
+			// The same point is computed in two different ways, which will
+			// in general give different representations of it.
+			a, b := secp256k1.RandomFn(), secp256k1.RandomFn()
+			var sum secp256k1.Fn
+			sum.Add(&a, &b)
+			var p1, p2, tmp secp256k1.Point
+			p1.BaseExp(&sum)
+			p2.BaseExp(&a)
+			tmp.BaseExp(&b)
+			p2.Add(&p2, &tmp)
+
+			// The encoding should be the same as the marshaling of the point
+			// after it has been unmarshaled, which is normalized.
+			var p3 secp256k1.Point
+			bs := make([]byte, secp256k1.PointSizeMarshalled)
+			p1.PutBytes(bs)
+			Expect(p3.SetBytes(bs)).To(Succeed())
+			p3.PutBytes(bs)
+
+			t1, t2, t3 := New("domain"), New("domain"), New("domain")
+			t1.AppendPoint("point", &p1)
+			t2.AppendPoint("point", &p2)
+			t3.AppendBytes("point", bs)
+			c1 := t1.Challenge("c")
+			c2 := t2.Challenge("c")
+			c3 := t3.Challenge("c")
+			Expect(c1.Eq(&c2)).To(BeTrue())
+			Expect(c1.Eq(&c3)).To(BeTrue())
+		}
+
+		inf := secp256k1.NewPointInfinity()
+		t1, t2 := New("domain"), New("domain")
+		t1.AppendPoint("point", &inf)
+		t2.AppendBytes("point", append([]byte{0xFF}, make([]byte, 32)...))
+		c1 := t1.Challenge("c")
+		c2 := t2.Challenge("c")
+		Expect(c1.Eq(&c2)).To(BeTrue())
+	})
+
+	It("should derive different challenges for different domains", func() {
+		t1 := New("domain")
+		t2 := New("other domain")
+		c1 := t1.Challenge("c")
+		c2 := t2.Challenge("c")
+		Expect(c1.Eq(&c2)).To(BeFalse())
+	})
+
+	It("should derive different challenges for different labels", func() {
+		for i := 0; i < trials; i++ {
+			bs := RandomBytes()
+			t1 := New("domain")
+			t2 := New("domain")
+			t1.AppendBytes("label", bs)
+			t2.AppendBytes("other label", bs)
+			c1 := t1.Challenge("c")
+			c2 := t2.Challenge("c")
+			Expect(c1.Eq(&c2)).To(BeFalse())
+
+			t1 = New("domain")
+			t2 = New("domain")
+			c1 = t1.Challenge("c")
+			c2 = t2.Challenge("other c")
+			Expect(c1.Eq(&c2)).To(BeFalse())
+		}
+	})
+
+	It("should derive different challenges for different messages", func() {
+		for i := 0; i < trials; i++ {
+			t1, _, _, _, _ := RandomTranscript("domain")
+			t2, _, _, _, _ := RandomTranscript("domain")
+			c1 := t1.Challenge("c")
+			c2 := t2.Challenge("c")
+			Expect(c1.Eq(&c2)).To(BeFalse())
+		}
+	})
+
+	It("should not be ambiguous about where messages start and end", func() {
+		t1 := New("domain")
+		t1.AppendBytes("a", []byte("bc"))
+		t2 := New("domain")
+		t2.AppendBytes("ab", []byte("c"))
+		c1 := t1.Challenge("c")
+		c2 := t2.Challenge("c")
+		Expect(c1.Eq(&c2)).To(BeFalse())
+
+		t1 = New("domain")
+		t1.AppendBytes("a", []byte("b"))
+		t1.AppendBytes("c", nil)
+		t2 = New("domain")
+		t2.AppendBytes("a", []byte("bc"))
+		c1 = t1.Challenge("c")
+		c2 = t2.Challenge("c")
+		Expect(c1.Eq(&c2)).To(BeFalse())
+	})
+
+	It("should derive different challenges for successive calls", func() {
+		t, _, _, _, _ := RandomTranscript("domain")
+		c1 := t.Challenge("c")
+		c2 := t.Challenge("c")
+		Expect(c1.Eq(&c2)).To(BeFalse())
+	})
+
+	It("should fork when copied", func() {
+		for i := 0; i < trials; i++ {
+			t, _, _, _, _ := RandomTranscript("domain")
+			fork := t
+			fork.AppendU32("position", uint32(i))
+
+			original := t
+			c1 := t.Challenge("c")
+			c2 := original.Challenge("c")
+			c3 := fork.Challenge("c")
+			Expect(c1.Eq(&c2)).To(BeTrue())
+			Expect(c1.Eq(&c3)).To(BeFalse())
+		}
+	})
+})
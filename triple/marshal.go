@@ -0,0 +1,209 @@
+package triple
+
+import (
+	"math/rand"
+	"reflect"
+
+	"github.com/renproject/mpc/mul"
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/surge"
+)
+
+// SizeHint implements the surge.SizeHinter interface.
+func (triple Triple) SizeHint() int {
+	return triple.A.SizeHint() +
+		triple.B.SizeHint() +
+		triple.C.SizeHint() +
+		triple.ACommitment.SizeHint() +
+		triple.BCommitment.SizeHint() +
+		triple.CCommitment.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (triple Triple) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := triple.A.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = triple.B.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = triple.C.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = triple.ACommitment.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = triple.BCommitment.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return triple.CCommitment.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (triple *Triple) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := triple.A.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = triple.B.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = triple.C.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = triple.ACommitment.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = triple.BCommitment.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return triple.CCommitment.Unmarshal(buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (triple Triple) Generate(rand *rand.Rand, size int) reflect.Value {
+	size /= 3
+	return reflect.ValueOf(randomTriple(rand, size))
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (generator Generator) SizeHint() int {
+	return generator.multiplier.SizeHint() +
+		generator.aShareBatch.SizeHint() +
+		generator.bShareBatch.SizeHint() +
+		surge.SizeHint(generator.aCommitmentBatch) +
+		surge.SizeHint(generator.bCommitmentBatch)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (generator Generator) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := generator.multiplier.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = generator.aShareBatch.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = generator.bShareBatch.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Marshal(generator.aCommitmentBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Marshal(generator.bCommitmentBatch, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (generator *Generator) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := generator.multiplier.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = generator.aShareBatch.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = generator.bShareBatch.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	buf, rem, err = surge.Unmarshal(&generator.aCommitmentBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.Unmarshal(&generator.bCommitmentBatch, buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (generator Generator) Generate(rand *rand.Rand, size int) reflect.Value {
+	size /= 2
+	multiplier := mul.Multiplier{}.Generate(rand, size).Interface().(mul.Multiplier)
+	b := rand.Intn(size/8+1) + 1
+	aShareBatch := make(shamir.VerifiableShares, b)
+	bShareBatch := make(shamir.VerifiableShares, b)
+	aCommitmentBatch := make([]shamir.Commitment, b)
+	bCommitmentBatch := make([]shamir.Commitment, b)
+	for i := 0; i < b; i++ {
+		aShareBatch[i] = randomVerifiableShare()
+		bShareBatch[i] = randomVerifiableShare()
+		aCommitmentBatch[i] = shamir.Commitment{}.Generate(rand, size/(4*b)).Interface().(shamir.Commitment)
+		bCommitmentBatch[i] = shamir.Commitment{}.Generate(rand, size/(4*b)).Interface().(shamir.Commitment)
+	}
+	g := Generator{
+		multiplier:       multiplier,
+		aShareBatch:      aShareBatch,
+		bShareBatch:      bShareBatch,
+		aCommitmentBatch: aCommitmentBatch,
+		bCommitmentBatch: bCommitmentBatch,
+	}
+	return reflect.ValueOf(g)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (multiplier Multiplier) SizeHint() int {
+	return surge.SizeHint(multiplier.tripleBatch) +
+		multiplier.opener.SizeHint()
+}
+
+// Marshal implements the surge.Marshaler interface. The opener is marshaled
+// last, since unmarshaling it computes a table for the Pedersen parameter,
+// which is expensive.
+func (multiplier Multiplier) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.Marshal(multiplier.tripleBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return multiplier.opener.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (multiplier *Multiplier) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.Unmarshal(&multiplier.tripleBatch, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return multiplier.opener.Unmarshal(buf, rem)
+}
+
+// Generate implements the quick.Generator interface.
+func (multiplier Multiplier) Generate(rand *rand.Rand, size int) reflect.Value {
+	size /= 2
+	opener := open.Opener{}.Generate(rand, size).Interface().(open.Opener)
+	tripleBatch := make([]Triple, rand.Intn(size/8+1)+1)
+	for i := range tripleBatch {
+		tripleBatch[i] = randomTriple(rand, size/(3*len(tripleBatch)))
+	}
+	return reflect.ValueOf(Multiplier{opener, tripleBatch})
+}
+
+func randomTriple(rand *rand.Rand, size int) Triple {
+	return Triple{
+		A:           randomVerifiableShare(),
+		B:           randomVerifiableShare(),
+		C:           randomVerifiableShare(),
+		ACommitment: shamir.Commitment{}.Generate(rand, size).Interface().(shamir.Commitment),
+		BCommitment: shamir.Commitment{}.Generate(rand, size).Interface().(shamir.Commitment),
+		CCommitment: shamir.Commitment{}.Generate(rand, size).Interface().(shamir.Commitment),
+	}
+}
+
+func randomVerifiableShare() shamir.VerifiableShare {
+	return shamir.NewVerifiableShare(
+		shamir.NewShare(secp256k1.RandomFn(), secp256k1.RandomFn()),
+		secp256k1.RandomFn(),
+	)
+}
@@ -0,0 +1,181 @@
+package triple
+
+import (
+	"fmt"
+
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// A Multiplier is a state machine that multiplies two secret shared values
+// using a multiplication triple.
+//
+// For inputs x and y and a triple (a, b, c), the players open the values
+//	d = x - a, and
+//	e = y - b.
+// Since a and b are random and unknown, d and e reveal nothing about x and y.
+// Each player then computes its share of the product locally as
+//	xy = c + d*b + e*a + d*e,
+// and similarly for the commitment. The output is a verifiable sharing of xy
+// with the same reconstruction threshold (k) as the inputs. Each triple must
+// only ever be used for a single multiplication, as otherwise the inputs can
+// be learned from the opened values.
+//
+// The state machine supports batching, in which case all of the inputs and
+// triples must have the same indices and reconstruction threshold (k).
+type Multiplier struct {
+	opener      open.Opener
+	tripleBatch []Triple
+}
+
+// Multiply returns a new Multiplier state machine for the multiplication of
+// the given inputs using the given triples, along with the share batch that is
+// to be broadcast to the other parties, tagged with the given instance ID. The
+// state machine will handle this share batch before being returned.
+//
+// Panics: This function will panic if any of the following conditions are
+// met.
+//	- The Pedersen parameter is insecure.
+//	- The batch size is less than 1.
+//	- The inputs and triples have different batch sizes.
+//	- The reconstruction threshold (k) is less than 2.
+//	- Not all of the commitments have the same reconstruction threshold (k).
+//	- Any of the conditions for which open.NewPublic would panic.
+//	- Not all of the input and triple shares have the same index.
+func Multiply(
+	instance params.InstanceID,
+	xShareBatch, yShareBatch shamir.VerifiableShares,
+	xCommitmentBatch, yCommitmentBatch []shamir.Commitment,
+	tripleBatch []Triple,
+	indices []secp256k1.Fn, h secp256k1.Point,
+) (Multiplier, shamir.VerifiableShares) {
+	if !params.ValidPedersenParameter(h) {
+		panic("insecure choice of pedersen parameter")
+	}
+	b := len(tripleBatch)
+	if b < 1 {
+		panic(fmt.Sprintf("batch size should be at least 1: got %v", b))
+	}
+	if len(xShareBatch) != b ||
+		len(yShareBatch) != b ||
+		len(xCommitmentBatch) != b ||
+		len(yCommitmentBatch) != b {
+		panic("inconsistent batch size")
+	}
+	k := xCommitmentBatch[0].Len()
+	if k < 2 {
+		panic(fmt.Sprintf("k should be at least 2: got %v", k))
+	}
+	for i, triple := range tripleBatch {
+		if xCommitmentBatch[i].Len() != k ||
+			yCommitmentBatch[i].Len() != k ||
+			triple.ACommitment.Len() != k ||
+			triple.BCommitment.Len() != k ||
+			triple.CCommitment.Len() != k {
+			panic("inconsistent threshold (k)")
+		}
+	}
+
+	one := secp256k1.NewFnFromU16(1)
+	var minusOne secp256k1.Fn
+	minusOne.Negate(&one)
+
+	// The first half of the batch for the open is for the values d and the
+	// second half is for the values e.
+	shareBatch := make(shamir.VerifiableShares, 2*b)
+	commitmentBatch := make([]shamir.Commitment, 2*b)
+	for i, triple := range tripleBatch {
+		var share shamir.VerifiableShare
+		share.Scale(&triple.A, &minusOne)
+		shareBatch[i].Add(&xShareBatch[i], &share)
+		share.Scale(&triple.B, &minusOne)
+		shareBatch[b+i].Add(&yShareBatch[i], &share)
+
+		commitment := shamir.NewCommitmentWithCapacity(k)
+		commitment.Scale(triple.ACommitment, &minusOne)
+		commitmentBatch[i].Set(xCommitmentBatch[i])
+		commitmentBatch[i].Add(commitmentBatch[i], commitment)
+		commitment = shamir.NewCommitmentWithCapacity(k)
+		commitment.Scale(triple.BCommitment, &minusOne)
+		commitmentBatch[b+i].Set(yCommitmentBatch[i])
+		commitmentBatch[b+i].Add(commitmentBatch[b+i], commitment)
+	}
+
+	opener := open.NewPublic(instance, commitmentBatch, indices, h)
+	secrets, _, err := opener.HandleShareBatch(instance, shareBatch)
+	if err != nil {
+		panic(fmt.Sprintf("unexpected error handling own share: %v", err))
+	}
+	if secrets != nil {
+		panic("opener should not have reconstructed after one share")
+	}
+
+	tripleBatchCopy := make([]Triple, b)
+	for i := range tripleBatchCopy {
+		tripleBatchCopy[i] = Triple{
+			A: tripleBatch[i].A,
+			B: tripleBatch[i].B,
+			C: tripleBatch[i].C,
+		}
+		tripleBatchCopy[i].ACommitment.Set(tripleBatch[i].ACommitment)
+		tripleBatchCopy[i].BCommitment.Set(tripleBatch[i].BCommitment)
+		tripleBatchCopy[i].CCommitment.Set(tripleBatch[i].CCommitment)
+	}
+	multiplier := Multiplier{
+		opener:      opener,
+		tripleBatch: tripleBatchCopy,
+	}
+	return multiplier, shareBatch
+}
+
+// HandleShareBatch applies a state transition upon receiving the given shares,
+// tagged with the given instance ID, from another party during the open. Once
+// enough valid shares have been received to reconstruct, the shares of the
+// products and the corresponding commitments are computed and returned. If
+// not enough shares have been received, the return values will be nil. If the
+// share batch is invalid in any way, an error will be returned along with nil
+// values.
+func (multiplier *Multiplier) HandleShareBatch(instance params.InstanceID, shareBatch shamir.VerifiableShares) (
+	shamir.VerifiableShares, []shamir.Commitment, error,
+) {
+	secrets, _, err := multiplier.opener.HandleShareBatch(instance, shareBatch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if secrets == nil {
+		return nil, nil, nil
+	}
+
+	b := len(multiplier.tripleBatch)
+	productShareBatch := make(shamir.VerifiableShares, b)
+	productCommitmentBatch := make([]shamir.Commitment, b)
+	for i, triple := range multiplier.tripleBatch {
+		d, e := secrets[i], secrets[b+i]
+		var de secp256k1.Fn
+		de.Mul(&d, &e)
+
+		// Compute a share of c + d*b + e*a + d*e.
+		var share shamir.VerifiableShare
+		productShareBatch[i] = triple.C
+		share.Scale(&triple.B, &d)
+		productShareBatch[i].Add(&productShareBatch[i], &share)
+		share.Scale(&triple.A, &e)
+		productShareBatch[i].Add(&productShareBatch[i], &share)
+		productShareBatch[i].Share.Value.Add(&productShareBatch[i].Share.Value, &de)
+
+		// Compute the corresponding commitment.
+		commitment := shamir.NewCommitmentWithCapacity(triple.BCommitment.Len())
+		productCommitmentBatch[i].Set(triple.CCommitment)
+		commitment.Scale(triple.BCommitment, &d)
+		productCommitmentBatch[i].Add(productCommitmentBatch[i], commitment)
+		commitment = shamir.NewCommitmentWithCapacity(triple.ACommitment.Len())
+		commitment.Scale(triple.ACommitment, &e)
+		productCommitmentBatch[i].Add(productCommitmentBatch[i], commitment)
+		var deG secp256k1.Point
+		deG.BaseExp(&de)
+		productCommitmentBatch[i][0].Add(&productCommitmentBatch[i][0], &deG)
+	}
+	return productShareBatch, productCommitmentBatch, nil
+}
@@ -0,0 +1,216 @@
+package triple_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/mpc/triple"
+
+	"github.com/renproject/mpc/open"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg/rkpgutil"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+)
+
+var _ = Describe("Multiply", func() {
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
+	RandomTestParams := func() (int, int, int, []secp256k1.Fn, secp256k1.Point) {
+		n := shamirutil.RandRange(5, 15)
+		k := shamirutil.RandRange(2, n-1)
+		b := shamirutil.RandRange(1, 5)
+		indices := shamirutil.RandomIndices(n)
+		h := secp256k1.RandomPoint()
+		return n, k, b, indices, h
+	}
+
+	// RandomTriples deals a batch of triples directly, so that triplesBatch[p]
+	// are the triples for the player with index indices[p].
+	RandomTriples := func(indices []secp256k1.Fn, k, b int, h secp256k1.Point) [][]Triple {
+		triplesBatch := make([][]Triple, len(indices))
+		for p := range triplesBatch {
+			triplesBatch[p] = make([]Triple, b)
+		}
+		for i := 0; i < b; i++ {
+			a, bb := secp256k1.RandomFn(), secp256k1.RandomFn()
+			var c secp256k1.Fn
+			c.Mul(&a, &bb)
+			aShares, aCommitment := rkpgutil.RXGOutput(indices, k, h, a)
+			bShares, bCommitment := rkpgutil.RXGOutput(indices, k, h, bb)
+			cShares, cCommitment := rkpgutil.RXGOutput(indices, k, h, c)
+			for p := range triplesBatch {
+				triplesBatch[p][i] = Triple{
+					A:           aShares[p],
+					B:           bShares[p],
+					C:           cShares[p],
+					ACommitment: aCommitment,
+					BCommitment: bCommitment,
+					CCommitment: cCommitment,
+				}
+			}
+		}
+		return triplesBatch
+	}
+
+	Context("multiplying", func() {
+		It("should compute a verifiable sharing of the product once k share batches are received", func() {
+			n, k, b, indices, h := RandomTestParams()
+			xShares, xCommitments, xSecrets := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			yShares, yCommitments, ySecrets := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			triplesBatch := RandomTriples(indices, k, b, h)
+
+			multipliers := make([]Multiplier, n)
+			shareBatches := make([]shamir.VerifiableShares, n)
+			for p := range multipliers {
+				multipliers[p], shareBatches[p] = Multiply(
+					instance, xShares[p], yShares[p], xCommitments, yCommitments,
+					triplesBatch[p], indices, h,
+				)
+				Expect(len(shareBatches[p])).To(Equal(2 * b))
+			}
+
+			productSharesBatch := make([]shamir.VerifiableShares, b)
+			for i := range productSharesBatch {
+				productSharesBatch[i] = make(shamir.VerifiableShares, n)
+			}
+			var productCommitmentBatch []shamir.Commitment
+			for p := range multipliers {
+				var shares shamir.VerifiableShares
+				var commitments []shamir.Commitment
+				var err error
+				others := rand.Perm(n)
+				count := 1
+				for _, q := range others {
+					if q == p {
+						continue
+					}
+					shares, commitments, err = multipliers[p].HandleShareBatch(instance, shareBatches[q])
+					count++
+					Expect(err).ToNot(HaveOccurred())
+					if count < k {
+						Expect(shares).To(BeNil())
+						Expect(commitments).To(BeNil())
+						continue
+					}
+					break
+				}
+
+				Expect(len(shares)).To(Equal(b))
+				Expect(len(commitments)).To(Equal(b))
+				for i := range shares {
+					Expect(shares[i].Share.IndexEq(&indices[p])).To(BeTrue())
+					Expect(shamir.IsValid(h, &commitments[i], &shares[i])).To(BeTrue())
+					productSharesBatch[i][p] = shares[i]
+				}
+				if productCommitmentBatch != nil {
+					for i := range commitments {
+						Expect(commitments[i].Eq(productCommitmentBatch[i])).To(BeTrue())
+					}
+				}
+				productCommitmentBatch = commitments
+			}
+
+			for i := 0; i < b; i++ {
+				Expect(shamirutil.VsharesAreConsistent(productSharesBatch[i], k)).To(BeTrue())
+				var product secp256k1.Fn
+				product.Mul(&xSecrets[i], &ySecrets[i])
+				secret := shamir.Open(sharesOf(productSharesBatch[i][:k]))
+				Expect(secret.Eq(&product)).To(BeTrue())
+			}
+		})
+
+		It("should reject invalid share batches", func() {
+			n, k, b, indices, h := RandomTestParams()
+			xShares, xCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			yShares, yCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			triplesBatch := RandomTriples(indices, k, b, h)
+
+			p, q := 0, 1+rand.Intn(n-1)
+			multiplier, _ := Multiply(
+				instance, xShares[p], yShares[p], xCommitments, yCommitments,
+				triplesBatch[p], indices, h,
+			)
+			_, shareBatch := Multiply(
+				instance, xShares[q], yShares[q], xCommitments, yCommitments,
+				triplesBatch[q], indices, h,
+			)
+
+			shares, commitments, err := multiplier.HandleShareBatch(instance, shareBatch[1:])
+			Expect(err).To(Equal(open.ErrIncorrectBatchSize))
+			Expect(shares).To(BeNil())
+			Expect(commitments).To(BeNil())
+
+			otherInstance := instance
+			otherInstance[rand.Intn(len(otherInstance))]++
+			shares, commitments, err = multiplier.HandleShareBatch(otherInstance, shareBatch)
+			Expect(err).To(Equal(open.ErrIncorrectInstance))
+			Expect(shares).To(BeNil())
+			Expect(commitments).To(BeNil())
+
+			shareBatch[rand.Intn(2*b)].Share.Value = secp256k1.RandomFn()
+			shares, commitments, err = multiplier.HandleShareBatch(instance, shareBatch)
+			Expect(err).To(Equal(open.ErrInvalidShares))
+			Expect(shares).To(BeNil())
+			Expect(commitments).To(BeNil())
+		})
+	})
+
+	Context("panics", func() {
+		Specify("insecure pedersen parameter", func() {
+			_, k, b, indices, h := RandomTestParams()
+			xShares, xCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			yShares, yCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			triplesBatch := RandomTriples(indices, k, b, h)
+			inf := secp256k1.NewPointInfinity()
+			Expect(func() {
+				Multiply(instance, xShares[0], yShares[0], xCommitments, yCommitments, triplesBatch[0], indices, inf)
+			}).To(Panic())
+		})
+
+		Specify("batch size too small or inconsistent", func() {
+			_, k, b, indices, h := RandomTestParams()
+			xShares, xCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			yShares, yCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			triplesBatch := RandomTriples(indices, k, b, h)
+			Expect(func() {
+				Multiply(instance, xShares[0], yShares[0], xCommitments, yCommitments, triplesBatch[0][:0], indices, h)
+			}).To(Panic())
+			Expect(func() {
+				Multiply(instance, xShares[0][1:], yShares[0], xCommitments, yCommitments, triplesBatch[0], indices, h)
+			}).To(Panic())
+			Expect(func() {
+				Multiply(instance, xShares[0], yShares[0][1:], xCommitments, yCommitments, triplesBatch[0], indices, h)
+			}).To(Panic())
+			Expect(func() {
+				Multiply(instance, xShares[0], yShares[0], xCommitments[1:], yCommitments, triplesBatch[0], indices, h)
+			}).To(Panic())
+			Expect(func() {
+				Multiply(instance, xShares[0], yShares[0], xCommitments, yCommitments[1:], triplesBatch[0], indices, h)
+			}).To(Panic())
+		})
+
+		Specify("k too small", func() {
+			_, _, b, indices, h := RandomTestParams()
+			xShares, xCommitments, _ := rkpgutil.RNGOutputBatch(indices, 1, b, h)
+			yShares, yCommitments, _ := rkpgutil.RNGOutputBatch(indices, 1, b, h)
+			triplesBatch := RandomTriples(indices, 1, b, h)
+			Expect(func() {
+				Multiply(instance, xShares[0], yShares[0], xCommitments, yCommitments, triplesBatch[0], indices, h)
+			}).To(Panic())
+		})
+
+		Specify("inconsistent k", func() {
+			_, k, b, indices, h := RandomTestParams()
+			xShares, xCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			yShares, yCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+			triplesBatch := RandomTriples(indices, k+1, b, h)
+			Expect(func() {
+				Multiply(instance, xShares[0], yShares[0], xCommitments, yCommitments, triplesBatch[0], indices, h)
+			}).To(Panic())
+		})
+	})
+})
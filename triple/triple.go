@@ -0,0 +1,130 @@
+// Package triple implements the generation of multiplication triples (also
+// known as Beaver triples) and their use for multiplying secret shared values.
+//
+// A multiplication triple is a verifiable sharing of random values a and b
+// along with a verifiable sharing of their product c = ab. Generating triples
+// is relatively expensive, but can be done in advance, independently of the
+// values that are to be multiplied. Once a triple is available, two secret
+// shared values can be multiplied using a single open; see Multiply.
+package triple
+
+import (
+	"github.com/renproject/mpc/brng"
+	"github.com/renproject/mpc/mul"
+	"github.com/renproject/mpc/mulopen/mulzkp"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+)
+
+// A Triple is a player's shares of a multiplication triple, along with the
+// commitments for the corresponding sharings. The value shared by C is the
+// product of the values shared by A and B.
+type Triple struct {
+	A, B, C                               shamir.VerifiableShare
+	ACommitment, BCommitment, CCommitment shamir.Commitment
+}
+
+// A Generator is a state machine that generates a batch of multiplication
+// triples.
+//
+// The inputs to the generation are two batches of verifiable sharings of
+// random values, which are the output of two instances of RNG. The sharing of
+// the product is then computed using the degree reduction protocol in the mul
+// package, in which each player proves, using the ZKP in mulzkp, that it has
+// correctly computed the product of its shares. The protocol proceeds as
+// follows.
+//	1. Each player creates a Generator using New, and submits the returned
+//	resharings and proofs to a consensus algorithm.
+//	2. The consensus algorithm decides on at least 2k-1 resharings that are
+//	valid for enough players, which players check using IsValid.
+//	3. Each player computes its shares of the triples using
+//	HandleConsensusOutput.
+//
+// The state machine supports batching, in which case all of the RNG outputs
+// must have the same indices and reconstruction threshold (k).
+type Generator struct {
+	multiplier                         mul.Multiplier
+	aShareBatch, bShareBatch           shamir.VerifiableShares
+	aCommitmentBatch, bCommitmentBatch []shamir.Commitment
+}
+
+// New returns a new Generator state machine for the given instance ID along
+// with the resharings of the product shares and the corresponding proofs,
+// which are to be submitted to consensus. The share and commitment batches are
+// the outputs of the two RNG instances.
+//
+// Panics: This function will panic if any of the conditions for which mul.New
+// would panic are met.
+func New(
+	instance params.InstanceID,
+	aShareBatch, bShareBatch shamir.VerifiableShares,
+	aCommitmentBatch, bCommitmentBatch []shamir.Commitment,
+	indices []secp256k1.Fn, h secp256k1.Point,
+) (Generator, []brng.Sharing, []mulzkp.Proof) {
+	multiplier, sharings, proofs := mul.New(
+		instance,
+		aShareBatch, bShareBatch,
+		aCommitmentBatch, bCommitmentBatch,
+		indices, h,
+	)
+
+	b := len(aShareBatch)
+	aShareBatchCopy := make(shamir.VerifiableShares, b)
+	bShareBatchCopy := make(shamir.VerifiableShares, b)
+	copy(aShareBatchCopy, aShareBatch)
+	copy(bShareBatchCopy, bShareBatch)
+	aCommitmentBatchCopy := make([]shamir.Commitment, b)
+	bCommitmentBatchCopy := make([]shamir.Commitment, b)
+	for i := 0; i < b; i++ {
+		aCommitmentBatchCopy[i].Set(aCommitmentBatch[i])
+		bCommitmentBatchCopy[i].Set(bCommitmentBatch[i])
+	}
+	generator := Generator{
+		multiplier:       multiplier,
+		aShareBatch:      aShareBatchCopy,
+		bShareBatch:      bShareBatchCopy,
+		aCommitmentBatch: aCommitmentBatchCopy,
+		bCommitmentBatch: bCommitmentBatchCopy,
+	}
+	return generator, sharings, proofs
+}
+
+// IsValid checks the validity of the given potential consensus output. The
+// arguments and return value are the same as for mul.Multiplier.IsValid.
+func (generator *Generator) IsValid(
+	dealerIndices []secp256k1.Fn,
+	sharesBatch []shamir.VerifiableShares,
+	commitmentsBatch [][]shamir.Commitment,
+	proofsBatch [][]mulzkp.Proof,
+) error {
+	return generator.multiplier.IsValid(dealerIndices, sharesBatch, commitmentsBatch, proofsBatch)
+}
+
+// HandleConsensusOutput computes the shares of the triples from the output of
+// the consensus algorithm. The arguments have the same form as for IsValid,
+// and it is assumed that they have been checked using IsValid. If the shares
+// in the consensus output were not valid for this player, the shares argument
+// should be nil, in which case the return value will also be nil.
+func (generator *Generator) HandleConsensusOutput(
+	dealerIndices []secp256k1.Fn,
+	sharesBatch []shamir.VerifiableShares,
+	commitmentsBatch [][]shamir.Commitment,
+) []Triple {
+	if sharesBatch == nil {
+		return nil
+	}
+	cShareBatch, cCommitmentBatch := mul.HandleConsensusOutput(dealerIndices, sharesBatch, commitmentsBatch)
+	triples := make([]Triple, len(cShareBatch))
+	for i := range triples {
+		triples[i] = Triple{
+			A:           generator.aShareBatch[i],
+			B:           generator.bShareBatch[i],
+			C:           cShareBatch[i],
+			CCommitment: cCommitmentBatch[i],
+		}
+		triples[i].ACommitment.Set(generator.aCommitmentBatch[i])
+		triples[i].BCommitment.Set(generator.bCommitmentBatch[i])
+	}
+	return triples
+}
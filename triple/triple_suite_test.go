@@ -0,0 +1,13 @@
+package triple_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestTriple(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Triple Suite")
+}
@@ -0,0 +1,144 @@
+package triple_test
+
+import (
+	"math/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/mpc/triple"
+
+	"github.com/renproject/mpc/mul"
+	"github.com/renproject/mpc/mulopen/mulzkp"
+	"github.com/renproject/mpc/params"
+	"github.com/renproject/mpc/rkpg/rkpgutil"
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/shamir"
+	"github.com/renproject/shamir/shamirutil"
+)
+
+var _ = Describe("Triple generation", func() {
+	var instance params.InstanceID
+	rand.Read(instance[:])
+
+	n := 10
+	k := 3
+	b := 3
+
+	// Setup returns the generators for all players along with the consensus
+	// output when a random subset of 2k-1 players act as dealers. In the
+	// consensus output, sharesBatches[i] are the shares for the player with
+	// index indices[i].
+	Setup := func(indices []secp256k1.Fn, h secp256k1.Point) (
+		[]Generator, []secp256k1.Fn, [][]shamir.VerifiableShares, [][]shamir.Commitment, [][]mulzkp.Proof,
+	) {
+		t := 2*k - 1
+		aShares, aCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+		bShares, bCommitments, _ := rkpgutil.RNGOutputBatch(indices, k, b, h)
+
+		generators := make([]Generator, n)
+		dealers := rand.Perm(n)[:t]
+		dealerIndices := make([]secp256k1.Fn, t)
+		sharesBatches := make([][]shamir.VerifiableShares, n)
+		for i := range sharesBatches {
+			sharesBatches[i] = make([]shamir.VerifiableShares, b)
+			for j := range sharesBatches[i] {
+				sharesBatches[i][j] = make(shamir.VerifiableShares, t)
+			}
+		}
+		commitmentsBatch := make([][]shamir.Commitment, b)
+		proofsBatch := make([][]mulzkp.Proof, b)
+		for i := 0; i < b; i++ {
+			commitmentsBatch[i] = make([]shamir.Commitment, t)
+			proofsBatch[i] = make([]mulzkp.Proof, t)
+		}
+
+		for p := range generators {
+			generator, sharings, proofs := New(
+				instance, aShares[p], bShares[p], aCommitments, bCommitments, indices, h,
+			)
+			generators[p] = generator
+			for d, dealer := range dealers {
+				if dealer != p {
+					continue
+				}
+				dealerIndices[d] = indices[p]
+				for i, sharing := range sharings {
+					commitmentsBatch[i][d] = sharing.Commitment
+					proofsBatch[i][d] = proofs[i]
+					for j := range indices {
+						sharesBatches[j][i][d] = sharing.Shares[j]
+					}
+				}
+			}
+		}
+		return generators, dealerIndices, sharesBatches, commitmentsBatch, proofsBatch
+	}
+
+	It("should generate valid multiplication triples", func() {
+		indices := shamirutil.RandomIndices(n)
+		h := secp256k1.RandomPoint()
+		generators, dealerIndices, sharesBatches, commitmentsBatch, proofsBatch := Setup(indices, h)
+
+		triplesBatch := make([][]Triple, n)
+		for p, generator := range generators {
+			Expect(generator.IsValid(dealerIndices, sharesBatches[p], commitmentsBatch, proofsBatch)).To(Succeed())
+			triplesBatch[p] = generator.HandleConsensusOutput(dealerIndices, sharesBatches[p], commitmentsBatch)
+			Expect(len(triplesBatch[p])).To(Equal(b))
+		}
+
+		for i := 0; i < b; i++ {
+			aShares := make(shamir.VerifiableShares, n)
+			bShares := make(shamir.VerifiableShares, n)
+			cShares := make(shamir.VerifiableShares, n)
+			for p := range triplesBatch {
+				triple := triplesBatch[p][i]
+				Expect(triple.A.Share.IndexEq(&indices[p])).To(BeTrue())
+				Expect(triple.B.Share.IndexEq(&indices[p])).To(BeTrue())
+				Expect(triple.C.Share.IndexEq(&indices[p])).To(BeTrue())
+				Expect(shamir.IsValid(h, &triple.ACommitment, &triple.A)).To(BeTrue())
+				Expect(shamir.IsValid(h, &triple.BCommitment, &triple.B)).To(BeTrue())
+				Expect(shamir.IsValid(h, &triple.CCommitment, &triple.C)).To(BeTrue())
+				Expect(triple.CCommitment.Eq(triplesBatch[0][i].CCommitment)).To(BeTrue())
+				aShares[p], bShares[p], cShares[p] = triple.A, triple.B, triple.C
+			}
+			Expect(shamirutil.VsharesAreConsistent(cShares, k)).To(BeTrue())
+
+			a := shamir.Open(sharesOf(aShares[:k]))
+			bb := shamir.Open(sharesOf(bShares[:k]))
+			c := shamir.Open(sharesOf(cShares[:k]))
+			var product secp256k1.Fn
+			product.Mul(&a, &bb)
+			Expect(c.Eq(&product)).To(BeTrue())
+		}
+	})
+
+	It("should forward validation errors from the multiplication", func() {
+		indices := shamirutil.RandomIndices(n)
+		h := secp256k1.RandomPoint()
+		generators, dealerIndices, sharesBatches, commitmentsBatch, proofsBatch := Setup(indices, h)
+
+		p := rand.Intn(n)
+		err := generators[p].IsValid(dealerIndices[1:], sharesBatches[p], commitmentsBatch, proofsBatch)
+		Expect(err).To(Equal(mul.ErrNotEnoughContributions))
+
+		sharesBatches[p][0][0].Share.Value = secp256k1.RandomFn()
+		err = generators[p].IsValid(dealerIndices, sharesBatches[p], commitmentsBatch, proofsBatch)
+		Expect(err).To(Equal(mul.ErrInvalidShares))
+	})
+
+	It("should return nil triples when the shares are nil", func() {
+		indices := shamirutil.RandomIndices(n)
+		h := secp256k1.RandomPoint()
+		generators, dealerIndices, _, commitmentsBatch, _ := Setup(indices, h)
+		Expect(generators[0].HandleConsensusOutput(dealerIndices, nil, commitmentsBatch)).To(BeNil())
+	})
+})
+
+// sharesOf returns the underlying shares of the given verifiable shares.
+func sharesOf(vshares shamir.VerifiableShares) shamir.Shares {
+	shares := make(shamir.Shares, len(vshares))
+	for i := range vshares {
+		shares[i] = vshares[i].Share
+	}
+	return shares
+}